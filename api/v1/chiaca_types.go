@@ -13,6 +13,70 @@ type ChiaCASpec struct {
 	// Secret defines the name of the secret to contain CA files
 	// +optional
 	Secret string `json:"secret,omitempty"`
+
+	// Import defines an existing CA to import into the CA Secret instead of generating a new one, such as the files in an existing
+	// farm's $CHIA_ROOT/config/ssl/ca directory. When the imported CA changes, the CA Secret is updated to use the new CA.
+	// +optional
+	Import *ChiaCAImport `json:"import,omitempty"`
+
+	// Rotation defines when the operator should replace a generated private CA with a new one.
+	// Rotation is not supported for imported CAs, update the import source instead.
+	// +optional
+	Rotation *ChiaCARotation `json:"rotation,omitempty"`
+
+	// CertManager delegates issuance of the private CA to cert-manager. The operator creates a cert-manager Certificate for the
+	// private CA, and copies the issued certificate into the CA Secret in the layout Chia expects.
+	// Mutually exclusive with Import and Rotation, cert-manager renews the private CA instead.
+	// +optional
+	CertManager *ChiaCACertManagerConfig `json:"certManager,omitempty"`
+
+	// OverlapPeriod is how long the previous private CA is still trusted after the private CA in the CA Secret changes,
+	// giving Chia components time to be restarted with certificates from the new CA. Defaults to 168h (7 days).
+	// +optional
+	OverlapPeriod *metav1.Duration `json:"overlapPeriod,omitempty"`
+}
+
+// ChiaCAImport defines the source of an existing CA to import. Exactly one of SecretName and ConfigMapName must be set.
+// The source must contain private_ca.crt and private_ca.key, and may contain chia_ca.crt and chia_ca.key.
+// If chia_ca.crt and chia_ca.key aren't present, the public Chia CA is used.
+type ChiaCAImport struct {
+	// SecretName is the name of a Secret in the same namespace to import the CA from
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// ConfigMapName is the name of a ConfigMap in the same namespace to import the CA from.
+	// Prefer a Secret, since a ConfigMap doesn't protect the private CA key.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+}
+
+// ChiaCACertManagerConfig defines how cert-manager issues a ChiaCA's private CA
+type ChiaCACertManagerConfig struct {
+	// IssuerRef references the cert-manager issuer that issues the private CA certificate.
+	// If unset, the operator creates a self-signed Issuer named after the ChiaCA.
+	// +optional
+	IssuerRef *CertManagerIssuerRef `json:"issuerRef,omitempty"`
+
+	// Duration is how long the private CA certificate is valid for. Defaults to cert-manager's default.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// RenewBefore is how long before the private CA certificate expires that cert-manager renews it. Defaults to cert-manager's default.
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+}
+
+// ChiaCARotation defines when the operator should replace a generated private CA with a new one
+type ChiaCARotation struct {
+	// RenewBefore is how long before the private CA expires that the operator generates a new one.
+	// If unset, the private CA is only rotated when the Trigger changes.
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+
+	// Trigger is an arbitrary value, changing it to a new value makes the operator generate a new private CA immediately.
+	// Setting it on an existing ChiaCA that has never been rotated also rotates the CA.
+	// +optional
+	Trigger string `json:"trigger,omitempty"`
 }
 
 // ChiaCAStatus defines the observed state of ChiaCA
@@ -20,10 +84,76 @@ type ChiaCAStatus struct {
 	// Ready says whether the CA is ready, this should be true when the SSL secret is in the target namespace
 	// +kubebuilder:default=false
 	Ready bool `json:"ready,omitempty"`
+
+	// ObservedGeneration is the most recent metadata.generation that was reconciled by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of this resource's state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Source is where the private CA in the CA Secret came from, either Generated, Imported, CertManager, or Existing for a CA Secret
+	// that was created outside of the operator
+	// +optional
+	Source ChiaCASource `json:"source,omitempty"`
+
+	// Fingerprint is the SHA-256 fingerprint of the private CA certificate
+	// +optional
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// NotAfter is the time the private CA certificate expires
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// RenewalTime is the time the operator will rotate the private CA, if automatic rotation is enabled
+	// +optional
+	RenewalTime *metav1.Time `json:"renewalTime,omitempty"`
+
+	// PreviousFingerprint is the SHA-256 fingerprint of the previous private CA certificate, while it is still trusted
+	// +optional
+	PreviousFingerprint string `json:"previousFingerprint,omitempty"`
+
+	// PreviousTrustedUntil is the time the previous private CA certificate will be removed from the CA Secret
+	// +optional
+	PreviousTrustedUntil *metav1.Time `json:"previousTrustedUntil,omitempty"`
+
+	// LastRotationTime is the last time the private CA in the CA Secret was replaced
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+
+	// LastRotationTrigger is the value of spec.rotation.trigger that was last acted on
+	// +optional
+	LastRotationTrigger string `json:"lastRotationTrigger,omitempty"`
 }
 
+// ChiaCASource is where the private CA in a ChiaCA's Secret came from
+// +kubebuilder:validation:Enum=Generated;Imported;CertManager;Existing
+type ChiaCASource string
+
+const (
+	// ChiaCASourceGenerated is used when the operator generated the private CA
+	ChiaCASourceGenerated ChiaCASource = "Generated"
+
+	// ChiaCASourceImported is used when the private CA was imported from the ChiaCA's import source
+	ChiaCASourceImported ChiaCASource = "Imported"
+
+	// ChiaCASourceCertManager is used when the private CA was issued by cert-manager
+	ChiaCASourceCertManager ChiaCASource = "CertManager"
+
+	// ChiaCASourceExisting is used when the CA Secret already existed and wasn't created by the operator
+	ChiaCASourceExisting ChiaCASource = "Existing"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.status.source`
+//+kubebuilder:printcolumn:name="Expires",type=date,JSONPath=`.status.notAfter`
+//+kubebuilder:printcolumn:name="Fingerprint",type=string,JSONPath=`.status.fingerprint`,priority=1
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ChiaCA is the Schema for the chiacas API
 type ChiaCA struct {
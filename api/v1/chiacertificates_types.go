@@ -16,6 +16,43 @@ type ChiaCertificatesSpec struct {
 
 	// CASecretName is the name of a Secret in the same namespace that contains the private Chia CA
 	CASecretName string `json:"caSecretName"`
+
+	// RenewBefore is how long before a certificate expires that the operator re-issues all the certificates in the Secret.
+	// Defaults to 720h (30 days).
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+
+	// RestartWorkloads defines whether the operator should trigger a rolling restart of the Deployments, StatefulSets, and DaemonSets
+	// in this namespace that mount the certificate Secret when the certificates are re-issued. Defaults to true.
+	// +optional
+	RestartWorkloads *bool `json:"restartWorkloads,omitempty"`
+
+	// CertManager delegates issuance of the certificates to cert-manager. The operator creates a cert-manager Certificate for each
+	// certificate, and copies the issued certificates into the Secret in the layout Chia expects.
+	// cert-manager renews the certificates instead of the operator, so RenewBefore is ignored.
+	// +optional
+	CertManager *ChiaCertificatesCertManagerConfig `json:"certManager,omitempty"`
+}
+
+// ChiaCertificatesCertManagerConfig defines how cert-manager issues the certificates in a ChiaCertificates Secret
+type ChiaCertificatesCertManagerConfig struct {
+	// PrivateIssuerRef references the cert-manager issuer that issues the private_* certificates.
+	// If unset, the operator creates a CA Issuer from the private CA in the CA Secret.
+	// +optional
+	PrivateIssuerRef *CertManagerIssuerRef `json:"privateIssuerRef,omitempty"`
+
+	// PublicIssuerRef references the cert-manager issuer that issues the public_* certificates.
+	// If unset, the operator creates a CA Issuer from the public Chia CA.
+	// +optional
+	PublicIssuerRef *CertManagerIssuerRef `json:"publicIssuerRef,omitempty"`
+
+	// Duration is how long each certificate is valid for. Defaults to cert-manager's default.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// RenewBefore is how long before each certificate expires that cert-manager renews it. Defaults to cert-manager's default.
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
 }
 
 // ChiaCertificatesStatus defines the observed state of ChiaCertificates.
@@ -23,10 +60,53 @@ type ChiaCertificatesStatus struct {
 	// Ready says whether the ChiaCertificates is ready, this should be true when the SSL secret is in the target namespace
 	// +kubebuilder:default=false
 	Ready bool `json:"ready,omitempty"`
+
+	// ObservedGeneration is the most recent metadata.generation that was reconciled by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of this resource's state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// NotAfter is the earliest expiry time of the certificates in the Secret
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// RenewalTime is the time the operator will re-issue the certificates in the Secret, unless the CA changes before then
+	// +optional
+	RenewalTime *metav1.Time `json:"renewalTime,omitempty"`
+
+	// Certificates contains the expiry time of each certificate in the Secret
+	// +optional
+	Certificates []ChiaCertificateStatus `json:"certificates,omitempty"`
+
+	// CAFingerprint is the SHA-256 fingerprint of the private CA certificate the certificates were issued from
+	// +optional
+	CAFingerprint string `json:"caFingerprint,omitempty"`
+
+	// LastRotationTime is the last time the operator re-issued the certificates in an existing Secret
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+}
+
+// ChiaCertificateStatus defines the observed state of a single certificate in a ChiaCertificates Secret
+type ChiaCertificateStatus struct {
+	// Name is the name of the certificate, such as private_full_node
+	Name string `json:"name"`
+
+	// NotAfter is the time the certificate expires
+	NotAfter metav1.Time `json:"notAfter"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Expires",type=date,JSONPath=`.status.notAfter`
+// +kubebuilder:printcolumn:name="Renews",type=date,JSONPath=`.status.renewalTime`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ChiaCertificates is the Schema for the chiacertificates API.
 type ChiaCertificates struct {
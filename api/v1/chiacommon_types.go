@@ -4,7 +4,34 @@ Copyright 2023 Chia Network Inc.
 
 package v1
 
-import corev1 "k8s.io/api/core/v1"
+import (
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Condition types reported in the status of every Chia custom resource
+const (
+	// ConditionTypeReady is true when every resource the operator manages for a CR is available
+	ConditionTypeReady = "Ready"
+
+	// ConditionTypeProgressing is true while a workload rollout is in progress
+	ConditionTypeProgressing = "Progressing"
+
+	// ConditionTypeDegraded is true when a workload is failing, such as a crash-looping container or an exceeded rollout deadline
+	ConditionTypeDegraded = "Degraded"
+
+	// ConditionTypeConfigValid is false when the CR's spec contains a configuration error the operator can not reconcile
+	ConditionTypeConfigValid = "ConfigValid"
+
+	// ConditionTypeNetworkResolved is false when a referenced ChiaNetwork could not be resolved
+	ConditionTypeNetworkResolved = "NetworkResolved"
+
+	// ConditionTypeSuspended is true while the operator is not applying changes to a CR's resources
+	ConditionTypeSuspended = "Suspended"
+)
 
 // CommonSpec represents the common configuration options for controller APIs at the top-spec level
 type CommonSpec struct {
@@ -58,6 +85,72 @@ type CommonSpec struct {
 	// Tolerations allow the pod to be scheduled on nodes with matching taints
 	// +optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// PodDisruptionBudget defines an optional PodDisruptionBudget for the Pods this resource creates,
+	// which limits how many of them voluntary disruptions like node drains can evict at once
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetConfig `json:"podDisruptionBudget,omitempty"`
+
+	// Suspend defines whether the operator stops applying changes to the resources it manages for this CR,
+	// such as to keep manual changes in place during an incident
+	// +optional
+	Suspend *SuspendConfig `json:"suspend,omitempty"`
+
+	// DeletionPolicy defines what happens to the PersistentVolumeClaims the operator generated for this resource, such as its
+	// CHIA_ROOT volume claims and a ChiaHarvester's generated plot volume claims, when the resource is deleted. Defaults to Retain.
+	// +optional
+	DeletionPolicy *DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// DeletionPolicy defines what happens to the storage the operator generated for a resource when the resource is deleted
+// +kubebuilder:validation:Enum=Retain;Delete;Snapshot
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyRetain keeps the generated PersistentVolumeClaims
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+
+	// DeletionPolicyDelete deletes the generated PersistentVolumeClaims. The bound volumes are then handled by their own reclaim policy.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+
+	// DeletionPolicySnapshot takes a VolumeSnapshot of each generated PersistentVolumeClaim, and deletes the claims once the snapshots are ready to use
+	DeletionPolicySnapshot DeletionPolicy = "Snapshot"
+)
+
+// SuspendConfig defines whether a resource is suspended. A resource can also be suspended with the k8s.chia.net/suspend annotation,
+// set to "true" to suspend it, or to "scale-to-zero" to suspend it and scale its workload to zero.
+type SuspendConfig struct {
+	// Enabled defines whether the operator stops applying changes to the resources it manages for this CR. Defaults to false.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// ScaleToZero defines whether the CR's Deployment or StatefulSet is scaled to zero replicas while it's suspended.
+	// PersistentVolumeClaims and Services are kept, and the previous number of replicas is restored when the CR is no longer suspended.
+	// Defaults to false.
+	// +optional
+	ScaleToZero *bool `json:"scaleToZero,omitempty"`
+}
+
+// PodDisruptionBudgetConfig defines the configuration of a PodDisruptionBudget for a resource's Pods
+type PodDisruptionBudgetConfig struct {
+	// Enabled defines whether a PodDisruptionBudget is created.
+	// Defaults to true for ChiaNodes with more than one replica, and false otherwise.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// MinAvailable is the number or percentage of Pods that must still be available after an eviction.
+	// Mutually exclusive with maxUnavailable.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// MaxUnavailable is the number or percentage of Pods that can be unavailable after an eviction.
+	// Mutually exclusive with minAvailable. Defaults to 1 if neither is set.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// UnhealthyPodEvictionPolicy defines the criteria for when unhealthy Pods should be considered for eviction
+	// +optional
+	UnhealthyPodEvictionPolicy *policyv1.UnhealthyPodEvictionPolicyType `json:"unhealthyPodEvictionPolicy,omitempty"`
 }
 
 // ExtraContainer allows defining a container spec that will share the kubernetes Pod alongside a Chia container, or run as an init container, along with some additional Pod spec configuration
@@ -156,6 +249,19 @@ type CommonSpecChia struct {
 	// +optional
 	AdditionalEnv *[]corev1.EnvVar `json:"additionalEnv,omitempty"`
 
+	// ConfigOverrides sets fields in the chia config, using the same structure as the chia config file. For example, {"full_node": {"enable_upnp": false}}.
+	// Keys and values are validated against the chia config schema, and unknown keys are rejected.
+	// Keys that are set by a first-class setting, such as full_node.port or self_hostname, and the daemon and RPC ports are rejected too.
+	// additionalEnv takes precedence over overrides.
+	// +optional
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	ConfigOverrides *apiextensionsv1.JSON `json:"configOverrides,omitempty"`
+
+	// ConfigFile defines an optional mode where the operator renders the chia config.yaml into a ConfigMap and mounts it in CHIA_ROOT
+	// +optional
+	ConfigFile *ChiaConfigFileConfig `json:"configFile,omitempty"`
+
 	// LivenessProbe used to determine if a container is running properly and will restart the container if the probe fails
 	// +optional
 	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
@@ -177,6 +283,15 @@ type CommonSpecChia struct {
 	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
 }
 
+// ChiaConfigFileConfig defines the configuration of the chia config.yaml rendered by the operator
+type ChiaConfigFileConfig struct {
+	// Enabled defines whether the operator renders the chia config.yaml into a ConfigMap, which is installed in CHIA_ROOT each time the chia container starts.
+	// A new CHIA_ROOT gets the whole rendered config, and an existing config.yaml has the fields the operator sets merged into it.
+	// Defaults to false, which leaves the chia image's entrypoint to write the config from environment variables.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
 // SpecChiaExporter defines the desired state of Chia exporter configuration
 type SpecChiaExporter struct {
 	// Enabled defines whether a chia-exporter sidecar container should run with the chia container
@@ -237,6 +352,19 @@ type SpecChiaHealthcheck struct {
 	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
 }
 
+// RPCStatusConfig defines how the operator queries a chia service's RPC server to report its state in the resource's status
+type RPCStatusConfig struct {
+	// Enabled defines whether the operator should query the RPC server in each of this resource's Pods.
+	// The operator authenticates to the RPC server with a client certificate signed by the private CA in the resource's CA Secret.
+	// Defaults to true.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Interval is the minimum amount of time between RPC queries to each Pod. Defaults to 1m.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
 // ChiaSecretKey defines the name of a kubernetes secret and key in that namespace that contains the Chia mnemonic
 type ChiaSecretKey struct {
 	// SecretName is the name of the kubernetes secret containing a mnemonic key
@@ -333,6 +461,15 @@ type PlotsConfig struct {
 	// HostPathVolume use an existing directory on the host to mount plot directories
 	// +optional
 	HostPathVolume []*HostPathVolumeConfig `json:"hostPathVolume,omitempty"`
+
+	// PlotDirectories is an explicit list of directories in the harvester container to add to the harvester's plot_directories config.
+	// Defaults to the mount path of each plot volume.
+	// +optional
+	PlotDirectories []string `json:"plotDirectories,omitempty"`
+
+	// RecursivePlotScan defines whether the harvester scans the subdirectories of its plot directories for plots. Defaults to true.
+	// +optional
+	RecursivePlotScan *bool `json:"recursivePlotScan,omitempty"`
 }
 
 // DataLayerServerFilesConfig optional config for data_layer server file persistent storage.
@@ -349,6 +486,14 @@ type DataLayerServerFilesConfig struct {
 
 // PersistentVolumeClaimConfig config for PVC volumes in kubernetes
 type PersistentVolumeClaimConfig struct {
+	// Name is a stable name for a harvester plot volume, which is required with the GenerateVolumeClaims option.
+	// The generated PVC is named <harvester name>-harvester-plots-<name>, so reordering the list doesn't change which PVC a volume uses.
+	// Only relevant for harvester plot volumes.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	Name string `json:"name,omitempty"`
+
 	// ClaimName is the name of an existing PersistentVolumeClaim in the target namespace
 	// This field does nothing on ChiaNode resources.
 	// This field does nothing when GenerateVolumeClaims is set to true.
@@ -361,18 +506,33 @@ type PersistentVolumeClaimConfig struct {
 	// +optional
 	GenerateVolumeClaims bool `json:"generateVolumeClaims,omitempty"`
 
-	// StorageClass is the name of a storage class for the PVC. Only relevant for ChiaNodes and use with the GenerateVolumeClaims option.
+	// StorageClass is the name of a storage class for the PVC. Only relevant for ChiaNodes, harvester plot volumes, and use with the GenerateVolumeClaims option.
 	// +optional
 	StorageClass string `json:"storageClass,omitempty"`
 
-	// AccessModes are the volume access modes. Only relevant for ChiaNodes and use with the GenerateVolumeClaims option.
+	// AccessModes are the volume access modes. Only relevant for ChiaNodes, harvester plot volumes, and use with the GenerateVolumeClaims option.
 	// Defaults to RWO if unspecified.
 	// +optional
 	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes"`
 
-	// ResourceRequest is the amount of storage requested. Only relevant for ChiaNodes and use with the GenerateVolumeClaims option.
+	// ResourceRequest is the amount of storage requested. Only relevant for ChiaNodes, harvester plot volumes, and use with the GenerateVolumeClaims option.
 	// +optional
 	ResourceRequest string `json:"resourceRequest,omitempty"`
+
+	// Selector is a label query over pre-provisioned PersistentVolumes for the PVC to bind to, such as local PersistentVolumes on a plotting node.
+	// Only relevant for harvester plot volumes and use with the GenerateVolumeClaims option.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// MountPath is the path the volume is mounted at in the harvester container. Defaults to /plots/pvc-plots-<index>.
+	// Only relevant for harvester plot volumes.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
+	// SubPath is a path within the volume to mount instead of the volume's root.
+	// Only relevant for harvester plot volumes.
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
 }
 
 // HostPathVolumeConfig config for hostPath volumes in kubernetes
@@ -381,6 +541,16 @@ type HostPathVolumeConfig struct {
 	// If a HostPath is used, it is highly recommended that a NodeSelector is used to keep the Pod on the host that has the directory to mount.
 	// +optional
 	Path string `json:"path,omitempty"`
+
+	// MountPath is the path the volume is mounted at in the harvester container. Defaults to /plots/hostpath-plots-<index>.
+	// Only relevant for harvester plot volumes.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
+	// SubPath is a path within the volume to mount instead of the volume's root.
+	// Only relevant for harvester plot volumes.
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
 }
 
 // Peer config for a peer - host and port
@@ -391,3 +561,18 @@ type Peer struct {
 	// Port is the port number the full_node's peer port is listening on.
 	Port uint16 `json:"port"`
 }
+
+// CertManagerIssuerRef references a cert-manager Issuer or ClusterIssuer
+type CertManagerIssuerRef struct {
+	// Name is the name of the Issuer or ClusterIssuer
+	Name string `json:"name"`
+
+	// Kind is the kind of the issuer, either Issuer or ClusterIssuer. Defaults to Issuer.
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Group is the API group of the issuer. Defaults to cert-manager.io, set it to use an external issuer.
+	// +optional
+	Group string `json:"group,omitempty"`
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ChiaFarmSpec defines the desired state of ChiaFarm
+type ChiaFarmSpec struct {
+	// ChiaConfig defines the Chia configuration shared by every component in the farm
+	ChiaConfig ChiaFarmSpecChia `json:"chia"`
+
+	// CA defines the ChiaCA the operator creates for the farm. The CA Secret defaults to <name>-ca.
+	// Not used if chia.caSecretName references an existing CA Secret.
+	// +optional
+	CA *ChiaCASpec `json:"ca,omitempty"`
+
+	// Node defines the configuration of the farm's ChiaNode
+	// +optional
+	Node ChiaFarmNodeSpec `json:"node,omitempty"`
+
+	// Farmer defines the configuration of the farm's ChiaFarmer
+	// +optional
+	Farmer ChiaFarmFarmerSpec `json:"farmer,omitempty"`
+
+	// Wallet defines the configuration of the farm's ChiaWallet
+	// +optional
+	Wallet ChiaFarmWalletSpec `json:"wallet,omitempty"`
+
+	// Harvesters is a list of ChiaHarvesters that connect to the farm's farmer. Each harvester is named <name>-<harvester name>.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Harvesters []ChiaFarmHarvesterSpec `json:"harvesters,omitempty"`
+}
+
+// ChiaFarmSpecChia defines the Chia configuration shared by every component in a farm.
+// Components that set their own image or logLevel use their own setting instead.
+type ChiaFarmSpecChia struct {
+	// CASecretName is the name of an existing Secret that contains the CA crt and key.
+	// If unset, the operator creates a ChiaCA for the farm.
+	// +optional
+	CASecretName string `json:"caSecretName,omitempty"`
+
+	// SecretKey defines the k8s Secret name and key for the Chia mnemonic used by the farmer and wallet
+	SecretKey ChiaSecretKey `json:"secretKey"`
+
+	// Image defines the image to use for the chia component containers
+	// +optional
+	Image *string `json:"image,omitempty"`
+
+	// ChiaNetwork is the name of a ChiaNetwork resource in the same namespace as this resource
+	// +optional
+	ChiaNetwork *string `json:"chiaNetwork,omitempty"`
+
+	// Timezone can be set to your local timezone for accurate timestamps. Defaults to UTC
+	// +optional
+	Timezone *string `json:"timezone,omitempty"`
+
+	// LogLevel is set to the desired chia config log_level
+	// +optional
+	LogLevel *string `json:"logLevel,omitempty"`
+}
+
+// ChiaFarmComponentSpec defines the configuration of a Chia component in a farm. It's a small subset of the component's own spec.
+// For component settings that aren't available here, create the component resources individually instead of with a ChiaFarm.
+type ChiaFarmComponentSpec struct {
+	// Image defines the image to use for the component's chia container. Defaults to the farm's chia.image.
+	// +optional
+	Image *string `json:"image,omitempty"`
+
+	// LogLevel is set to the desired chia config log_level. Defaults to the farm's chia.logLevel.
+	// +optional
+	LogLevel *string `json:"logLevel,omitempty"`
+
+	// ConfigOverrides sets fields in the component's chia config, the same way as a component's own chia.configOverrides
+	// +optional
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	ConfigOverrides *apiextensionsv1.JSON `json:"configOverrides,omitempty"`
+
+	// Resources defines the compute resources for the component's chia container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Storage defines the component's persistent storage
+	// +optional
+	Storage *StorageConfig `json:"storage,omitempty"`
+
+	// NodeSelector selects the nodes the component's pods can be scheduled to
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations allows the component's pods to be scheduled to nodes with matching taints
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Suspend stops the operator from applying changes to the component's resources
+	// +optional
+	Suspend *SuspendConfig `json:"suspend,omitempty"`
+
+	// DeletionPolicy defines what happens to the PersistentVolumeClaims the operator generated for the component when it's deleted.
+	// Defaults to Retain.
+	// +optional
+	DeletionPolicy *DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// ChiaFarmNodeSpec defines the configuration of a farm's ChiaNode
+type ChiaFarmNodeSpec struct {
+	ChiaFarmComponentSpec `json:",inline"`
+
+	// ChiaHealthcheckConfig defines the configuration options available to an optional Chia healthcheck sidecar
+	// +optional
+	ChiaHealthcheckConfig SpecChiaHealthcheck `json:"chiaHealthcheck,omitempty"`
+
+	// ChiaDBPullConfig defines the configuration options available to an optional chia-db-pull init container
+	// +optional
+	ChiaDBPullConfig SpecChiaDBPull `json:"chiaDBPull,omitempty"`
+
+	// Replicas is the desired number of full_node replicas. defaults to 1.
+	// +optional
+	// +kubebuilder:default=1
+	Replicas int32 `json:"replicas,omitempty"`
+}
+
+// ChiaFarmFarmerSpec defines the configuration of a farm's ChiaFarmer
+type ChiaFarmFarmerSpec struct {
+	ChiaFarmComponentSpec `json:",inline"`
+
+	// ChiaHealthcheckConfig defines the configuration options available to an optional Chia healthcheck sidecar
+	// +optional
+	ChiaHealthcheckConfig SpecChiaHealthcheck `json:"chiaHealthcheck,omitempty"`
+}
+
+// ChiaFarmWalletSpec defines the configuration of a farm's ChiaWallet
+type ChiaFarmWalletSpec struct {
+	ChiaFarmComponentSpec `json:",inline"`
+
+	// Enabled defines whether the farm includes a ChiaWallet. Defaults to true.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// ChiaFarmHarvesterSpec defines the configuration of one of a farm's ChiaHarvesters
+type ChiaFarmHarvesterSpec struct {
+	ChiaFarmComponentSpec `json:",inline"`
+
+	// ChiaHealthcheckConfig defines the configuration options available to an optional Chia healthcheck sidecar
+	// +optional
+	ChiaHealthcheckConfig SpecChiaHealthcheck `json:"chiaHealthcheck,omitempty"`
+
+	// Name identifies the harvester in the farm, and is appended to the farm's name to name the ChiaHarvester
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// ChiaFarmStatus defines the observed state of ChiaFarm
+type ChiaFarmStatus struct {
+	// Ready says whether the farm is ready, this should be true when all of the farm's components are ready
+	// +kubebuilder:default=false
+	Ready bool `json:"ready,omitempty"`
+
+	// ObservedGeneration is the most recent metadata.generation that was reconciled by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of this resource's state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// CASecretName is the name of the CA Secret the farm's components use
+	// +optional
+	CASecretName string `json:"caSecretName,omitempty"`
+
+	// Components contains the readiness of each of the farm's child resources
+	// +optional
+	Components []ChiaFarmComponentStatus `json:"components,omitempty"`
+}
+
+// ChiaFarmComponentStatus defines the observed state of one of a farm's child resources
+type ChiaFarmComponentStatus struct {
+	// Kind is the kind of the child resource
+	Kind string `json:"kind"`
+
+	// Name is the name of the child resource
+	Name string `json:"name"`
+
+	// Ready says whether the child resource is ready
+	Ready bool `json:"ready"`
+
+	// Message is the message of the child resource's Ready condition
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="CA Secret",type=string,JSONPath=`.status.caSecretName`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ChiaFarm is the Schema for the chiafarms API
+type ChiaFarm struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ChiaFarmSpec   `json:"spec,omitempty"`
+	Status ChiaFarmStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ChiaFarmList contains a list of ChiaFarm
+type ChiaFarmList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ChiaFarm `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ChiaFarm{}, &ChiaFarmList{})
+}
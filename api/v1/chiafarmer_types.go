@@ -20,6 +20,10 @@ type ChiaFarmerSpec struct {
 	// +optional
 	ChiaHealthcheckConfig SpecChiaHealthcheck `json:"chiaHealthcheck,omitempty"`
 
+	// RPCStatus defines how the operator queries the farmer RPC server to report its connections and farming activity
+	// +optional
+	RPCStatus RPCStatusConfig `json:"rpcStatus,omitempty"`
+
 	// Strategy describes how to replace existing pods with new ones.
 	// +optional
 	Strategy *appsv1.DeploymentStrategy `json:"strategy,omitempty"`
@@ -53,10 +57,95 @@ type ChiaFarmerStatus struct {
 	// Ready says whether the node is ready, this should be true when the node statefulset is in the target namespace
 	// +kubebuilder:default=false
 	Ready bool `json:"ready,omitempty"`
+
+	// ObservedGeneration is the most recent metadata.generation that was reconciled by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of this resource's state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Farming contains the farmer's connections and recent farming activity, as reported by its RPC server
+	// +optional
+	Farming *ChiaFarmerFarmingStatus `json:"farming,omitempty"`
+}
+
+// ChiaFarmerFarmingStatus defines the connections and recent farming activity of a farmer, as reported by its RPC server
+type ChiaFarmerFarmingStatus struct {
+	// PodName is the name of the farmer Pod that was queried
+	// +optional
+	PodName string `json:"podName,omitempty"`
+
+	// ConnectedHarvesters is the number of harvesters connected to this farmer
+	ConnectedHarvesters int32 `json:"connectedHarvesters"`
+
+	// Harvesters lists the harvesters connected to this farmer
+	// +optional
+	Harvesters []ChiaFarmerHarvesterConnection `json:"harvesters,omitempty"`
+
+	// FullNodePeers lists the full_nodes this farmer is connected to
+	// +optional
+	FullNodePeers []ChiaFarmerPeerConnection `json:"fullNodePeers,omitempty"`
+
+	// RecentProofs is the number of proofs found for the recent signage points the farmer keeps track of
+	RecentProofs int32 `json:"recentProofs"`
+
+	// PartialsFound24h is the number of pool partials found in the last 24 hours, across all of the farmer's pools
+	PartialsFound24h int32 `json:"partialsFound24h"`
+
+	// PartialsAcknowledged24h is the number of pool partials acknowledged by pools in the last 24 hours, across all of the farmer's pools
+	PartialsAcknowledged24h int32 `json:"partialsAcknowledged24h"`
+
+	// DisconnectedHarvesters lists the names of ChiaHarvesters in this namespace whose farmerAddress points to this farmer, but are not connected to it
+	// +optional
+	DisconnectedHarvesters []string `json:"disconnectedHarvesters,omitempty"`
+
+	// LastChecked is the last time the operator queried the farmer's RPC server
+	LastChecked metav1.Time `json:"lastChecked"`
+
+	// Error contains the error encountered the last time the operator queried the farmer's RPC server, if any
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// ChiaFarmerHarvesterConnection defines a harvester connected to a farmer
+type ChiaFarmerHarvesterConnection struct {
+	// NodeID is the harvester's node ID
+	NodeID string `json:"nodeID"`
+
+	// Host is the address the harvester connected from
+	Host string `json:"host"`
+
+	// PlotCount is the number of plots the harvester reported to the farmer
+	PlotCount int32 `json:"plotCount"`
+
+	// ChiaHarvester is the name of the ChiaHarvester in this namespace that this harvester belongs to, if any
+	// +optional
+	ChiaHarvester string `json:"chiaHarvester,omitempty"`
+}
+
+// ChiaFarmerPeerConnection defines a full_node peer connected to a farmer
+type ChiaFarmerPeerConnection struct {
+	// NodeID is the peer's node ID
+	NodeID string `json:"nodeID"`
+
+	// Host is the peer's address
+	Host string `json:"host"`
+
+	// Port is the peer's port
+	Port int32 `json:"port"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Harvesters",type=integer,JSONPath=`.status.farming.connectedHarvesters`
+//+kubebuilder:printcolumn:name="Proofs",type=integer,JSONPath=`.status.farming.recentProofs`,priority=1
+//+kubebuilder:printcolumn:name="Partials",type=integer,JSONPath=`.status.farming.partialsFound24h`,priority=1
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ChiaFarmer is the Schema for the chiafarmers API
 type ChiaFarmer struct {
@@ -6,6 +6,8 @@ package v1
 
 import (
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -20,9 +22,42 @@ type ChiaHarvesterSpec struct {
 	// +optional
 	ChiaHealthcheckConfig SpecChiaHealthcheck `json:"chiaHealthcheck,omitempty"`
 
+	// RPCStatus defines how the operator queries the harvester RPC server to report its plot inventory
+	// +optional
+	RPCStatus RPCStatusConfig `json:"rpcStatus,omitempty"`
+
 	// Strategy describes how to replace existing pods with new ones.
+	// Not used when the harvester runs as a DaemonSet, see daemonSet.updateStrategy.
 	// +optional
 	Strategy *appsv1.DeploymentStrategy `json:"strategy,omitempty"`
+
+	// DaemonSet defines an optional mode where the harvester runs as a DaemonSet on every node matching the nodeSelector,
+	// affinity, and tolerations, instead of as a single Deployment
+	// +optional
+	DaemonSet *ChiaHarvesterDaemonSetConfig `json:"daemonSet,omitempty"`
+}
+
+// ChiaHarvesterDaemonSetConfig defines the configuration of a harvester ran as a DaemonSet.
+// Each harvester Pod discovers the plot directories on its own node when it starts.
+type ChiaHarvesterDaemonSetConfig struct {
+	// Enabled defines whether the harvester runs as a DaemonSet instead of as a Deployment. Defaults to false.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// HostPath is a directory on each node that contains the node's plot directories.
+	// It's mounted read-only at the same path in the harvester Pods.
+	// +kubebuilder:validation:Pattern=`^/.+`
+	HostPath string `json:"hostPath"`
+
+	// PathGlobs is a list of glob patterns, under hostPath, that match the plot directories on each node, such as /mnt/disk*/plots.
+	// Defaults to hostPath itself, which is scanned recursively for plots.
+	// Nodes that have the k8s.chia.net/plot-directories annotation use the comma separated paths or globs in that annotation instead.
+	// +optional
+	PathGlobs []string `json:"pathGlobs,omitempty"`
+
+	// UpdateStrategy describes how to replace existing harvester Pods with new ones.
+	// +optional
+	UpdateStrategy *appsv1.DaemonSetUpdateStrategy `json:"updateStrategy,omitempty"`
 }
 
 // ChiaHarvesterSpecChia defines the desired state of Chia component configuration
@@ -42,10 +77,133 @@ type ChiaHarvesterStatus struct {
 	// Ready says whether the node is ready, this should be true when the node statefulset is in the target namespace
 	// +kubebuilder:default=false
 	Ready bool `json:"ready,omitempty"`
+
+	// ObservedGeneration is the most recent metadata.generation that was reconciled by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of this resource's state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Plots contains the plot inventory reported by the harvester's RPC server
+	// +optional
+	Plots *ChiaHarvesterPlotStatus `json:"plots,omitempty"`
+
+	// PlotVolumeClaims contains the state of each of the harvester's plot PersistentVolumeClaims
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	PlotVolumeClaims []ChiaHarvesterPlotVolumeClaimStatus `json:"plotVolumeClaims,omitempty"`
+}
+
+// ChiaHarvesterPlotVolumeClaimStatus defines the observed state of one of a harvester's plot PersistentVolumeClaims
+type ChiaHarvesterPlotVolumeClaimStatus struct {
+	// Name is the name of the plot volume in the harvester Pod, such as pvc-plots-0
+	Name string `json:"name"`
+
+	// ClaimName is the name of the PersistentVolumeClaim
+	ClaimName string `json:"claimName"`
+
+	// Generated says whether the operator generated the PersistentVolumeClaim
+	Generated bool `json:"generated"`
+
+	// Phase is the PersistentVolumeClaim's phase, or empty if the claim doesn't exist
+	// +optional
+	Phase corev1.PersistentVolumeClaimPhase `json:"phase,omitempty"`
+
+	// Capacity is the storage capacity of the volume bound to the PersistentVolumeClaim
+	// +optional
+	Capacity *resource.Quantity `json:"capacity,omitempty"`
+}
+
+// ChiaHarvesterPlotStatus defines the plot inventory of a harvester, as reported by its RPC server
+type ChiaHarvesterPlotStatus struct {
+	// PodName is the name of the harvester Pod that was queried. For harvesters ran as a DaemonSet, it's only set to the Pod
+	// whose query failed, if one did.
+	// +optional
+	PodName string `json:"podName,omitempty"`
+
+	// PodCount is the number of harvester Pods whose plot inventories are included. Harvesters ran as a DaemonSet include
+	// the plot inventory of every running Pod.
+	// +optional
+	PodCount int32 `json:"podCount,omitempty"`
+
+	// PlotCount is the number of plots the harvester has loaded
+	PlotCount int32 `json:"plotCount"`
+
+	// TotalRawSize is the total size on disk of the loaded plots, rounded down to the nearest mebibyte
+	TotalRawSize resource.Quantity `json:"totalRawSize"`
+
+	// TotalEffectiveSize is the total effective size of the loaded plots, rounded down to the nearest mebibyte.
+	// This is the size the loaded plots would be if they were uncompressed.
+	TotalEffectiveSize resource.Quantity `json:"totalEffectiveSize"`
+
+	// FailedToOpenCount is the number of plot files the harvester failed to open
+	FailedToOpenCount int32 `json:"failedToOpenCount"`
+
+	// NotFoundCount is the number of plot files the harvester could no longer find
+	NotFoundCount int32 `json:"notFoundCount"`
+
+	// DuplicateCount is the number of loaded plot files that have the same plot ID as another loaded plot file
+	DuplicateCount int32 `json:"duplicateCount"`
+
+	// InvalidPlots lists some of the plot files that failed to open or could no longer be found
+	// +optional
+	InvalidPlots []string `json:"invalidPlots,omitempty"`
+
+	// DuplicatePlots lists some of the plot files that have the same plot ID as another loaded plot file
+	// +optional
+	DuplicatePlots []string `json:"duplicatePlots,omitempty"`
+
+	// Volumes contains the plot inventory of each plot volume, keyed by the name of the volume
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Volumes []ChiaHarvesterPlotVolumeStatus `json:"volumes,omitempty"`
+
+	// LastChecked is the last time the operator queried the harvester's RPC server
+	LastChecked metav1.Time `json:"lastChecked"`
+
+	// Error contains the error encountered the last time the operator queried the harvester's RPC server, if any
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// ChiaHarvesterPlotVolumeStatus defines the plot inventory of a single plot volume
+type ChiaHarvesterPlotVolumeStatus struct {
+	// Name is the name of the plot volume in the harvester Pod, such as pvc-plots-0 or hostpath-plots-0
+	Name string `json:"name"`
+
+	// MountPath is the path the plot volume is mounted at in the harvester Pod
+	MountPath string `json:"mountPath"`
+
+	// PlotCount is the number of plots the harvester has loaded from this volume
+	PlotCount int32 `json:"plotCount"`
+
+	// TotalRawSize is the total size on disk of the plots loaded from this volume, rounded down to the nearest mebibyte
+	TotalRawSize resource.Quantity `json:"totalRawSize"`
+
+	// TotalEffectiveSize is the total effective size of the plots loaded from this volume, rounded down to the nearest mebibyte
+	TotalEffectiveSize resource.Quantity `json:"totalEffectiveSize"`
+
+	// InvalidCount is the number of plot files on this volume that failed to open or could no longer be found
+	InvalidCount int32 `json:"invalidCount"`
+
+	// DuplicateCount is the number of plot files on this volume that have the same plot ID as another loaded plot file
+	DuplicateCount int32 `json:"duplicateCount"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Plots",type=integer,JSONPath=`.status.plots.plotCount`
+//+kubebuilder:printcolumn:name="Raw Size",type=string,JSONPath=`.status.plots.totalRawSize`
+//+kubebuilder:printcolumn:name="Effective Size",type=string,JSONPath=`.status.plots.totalEffectiveSize`
+//+kubebuilder:printcolumn:name="Invalid",type=integer,JSONPath=`.status.plots.failedToOpenCount`,priority=1
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ChiaHarvester is the Schema for the chiaharvesters API
 type ChiaHarvester struct {
@@ -22,11 +22,21 @@ type ChiaNodeSpec struct {
 	ChiaHealthcheckConfig SpecChiaHealthcheck `json:"chiaHealthcheck,omitempty"`
 
 	// ChiaDBPullConfig defines the configuration options available to an optional chia-db-pull init container
-	// that downloads a chia blockchain database from an S3-compatible bucket into CHIA_ROOT before the chia
-	// container starts.
+	// that downloads a chia blockchain database from an S3-compatible bucket, HTTP(S) mirrors, or a PersistentVolumeClaim
+	// into CHIA_ROOT before the chia container starts, or restores CHIA_ROOT from another ChiaNode's VolumeSnapshot.
 	// +optional
 	ChiaDBPullConfig SpecChiaDBPull `json:"chiaDBPull,omitempty"`
 
+	// RPCStatus defines how the operator queries the full_node RPC server in each replica to report its sync status
+	// +optional
+	RPCStatus RPCStatusConfig `json:"rpcStatus,omitempty"`
+
+	// Snapshots defines an optional schedule of CSI VolumeSnapshots of a synced replica's CHIA_ROOT volume,
+	// which new replicas' CHIA_ROOT volumes are restored from instead of syncing the blockchain from scratch.
+	// Requires a CHIA_ROOT persistentVolumeClaim with a resourceRequest, and RPC status queries.
+	// +optional
+	Snapshots *ChiaNodeSnapshotConfig `json:"snapshots,omitempty"`
+
 	// Replicas is the desired number of replicas of the given Statefulset. defaults to 1.
 	// +optional
 	// +kubebuilder:default=1
@@ -44,15 +54,31 @@ type SpecChiaDBPull struct {
 	// +optional
 	Enabled *bool `json:"enabled,omitempty"`
 
-	// Image defines the image to use for the chia-db-pull init container
+	// Image defines the image to use for the chia-db-pull init container. Defaults to the chia-db-pull image for an s3Prefix source,
+	// and the operator's image, which contains chia-db-restore, for http and persistentVolumeClaim sources.
 	// +optional
 	Image *string `json:"image,omitempty"`
 
-	// S3Prefix is the S3 URI prefix the chia-db-pull container will download the database from.
-	// Required when Enabled is true. Mapped to the S3_PREFIX env var.
+	// S3Prefix is the S3 URI prefix the chia-db-pull container will download the database from. Mapped to the S3_PREFIX env var.
+	// Exactly one of s3Prefix, http, persistentVolumeClaim, or chiaNodeSnapshot is required when Enabled is true.
 	// +optional
 	S3Prefix string `json:"s3Prefix,omitempty"`
 
+	// HTTP downloads the database from a list of HTTP(S) mirrors instead of S3
+	// +optional
+	HTTP *ChiaDBPullHTTPSource `json:"http,omitempty"`
+
+	// PersistentVolumeClaim copies the database from a file on an existing PersistentVolumeClaim instead of S3,
+	// such as an in-cluster mirror in an air-gapped cluster
+	// +optional
+	PersistentVolumeClaim *ChiaDBPullPersistentVolumeClaimSource `json:"persistentVolumeClaim,omitempty"`
+
+	// ChiaNodeSnapshot restores new replicas' CHIA_ROOT volumes from the latest ready VolumeSnapshot of another ChiaNode
+	// in the same namespace, instead of running an init container.
+	// Requires a CHIA_ROOT persistentVolumeClaim with a resourceRequest.
+	// +optional
+	ChiaNodeSnapshot *ChiaDBPullChiaNodeSnapshotSource `json:"chiaNodeSnapshot,omitempty"`
+
 	// Network is the chia network name the database belongs to. Mapped to the NETWORK env var.
 	// If unset, the operator derives the value from the surrounding chia config: it first looks
 	// for a "network" key in the ChiaNetwork ConfigMap referenced by spec.chia.chiaNetwork, then
@@ -62,9 +88,19 @@ type SpecChiaDBPull struct {
 	Network *string `json:"network,omitempty"`
 
 	// MinHeight is the minimum block height the downloaded database should be at. Mapped to the MIN_HEIGHT env var.
+	// Not used with the chiaNodeSnapshot source.
 	// +optional
 	MinHeight *int64 `json:"minHeight,omitempty"`
 
+	// MaxHeightLag is how many blocks behind minHeight, or the ChiaNode's peak height, an existing database in CHIA_ROOT can be
+	// and still be kept when a Pod starts. Existing databases further behind are replaced once a new download is verified.
+	// The ChiaNode's peak height is the highest peak its own replicas last reported in status.peakHeight, not the network's peak,
+	// so it's only known when rpcStatus is enabled.
+	// If unset, an existing database that can be read is always kept. Not used with the chiaNodeSnapshot source.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxHeightLag *int64 `json:"maxHeightLag,omitempty"`
+
 	// AWSCredentialsSecret is the name of a kubernetes Secret in the same namespace whose keys will be loaded
 	// into the chia-db-pull container as environment variables via envFrom.
 	// Use this to inject AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY (or any other credentials) without putting them in plaintext.
@@ -86,6 +122,67 @@ type SpecChiaDBPull struct {
 	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
 }
 
+// ChiaDBPullHTTPSource defines a list of HTTP(S) mirrors of a chia blockchain database
+type ChiaDBPullHTTPSource struct {
+	// URLs of the database file, tried in order until one of them is downloaded and verified.
+	// Files with a .gz extension are decompressed.
+	// +kubebuilder:validation:MinItems=1
+	URLs []string `json:"urls"`
+
+	// SHA256 is the hex encoded SHA-256 checksum of the file as it's downloaded, before it's decompressed.
+	// A mirror whose file doesn't match is skipped.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9a-fA-F]{64}$`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// ChiaDBPullPersistentVolumeClaimSource defines a chia blockchain database file on an existing PersistentVolumeClaim
+type ChiaDBPullPersistentVolumeClaimSource struct {
+	// ClaimName is the name of the PersistentVolumeClaim in the ChiaNode's namespace. It's mounted read-only,
+	// so it needs an access mode that lets it be mounted by each replica's Pod.
+	ClaimName string `json:"claimName"`
+
+	// Path is the path to the database file within the volume. Files with a .gz extension are decompressed.
+	Path string `json:"path"`
+
+	// SHA256 is the hex encoded SHA-256 checksum of the file, before it's decompressed
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9a-fA-F]{64}$`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// ChiaDBPullChiaNodeSnapshotSource defines another ChiaNode whose CHIA_ROOT VolumeSnapshots new replicas are restored from
+type ChiaDBPullChiaNodeSnapshotSource struct {
+	// Name is the name of a ChiaNode in the same namespace that takes VolumeSnapshots.
+	// If this ChiaNode takes its own VolumeSnapshots too, new replicas are restored from whichever ready snapshot has the highest peak height.
+	Name string `json:"name"`
+}
+
+// ChiaNodeSnapshotConfig defines the desired state of a ChiaNode's CHIA_ROOT VolumeSnapshots
+type ChiaNodeSnapshotConfig struct {
+	// Enabled defines whether the operator takes VolumeSnapshots of a synced replica's CHIA_ROOT volume. Defaults to false.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// VolumeSnapshotClassName is the name of the VolumeSnapshotClass to take snapshots with.
+	// Defaults to the cluster's default VolumeSnapshotClass for the CHIA_ROOT volume's CSI driver.
+	// +optional
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+
+	// Interval is the minimum amount of time between snapshots. Defaults to 24h.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Retain is the number of snapshots to keep. Older snapshots are deleted, except for the latest snapshot that's ready to use. Defaults to 3.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Retain *int32 `json:"retain,omitempty"`
+
+	// RestoreNewReplicas defines whether the CHIA_ROOT volumes of new replicas are restored from the latest snapshot that's ready to use. Defaults to true.
+	// +optional
+	RestoreNewReplicas *bool `json:"restoreNewReplicas,omitempty"`
+}
+
 // ChiaNodeSpecChia defines the desired state of Chia component configuration
 type ChiaNodeSpecChia struct {
 	CommonSpecChia `json:",inline"`
@@ -101,6 +198,31 @@ type ChiaNodeSpecChia struct {
 	// FullNodePeers is a list of hostnames/IPs and port numbers to full_node peers.
 	// +optional
 	FullNodePeers *[]Peer `json:"fullNodePeers,omitempty"`
+
+	// ReplicaService defines an optional peer Service for each replica of the StatefulSet, so each full_node can be reached on its own address.
+	// This Service defaults to being disabled.
+	// +optional
+	ReplicaService ChiaNodeReplicaService `json:"replicaService,omitempty"`
+}
+
+// ChiaNodeReplicaService defines the peer Services created for each replica of a ChiaNode's StatefulSet
+type ChiaNodeReplicaService struct {
+	Service `json:",inline"`
+
+	// Replicas contains additional labels and annotations for the Services of individual replicas, such as a static load balancer IP for each one
+	// +optional
+	// +listType=map
+	// +listMapKey=ordinal
+	Replicas []ChiaNodeReplicaServiceOverride `json:"replicas,omitempty"`
+}
+
+// ChiaNodeReplicaServiceOverride defines additional metadata for the Service of a single replica
+type ChiaNodeReplicaServiceOverride struct {
+	AdditionalMetadata `json:",inline"`
+
+	// Ordinal is the StatefulSet ordinal of the replica
+	// +kubebuilder:validation:Minimum=0
+	Ordinal int32 `json:"ordinal"`
 }
 
 // ChiaNodeStatus defines the observed state of ChiaNode
@@ -108,10 +230,138 @@ type ChiaNodeStatus struct {
 	// Ready says whether the node is ready, this should be true when the node statefulset is in the target namespace
 	// +kubebuilder:default=false
 	Ready bool `json:"ready,omitempty"`
+
+	// ObservedGeneration is the most recent metadata.generation that was reconciled by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of this resource's state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// PeakHeight is the highest peak height reported by any of this ChiaNode's full_node replicas
+	// +optional
+	PeakHeight uint32 `json:"peakHeight,omitempty"`
+
+	// SyncedReplicas is the number of full_node replicas that report being synced
+	// +optional
+	SyncedReplicas int32 `json:"syncedReplicas,omitempty"`
+
+	// Replicas contains the sync status of each full_node replica, as reported by its RPC server
+	// +optional
+	Replicas []ChiaNodeReplicaStatus `json:"replicas,omitempty"`
+
+	// Snapshots contains the state of the ChiaNode's CHIA_ROOT VolumeSnapshots
+	// +optional
+	Snapshots *ChiaNodeSnapshotStatus `json:"snapshots,omitempty"`
+
+	// DatabaseRestores contains the source and height of the blockchain database each replica's CHIA_ROOT was bootstrapped from
+	// +optional
+	// +listType=map
+	// +listMapKey=podName
+	DatabaseRestores []ChiaNodeDatabaseRestoreStatus `json:"databaseRestores,omitempty"`
+
+	// ReplicaServices contains the external address of each replica's own peer Service
+	// +optional
+	// +listType=map
+	// +listMapKey=serviceName
+	ReplicaServices []ChiaNodeReplicaServiceStatus `json:"replicaServices,omitempty"`
+}
+
+// ChiaNodeReplicaServiceStatus defines the observed state of a replica's own peer Service
+type ChiaNodeReplicaServiceStatus struct {
+	// PodName is the name of the replica's Pod
+	PodName string `json:"podName"`
+
+	// ServiceName is the name of the replica's Service
+	ServiceName string `json:"serviceName"`
+
+	// ExternalAddress is the IP address or hostname the replica can be reached at from outside the cluster.
+	// This is the load balancer's address for LoadBalancer Services, and the external IP of the replica's Kubernetes node for NodePort Services.
+	// Empty if it isn't known yet.
+	// +optional
+	ExternalAddress string `json:"externalAddress,omitempty"`
+
+	// Port is the port the replica's full_node peer port can be reached at on the external address
+	// +optional
+	Port int32 `json:"port,omitempty"`
+}
+
+// ChiaNodeDatabaseRestoreStatus defines where a replica's blockchain database was bootstrapped from
+type ChiaNodeDatabaseRestoreStatus struct {
+	// PodName is the name of the replica's Pod
+	PodName string `json:"podName"`
+
+	// Source is the S3 prefix, URL, file, or VolumeSnapshot the database was restored from.
+	// Empty if the replica already had a database, which was kept.
+	// +optional
+	Source string `json:"source,omitempty"`
+
+	// Height is the peak height of the database when it was restored, if known
+	// +optional
+	Height uint32 `json:"height,omitempty"`
+
+	// Restored says whether the database was restored, or the replica's existing database was kept
+	Restored bool `json:"restored"`
+}
+
+// ChiaNodeSnapshotStatus defines the observed state of a ChiaNode's CHIA_ROOT VolumeSnapshots
+type ChiaNodeSnapshotStatus struct {
+	// Count is the number of VolumeSnapshots the operator has taken that still exist
+	Count int32 `json:"count"`
+
+	// LatestReadySnapshot is the name of the latest VolumeSnapshot that's ready to use, which new replicas are restored from
+	// +optional
+	LatestReadySnapshot string `json:"latestReadySnapshot,omitempty"`
+
+	// LatestReadySnapshotPeakHeight is the peak height of the replica the latest ready VolumeSnapshot was taken from
+	// +optional
+	LatestReadySnapshotPeakHeight uint32 `json:"latestReadySnapshotPeakHeight,omitempty"`
+
+	// LastSnapshotTime is the last time the operator took a VolumeSnapshot
+	// +optional
+	LastSnapshotTime *metav1.Time `json:"lastSnapshotTime,omitempty"`
+
+	// Error contains the error of the latest VolumeSnapshot, if it failed
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// ChiaNodeReplicaStatus defines the observed state of a single full_node replica
+type ChiaNodeReplicaStatus struct {
+	// PodName is the name of the Pod running this replica
+	PodName string `json:"podName"`
+
+	// PeakHeight is the height of this full_node's peak block
+	// +optional
+	PeakHeight uint32 `json:"peakHeight,omitempty"`
+
+	// Synced says whether this full_node reports being synced to the blockchain
+	Synced bool `json:"synced"`
+
+	// SyncMode says whether this full_node is currently syncing
+	SyncMode bool `json:"syncMode"`
+
+	// ConnectedPeers is the number of full_node peers this full_node is connected to
+	ConnectedPeers int32 `json:"connectedPeers"`
+
+	// LastChecked is the last time the operator queried this full_node's RPC server
+	LastChecked metav1.Time `json:"lastChecked"`
+
+	// Error contains the error encountered the last time the operator queried this full_node's RPC server, if any
+	// +optional
+	Error string `json:"error,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Peak Height",type=integer,JSONPath=`.status.peakHeight`
+//+kubebuilder:printcolumn:name="Synced",type=integer,JSONPath=`.status.syncedReplicas`
+//+kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.spec.replicas`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ChiaNode is the Schema for the chianodes API
 type ChiaNode struct {
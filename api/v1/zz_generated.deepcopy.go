@@ -25,7 +25,11 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -57,13 +61,28 @@ func (in *AdditionalMetadata) DeepCopy() *AdditionalMetadata {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerIssuerRef) DeepCopyInto(out *CertManagerIssuerRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerIssuerRef.
+func (in *CertManagerIssuerRef) DeepCopy() *CertManagerIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaCA) DeepCopyInto(out *ChiaCA) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCA.
@@ -84,6 +103,51 @@ func (in *ChiaCA) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaCACertManagerConfig) DeepCopyInto(out *ChiaCACertManagerConfig) {
+	*out = *in
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(CertManagerIssuerRef)
+		**out = **in
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCACertManagerConfig.
+func (in *ChiaCACertManagerConfig) DeepCopy() *ChiaCACertManagerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaCACertManagerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaCAImport) DeepCopyInto(out *ChiaCAImport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCAImport.
+func (in *ChiaCAImport) DeepCopy() *ChiaCAImport {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaCAImport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaCAList) DeepCopyInto(out *ChiaCAList) {
 	*out = *in
@@ -116,9 +180,49 @@ func (in *ChiaCAList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaCARotation) DeepCopyInto(out *ChiaCARotation) {
+	*out = *in
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCARotation.
+func (in *ChiaCARotation) DeepCopy() *ChiaCARotation {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaCARotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaCASpec) DeepCopyInto(out *ChiaCASpec) {
 	*out = *in
+	if in.Import != nil {
+		in, out := &in.Import, &out.Import
+		*out = new(ChiaCAImport)
+		**out = **in
+	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(ChiaCARotation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CertManager != nil {
+		in, out := &in.CertManager, &out.CertManager
+		*out = new(ChiaCACertManagerConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OverlapPeriod != nil {
+		in, out := &in.OverlapPeriod, &out.OverlapPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCASpec.
@@ -134,6 +238,29 @@ func (in *ChiaCASpec) DeepCopy() *ChiaCASpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaCAStatus) DeepCopyInto(out *ChiaCAStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.RenewalTime != nil {
+		in, out := &in.RenewalTime, &out.RenewalTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PreviousTrustedUntil != nil {
+		in, out := &in.PreviousTrustedUntil, &out.PreviousTrustedUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCAStatus.
@@ -146,13 +273,29 @@ func (in *ChiaCAStatus) DeepCopy() *ChiaCAStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaCertificateStatus) DeepCopyInto(out *ChiaCertificateStatus) {
+	*out = *in
+	in.NotAfter.DeepCopyInto(&out.NotAfter)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCertificateStatus.
+func (in *ChiaCertificateStatus) DeepCopy() *ChiaCertificateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaCertificateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaCertificates) DeepCopyInto(out *ChiaCertificates) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCertificates.
@@ -173,6 +316,41 @@ func (in *ChiaCertificates) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaCertificatesCertManagerConfig) DeepCopyInto(out *ChiaCertificatesCertManagerConfig) {
+	*out = *in
+	if in.PrivateIssuerRef != nil {
+		in, out := &in.PrivateIssuerRef, &out.PrivateIssuerRef
+		*out = new(CertManagerIssuerRef)
+		**out = **in
+	}
+	if in.PublicIssuerRef != nil {
+		in, out := &in.PublicIssuerRef, &out.PublicIssuerRef
+		*out = new(CertManagerIssuerRef)
+		**out = **in
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCertificatesCertManagerConfig.
+func (in *ChiaCertificatesCertManagerConfig) DeepCopy() *ChiaCertificatesCertManagerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaCertificatesCertManagerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaCertificatesList) DeepCopyInto(out *ChiaCertificatesList) {
 	*out = *in
@@ -208,6 +386,21 @@ func (in *ChiaCertificatesList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaCertificatesSpec) DeepCopyInto(out *ChiaCertificatesSpec) {
 	*out = *in
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RestartWorkloads != nil {
+		in, out := &in.RestartWorkloads, &out.RestartWorkloads
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CertManager != nil {
+		in, out := &in.CertManager, &out.CertManager
+		*out = new(ChiaCertificatesCertManagerConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCertificatesSpec.
@@ -223,6 +416,32 @@ func (in *ChiaCertificatesSpec) DeepCopy() *ChiaCertificatesSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaCertificatesStatus) DeepCopyInto(out *ChiaCertificatesStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.RenewalTime != nil {
+		in, out := &in.RenewalTime, &out.RenewalTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = make([]ChiaCertificateStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCertificatesStatus.
@@ -235,13 +454,33 @@ func (in *ChiaCertificatesStatus) DeepCopy() *ChiaCertificatesStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaConfigFileConfig) DeepCopyInto(out *ChiaConfigFileConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaConfigFileConfig.
+func (in *ChiaConfigFileConfig) DeepCopy() *ChiaConfigFileConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaConfigFileConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaCrawler) DeepCopyInto(out *ChiaCrawler) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCrawler.
@@ -340,6 +579,13 @@ func (in *ChiaCrawlerSpecChia) DeepCopy() *ChiaCrawlerSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaCrawlerStatus) DeepCopyInto(out *ChiaCrawlerStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaCrawlerStatus.
@@ -352,13 +598,63 @@ func (in *ChiaCrawlerStatus) DeepCopy() *ChiaCrawlerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaDBPullChiaNodeSnapshotSource) DeepCopyInto(out *ChiaDBPullChiaNodeSnapshotSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaDBPullChiaNodeSnapshotSource.
+func (in *ChiaDBPullChiaNodeSnapshotSource) DeepCopy() *ChiaDBPullChiaNodeSnapshotSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaDBPullChiaNodeSnapshotSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaDBPullHTTPSource) DeepCopyInto(out *ChiaDBPullHTTPSource) {
+	*out = *in
+	if in.URLs != nil {
+		in, out := &in.URLs, &out.URLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaDBPullHTTPSource.
+func (in *ChiaDBPullHTTPSource) DeepCopy() *ChiaDBPullHTTPSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaDBPullHTTPSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaDBPullPersistentVolumeClaimSource) DeepCopyInto(out *ChiaDBPullPersistentVolumeClaimSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaDBPullPersistentVolumeClaimSource.
+func (in *ChiaDBPullPersistentVolumeClaimSource) DeepCopy() *ChiaDBPullPersistentVolumeClaimSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaDBPullPersistentVolumeClaimSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaDataLayer) DeepCopyInto(out *ChiaDataLayer) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaDataLayer.
@@ -482,6 +778,13 @@ func (in *ChiaDataLayerSpecChia) DeepCopy() *ChiaDataLayerSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaDataLayerStatus) DeepCopyInto(out *ChiaDataLayerStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaDataLayerStatus.
@@ -495,26 +798,26 @@ func (in *ChiaDataLayerStatus) DeepCopy() *ChiaDataLayerStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiaFarmer) DeepCopyInto(out *ChiaFarmer) {
+func (in *ChiaFarm) DeepCopyInto(out *ChiaFarm) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmer.
-func (in *ChiaFarmer) DeepCopy() *ChiaFarmer {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarm.
+func (in *ChiaFarm) DeepCopy() *ChiaFarm {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiaFarmer)
+	out := new(ChiaFarm)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ChiaFarmer) DeepCopyObject() runtime.Object {
+func (in *ChiaFarm) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -522,67 +825,434 @@ func (in *ChiaFarmer) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiaFarmerList) DeepCopyInto(out *ChiaFarmerList) {
+func (in *ChiaFarmComponentSpec) DeepCopyInto(out *ChiaFarmComponentSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]ChiaFarmer, len(*in))
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(string)
+		**out = **in
+	}
+	if in.LogLevel != nil {
+		in, out := &in.LogLevel, &out.LogLevel
+		*out = new(string)
+		**out = **in
+	}
+	if in.ConfigOverrides != nil {
+		in, out := &in.ConfigOverrides, &out.ConfigOverrides
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Suspend != nil {
+		in, out := &in.Suspend, &out.Suspend
+		*out = new(SuspendConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeletionPolicy != nil {
+		in, out := &in.DeletionPolicy, &out.DeletionPolicy
+		*out = new(DeletionPolicy)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerList.
-func (in *ChiaFarmerList) DeepCopy() *ChiaFarmerList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmComponentSpec.
+func (in *ChiaFarmComponentSpec) DeepCopy() *ChiaFarmComponentSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiaFarmerList)
+	out := new(ChiaFarmComponentSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ChiaFarmerList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmComponentStatus) DeepCopyInto(out *ChiaFarmComponentStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmComponentStatus.
+func (in *ChiaFarmComponentStatus) DeepCopy() *ChiaFarmComponentStatus {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(ChiaFarmComponentStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiaFarmerSpec) DeepCopyInto(out *ChiaFarmerSpec) {
+func (in *ChiaFarmFarmerSpec) DeepCopyInto(out *ChiaFarmFarmerSpec) {
 	*out = *in
-	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
-	in.ChiaConfig.DeepCopyInto(&out.ChiaConfig)
+	in.ChiaFarmComponentSpec.DeepCopyInto(&out.ChiaFarmComponentSpec)
 	in.ChiaHealthcheckConfig.DeepCopyInto(&out.ChiaHealthcheckConfig)
-	if in.Strategy != nil {
-		in, out := &in.Strategy, &out.Strategy
-		*out = new(appsv1.DeploymentStrategy)
-		(*in).DeepCopyInto(*out)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerSpec.
-func (in *ChiaFarmerSpec) DeepCopy() *ChiaFarmerSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmFarmerSpec.
+func (in *ChiaFarmFarmerSpec) DeepCopy() *ChiaFarmFarmerSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiaFarmerSpec)
+	out := new(ChiaFarmFarmerSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiaFarmerSpecChia) DeepCopyInto(out *ChiaFarmerSpecChia) {
+func (in *ChiaFarmHarvesterSpec) DeepCopyInto(out *ChiaFarmHarvesterSpec) {
 	*out = *in
-	in.CommonSpecChia.DeepCopyInto(&out.CommonSpecChia)
-	out.SecretKey = in.SecretKey
-	if in.FullNodePeer != nil {
-		in, out := &in.FullNodePeer, &out.FullNodePeer
+	in.ChiaFarmComponentSpec.DeepCopyInto(&out.ChiaFarmComponentSpec)
+	in.ChiaHealthcheckConfig.DeepCopyInto(&out.ChiaHealthcheckConfig)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmHarvesterSpec.
+func (in *ChiaFarmHarvesterSpec) DeepCopy() *ChiaFarmHarvesterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmHarvesterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmList) DeepCopyInto(out *ChiaFarmList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ChiaFarm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmList.
+func (in *ChiaFarmList) DeepCopy() *ChiaFarmList {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ChiaFarmList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmNodeSpec) DeepCopyInto(out *ChiaFarmNodeSpec) {
+	*out = *in
+	in.ChiaFarmComponentSpec.DeepCopyInto(&out.ChiaFarmComponentSpec)
+	in.ChiaHealthcheckConfig.DeepCopyInto(&out.ChiaHealthcheckConfig)
+	in.ChiaDBPullConfig.DeepCopyInto(&out.ChiaDBPullConfig)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmNodeSpec.
+func (in *ChiaFarmNodeSpec) DeepCopy() *ChiaFarmNodeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmNodeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmSpec) DeepCopyInto(out *ChiaFarmSpec) {
+	*out = *in
+	in.ChiaConfig.DeepCopyInto(&out.ChiaConfig)
+	if in.CA != nil {
+		in, out := &in.CA, &out.CA
+		*out = new(ChiaCASpec)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Node.DeepCopyInto(&out.Node)
+	in.Farmer.DeepCopyInto(&out.Farmer)
+	in.Wallet.DeepCopyInto(&out.Wallet)
+	if in.Harvesters != nil {
+		in, out := &in.Harvesters, &out.Harvesters
+		*out = make([]ChiaFarmHarvesterSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmSpec.
+func (in *ChiaFarmSpec) DeepCopy() *ChiaFarmSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmSpecChia) DeepCopyInto(out *ChiaFarmSpecChia) {
+	*out = *in
+	out.SecretKey = in.SecretKey
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(string)
+		**out = **in
+	}
+	if in.ChiaNetwork != nil {
+		in, out := &in.ChiaNetwork, &out.ChiaNetwork
+		*out = new(string)
+		**out = **in
+	}
+	if in.Timezone != nil {
+		in, out := &in.Timezone, &out.Timezone
+		*out = new(string)
+		**out = **in
+	}
+	if in.LogLevel != nil {
+		in, out := &in.LogLevel, &out.LogLevel
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmSpecChia.
+func (in *ChiaFarmSpecChia) DeepCopy() *ChiaFarmSpecChia {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmSpecChia)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmStatus) DeepCopyInto(out *ChiaFarmStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]ChiaFarmComponentStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmStatus.
+func (in *ChiaFarmStatus) DeepCopy() *ChiaFarmStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmWalletSpec) DeepCopyInto(out *ChiaFarmWalletSpec) {
+	*out = *in
+	in.ChiaFarmComponentSpec.DeepCopyInto(&out.ChiaFarmComponentSpec)
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmWalletSpec.
+func (in *ChiaFarmWalletSpec) DeepCopy() *ChiaFarmWalletSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmWalletSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmer) DeepCopyInto(out *ChiaFarmer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmer.
+func (in *ChiaFarmer) DeepCopy() *ChiaFarmer {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ChiaFarmer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmerFarmingStatus) DeepCopyInto(out *ChiaFarmerFarmingStatus) {
+	*out = *in
+	if in.Harvesters != nil {
+		in, out := &in.Harvesters, &out.Harvesters
+		*out = make([]ChiaFarmerHarvesterConnection, len(*in))
+		copy(*out, *in)
+	}
+	if in.FullNodePeers != nil {
+		in, out := &in.FullNodePeers, &out.FullNodePeers
+		*out = make([]ChiaFarmerPeerConnection, len(*in))
+		copy(*out, *in)
+	}
+	if in.DisconnectedHarvesters != nil {
+		in, out := &in.DisconnectedHarvesters, &out.DisconnectedHarvesters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.LastChecked.DeepCopyInto(&out.LastChecked)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerFarmingStatus.
+func (in *ChiaFarmerFarmingStatus) DeepCopy() *ChiaFarmerFarmingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmerFarmingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmerHarvesterConnection) DeepCopyInto(out *ChiaFarmerHarvesterConnection) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerHarvesterConnection.
+func (in *ChiaFarmerHarvesterConnection) DeepCopy() *ChiaFarmerHarvesterConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmerHarvesterConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmerList) DeepCopyInto(out *ChiaFarmerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ChiaFarmer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerList.
+func (in *ChiaFarmerList) DeepCopy() *ChiaFarmerList {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ChiaFarmerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmerPeerConnection) DeepCopyInto(out *ChiaFarmerPeerConnection) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerPeerConnection.
+func (in *ChiaFarmerPeerConnection) DeepCopy() *ChiaFarmerPeerConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmerPeerConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmerSpec) DeepCopyInto(out *ChiaFarmerSpec) {
+	*out = *in
+	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
+	in.ChiaConfig.DeepCopyInto(&out.ChiaConfig)
+	in.ChiaHealthcheckConfig.DeepCopyInto(&out.ChiaHealthcheckConfig)
+	in.RPCStatus.DeepCopyInto(&out.RPCStatus)
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(appsv1.DeploymentStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerSpec.
+func (in *ChiaFarmerSpec) DeepCopy() *ChiaFarmerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaFarmerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaFarmerSpecChia) DeepCopyInto(out *ChiaFarmerSpecChia) {
+	*out = *in
+	in.CommonSpecChia.DeepCopyInto(&out.CommonSpecChia)
+	out.SecretKey = in.SecretKey
+	if in.FullNodePeer != nil {
+		in, out := &in.FullNodePeer, &out.FullNodePeer
 		*out = new(string)
 		**out = **in
 	}
@@ -610,6 +1280,18 @@ func (in *ChiaFarmerSpecChia) DeepCopy() *ChiaFarmerSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaFarmerStatus) DeepCopyInto(out *ChiaFarmerStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Farming != nil {
+		in, out := &in.Farming, &out.Farming
+		*out = new(ChiaFarmerFarmingStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaFarmerStatus.
@@ -628,7 +1310,7 @@ func (in *ChiaHarvester) DeepCopyInto(out *ChiaHarvester) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvester.
@@ -649,6 +1331,36 @@ func (in *ChiaHarvester) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaHarvesterDaemonSetConfig) DeepCopyInto(out *ChiaHarvesterDaemonSetConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PathGlobs != nil {
+		in, out := &in.PathGlobs, &out.PathGlobs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UpdateStrategy != nil {
+		in, out := &in.UpdateStrategy, &out.UpdateStrategy
+		*out = new(appsv1.DaemonSetUpdateStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterDaemonSetConfig.
+func (in *ChiaHarvesterDaemonSetConfig) DeepCopy() *ChiaHarvesterDaemonSetConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaHarvesterDaemonSetConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaHarvesterList) DeepCopyInto(out *ChiaHarvesterList) {
 	*out = *in
@@ -663,22 +1375,94 @@ func (in *ChiaHarvesterList) DeepCopyInto(out *ChiaHarvesterList) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterList.
-func (in *ChiaHarvesterList) DeepCopy() *ChiaHarvesterList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterList.
+func (in *ChiaHarvesterList) DeepCopy() *ChiaHarvesterList {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaHarvesterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ChiaHarvesterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaHarvesterPlotStatus) DeepCopyInto(out *ChiaHarvesterPlotStatus) {
+	*out = *in
+	out.TotalRawSize = in.TotalRawSize.DeepCopy()
+	out.TotalEffectiveSize = in.TotalEffectiveSize.DeepCopy()
+	if in.InvalidPlots != nil {
+		in, out := &in.InvalidPlots, &out.InvalidPlots
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DuplicatePlots != nil {
+		in, out := &in.DuplicatePlots, &out.DuplicatePlots
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]ChiaHarvesterPlotVolumeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.LastChecked.DeepCopyInto(&out.LastChecked)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterPlotStatus.
+func (in *ChiaHarvesterPlotStatus) DeepCopy() *ChiaHarvesterPlotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaHarvesterPlotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaHarvesterPlotVolumeClaimStatus) DeepCopyInto(out *ChiaHarvesterPlotVolumeClaimStatus) {
+	*out = *in
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterPlotVolumeClaimStatus.
+func (in *ChiaHarvesterPlotVolumeClaimStatus) DeepCopy() *ChiaHarvesterPlotVolumeClaimStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiaHarvesterList)
+	out := new(ChiaHarvesterPlotVolumeClaimStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ChiaHarvesterList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaHarvesterPlotVolumeStatus) DeepCopyInto(out *ChiaHarvesterPlotVolumeStatus) {
+	*out = *in
+	out.TotalRawSize = in.TotalRawSize.DeepCopy()
+	out.TotalEffectiveSize = in.TotalEffectiveSize.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterPlotVolumeStatus.
+func (in *ChiaHarvesterPlotVolumeStatus) DeepCopy() *ChiaHarvesterPlotVolumeStatus {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(ChiaHarvesterPlotVolumeStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -687,11 +1471,17 @@ func (in *ChiaHarvesterSpec) DeepCopyInto(out *ChiaHarvesterSpec) {
 	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
 	in.ChiaConfig.DeepCopyInto(&out.ChiaConfig)
 	in.ChiaHealthcheckConfig.DeepCopyInto(&out.ChiaHealthcheckConfig)
+	in.RPCStatus.DeepCopyInto(&out.RPCStatus)
 	if in.Strategy != nil {
 		in, out := &in.Strategy, &out.Strategy
 		*out = new(appsv1.DeploymentStrategy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DaemonSet != nil {
+		in, out := &in.DaemonSet, &out.DaemonSet
+		*out = new(ChiaHarvesterDaemonSetConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterSpec.
@@ -723,6 +1513,25 @@ func (in *ChiaHarvesterSpecChia) DeepCopy() *ChiaHarvesterSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaHarvesterStatus) DeepCopyInto(out *ChiaHarvesterStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Plots != nil {
+		in, out := &in.Plots, &out.Plots
+		*out = new(ChiaHarvesterPlotStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PlotVolumeClaims != nil {
+		in, out := &in.PlotVolumeClaims, &out.PlotVolumeClaims
+		*out = make([]ChiaHarvesterPlotVolumeClaimStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaHarvesterStatus.
@@ -741,7 +1550,7 @@ func (in *ChiaIntroducer) DeepCopyInto(out *ChiaIntroducer) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaIntroducer.
@@ -840,6 +1649,13 @@ func (in *ChiaIntroducerSpecChia) DeepCopy() *ChiaIntroducerSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaIntroducerStatus) DeepCopyInto(out *ChiaIntroducerStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaIntroducerStatus.
@@ -858,7 +1674,7 @@ func (in *ChiaNetwork) DeepCopyInto(out *ChiaNetwork) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNetwork.
@@ -959,6 +1775,13 @@ func (in *ChiaNetworkSpec) DeepCopy() *ChiaNetworkSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaNetworkStatus) DeepCopyInto(out *ChiaNetworkStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNetworkStatus.
@@ -977,7 +1800,7 @@ func (in *ChiaNode) DeepCopyInto(out *ChiaNode) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNode.
@@ -998,6 +1821,21 @@ func (in *ChiaNode) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeDatabaseRestoreStatus) DeepCopyInto(out *ChiaNodeDatabaseRestoreStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeDatabaseRestoreStatus.
+func (in *ChiaNodeDatabaseRestoreStatus) DeepCopy() *ChiaNodeDatabaseRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeDatabaseRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaNodeList) DeepCopyInto(out *ChiaNodeList) {
 	*out = *in
@@ -1030,6 +1868,135 @@ func (in *ChiaNodeList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeReplicaService) DeepCopyInto(out *ChiaNodeReplicaService) {
+	*out = *in
+	in.Service.DeepCopyInto(&out.Service)
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = make([]ChiaNodeReplicaServiceOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeReplicaService.
+func (in *ChiaNodeReplicaService) DeepCopy() *ChiaNodeReplicaService {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeReplicaService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeReplicaServiceOverride) DeepCopyInto(out *ChiaNodeReplicaServiceOverride) {
+	*out = *in
+	in.AdditionalMetadata.DeepCopyInto(&out.AdditionalMetadata)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeReplicaServiceOverride.
+func (in *ChiaNodeReplicaServiceOverride) DeepCopy() *ChiaNodeReplicaServiceOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeReplicaServiceOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeReplicaServiceStatus) DeepCopyInto(out *ChiaNodeReplicaServiceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeReplicaServiceStatus.
+func (in *ChiaNodeReplicaServiceStatus) DeepCopy() *ChiaNodeReplicaServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeReplicaServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeReplicaStatus) DeepCopyInto(out *ChiaNodeReplicaStatus) {
+	*out = *in
+	in.LastChecked.DeepCopyInto(&out.LastChecked)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeReplicaStatus.
+func (in *ChiaNodeReplicaStatus) DeepCopy() *ChiaNodeReplicaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeReplicaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeSnapshotConfig) DeepCopyInto(out *ChiaNodeSnapshotConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.VolumeSnapshotClassName != nil {
+		in, out := &in.VolumeSnapshotClassName, &out.VolumeSnapshotClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Retain != nil {
+		in, out := &in.Retain, &out.Retain
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RestoreNewReplicas != nil {
+		in, out := &in.RestoreNewReplicas, &out.RestoreNewReplicas
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeSnapshotConfig.
+func (in *ChiaNodeSnapshotConfig) DeepCopy() *ChiaNodeSnapshotConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeSnapshotConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiaNodeSnapshotStatus) DeepCopyInto(out *ChiaNodeSnapshotStatus) {
+	*out = *in
+	if in.LastSnapshotTime != nil {
+		in, out := &in.LastSnapshotTime, &out.LastSnapshotTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeSnapshotStatus.
+func (in *ChiaNodeSnapshotStatus) DeepCopy() *ChiaNodeSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiaNodeSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaNodeSpec) DeepCopyInto(out *ChiaNodeSpec) {
 	*out = *in
@@ -1037,6 +2004,12 @@ func (in *ChiaNodeSpec) DeepCopyInto(out *ChiaNodeSpec) {
 	in.ChiaConfig.DeepCopyInto(&out.ChiaConfig)
 	in.ChiaHealthcheckConfig.DeepCopyInto(&out.ChiaHealthcheckConfig)
 	in.ChiaDBPullConfig.DeepCopyInto(&out.ChiaDBPullConfig)
+	in.RPCStatus.DeepCopyInto(&out.RPCStatus)
+	if in.Snapshots != nil {
+		in, out := &in.Snapshots, &out.Snapshots
+		*out = new(ChiaNodeSnapshotConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.UpdateStrategy != nil {
 		in, out := &in.UpdateStrategy, &out.UpdateStrategy
 		*out = new(appsv1.StatefulSetUpdateStrategy)
@@ -1076,6 +2049,7 @@ func (in *ChiaNodeSpecChia) DeepCopyInto(out *ChiaNodeSpecChia) {
 			copy(*out, *in)
 		}
 	}
+	in.ReplicaService.DeepCopyInto(&out.ReplicaService)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeSpecChia.
@@ -1091,6 +2065,35 @@ func (in *ChiaNodeSpecChia) DeepCopy() *ChiaNodeSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaNodeStatus) DeepCopyInto(out *ChiaNodeStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = make([]ChiaNodeReplicaStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Snapshots != nil {
+		in, out := &in.Snapshots, &out.Snapshots
+		*out = new(ChiaNodeSnapshotStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DatabaseRestores != nil {
+		in, out := &in.DatabaseRestores, &out.DatabaseRestores
+		*out = make([]ChiaNodeDatabaseRestoreStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReplicaServices != nil {
+		in, out := &in.ReplicaServices, &out.ReplicaServices
+		*out = make([]ChiaNodeReplicaServiceStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaNodeStatus.
@@ -1149,7 +2152,7 @@ func (in *ChiaSeeder) DeepCopyInto(out *ChiaSeeder) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaSeeder.
@@ -1273,6 +2276,13 @@ func (in *ChiaSeederSpecChia) DeepCopy() *ChiaSeederSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaSeederStatus) DeepCopyInto(out *ChiaSeederStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaSeederStatus.
@@ -1291,7 +2301,7 @@ func (in *ChiaTimelord) DeepCopyInto(out *ChiaTimelord) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaTimelord.
@@ -1400,6 +2410,13 @@ func (in *ChiaTimelordSpecChia) DeepCopy() *ChiaTimelordSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaTimelordStatus) DeepCopyInto(out *ChiaTimelordStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaTimelordStatus.
@@ -1418,7 +2435,7 @@ func (in *ChiaWallet) DeepCopyInto(out *ChiaWallet) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaWallet.
@@ -1546,6 +2563,13 @@ func (in *ChiaWalletSpecChia) DeepCopy() *ChiaWalletSpecChia {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiaWalletStatus) DeepCopyInto(out *ChiaWalletStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiaWalletStatus.
@@ -1627,6 +2651,21 @@ func (in *CommonSpec) DeepCopyInto(out *CommonSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(PodDisruptionBudgetConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Suspend != nil {
+		in, out := &in.Suspend, &out.Suspend
+		*out = new(SuspendConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeletionPolicy != nil {
+		in, out := &in.DeletionPolicy, &out.DeletionPolicy
+		*out = new(DeletionPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommonSpec.
@@ -1712,6 +2751,16 @@ func (in *CommonSpecChia) DeepCopyInto(out *CommonSpecChia) {
 			}
 		}
 	}
+	if in.ConfigOverrides != nil {
+		in, out := &in.ConfigOverrides, &out.ConfigOverrides
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigFile != nil {
+		in, out := &in.ConfigFile, &out.ConfigFile
+		*out = new(ChiaConfigFileConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.LivenessProbe != nil {
 		in, out := &in.LivenessProbe, &out.LivenessProbe
 		*out = new(corev1.Probe)
@@ -2121,6 +3170,11 @@ func (in *PersistentVolumeClaimConfig) DeepCopyInto(out *PersistentVolumeClaimCo
 		*out = make([]corev1.PersistentVolumeAccessMode, len(*in))
 		copy(*out, *in)
 	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistentVolumeClaimConfig.
@@ -2158,6 +3212,16 @@ func (in *PlotsConfig) DeepCopyInto(out *PlotsConfig) {
 			}
 		}
 	}
+	if in.PlotDirectories != nil {
+		in, out := &in.PlotDirectories, &out.PlotDirectories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RecursivePlotScan != nil {
+		in, out := &in.RecursivePlotScan, &out.RecursivePlotScan
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlotsConfig.
@@ -2170,6 +3234,66 @@ func (in *PlotsConfig) DeepCopy() *PlotsConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodDisruptionBudgetConfig) DeepCopyInto(out *PodDisruptionBudgetConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.UnhealthyPodEvictionPolicy != nil {
+		in, out := &in.UnhealthyPodEvictionPolicy, &out.UnhealthyPodEvictionPolicy
+		*out = new(policyv1.UnhealthyPodEvictionPolicyType)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodDisruptionBudgetConfig.
+func (in *PodDisruptionBudgetConfig) DeepCopy() *PodDisruptionBudgetConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PodDisruptionBudgetConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RPCStatusConfig) DeepCopyInto(out *RPCStatusConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RPCStatusConfig.
+func (in *RPCStatusConfig) DeepCopy() *RPCStatusConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RPCStatusConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Service) DeepCopyInto(out *Service) {
 	*out = *in
@@ -2243,6 +3367,21 @@ func (in *SpecChiaDBPull) DeepCopyInto(out *SpecChiaDBPull) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(ChiaDBPullHTTPSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(ChiaDBPullPersistentVolumeClaimSource)
+		**out = **in
+	}
+	if in.ChiaNodeSnapshot != nil {
+		in, out := &in.ChiaNodeSnapshot, &out.ChiaNodeSnapshot
+		*out = new(ChiaDBPullChiaNodeSnapshotSource)
+		**out = **in
+	}
 	if in.Network != nil {
 		in, out := &in.Network, &out.Network
 		*out = new(string)
@@ -2253,6 +3392,11 @@ func (in *SpecChiaDBPull) DeepCopyInto(out *SpecChiaDBPull) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.MaxHeightLag != nil {
+		in, out := &in.MaxHeightLag, &out.MaxHeightLag
+		*out = new(int64)
+		**out = **in
+	}
 	if in.AWSCredentialsSecret != nil {
 		in, out := &in.AWSCredentialsSecret, &out.AWSCredentialsSecret
 		*out = new(string)
@@ -2402,3 +3546,28 @@ func (in *StorageConfig) DeepCopy() *StorageConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SuspendConfig) DeepCopyInto(out *SuspendConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ScaleToZero != nil {
+		in, out := &in.ScaleToZero, &out.ScaleToZero
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SuspendConfig.
+func (in *SuspendConfig) DeepCopy() *SuspendConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SuspendConfig)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,30 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+// chia-config installs the chia config the operator rendered into a ConfigMap in CHIA_ROOT, merging it into an existing config.yaml.
+// It's configured with environment variables, see internal/controller/common/chiaconfig.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chia-network/chia-operator/internal/controller/common/chiaconfig"
+)
+
+func main() {
+	chiaRoot := os.Getenv(chiaconfig.EnvChiaRoot)
+	renderedDir := os.Getenv(chiaconfig.EnvRenderedDir)
+	if chiaRoot == "" || renderedDir == "" {
+		fmt.Fprintf(os.Stderr, "%s and %s must be set\n", chiaconfig.EnvChiaRoot, chiaconfig.EnvRenderedDir)
+		os.Exit(1)
+	}
+
+	message, err := chiaconfig.Install(chiaRoot, renderedDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(message)
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+// chia-db-restore restores a ChiaNode's blockchain database from HTTP(S) URLs or a mounted file, or checks whether its
+// existing database is recent enough to keep. It's configured with environment variables, see internal/dbrestore.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/chia-network/chia-operator/internal/dbrestore"
+)
+
+func main() {
+	config, err := dbrestore.ConfigFromEnv(os.Getenv)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	restorer := dbrestore.Restorer{
+		Config:                 config,
+		Client:                 dbrestore.NewHTTPClient(),
+		Log:                    os.Stdout,
+		TerminationMessagePath: corev1.TerminationMessagePathDefault,
+	}
+	if err := restorer.Run(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// controllerKinds lists the kinds of custom resources the operator runs a controller for
+var controllerKinds = []consts.ChiaKind{
+	consts.ChiaCAKind,
+	consts.ChiaCertificatesKind,
+	consts.ChiaCrawlerKind,
+	consts.ChiaDataLayerKind,
+	consts.ChiaFarmKind,
+	consts.ChiaFarmerKind,
+	consts.ChiaHarvesterKind,
+	consts.ChiaIntroducerKind,
+	consts.ChiaNetworkKind,
+	consts.ChiaNodeKind,
+	consts.ChiaSeederKind,
+	consts.ChiaTimelordKind,
+	consts.ChiaWalletKind,
+}
+
+// concurrencyConfig contains the maximum number of concurrent reconciles for each controller
+type concurrencyConfig struct {
+	// defaultValue applies to every controller that isn't listed in kinds
+	defaultValue int
+
+	// kinds contains the maximum number of concurrent reconciles for specific controllers
+	kinds map[consts.ChiaKind]int
+}
+
+// forKind returns the maximum number of concurrent reconciles for a kind's controller
+func (c concurrencyConfig) forKind(kind consts.ChiaKind) int {
+	if n, ok := c.kinds[kind]; ok {
+		return n
+	}
+	return c.defaultValue
+}
+
+// parseMaxConcurrentReconciles parses the value of the --max-concurrent-reconciles flag. The value is a comma separated list
+// of a number that applies to every controller, and kind=number pairs that apply to specific controllers, such as "2,ChiaNode=4".
+func parseMaxConcurrentReconciles(value string) (concurrencyConfig, error) {
+	config := concurrencyConfig{
+		defaultValue: 1,
+		kinds:        make(map[consts.ChiaKind]int),
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kind, number, hasKind := strings.Cut(entry, "=")
+		if !hasKind {
+			number = kind
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(number))
+		if err != nil || n < 1 {
+			return concurrencyConfig{}, fmt.Errorf("invalid max concurrent reconciles %q, must be a positive integer", entry)
+		}
+
+		if !hasKind {
+			config.defaultValue = n
+			continue
+		}
+		chiaKind, err := parseControllerKind(kind)
+		if err != nil {
+			return concurrencyConfig{}, err
+		}
+		config.kinds[chiaKind] = n
+	}
+
+	return config, nil
+}
+
+// parseControllerKind returns the kind of custom resource with the given name, if the operator runs a controller for it
+func parseControllerKind(name string) (consts.ChiaKind, error) {
+	name = strings.TrimSpace(name)
+	for _, kind := range controllerKinds {
+		if strings.EqualFold(string(kind), name) {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("unknown kind %q", name)
+}
+
+// parseWatchNamespaces parses the value of the --watch-namespaces flag, a comma separated list of namespaces.
+// Returns nil if the operator should watch every namespace.
+func parseWatchNamespaces(value string) []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(value, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace != "" && !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// parseLabelSelector parses the value of the --label-selector flag. Returns nil if every custom resource should be reconciled.
+func parseLabelSelector(value string) (labels.Selector, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector %q: %v", value, err)
+	}
+	return selector, nil
+}
+
+// getLabelSelectorKeys returns the sorted label keys a label selector has requirements on
+func getLabelSelectorKeys(selector labels.Selector) []string {
+	if selector == nil {
+		return nil
+	}
+	var keys []string
+	requirements, _ := selector.Requirements()
+	for _, requirement := range requirements {
+		if !slices.Contains(keys, requirement.Key()) {
+			keys = append(keys, requirement.Key())
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// parseControllers parses the value of the --controllers flag, and returns the kinds whose controllers are enabled.
+// The value is a comma separated list of kinds to enable. "*" enables every controller, and a kind prefixed with "-" disables its controller,
+// such as "*,-ChiaSeeder".
+func parseControllers(value string) (map[consts.ChiaKind]bool, error) {
+	enabled := make(map[consts.ChiaKind]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			for _, kind := range controllerKinds {
+				if _, ok := enabled[kind]; !ok {
+					enabled[kind] = true
+				}
+			}
+		case strings.HasPrefix(entry, "-"):
+			kind, err := parseControllerKind(strings.TrimPrefix(entry, "-"))
+			if err != nil {
+				return nil, err
+			}
+			enabled[kind] = false
+		default:
+			kind, err := parseControllerKind(entry)
+			if err != nil {
+				return nil, err
+			}
+			enabled[kind] = true
+		}
+	}
+	return enabled, nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+func TestParseMaxConcurrentReconciles(t *testing.T) {
+	config, err := parseMaxConcurrentReconciles("")
+	require.NoError(t, err)
+	require.Equal(t, 1, config.forKind(consts.ChiaNodeKind))
+
+	config, err = parseMaxConcurrentReconciles("4")
+	require.NoError(t, err)
+	require.Equal(t, 4, config.forKind(consts.ChiaNodeKind))
+	require.Equal(t, 4, config.forKind(consts.ChiaCAKind))
+
+	config, err = parseMaxConcurrentReconciles("2, ChiaNode=8,chiaharvester=3")
+	require.NoError(t, err)
+	require.Equal(t, 8, config.forKind(consts.ChiaNodeKind))
+	require.Equal(t, 3, config.forKind(consts.ChiaHarvesterKind))
+	require.Equal(t, 2, config.forKind(consts.ChiaWalletKind))
+
+	_, err = parseMaxConcurrentReconciles("0")
+	require.Error(t, err)
+
+	_, err = parseMaxConcurrentReconciles("ChiaNode=two")
+	require.Error(t, err)
+
+	_, err = parseMaxConcurrentReconciles("ChiaPlotter=2")
+	require.EqualError(t, err, `unknown kind "ChiaPlotter"`)
+}
+
+func TestParseWatchNamespaces(t *testing.T) {
+	require.Nil(t, parseWatchNamespaces(""))
+	require.Equal(t, []string{"mainnet", "testnet"}, parseWatchNamespaces("mainnet, testnet,,mainnet"))
+}
+
+func TestParseLabelSelector(t *testing.T) {
+	selector, err := parseLabelSelector("")
+	require.NoError(t, err)
+	require.Nil(t, selector)
+
+	selector, err = parseLabelSelector("k8s.chia.net/shard=mainnet")
+	require.NoError(t, err)
+	require.True(t, selector.Matches(labels.Set{"k8s.chia.net/shard": "mainnet"}))
+	require.False(t, selector.Matches(labels.Set{"k8s.chia.net/shard": "testnet"}))
+
+	_, err = parseLabelSelector("k8s.chia.net/shard in (mainnet")
+	require.Error(t, err)
+}
+
+func TestParseControllers(t *testing.T) {
+	enabled, err := parseControllers("*")
+	require.NoError(t, err)
+	for _, kind := range controllerKinds {
+		require.True(t, enabled[kind])
+	}
+
+	enabled, err = parseControllers("*,-ChiaSeeder,-chiacrawler")
+	require.NoError(t, err)
+	require.True(t, enabled[consts.ChiaNodeKind])
+	require.False(t, enabled[consts.ChiaSeederKind])
+	require.False(t, enabled[consts.ChiaCrawlerKind])
+
+	// A disabled kind stays disabled even if "*" comes after it
+	enabled, err = parseControllers("-ChiaSeeder,*")
+	require.NoError(t, err)
+	require.False(t, enabled[consts.ChiaSeederKind])
+
+	enabled, err = parseControllers("ChiaCA,ChiaNode")
+	require.NoError(t, err)
+	require.True(t, enabled[consts.ChiaCAKind])
+	require.True(t, enabled[consts.ChiaNodeKind])
+	require.False(t, enabled[consts.ChiaWalletKind])
+
+	_, err = parseControllers("*,-ChiaPlotter")
+	require.EqualError(t, err, `unknown kind "ChiaPlotter"`)
+}
+
+func TestGetLabelSelectorKeys(t *testing.T) {
+	require.Nil(t, getLabelSelectorKeys(nil))
+
+	selector, err := parseLabelSelector("k8s.chia.net/shard=mainnet,tier in (a,b),!legacy,tier!=c")
+	require.NoError(t, err)
+	require.Equal(t, []string{"k8s.chia.net/shard", "legacy", "tier"}, getLabelSelectorKeys(selector))
+}
+
+func TestGetCacheOptions(t *testing.T) {
+	opts := getCacheOptions(nil, nil)
+	require.Nil(t, opts.DefaultNamespaces)
+	require.Nil(t, opts.ByObject)
+
+	selector, err := parseLabelSelector("k8s.chia.net/shard=mainnet")
+	require.NoError(t, err)
+	opts = getCacheOptions([]string{"mainnet"}, selector)
+	require.Contains(t, opts.DefaultNamespaces, "mainnet")
+	require.Len(t, opts.ByObject, len(controllerKinds))
+	for _, byObject := range opts.ByObject {
+		require.Equal(t, selector, byObject.Label)
+	}
+}
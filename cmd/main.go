@@ -10,10 +10,13 @@ import (
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -23,6 +26,7 @@ import (
 	"github.com/chia-network/chia-operator/internal/controller/chiacertificates"
 	"github.com/chia-network/chia-operator/internal/controller/chiacrawler"
 	"github.com/chia-network/chia-operator/internal/controller/chiadatalayer"
+	"github.com/chia-network/chia-operator/internal/controller/chiafarm"
 	"github.com/chia-network/chia-operator/internal/controller/chiafarmer"
 	"github.com/chia-network/chia-operator/internal/controller/chiaharvester"
 	"github.com/chia-network/chia-operator/internal/controller/chiaintroducer"
@@ -31,6 +35,8 @@ import (
 	"github.com/chia-network/chia-operator/internal/controller/chiaseeder"
 	"github.com/chia-network/chia-operator/internal/controller/chiatimelord"
 	"github.com/chia-network/chia-operator/internal/controller/chiawallet"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	webhookv1 "github.com/chia-network/chia-operator/internal/webhook/v1"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -50,25 +56,65 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var enableWebhooks bool
+	var maxConcurrentReconciles string
+	var watchNamespaces string
+	var labelSelector string
+	var controllers string
+	var leaderElectionID string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&leaderElectionID, "leader-election-id", "724bee8b.k8s.chia.net",
+		"The name of the Lease used for leader election. "+
+			"Operator instances that run in the same namespace with different --watch-namespaces, --label-selector, or --controllers flags need different IDs.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Enable the defaulting and validating admission webhooks for Chia resources. "+
+			"The webhook server requires a serving certificate in /tmp/k8s-webhook-server/serving-certs.")
+	flag.StringVar(&maxConcurrentReconciles, "max-concurrent-reconciles", "1",
+		"The maximum number of resources each controller reconciles at the same time. "+
+			"Takes a number for every controller, followed by comma separated overrides for specific kinds, such as \"2,ChiaNode=4\".")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma separated list of namespaces to watch and reconcile Chia resources in. Watches every namespace if empty.")
+	flag.StringVar(&labelSelector, "label-selector", "",
+		"Only reconcile Chia resources with labels that match this label selector, such as \"k8s.chia.net/shard=mainnet\". "+
+			"Reconciles every Chia resource if empty.")
+	flag.StringVar(&controllers, "controllers", "*",
+		"Comma separated list of controllers to enable, by kind. \"*\" enables every controller, "+
+			"and a kind prefixed with \"-\" disables its controller, such as \"*,-ChiaSeeder\".")
 	opts := zap.Options{}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	concurrency, err := parseMaxConcurrentReconciles(maxConcurrentReconciles)
+	if err != nil {
+		setupLog.Error(err, "invalid --max-concurrent-reconciles flag")
+		os.Exit(1)
+	}
+	selector, err := parseLabelSelector(labelSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid --label-selector flag")
+		os.Exit(1)
+	}
+	enabledControllers, err := parseControllers(controllers)
+	if err != nil {
+		setupLog.Error(err, "invalid --controllers flag")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
+		Cache:  getCacheOptions(parseWatchNamespaces(watchNamespaces), selector),
 		Metrics: server.Options{
 			BindAddress: metricsAddr,
 		},
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "724bee8b.k8s.chia.net",
+		LeaderElectionID:       leaderElectionID,
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -86,104 +132,110 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&chianode.ChiaNodeReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorder("chianode-controller"),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChiaNode")
-		os.Exit(1)
-	}
-	if err = (&chiafarmer.ChiaFarmerReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorder("chiafarmer-controller"),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChiaFarmer")
-		os.Exit(1)
-	}
-	if err = (&chiaharvester.ChiaHarvesterReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorder("chiaharvester-controller"),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChiaHarvester")
-		os.Exit(1)
-	}
-	if err = (&chiaca.ChiaCAReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorder("chiaca-controller"),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChiaCA")
-		os.Exit(1)
-	}
-	if err = (&chiawallet.ChiaWalletReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorder("chiawallet-controller"),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChiaWallet")
-		os.Exit(1)
+	// Set up the enabled controllers
+	reconcilers := []struct {
+		kind  consts.ChiaKind
+		setup func(ctrl.Manager) error
+	}{
+		{consts.ChiaNodeKind, (&chianode.ChiaNodeReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chianode-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaNodeKind),
+		}).SetupWithManager},
+		{consts.ChiaFarmerKind, (&chiafarmer.ChiaFarmerReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chiafarmer-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaFarmerKind),
+		}).SetupWithManager},
+		{consts.ChiaHarvesterKind, (&chiaharvester.ChiaHarvesterReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chiaharvester-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaHarvesterKind),
+		}).SetupWithManager},
+		{consts.ChiaCAKind, (&chiaca.ChiaCAReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chiaca-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaCAKind),
+		}).SetupWithManager},
+		{consts.ChiaWalletKind, (&chiawallet.ChiaWalletReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chiawallet-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaWalletKind),
+		}).SetupWithManager},
+		{consts.ChiaTimelordKind, (&chiatimelord.ChiaTimelordReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chiatimelord-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaTimelordKind),
+		}).SetupWithManager},
+		{consts.ChiaSeederKind, (&chiaseeder.ChiaSeederReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chiaseeder-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaSeederKind),
+		}).SetupWithManager},
+		{consts.ChiaIntroducerKind, (&chiaintroducer.ChiaIntroducerReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chiaintroducer-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaIntroducerKind),
+		}).SetupWithManager},
+		{consts.ChiaCrawlerKind, (&chiacrawler.ChiaCrawlerReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chiacrawler-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaCrawlerKind),
+		}).SetupWithManager},
+		{consts.ChiaNetworkKind, (&chianetwork.ChiaNetworkReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chianetwork-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaNetworkKind),
+		}).SetupWithManager},
+		{consts.ChiaDataLayerKind, (&chiadatalayer.ChiaDataLayerReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chiadatalayer-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaDataLayerKind),
+		}).SetupWithManager},
+		{consts.ChiaCertificatesKind, (&chiacertificates.ChiaCertificatesReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chiacertificates-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaCertificatesKind),
+		}).SetupWithManager},
+		{consts.ChiaFarmKind, (&chiafarm.ChiaFarmReconciler{
+			Client:                  mgr.GetClient(),
+			Scheme:                  mgr.GetScheme(),
+			Recorder:                mgr.GetEventRecorder("chiafarm-controller"),
+			MaxConcurrentReconciles: concurrency.forKind(consts.ChiaFarmKind),
+			InheritedLabels:         getLabelSelectorKeys(selector),
+		}).SetupWithManager},
 	}
-	if err = (&chiatimelord.ChiaTimelordReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorder("chiatimelord-controller"),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChiaTimelord")
-		os.Exit(1)
-	}
-	if err = (&chiaseeder.ChiaSeederReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorder("chiaseeder-controller"),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChiaSeeder")
-		os.Exit(1)
-	}
-	if err = (&chiaintroducer.ChiaIntroducerReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorder("chiaintroducer-controller"),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChiaIntroducer")
-		os.Exit(1)
-	}
-	if err = (&chiacrawler.ChiaCrawlerReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorder("chiacrawler-controller"),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChiaCrawler")
-		os.Exit(1)
-	}
-	if err = (&chianetwork.ChiaNetworkReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorder("chianetwork-controller"),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChiaNetwork")
-		os.Exit(1)
-	}
-	if err = (&chiadatalayer.ChiaDataLayerReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorder("chiadatalayer-controller"),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChiaDataLayer")
-		os.Exit(1)
-	}
-	if err = (&chiacertificates.ChiaCertificatesReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorder("chiacertificates-controller"),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChiaCertificates")
-		os.Exit(1)
+	for _, reconciler := range reconcilers {
+		if !enabledControllers[reconciler.kind] {
+			setupLog.Info("controller is disabled", "controller", reconciler.kind)
+			continue
+		}
+		if err = reconciler.setup(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", reconciler.kind)
+			os.Exit(1)
+		}
 	}
 	//+kubebuilder:scaffold:builder
 
+	if enableWebhooks {
+		if err = webhookv1.SetupWebhooksWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhooks")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -199,3 +251,37 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// getCacheOptions returns the manager's cache options. If namespaces isn't empty, only objects in those namespaces are cached.
+// If selector isn't nil, only Chia custom resources with labels that match it are cached, so the operator doesn't see,
+// or reconcile, any other Chia custom resources.
+func getCacheOptions(namespaces []string, selector labels.Selector) cache.Options {
+	var opts cache.Options
+	if len(namespaces) > 0 {
+		opts.DefaultNamespaces = make(map[string]cache.Config)
+		for _, namespace := range namespaces {
+			opts.DefaultNamespaces[namespace] = cache.Config{}
+		}
+	}
+	if selector != nil {
+		opts.ByObject = make(map[client.Object]cache.ByObject)
+		for _, obj := range []client.Object{
+			&k8schianetv1.ChiaCA{},
+			&k8schianetv1.ChiaCertificates{},
+			&k8schianetv1.ChiaCrawler{},
+			&k8schianetv1.ChiaDataLayer{},
+			&k8schianetv1.ChiaFarm{},
+			&k8schianetv1.ChiaFarmer{},
+			&k8schianetv1.ChiaHarvester{},
+			&k8schianetv1.ChiaIntroducer{},
+			&k8schianetv1.ChiaNetwork{},
+			&k8schianetv1.ChiaNode{},
+			&k8schianetv1.ChiaSeeder{},
+			&k8schianetv1.ChiaTimelord{},
+			&k8schianetv1.ChiaWallet{},
+		} {
+			opts.ByObject[obj] = cache.ByObject{Label: selector}
+		}
+	}
+	return opts
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiaca
+
+import (
+	"context"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/certmanager"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// getCertManagerSecretName returns the name of the Secret cert-manager stores a ChiaCA's private CA in
+func getCertManagerSecretName(ca k8schianetv1.ChiaCA) string {
+	return ca.Name + "-private-ca"
+}
+
+// getCertManagerIssuerName returns the name of the self-signed Issuer the operator creates for a ChiaCA
+func getCertManagerIssuerName(ca k8schianetv1.ChiaCA) string {
+	return ca.Name + "-selfsigned"
+}
+
+// reconcileCertManagerCA applies the cert-manager resources that issue a ChiaCA's private CA, and returns the issued private CA
+// in the layout of a CA import source. Returns false if cert-manager hasn't issued the private CA yet.
+func (r *ChiaCAReconciler) reconcileCertManagerCA(ctx context.Context, ca k8schianetv1.ChiaCA) (map[string][]byte, bool, error) {
+	labels := kube.GetCommonLabels(string(consts.ChiaCAKind), ca.ObjectMeta)
+
+	if ca.Spec.CertManager.IssuerRef == nil {
+		issuer := certmanager.AssembleSelfSignedIssuer(getCertManagerIssuerName(ca), ca.Namespace, labels)
+		if err := certmanager.Apply(ctx, r.Client, r.Scheme, &ca, issuer); err != nil {
+			return nil, false, err
+		}
+	}
+
+	certificate := certmanager.AssembleCertificate(certmanager.CertificateInputs{
+		Name:        getCertManagerSecretName(ca),
+		Namespace:   ca.Namespace,
+		Labels:      labels,
+		IssuerRef:   certmanager.IssuerRefOrDefault(ca.Spec.CertManager.IssuerRef, getCertManagerIssuerName(ca)),
+		IsCA:        true,
+		Duration:    ca.Spec.CertManager.Duration,
+		RenewBefore: ca.Spec.CertManager.RenewBefore,
+	})
+	if err := certmanager.Apply(ctx, r.Client, r.Scheme, &ca, certificate); err != nil {
+		return nil, false, err
+	}
+
+	// The Secret keeps the previously issued certificate while cert-manager renews it, so it's used as long as it contains a key pair
+	secret, exists, err := r.getSecret(ctx, ca.Namespace, getCertManagerSecretName(ca))
+	if err != nil {
+		return nil, false, err
+	}
+	if !exists {
+		return nil, false, nil
+	}
+	certPEM, keyPEM, err := certmanager.KeyPairFromSecret(secret)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	return map[string][]byte{
+		"private_ca.crt": certPEM,
+		"private_ca.key": keyPEM,
+	}, true, nil
+}
@@ -5,23 +5,29 @@ Copyright 2023 Chia Network Inc.
 package chiaca
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
 	"fmt"
 	"strings"
 	"time"
 
-	"github.com/chia-network/go-chia-libs/pkg/tls"
-
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 	"github.com/chia-network/chia-operator/internal/metrics"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 // ChiaCAReconciler reconciles a ChiaCA object
@@ -29,32 +35,40 @@ type ChiaCAReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder events.EventRecorder
+
+	// MaxConcurrentReconciles is the maximum number of ChiaCAs that can be reconciled at the same time, which defaults to 1
+	MaxConcurrentReconciles int
 }
 
-var chiacas = make(map[string]bool)
+// maxRotationCheckInterval is the maximum amount of time between checks for whether a private CA needs to be rotated
+const maxRotationCheckInterval = 24 * time.Hour
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiacas,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiacas/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiacas/finalizers,verbs=update
-//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cert-manager.io,resources=issuers;certificates,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch;update
 
 // Reconcile is invoked on any event to a controlled Kubernetes resource
-func (r *ChiaCAReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ChiaCAReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
 	log := log.FromContext(ctx)
 	log.Info("Running reconciler...")
 
+	// Record how long this reconcile took, and why it failed if it returned an error
+	start := time.Now()
+	defer func() {
+		kube.ObserveReconcile(string(consts.ChiaCAKind), start, reterr)
+	}()
+
 	// Get the custom resource
 	var ca k8schianetv1.ChiaCA
 	err := r.Get(ctx, req.NamespacedName, &ca)
 	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chiacas[req.String()]
-		if exists {
-			delete(chiacas, req.String())
-			metrics.ChiaCAs.Sub(1.0)
-		}
+		// Remove this object from the operator's metrics
+		metrics.Resources.Delete(string(consts.ChiaCAKind), req.String())
 		return ctrl.Result{}, nil
 	}
 	if err != nil {
@@ -62,64 +76,221 @@ func (r *ChiaCAReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, err
 	}
 
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chiacas[req.String()]
-	if !exists {
-		chiacas[req.String()] = true
-		metrics.ChiaCAs.Add(1.0)
+	// Add this object to the operator's metrics, if it wasn't already added
+	metrics.Resources.Add(string(consts.ChiaCAKind), req.String())
+
+	// Record the ChiaCA's Ready condition in the operator's metrics, after any reconciliation error was recorded in it
+	defer func() {
+		if ca.DeletionTimestamp.IsZero() {
+			metrics.Resources.SetReadyCondition(string(consts.ChiaCAKind), req.String(), kube.GetReadyConditionStatus(ca.Status.Conditions))
+		}
+	}()
+
+	// Record any reconciliation error in the CR's status conditions
+	defer func() {
+		if reterr != nil {
+			kube.SetReconcileFailedConditions(&ca.Status.Conditions, ca.Generation, reterr)
+			ca.Status.Ready = false
+			if err := r.Status().Update(ctx, &ca); err != nil && !strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+				log.Error(err, "encountered error updating ChiaCA status conditions")
+			}
+		}
+	}()
+
+	// Stop applying changes while the ChiaCA is suspended
+	suspended, err := kube.ReconcileSuspension(ctx, r.Client, ca.ObjectMeta, nil, &ca.Status.Conditions, nil)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("ChiaCAReconciler ChiaCA=%s %w", req.NamespacedName, err)
+	}
+	if suspended {
+		return kube.UpdateSuspendedStatus(ctx, r.Client, consts.ChiaCAKind, &ca, ca.Status.Conditions, &ca.Status.ObservedGeneration, &ca.Status.Ready)
 	}
 
-	// Check if CA Secret exists
-	caExists, err := r.caSecretExists(ctx, ca)
+	secretName := getChiaCASecretName(ca)
+	secret, secretExists, err := r.getSecret(ctx, ca.Namespace, secretName)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("encountered error querying for existing CA Secret: %v", err)
+		return ctrl.Result{}, fmt.Errorf("encountered error querying for existing CA Secret: %w", err)
 	}
 
-	// If CA Secret doesn't exist, generate a CA and create one
-	if !caExists {
-		// Get the public CA cert and key byte slices
-		publicCACrtBytes, publicCAKeyBytes := tls.GetChiaCACertAndKey()
+	now := time.Now().Truncate(time.Second)
 
-		// Generate a private CA cert and key
-		privateCACrt, privateCAKey, err := tls.GenerateNewCA()
+	// Validate the imported CA, if this ChiaCA imports one or has cert-manager issue one
+	var importedFiles caFiles
+	var importedCert *x509.Certificate
+	var importedSource k8schianetv1.ChiaCASource
+	if ca.Spec.CertManager != nil || ca.Spec.Import != nil {
+		var data map[string][]byte
+		if ca.Spec.CertManager != nil {
+			var issued bool
+			data, issued, err = r.reconcileCertManagerCA(ctx, ca)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if !issued {
+				log.Info("Waiting for cert-manager to issue the private CA, retrying in 10 seconds")
+				kube.SetCondition(&ca.Status.Conditions, ca.Generation, k8schianetv1.ConditionTypeReady, metav1.ConditionFalse, kube.ReasonCertificatePending, "Waiting for cert-manager to issue the private CA")
+				kube.SetCondition(&ca.Status.Conditions, ca.Generation, k8schianetv1.ConditionTypeProgressing, metav1.ConditionTrue, kube.ReasonCertificatePending, "Waiting for cert-manager to issue the private CA")
+				if err := r.Status().Update(ctx, &ca); err != nil && !strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+					log.Error(err, "encountered error updating ChiaCA status")
+				}
+				return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+			}
+			importedSource = k8schianetv1.ChiaCASourceCertManager
+		} else {
+			data, err = r.getImportData(ctx, ca)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			importedSource = k8schianetv1.ChiaCASourceImported
+		}
+		importedFiles, importedCert, err = parseImportedCA(data, now)
 		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("encountered error generating new private CA cert and key: %v", err)
+			r.Recorder.Eventf(&ca, nil, corev1.EventTypeWarning, "Failed", "Failed", "Invalid CA import source: %v", err)
+			return ctrl.Result{}, kube.NewConditionError(k8schianetv1.ConditionTypeConfigValid, kube.ReasonInvalidConfig, err)
 		}
+	}
 
-		// Encode the private CA cert and key to PEM byte slices
-		privateCACrtBytes, privateCAKeyBytes, err := tls.EncodeCertAndKeyToPEM(privateCACrt, privateCAKey)
-		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("encountered error encoding private CA cert and key to PEM: %v", err)
+	if !secretExists {
+		// If CA Secret doesn't exist, import or generate a CA and create one
+		files := importedFiles
+		source := importedSource
+		if importedCert == nil {
+			files, err = generateCAFiles()
+			if err != nil {
+				return ctrl.Result{}, kube.NewConditionError(k8schianetv1.ConditionTypeReady, kube.ReasonCertificateGenerationFailed, err)
+			}
+			source = k8schianetv1.ChiaCASourceGenerated
 		}
 
 		// Assemble CA Secret and create in cluster
-		secret := assembleCASecret(ca, string(publicCACrtBytes), string(publicCAKeyBytes), string(privateCACrtBytes), string(privateCAKeyBytes))
+		secret = assembleCASecret(ca, string(files.chiaCACrt), string(files.chiaCAKey), string(files.privateCACrt), string(files.privateCAKey))
+		secret.Annotations = map[string]string{
+			sourceAnnotation: string(source),
+		}
+		if source == k8schianetv1.ChiaCASourceGenerated && ca.Spec.Rotation != nil && ca.Spec.Rotation.Trigger != "" {
+			secret.Annotations[rotationTriggerAnnotation] = ca.Spec.Rotation.Trigger
+		}
 		if err = r.Create(ctx, &secret); err != nil {
-			return ctrl.Result{}, fmt.Errorf("error creating CA Secret \"%s\": %v", secret.Name, err)
+			return ctrl.Result{}, fmt.Errorf("error creating CA Secret \"%s\": %w", secret.Name, err)
+		}
+		secret.Data = stringDataToData(files.toStringData())
+	} else {
+		certs, err := kube.ParseCACertificates(secret.Data["private_ca.crt"])
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("error parsing private CA certificate from Secret: %w", err)
 		}
+		currentCA := certs[0]
+
+		if importedCert != nil && !bytes.Equal(importedCert.Raw, currentCA.Raw) {
+			// The imported CA changed, replace the private CA and keep trusting the current one during the overlap period
+			log.Info("Importing new private CA", "fingerprint", kube.CertificateFingerprint(importedCert))
+			rotateCASecret(&secret, importedFiles, currentCA, importedSource, getOverlapPeriod(ca), now)
+			if err := r.Update(ctx, &secret); err != nil {
+				if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+					return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+				}
+				return ctrl.Result{}, fmt.Errorf("error updating CA Secret \"%s\": %w", secret.Name, err)
+			}
+			r.Recorder.Eventf(&ca, nil, corev1.EventTypeNormal, "Rotated", "Rotated",
+				"Imported new private CA from %s into Secret %s/%s", importedSource, ca.Namespace, secret.Name)
+		} else if importedCert != nil && importedFilesChanged(secret, importedFiles, importedSource) {
+			// The imported private CA is unchanged, but the other CA files differ
+			updateImportedCASecret(&secret, importedFiles, importedSource)
+			if err := r.Update(ctx, &secret); err != nil {
+				if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+					return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+				}
+				return ctrl.Result{}, fmt.Errorf("error updating CA Secret \"%s\": %w", secret.Name, err)
+			}
+		} else if reason := getRotationReason(ca, secret, currentCA, now); reason != "" {
+			// Generate a new private CA, and keep trusting the current one during the overlap period
+			log.Info("Rotating private CA", "reason", reason)
+			files, err := generateCAFiles()
+			if err != nil {
+				return ctrl.Result{}, kube.NewConditionError(k8schianetv1.ConditionTypeReady, kube.ReasonCertificateGenerationFailed, err)
+			}
+			rotateCASecret(&secret, files, currentCA, k8schianetv1.ChiaCASourceGenerated, getOverlapPeriod(ca), now)
+			if ca.Spec.Rotation.Trigger != "" {
+				secret.Annotations[rotationTriggerAnnotation] = ca.Spec.Rotation.Trigger
+			}
+			if err := r.Update(ctx, &secret); err != nil {
+				if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+					return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+				}
+				return ctrl.Result{}, fmt.Errorf("error updating CA Secret \"%s\": %w", secret.Name, err)
+			}
+			r.Recorder.Eventf(&ca, nil, corev1.EventTypeNormal, "Rotated", "Rotated",
+				"Rotated private CA in Secret %s/%s: %s", ca.Namespace, secret.Name, reason)
+		} else if trustedUntil := getPreviousTrustedUntil(secret); trustedUntil != nil && !now.Before(*trustedUntil) {
+			// The overlap period ended, stop trusting the previous private CA
+			log.Info("Removing previous private CA from CA Secret")
+			removePreviousCA(&secret, currentCA)
+			if err := r.Update(ctx, &secret); err != nil {
+				if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+					return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+				}
+				return ctrl.Result{}, fmt.Errorf("error updating CA Secret \"%s\": %w", secret.Name, err)
+			}
+			r.Recorder.Eventf(&ca, nil, corev1.EventTypeNormal, "Updated", "Updated",
+				"Removed previous private CA from Secret %s/%s after the overlap period", ca.Namespace, secret.Name)
+		}
+	}
+
+	result, err := updateCAStatus(&ca, secret, now)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
 	if !ca.Status.Ready {
 		r.Recorder.Eventf(&ca, nil, corev1.EventTypeNormal, "Created", "Created",
 			"Successfully created CA Secret in %s/%s", ca.Namespace, ca.Name)
+	}
 
-		ca.Status.Ready = true
-		err = r.Status().Update(ctx, &ca)
-		if err != nil {
-			if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
-				return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
-			}
-			log.Error(err, "encountered error updating ChiaCA status")
-			return ctrl.Result{}, err
+	kube.SetResourceReadyConditions(&ca.Status.Conditions, ca.Generation, fmt.Sprintf("CA Secret %q exists", secretName))
+	ca.Status.ObservedGeneration = ca.Generation
+	ca.Status.Ready = true
+	err = r.Status().Update(ctx, &ca)
+	if err != nil {
+		if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 		}
+		log.Error(err, "encountered error updating ChiaCA status")
+		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ChiaCAReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&k8schianetv1.ChiaCA{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.findCAsForObject)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.findCAsForObject)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
+
+// findCAsForObject returns a reconcile request for every ChiaCA in an object's namespace that imports its CA from it, or uses it as its CA Secret,
+// so imported CAs are updated as soon as the import source changes, and CA Secrets are re-created if deleted.
+func (r *ChiaCAReconciler) findCAsForObject(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list k8schianetv1.ChiaCAList
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list ChiaCAs for object", "name", obj.GetName())
+		return nil
+	}
+
+	_, isConfigMap := obj.(*corev1.ConfigMap)
+	var requests []reconcile.Request
+	for _, ca := range list.Items {
+		if referencesObject(ca, obj.GetName(), isConfigMap) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: ca.Namespace,
+					Name:      ca.Name,
+				},
+			})
+		}
+	}
+	return requests
+}
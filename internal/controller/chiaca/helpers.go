@@ -5,30 +5,110 @@ Copyright 2023 Chia Network Inc.
 package chiaca
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/chia-network/go-chia-libs/pkg/tls"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 )
 
-// caSecretExists fetches the k8s Secret that matches this ChiaCA deployment. Returns true if the Secret exists.
-func (r *ChiaCAReconciler) caSecretExists(ctx context.Context, ca k8schianetv1.ChiaCA) (bool, error) {
+const (
+	// sourceAnnotation is set on the CA Secret to record where the operator got the private CA from
+	sourceAnnotation = "k8s.chia.net/chiaca.source"
+
+	// rotatedAtAnnotation is set on the CA Secret when the private CA in it is replaced
+	rotatedAtAnnotation = "k8s.chia.net/chiaca.rotated-at"
+
+	// previousTrustedUntilAnnotation is set on the CA Secret while the previous private CA is still trusted
+	previousTrustedUntilAnnotation = "k8s.chia.net/chiaca.previous-trusted-until"
+
+	// rotationTriggerAnnotation is set on the CA Secret to record the last spec.rotation.trigger value that was acted on
+	rotationTriggerAnnotation = "k8s.chia.net/chiaca.rotation-trigger"
+)
+
+// caFiles contains the PEM encoded files in a CA Secret
+type caFiles struct {
+	chiaCACrt    []byte
+	chiaCAKey    []byte
+	privateCACrt []byte
+	privateCAKey []byte
+}
+
+// toStringData returns the CA files as the string data of a CA Secret
+func (f caFiles) toStringData() map[string]string {
+	return map[string]string{
+		"chia_ca.crt":    string(f.chiaCACrt),
+		"chia_ca.key":    string(f.chiaCAKey),
+		"private_ca.crt": string(f.privateCACrt),
+		"private_ca.key": string(f.privateCAKey),
+	}
+}
+
+// getSecret fetches a k8s Secret in the ChiaCA's namespace. Returns false if the Secret doesn't exist.
+func (r *ChiaCAReconciler) getSecret(ctx context.Context, namespace, name string) (corev1.Secret, bool, error) {
 	var secret corev1.Secret
 	err := r.Get(ctx, types.NamespacedName{
-		Namespace: ca.Namespace,
-		Name:      getChiaCASecretName(ca),
+		Namespace: namespace,
+		Name:      name,
 	}, &secret)
 	if err != nil && errors.IsNotFound(err) {
-		return false, nil
+		return secret, false, nil
 	}
 	if err != nil {
-		return false, err
+		return secret, false, err
 	}
-	return true, nil
+	return secret, true, nil
+}
+
+// getImportData fetches the data of the Secret or ConfigMap a ChiaCA imports its CA from
+func (r *ChiaCAReconciler) getImportData(ctx context.Context, ca k8schianetv1.ChiaCA) (map[string][]byte, error) {
+	if ca.Spec.Import.SecretName != "" {
+		secret, exists, err := r.getSecret(ctx, ca.Namespace, ca.Spec.Import.SecretName)
+		if err != nil {
+			return nil, fmt.Errorf("encountered error querying for CA import Secret: %w", err)
+		}
+		if !exists {
+			return nil, kube.NewConditionError(k8schianetv1.ConditionTypeConfigValid, kube.ReasonInvalidConfig, fmt.Errorf("CA import Secret %q not found", ca.Spec.Import.SecretName))
+		}
+		return secret.Data, nil
+	}
+
+	if ca.Spec.Import.ConfigMapName != "" {
+		var cm corev1.ConfigMap
+		err := r.Get(ctx, types.NamespacedName{
+			Namespace: ca.Namespace,
+			Name:      ca.Spec.Import.ConfigMapName,
+		}, &cm)
+		if err != nil && errors.IsNotFound(err) {
+			return nil, kube.NewConditionError(k8schianetv1.ConditionTypeConfigValid, kube.ReasonInvalidConfig, fmt.Errorf("CA import ConfigMap %q not found", ca.Spec.Import.ConfigMapName))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encountered error querying for CA import ConfigMap: %w", err)
+		}
+		data := make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+		for k, v := range cm.BinaryData {
+			data[k] = v
+		}
+		for k, v := range cm.Data {
+			data[k] = []byte(v)
+		}
+		return data, nil
+	}
+
+	return nil, kube.NewConditionError(k8schianetv1.ConditionTypeConfigValid, kube.ReasonInvalidConfig, fmt.Errorf("one of import.secretName or import.configMapName is required"))
 }
 
 // getChiaCASecretName gets the name of the Secret to check if it exists
@@ -39,3 +119,258 @@ func getChiaCASecretName(ca k8schianetv1.ChiaCA) string {
 	}
 	return secretName
 }
+
+// getOverlapPeriod returns how long the previous private CA is trusted after the private CA changes
+func getOverlapPeriod(ca k8schianetv1.ChiaCA) time.Duration {
+	if ca.Spec.OverlapPeriod != nil {
+		return ca.Spec.OverlapPeriod.Duration
+	}
+	return consts.DefaultCAOverlapPeriod
+}
+
+// generateCAFiles generates a new private CA, and returns it with the public Chia CA
+func generateCAFiles() (caFiles, error) {
+	publicCACrtBytes, publicCAKeyBytes := tls.GetChiaCACertAndKey()
+
+	privateCACrt, privateCAKey, err := tls.GenerateNewCA()
+	if err != nil {
+		return caFiles{}, fmt.Errorf("encountered error generating new private CA cert and key: %w", err)
+	}
+
+	privateCACrtBytes, privateCAKeyBytes, err := tls.EncodeCertAndKeyToPEM(privateCACrt, privateCAKey)
+	if err != nil {
+		return caFiles{}, fmt.Errorf("encountered error encoding private CA cert and key to PEM: %w", err)
+	}
+
+	return caFiles{
+		chiaCACrt:    publicCACrtBytes,
+		chiaCAKey:    publicCAKeyBytes,
+		privateCACrt: privateCACrtBytes,
+		privateCAKey: privateCAKeyBytes,
+	}, nil
+}
+
+// parseImportedCA validates the data of a CA import source, and returns its CA files and private CA certificate.
+// The public Chia CA is used if the source doesn't contain chia_ca.crt and chia_ca.key.
+func parseImportedCA(data map[string][]byte, now time.Time) (caFiles, *x509.Certificate, error) {
+	files := caFiles{
+		chiaCACrt:    data["chia_ca.crt"],
+		chiaCAKey:    data["chia_ca.key"],
+		privateCACrt: data["private_ca.crt"],
+		privateCAKey: data["private_ca.key"],
+	}
+	if len(files.privateCACrt) == 0 || len(files.privateCAKey) == 0 {
+		return caFiles{}, nil, fmt.Errorf("CA import source must contain private_ca.crt and private_ca.key")
+	}
+	if (len(files.chiaCACrt) == 0) != (len(files.chiaCAKey) == 0) {
+		return caFiles{}, nil, fmt.Errorf("CA import source must contain both or neither of chia_ca.crt and chia_ca.key")
+	}
+	if len(files.chiaCACrt) == 0 {
+		files.chiaCACrt, files.chiaCAKey = tls.GetChiaCACertAndKey()
+	}
+
+	cert, err := tls.ParsePemCertificate(files.privateCACrt)
+	if err != nil {
+		return caFiles{}, nil, fmt.Errorf("error parsing imported private_ca.crt: %w", err)
+	}
+	key, err := tls.ParsePemKey(files.privateCAKey)
+	if err != nil {
+		return caFiles{}, nil, fmt.Errorf("error parsing imported private_ca.key: %w", err)
+	}
+	if !tls.CertMatchesPrivateKey(cert, key) {
+		return caFiles{}, nil, fmt.Errorf("imported private_ca.key does not match private_ca.crt")
+	}
+	if !cert.IsCA {
+		return caFiles{}, nil, fmt.Errorf("imported private_ca.crt is not a CA certificate")
+	}
+	if !now.Before(cert.NotAfter) {
+		return caFiles{}, nil, fmt.Errorf("imported private_ca.crt expired at %s", cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	return files, cert, nil
+}
+
+// getRenewalTime returns the time a generated private CA should be rotated, or nil if automatic rotation isn't enabled
+func getRenewalTime(ca k8schianetv1.ChiaCA, cert *x509.Certificate) *time.Time {
+	if ca.Spec.Import != nil || ca.Spec.CertManager != nil || ca.Spec.Rotation == nil || ca.Spec.Rotation.RenewBefore == nil {
+		return nil
+	}
+	renewalTime := cert.NotAfter.Add(-ca.Spec.Rotation.RenewBefore.Duration)
+	return &renewalTime
+}
+
+// getRotationReason returns the reason a generated private CA needs to be rotated, or an empty string if it doesn't.
+// Only private CAs the operator generated are rotated, never ones that were imported or already existed in spec.secret.
+func getRotationReason(ca k8schianetv1.ChiaCA, secret corev1.Secret, cert *x509.Certificate, now time.Time) string {
+	if ca.Spec.Import != nil || ca.Spec.CertManager != nil || ca.Spec.Rotation == nil {
+		return ""
+	}
+	if getCASource(secret) != k8schianetv1.ChiaCASourceGenerated {
+		return ""
+	}
+	if trigger := ca.Spec.Rotation.Trigger; trigger != "" && trigger != secret.Annotations[rotationTriggerAnnotation] {
+		return fmt.Sprintf("rotation trigger changed to %q", trigger)
+	}
+	if renewalTime := getRenewalTime(ca, cert); renewalTime != nil && !now.Before(*renewalTime) {
+		return fmt.Sprintf("private CA expires at %s, which is within the renewal window", cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return ""
+}
+
+// getPreviousTrustedUntil returns the time the previous private CA in a CA Secret stops being trusted, or nil if there isn't one
+func getPreviousTrustedUntil(secret corev1.Secret) *time.Time {
+	value, ok := secret.Annotations[previousTrustedUntilAnnotation]
+	if !ok {
+		return nil
+	}
+	trustedUntil, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		// An unparseable time is treated as expired so the previous CA is removed
+		return &time.Time{}
+	}
+	return &trustedUntil
+}
+
+// rotateCASecret replaces the private CA in a CA Secret with new CA files. The current private CA is appended to private_ca.crt
+// until the overlap period ends, so Chia components are trusted by each other while they restart with certificates from the new CA.
+func rotateCASecret(secret *corev1.Secret, files caFiles, currentCA *x509.Certificate, source k8schianetv1.ChiaCASource, overlap time.Duration, now time.Time) {
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[sourceAnnotation] = string(source)
+	secret.Annotations[rotatedAtAnnotation] = now.UTC().Format(time.RFC3339)
+
+	privateCACrt := files.privateCACrt
+	if currentCA != nil && overlap > 0 {
+		privateCACrt = appendCertificate(privateCACrt, currentCA)
+		secret.Annotations[previousTrustedUntilAnnotation] = now.Add(overlap).UTC().Format(time.RFC3339)
+	} else {
+		delete(secret.Annotations, previousTrustedUntilAnnotation)
+	}
+
+	secret.StringData = nil
+	secret.Data = map[string][]byte{
+		"chia_ca.crt":    files.chiaCACrt,
+		"chia_ca.key":    files.chiaCAKey,
+		"private_ca.crt": privateCACrt,
+		"private_ca.key": files.privateCAKey,
+	}
+}
+
+// removePreviousCA removes the previous private CA from a CA Secret's private_ca.crt once the overlap period has ended
+func removePreviousCA(secret *corev1.Secret, currentCA *x509.Certificate) {
+	delete(secret.Annotations, previousTrustedUntilAnnotation)
+	secret.Data["private_ca.crt"] = appendCertificate(nil, currentCA)
+}
+
+// appendCertificate appends a PEM encoded certificate to a PEM encoded certificate file
+func appendCertificate(certPEM []byte, cert *x509.Certificate) []byte {
+	out := append([]byte{}, certPEM...)
+	if len(out) > 0 && out[len(out)-1] != '\n' {
+		out = append(out, '\n')
+	}
+	return append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+}
+
+// getCASource returns where the private CA in a CA Secret came from
+func getCASource(secret corev1.Secret) k8schianetv1.ChiaCASource {
+	switch source := k8schianetv1.ChiaCASource(secret.Annotations[sourceAnnotation]); source {
+	case k8schianetv1.ChiaCASourceGenerated, k8schianetv1.ChiaCASourceImported, k8schianetv1.ChiaCASourceCertManager:
+		return source
+	}
+	return k8schianetv1.ChiaCASourceExisting
+}
+
+// importedFilesChanged returns true if the CA files in a CA Secret differ from the imported CA files, other than the private CA certificate
+func importedFilesChanged(secret corev1.Secret, files caFiles, source k8schianetv1.ChiaCASource) bool {
+	return !bytes.Equal(secret.Data["chia_ca.crt"], files.chiaCACrt) ||
+		!bytes.Equal(secret.Data["chia_ca.key"], files.chiaCAKey) ||
+		!bytes.Equal(secret.Data["private_ca.key"], files.privateCAKey) ||
+		getCASource(secret) != source
+}
+
+// updateImportedCASecret updates the CA files in a CA Secret whose imported private CA certificate is unchanged.
+// The private CA certificate is left as is, since it may still contain the previous private CA during the overlap period.
+func updateImportedCASecret(secret *corev1.Secret, files caFiles, source k8schianetv1.ChiaCASource) {
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[sourceAnnotation] = string(source)
+	secret.Data["chia_ca.crt"] = files.chiaCACrt
+	secret.Data["chia_ca.key"] = files.chiaCAKey
+	secret.Data["private_ca.key"] = files.privateCAKey
+}
+
+// referencesObject returns true if a ChiaCA imports its CA from the named Secret or ConfigMap, or uses the named Secret as its CA Secret
+func referencesObject(ca k8schianetv1.ChiaCA, name string, isConfigMap bool) bool {
+	if isConfigMap {
+		return ca.Spec.Import != nil && ca.Spec.Import.ConfigMapName == name
+	}
+	if ca.Spec.Import != nil && ca.Spec.Import.SecretName == name {
+		return true
+	}
+	if ca.Spec.CertManager != nil && getCertManagerSecretName(ca) == name {
+		return true
+	}
+	return getChiaCASecretName(ca) == name
+}
+
+// stringDataToData converts the string data of a Secret to its data
+func stringDataToData(stringData map[string]string) map[string][]byte {
+	data := make(map[string][]byte, len(stringData))
+	for k, v := range stringData {
+		data[k] = []byte(v)
+	}
+	return data
+}
+
+// updateCAStatus sets the status fields of a ChiaCA from its CA Secret, and returns when the ChiaCA should next be reconciled
+// to rotate the private CA or remove the previous private CA.
+func updateCAStatus(ca *k8schianetv1.ChiaCA, secret corev1.Secret, now time.Time) (ctrl.Result, error) {
+	certs, err := kube.ParseCACertificates(secret.Data["private_ca.crt"])
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("error parsing private CA certificate from Secret: %w", err)
+	}
+	currentCA := certs[0]
+
+	ca.Status.Source = getCASource(secret)
+	ca.Status.Fingerprint = kube.CertificateFingerprint(currentCA)
+	notAfter := metav1.NewTime(currentCA.NotAfter)
+	ca.Status.NotAfter = &notAfter
+	ca.Status.LastRotationTrigger = secret.Annotations[rotationTriggerAnnotation]
+	ca.Status.LastRotationTime = nil
+	if rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[rotatedAtAnnotation]); err == nil {
+		lastRotation := metav1.NewTime(rotatedAt)
+		ca.Status.LastRotationTime = &lastRotation
+	}
+
+	var requeueAfter time.Duration
+	requeueAt := func(t time.Time) {
+		if until := t.Sub(now); until > 0 && (requeueAfter == 0 || until < requeueAfter) {
+			requeueAfter = until
+		}
+	}
+
+	ca.Status.RenewalTime = nil
+	if renewalTime := getRenewalTime(*ca, currentCA); renewalTime != nil {
+		renewal := metav1.NewTime(*renewalTime)
+		ca.Status.RenewalTime = &renewal
+		requeueAt(*renewalTime)
+	}
+
+	ca.Status.PreviousFingerprint = ""
+	ca.Status.PreviousTrustedUntil = nil
+	if len(certs) > 1 {
+		ca.Status.PreviousFingerprint = kube.CertificateFingerprint(certs[1])
+		if trustedUntil := getPreviousTrustedUntil(secret); trustedUntil != nil {
+			until := metav1.NewTime(*trustedUntil)
+			ca.Status.PreviousTrustedUntil = &until
+			requeueAt(*trustedUntil)
+		}
+	}
+
+	if requeueAfter > 0 {
+		return ctrl.Result{RequeueAfter: min(requeueAfter, maxRotationCheckInterval)}, nil
+	}
+	return ctrl.Result{}, nil
+}
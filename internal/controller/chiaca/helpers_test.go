@@ -6,9 +6,13 @@ package chiaca
 
 import (
 	"testing"
+	"time"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -52,3 +56,119 @@ func TestGetChiaCASecretName_WhitespaceString(t *testing.T) {
 	secretName := getChiaCASecretName(customCA)
 	assert.Equal(t, "testname", secretName)
 }
+
+func TestParseImportedCA(t *testing.T) {
+	files, err := generateCAFiles()
+	require.NoError(t, err)
+	otherFiles, err := generateCAFiles()
+	require.NoError(t, err)
+
+	// The public Chia CA is used if the source doesn't contain one
+	imported, cert, err := parseImportedCA(map[string][]byte{
+		"private_ca.crt": files.privateCACrt,
+		"private_ca.key": files.privateCAKey,
+	}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, files, imported)
+	assert.True(t, cert.IsCA)
+
+	// The key must match the certificate
+	_, _, err = parseImportedCA(map[string][]byte{
+		"private_ca.crt": files.privateCACrt,
+		"private_ca.key": otherFiles.privateCAKey,
+	}, time.Now())
+	assert.ErrorContains(t, err, "does not match")
+
+	// Expired CAs can't be imported
+	_, _, err = parseImportedCA(map[string][]byte{
+		"private_ca.crt": files.privateCACrt,
+		"private_ca.key": files.privateCAKey,
+	}, cert.NotAfter)
+	assert.ErrorContains(t, err, "expired")
+
+	_, _, err = parseImportedCA(map[string][]byte{
+		"private_ca.crt": files.privateCACrt,
+	}, time.Now())
+	assert.ErrorContains(t, err, "must contain private_ca.crt and private_ca.key")
+}
+
+func TestGetRotationReason(t *testing.T) {
+	files, err := generateCAFiles()
+	require.NoError(t, err)
+	certs, err := kube.ParseCACertificates(files.privateCACrt)
+	require.NoError(t, err)
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{sourceAnnotation: string(k8schianetv1.ChiaCASourceGenerated)},
+		},
+	}
+
+	// Rotation is disabled by default
+	assert.Empty(t, getRotationReason(testChiaCA, secret, certs[0], time.Now()))
+
+	ca := testChiaCA
+	ca.Spec.Rotation = &k8schianetv1.ChiaCARotation{
+		RenewBefore: &metav1.Duration{Duration: 30 * 24 * time.Hour},
+	}
+	assert.Empty(t, getRotationReason(ca, secret, certs[0], time.Now()))
+	assert.Contains(t, getRotationReason(ca, secret, certs[0], certs[0].NotAfter.Add(-24*time.Hour)), "within the renewal window")
+
+	// A new trigger value rotates the CA, but only once
+	ca.Spec.Rotation.Trigger = "2026-01-01"
+	assert.Contains(t, getRotationReason(ca, secret, certs[0], time.Now()), "rotation trigger changed")
+	secret.Annotations[rotationTriggerAnnotation] = "2026-01-01"
+	assert.Empty(t, getRotationReason(ca, secret, certs[0], time.Now()))
+
+	// CAs that already existed in the CA Secret are never rotated
+	ca.Spec.Rotation.Trigger = "2026-02-01"
+	existing := corev1.Secret{}
+	assert.Empty(t, getRotationReason(ca, existing, certs[0], time.Now()))
+	assert.Empty(t, getRotationReason(ca, existing, certs[0], certs[0].NotAfter.Add(-24*time.Hour)))
+
+	// Imported CAs are never rotated
+	ca.Spec.Import = &k8schianetv1.ChiaCAImport{SecretName: "farm-ca"}
+	ca.Spec.Rotation.Trigger = "2026-02-01"
+	assert.Empty(t, getRotationReason(ca, secret, certs[0], time.Now()))
+}
+
+func TestRotateCASecret(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	oldFiles, err := generateCAFiles()
+	require.NoError(t, err)
+	newFiles, err := generateCAFiles()
+	require.NoError(t, err)
+	oldCerts, err := kube.ParseCACertificates(oldFiles.privateCACrt)
+	require.NoError(t, err)
+	newCerts, err := kube.ParseCACertificates(newFiles.privateCACrt)
+	require.NoError(t, err)
+
+	secret := corev1.Secret{Data: stringDataToData(oldFiles.toStringData())}
+	rotateCASecret(&secret, newFiles, oldCerts[0], k8schianetv1.ChiaCASourceGenerated, 24*time.Hour, now)
+
+	// Both CAs are trusted during the overlap period, and the new CA signs certificates
+	certs, err := kube.ParseCACertificates(secret.Data["private_ca.crt"])
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	assert.Equal(t, newCerts[0].Raw, certs[0].Raw)
+	assert.Equal(t, oldCerts[0].Raw, certs[1].Raw)
+	assert.Equal(t, newFiles.privateCAKey, secret.Data["private_ca.key"])
+
+	var ca k8schianetv1.ChiaCA
+	result, err := updateCAStatus(&ca, secret, now)
+	require.NoError(t, err)
+	assert.Equal(t, k8schianetv1.ChiaCASourceGenerated, ca.Status.Source)
+	assert.Equal(t, kube.CertificateFingerprint(newCerts[0]), ca.Status.Fingerprint)
+	assert.Equal(t, kube.CertificateFingerprint(oldCerts[0]), ca.Status.PreviousFingerprint)
+	require.NotNil(t, ca.Status.PreviousTrustedUntil)
+	assert.True(t, ca.Status.PreviousTrustedUntil.Time.Equal(now.Add(24*time.Hour)))
+	require.NotNil(t, ca.Status.LastRotationTime)
+	assert.Equal(t, 24*time.Hour, result.RequeueAfter)
+
+	// The previous CA is removed after the overlap period
+	removePreviousCA(&secret, certs[0])
+	certs, err = kube.ParseCACertificates(secret.Data["private_ca.crt"])
+	require.NoError(t, err)
+	require.Len(t, certs, 1)
+	assert.Equal(t, newCerts[0].Raw, certs[0].Raw)
+	assert.Nil(t, getPreviousTrustedUntil(secret))
+}
@@ -28,3 +28,12 @@ func assembleSecret(cr k8schianetv1.ChiaCertificates, certMap map[string]string)
 		StringData: certMap,
 	}
 }
+
+// stringDataToData converts Secret string data to Secret data, like the API server does when a Secret is written
+func stringDataToData(stringData map[string]string) map[string][]byte {
+	data := make(map[string][]byte, len(stringData))
+	for k, v := range stringData {
+		data[k] = []byte(v)
+	}
+	return data
+}
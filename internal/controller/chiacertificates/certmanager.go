@@ -0,0 +1,186 @@
+/*
+Copyright 2025 Chia Network Inc.
+*/
+
+package chiacertificates
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/chia-network/go-chia-libs/pkg/tls"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/certmanager"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// getPrivateIssuerName returns the name of the CA Issuer, and its Secret, the operator creates from the private CA for a ChiaCertificates
+func getPrivateIssuerName(cr k8schianetv1.ChiaCertificates) string {
+	return cr.Name + "-private-ca"
+}
+
+// getPublicIssuerName returns the name of the CA Issuer, and its Secret, the operator creates from the public Chia CA for a ChiaCertificates
+func getPublicIssuerName(cr k8schianetv1.ChiaCertificates) string {
+	return cr.Name + "-chia-ca"
+}
+
+// getCertManagerCertificateName returns the name of the cert-manager Certificate, and the Secret it's stored in, for a chia certificate
+func getCertManagerCertificateName(cr k8schianetv1.ChiaCertificates, filenameBase string) string {
+	return cr.Name + "-" + strings.ReplaceAll(filenameBase, "_", "-")
+}
+
+// assembleIssuerSecret assembles a Secret containing a CA in the layout a cert-manager CA Issuer expects
+func assembleIssuerSecret(cr k8schianetv1.ChiaCertificates, name string, certPEM, keyPEM []byte) corev1.Secret {
+	return corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cr.Namespace,
+			Labels:    kube.GetCommonLabels(string(consts.ChiaCertificatesKind), cr.ObjectMeta),
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+}
+
+// reconcileIssuer applies a CA Issuer, and the Secret containing its CA, for a ChiaCertificates
+func (r *ChiaCertificatesReconciler) reconcileIssuer(ctx context.Context, cr k8schianetv1.ChiaCertificates, name string, certPEM, keyPEM []byte) error {
+	secret := assembleIssuerSecret(cr, name, certPEM, keyPEM)
+	if err := controllerutil.SetControllerReference(&cr, &secret, r.Scheme); err != nil {
+		return fmt.Errorf("error setting controller reference on Secret \"%s\": %w", secret.Name, err)
+	}
+	if _, err := kube.ReconcileSecret(ctx, r.Client, secret); err != nil {
+		return err
+	}
+
+	issuer := certmanager.AssembleCAIssuer(name, cr.Namespace, name, kube.GetCommonLabels(string(consts.ChiaCertificatesKind), cr.ObjectMeta))
+	return certmanager.Apply(ctx, r.Client, r.Scheme, &cr, issuer)
+}
+
+// reconcileCertManagerCertificates applies the cert-manager resources that issue a ChiaCertificates' certificates, and returns the issued
+// certificates in the layout of a certificate Secret. Returns false if cert-manager hasn't issued all the certificates yet.
+func (r *ChiaCertificatesReconciler) reconcileCertManagerCertificates(ctx context.Context, cr k8schianetv1.ChiaCertificates, caSecret corev1.Secret, caSecretExists bool) (map[string]string, bool, error) {
+	cm := cr.Spec.CertManager
+	labels := kube.GetCommonLabels(string(consts.ChiaCertificatesKind), cr.ObjectMeta)
+
+	// Create CA Issuers for any issuers that weren't specified
+	var privateCACert *x509.Certificate
+	if cm.PrivateIssuerRef == nil {
+		if !caSecretExists {
+			return nil, false, kube.NewConditionError(k8schianetv1.ConditionTypeConfigValid, kube.ReasonCASecretNotFound, fmt.Errorf("CA Secret %q not found", cr.Spec.CASecretName))
+		}
+		// The CA Secret may also contain a ChiaCA's previous private CA while it's still trusted, certificates are issued from the current one
+		privateCACerts, err := kube.ParseCACertificates(caSecret.Data["private_ca.crt"])
+		if err != nil {
+			return nil, false, fmt.Errorf("error parsing private CA certificate from Secret: %w", err)
+		}
+		privateCAKey, ok := caSecret.Data["private_ca.key"]
+		if !ok {
+			return nil, false, fmt.Errorf("private CA key not present in CA Secret")
+		}
+		privateCACert = privateCACerts[0]
+		privateCAPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: privateCACert.Raw})
+		if err := r.reconcileIssuer(ctx, cr, getPrivateIssuerName(cr), privateCAPEM, privateCAKey); err != nil {
+			return nil, false, err
+		}
+	}
+	if cm.PublicIssuerRef == nil {
+		publicCACrt, publicCAKey := tls.GetChiaCACertAndKey()
+		if err := r.reconcileIssuer(ctx, cr, getPublicIssuerName(cr), publicCACrt, publicCAKey); err != nil {
+			return nil, false, err
+		}
+	}
+
+	privateIssuerRef := certmanager.IssuerRefOrDefault(cm.PrivateIssuerRef, getPrivateIssuerName(cr))
+	publicIssuerRef := certmanager.IssuerRefOrDefault(cm.PublicIssuerRef, getPublicIssuerName(cr))
+
+	certMap := make(map[string]string)
+	issued := true
+	for filenameBase := range certNodes {
+		issuerRef := publicIssuerRef
+		if strings.HasPrefix(filenameBase, "private_") {
+			issuerRef = privateIssuerRef
+		}
+
+		name := getCertManagerCertificateName(cr, filenameBase)
+		certificate := certmanager.AssembleCertificate(certmanager.CertificateInputs{
+			Name:        name,
+			Namespace:   cr.Namespace,
+			Labels:      labels,
+			IssuerRef:   issuerRef,
+			Duration:    cm.Duration,
+			RenewBefore: cm.RenewBefore,
+		})
+		if err := certmanager.Apply(ctx, r.Client, r.Scheme, &cr, certificate); err != nil {
+			return nil, false, err
+		}
+
+		secret, exists, err := r.getSecret(ctx, cr.Namespace, name)
+		if err != nil {
+			return nil, false, fmt.Errorf("encountered error querying for cert-manager Secret: %w", err)
+		}
+		if !exists {
+			issued = false
+			continue
+		}
+		certPEM, keyPEM, err := certmanager.KeyPairFromSecret(secret)
+		if err != nil {
+			issued = false
+			continue
+		}
+
+		// cert-manager doesn't re-issue certificates when the CA of an Issuer changes, so delete the Secret of any certificate
+		// that wasn't issued from the current private CA, which makes cert-manager issue it again
+		if privateCACert != nil && issuerRef == privateIssuerRef && !issuedFrom(certPEM, privateCACert) {
+			log.FromContext(ctx).Info("Deleting cert-manager Secret issued from a previous private CA", "Secret", name)
+			if err := r.Delete(ctx, &secret); err != nil {
+				return nil, false, fmt.Errorf("error deleting cert-manager Secret \"%s\": %w", name, err)
+			}
+			issued = false
+			continue
+		}
+
+		certMap[filenameBase+".crt"] = string(certPEM)
+		certMap[filenameBase+".key"] = string(keyPEM)
+	}
+
+	return certMap, issued, nil
+}
+
+// issuedFrom returns true if a PEM encoded certificate was signed by the given CA certificate
+func issuedFrom(certPEM []byte, caCert *x509.Certificate) bool {
+	cert, err := tls.ParsePemCertificate(certPEM)
+	if err != nil {
+		return false
+	}
+	return cert.CheckSignatureFrom(caCert) == nil
+}
+
+// certDataMatches returns true if a certificate Secret's data matches the given certificates
+func certDataMatches(data map[string][]byte, certMap map[string]string) bool {
+	if len(data) != len(certMap) {
+		return false
+	}
+	for k, v := range certMap {
+		if !bytes.Equal(data[k], []byte(v)) {
+			return false
+		}
+	}
+	return true
+}
@@ -11,18 +11,23 @@ import (
 	"strings"
 	"time"
 
-	"github.com/chia-network/go-chia-libs/pkg/tls"
-
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 	"github.com/chia-network/chia-operator/internal/metrics"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 // ChiaCertificatesReconciler reconciles a ChiaCertificates object
@@ -30,32 +35,41 @@ type ChiaCertificatesReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder events.EventRecorder
+
+	// MaxConcurrentReconciles is the maximum number of ChiaCertificates that can be reconciled at the same time, which defaults to 1
+	MaxConcurrentReconciles int
 }
 
-var chiacertificates = make(map[string]bool)
+// maxRenewalCheckInterval is the maximum amount of time between checks for whether certificates need to be re-issued
+const maxRenewalCheckInterval = 24 * time.Hour
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiacertificates,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiacertificates/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiacertificates/finalizers,verbs=update
-//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=delete
+//+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=cert-manager.io,resources=issuers;certificates,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch;update
 
 // Reconcile is invoked on any event to a controlled Kubernetes resource
-func (r *ChiaCertificatesReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ChiaCertificatesReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
 	log := log.FromContext(ctx)
 	log.Info("Running reconciler...")
 
+	// Record how long this reconcile took, and why it failed if it returned an error
+	start := time.Now()
+	defer func() {
+		kube.ObserveReconcile(string(consts.ChiaCertificatesKind), start, reterr)
+	}()
+
 	// Get the custom resource
 	var cr k8schianetv1.ChiaCertificates
 	err := r.Get(ctx, req.NamespacedName, &cr)
 	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chiacertificates[req.String()]
-		if exists {
-			delete(chiacertificates, req.String())
-			metrics.ChiaCertificates.Sub(1.0)
-		}
+		// Remove this object from the operator's metrics
+		metrics.Resources.Delete(string(consts.ChiaCertificatesKind), req.String())
 		return ctrl.Result{}, nil
 	}
 	if err != nil {
@@ -63,96 +77,325 @@ func (r *ChiaCertificatesReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, err
 	}
 
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chiacertificates[req.String()]
-	if !exists {
-		chiacertificates[req.String()] = true
-		metrics.ChiaCertificates.Add(1.0)
+	// Add this object to the operator's metrics, if it wasn't already added
+	metrics.Resources.Add(string(consts.ChiaCertificatesKind), req.String())
+
+	// Record the ChiaCertificates's Ready condition in the operator's metrics, after any reconciliation error was recorded in it
+	defer func() {
+		if cr.DeletionTimestamp.IsZero() {
+			metrics.Resources.SetReadyCondition(string(consts.ChiaCertificatesKind), req.String(), kube.GetReadyConditionStatus(cr.Status.Conditions))
+		}
+	}()
+
+	// Record any reconciliation error in the CR's status conditions
+	defer func() {
+		if reterr != nil {
+			kube.SetReconcileFailedConditions(&cr.Status.Conditions, cr.Generation, reterr)
+			cr.Status.Ready = false
+			if err := r.Status().Update(ctx, &cr); err != nil && !strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+				log.Error(err, "encountered error updating ChiaCertificates status conditions")
+			}
+		}
+	}()
+
+	// Stop applying changes while the ChiaCertificates is suspended
+	suspended, err := kube.ReconcileSuspension(ctx, r.Client, cr.ObjectMeta, nil, &cr.Status.Conditions, nil)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("ChiaCertificatesReconciler ChiaCertificates=%s %w", req.NamespacedName, err)
+	}
+	if suspended {
+		return kube.UpdateSuspendedStatus(ctx, r.Client, consts.ChiaCertificatesKind, &cr, cr.Status.Conditions, &cr.Status.ObservedGeneration, &cr.Status.Ready)
 	}
 
 	// Verify that certificate Secret name does not match the CA Secret name
 	certSecretName := getChiaCertificatesSecretName(cr)
 	caSecretName := cr.Spec.CASecretName
 	if certSecretName == caSecretName {
-		log.Error(stdlibErrors.New("certificate Secret cannot be the same name as the CA Secret"),
-			"Invalid certificate Secret name", "certificate Secret name", certSecretName, "CA Secret name", caSecretName)
+		err := stdlibErrors.New("certificate Secret cannot be the same name as the CA Secret")
+		log.Error(err, "Invalid certificate Secret name", "certificate Secret name", certSecretName, "CA Secret name", caSecretName)
+
+		// Requeueing won't help here, the CR's spec needs to change, so only surface the error in the CR's status
+		kube.SetReconcileFailedConditions(&cr.Status.Conditions, cr.Generation, kube.NewConditionError(k8schianetv1.ConditionTypeConfigValid, kube.ReasonInvalidConfig, err))
+		cr.Status.ObservedGeneration = cr.Generation
+		cr.Status.Ready = false
+		if err := r.Status().Update(ctx, &cr); err != nil {
+			if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+				return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+			}
+			log.Error(err, "encountered error updating ChiaCertificates status")
+			return ctrl.Result{}, err
+		}
 		return ctrl.Result{}, nil
 	}
 
+	caSecret, caSecretExists, err := r.getSecret(ctx, cr.Namespace, caSecretName)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("encountered error querying for existing CA Secret: %w", err)
+	}
+
 	// Check if certificate Secret exists
-	_, certSecretExists, err := r.getSecret(ctx, cr.Namespace, certSecretName)
+	certSecret, certSecretExists, err := r.getSecret(ctx, cr.Namespace, certSecretName)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("encountered error querying for existing Certificates Secret: %v", err)
+		return ctrl.Result{}, fmt.Errorf("encountered error querying for existing Certificates Secret: %w", err)
 	}
 
-	// If Certificates Secret doesn't exist, generate certificates and create one
-	if !certSecretExists {
-		caSecret, caSecretExists, err := r.getSecret(ctx, cr.Namespace, caSecretName)
+	// The CA is needed to generate certificates, but existing certificates are still usable without it
+	if !caSecretExists && !certSecretExists {
+		log.Info("CA Secret not found, cancelling reconciliation and retrying in 10 seconds")
+		kube.SetReconcileFailedConditions(&cr.Status.Conditions, cr.Generation, kube.NewConditionError(k8schianetv1.ConditionTypeConfigValid, kube.ReasonCASecretNotFound, fmt.Errorf("CA Secret %q not found", caSecretName)))
+		cr.Status.Ready = false
+		if err := r.Status().Update(ctx, &cr); err != nil && !strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+			log.Error(err, "encountered error updating ChiaCertificates status")
+		}
+		return ctrl.Result{
+			RequeueAfter: 10 * time.Second,
+		}, nil
+	}
+
+	if cr.Spec.CertManager != nil {
+		// cert-manager issues and renews the certificates, copy them into the certificate Secret
+		certMap, issued, err := r.reconcileCertManagerCertificates(ctx, cr, caSecret, caSecretExists)
 		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("encountered error querying for existing CA Secret: %v", err)
+			return ctrl.Result{}, err
 		}
-		if !caSecretExists {
-			log.Info("CA Secret not found, cancelling reconciliation and retrying in 10 seconds")
-			return ctrl.Result{
-				RequeueAfter: 10 * time.Second,
-			}, nil
+		if !issued && !certSecretExists {
+			log.Info("Waiting for cert-manager to issue certificates, retrying in 10 seconds")
+			kube.SetCondition(&cr.Status.Conditions, cr.Generation, k8schianetv1.ConditionTypeReady, metav1.ConditionFalse, kube.ReasonCertificatePending, "Waiting for cert-manager to issue certificates")
+			kube.SetCondition(&cr.Status.Conditions, cr.Generation, k8schianetv1.ConditionTypeProgressing, metav1.ConditionTrue, kube.ReasonCertificatePending, "Waiting for cert-manager to issue certificates")
+			if err := r.Status().Update(ctx, &cr); err != nil && !strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+				log.Error(err, "encountered error updating ChiaCertificates status")
+			}
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 		}
 
+		if !certSecretExists {
+			certSecret = assembleSecret(cr, certMap)
+			if err = r.Create(ctx, &certSecret); err != nil {
+				return ctrl.Result{}, fmt.Errorf("error creating certificate Secret \"%s\": %w", certSecret.Name, err)
+			}
+			certSecret.Data = stringDataToData(certMap)
+		} else if issued && !certDataMatches(certSecret.Data, certMap) {
+			// cert-manager renewed or re-issued some of the certificates, only update the Secret once they've all been issued
+			log.Info("Updating certificates issued by cert-manager")
+			rotatedAt := metav1.NewTime(time.Now().Truncate(time.Second))
+			if certSecret.Annotations == nil {
+				certSecret.Annotations = make(map[string]string)
+			}
+			certSecret.Annotations[rotatedAtAnnotation] = rotatedAt.UTC().Format(time.RFC3339)
+			certSecret.Data = stringDataToData(certMap)
+			if err = r.Update(ctx, &certSecret); err != nil {
+				if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+					return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+				}
+				return ctrl.Result{}, fmt.Errorf("error updating certificate Secret \"%s\": %w", certSecret.Name, err)
+			}
+			cr.Status.LastRotationTime = &rotatedAt
+			r.Recorder.Eventf(&cr, nil, corev1.EventTypeNormal, "Rotated", "Rotated",
+				"Updated Secret %s/%s with certificates issued by cert-manager", cr.Namespace, certSecretName)
+		}
+	} else if caSecretExists {
 		privateCACertData, ok := caSecret.Data["private_ca.crt"]
 		if !ok {
 			return ctrl.Result{}, fmt.Errorf("private CA certificate not present in CA Secret")
 		}
-		privateCACert, err := tls.ParsePemCertificate(privateCACertData)
+		// The CA Secret may also contain a ChiaCA's previous private CA while it's still trusted, certificates are issued from the current one
+		privateCACerts, err := kube.ParseCACertificates(privateCACertData)
 		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("error parsing private CA certificate from Secret: %v", err)
+			return ctrl.Result{}, fmt.Errorf("error parsing private CA certificate from Secret: %w", err)
 		}
-
+		privateCACert := privateCACerts[0]
 		privateCAKeyData, ok := caSecret.Data["private_ca.key"]
 		if !ok {
 			return ctrl.Result{}, fmt.Errorf("private CA key not present in CA Secret")
 		}
-		privateCAKey, err := tls.ParsePemKey(privateCAKeyData)
-		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("error parsing private CA key from Secret: %v", err)
-		}
+		cr.Status.CAFingerprint = kube.CertificateFingerprint(privateCACert)
 
-		allCerts, err := tls.GenerateAllCerts(privateCACert, privateCAKey)
-		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("error generating new certificates: %v", err)
+		// If Certificates Secret doesn't exist, generate certificates and create one
+		if !certSecretExists {
+			certMap, err := generateCertMap(privateCACert, privateCAKeyData)
+			if err != nil {
+				return ctrl.Result{}, kube.NewConditionError(k8schianetv1.ConditionTypeReady, kube.ReasonCertificateGenerationFailed, err)
+			}
+
+			certSecret = assembleSecret(cr, certMap)
+			if err = r.Create(ctx, &certSecret); err != nil {
+				return ctrl.Result{}, fmt.Errorf("error creating certificate Secret \"%s\": %w", certSecret.Name, err)
+			}
+			certSecret.Data = stringDataToData(certMap)
+		} else if reason := getRenewalReason(certSecret.Data, privateCACert, getRenewBefore(cr), time.Now()); reason != "" {
+			// Re-issue the certificates in the existing Secret
+			log.Info("Re-issuing certificates", "reason", reason)
+			certMap, err := generateCertMap(privateCACert, privateCAKeyData)
+			if err != nil {
+				return ctrl.Result{}, kube.NewConditionError(k8schianetv1.ConditionTypeReady, kube.ReasonCertificateGenerationFailed, err)
+			}
+
+			rotatedAt := metav1.NewTime(time.Now().Truncate(time.Second))
+			if certSecret.Annotations == nil {
+				certSecret.Annotations = make(map[string]string)
+			}
+			certSecret.Annotations[rotatedAtAnnotation] = rotatedAt.UTC().Format(time.RFC3339)
+			certSecret.Data = stringDataToData(certMap)
+			if err = r.Update(ctx, &certSecret); err != nil {
+				if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+					return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+				}
+				return ctrl.Result{}, fmt.Errorf("error updating certificate Secret \"%s\": %w", certSecret.Name, err)
+			}
+			cr.Status.LastRotationTime = &rotatedAt
+			r.Recorder.Eventf(&cr, nil, corev1.EventTypeNormal, "Rotated", "Rotated",
+				"Re-issued certificates in Secret %s/%s: %s", cr.Namespace, certSecretName, reason)
 		}
+	} else {
+		log.Info("CA Secret not found, unable to check if certificates need to be re-issued", "CA Secret name", caSecretName)
+	}
 
-		certMap, err := constructCertMap(allCerts)
+	// Restart the workloads that mount the certificate Secret if the certificates were re-issued
+	if rotatedAtValue, ok := certSecret.Annotations[rotatedAtAnnotation]; ok && restartWorkloadsEnabled(cr) {
+		rotatedAt, err := time.Parse(time.RFC3339, rotatedAtValue)
 		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("error converting certificates to map: %v", err)
+			log.Error(err, "unable to parse certificate rotation time from Secret annotation", "annotation", rotatedAtAnnotation)
+		} else if err := r.restartWorkloads(ctx, cr, certSecretName, rotatedAt); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error restarting workloads that mount certificate Secret \"%s\": %w", certSecretName, err)
 		}
+	}
 
-		secret := assembleSecret(cr, certMap)
-		if err = r.Create(ctx, &secret); err != nil {
-			return ctrl.Result{}, fmt.Errorf("error creating certificate Secret \"%s\": %v", secret.Name, err)
+	certStatuses, err := getCertificateStatuses(certSecret.Data)
+	if err != nil {
+		log.Error(err, "unable to read certificate expiry times from Secret")
+	}
+	cr.Status.Certificates = certStatuses
+	cr.Status.NotAfter = getEarliestNotAfter(certStatuses)
+	cr.Status.RenewalTime = nil
+	var result ctrl.Result
+	if cr.Status.NotAfter != nil && cr.Spec.CertManager == nil {
+		renewalTime := metav1.NewTime(cr.Status.NotAfter.Add(-getRenewBefore(cr)))
+		cr.Status.RenewalTime = &renewalTime
+		if untilRenewal := time.Until(renewalTime.Time); untilRenewal > 0 {
+			result.RequeueAfter = min(untilRenewal, maxRenewalCheckInterval)
 		}
 	}
 
 	if !cr.Status.Ready {
 		r.Recorder.Eventf(&cr, nil, corev1.EventTypeNormal, "Created", "Created",
 			"Successfully created Certificates Secret in %s/%s", cr.Namespace, cr.Name)
+	}
 
-		cr.Status.Ready = true
-		err = r.Status().Update(ctx, &cr)
-		if err != nil {
-			if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
-				return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
-			}
-			log.Error(err, "encountered error updating ChiaCertificates status")
-			return ctrl.Result{}, err
+	kube.SetResourceReadyConditions(&cr.Status.Conditions, cr.Generation, fmt.Sprintf("Certificates Secret %q exists", certSecretName))
+	cr.Status.ObservedGeneration = cr.Generation
+	cr.Status.Ready = true
+	err = r.Status().Update(ctx, &cr)
+	if err != nil {
+		if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 		}
+		log.Error(err, "encountered error updating ChiaCertificates status")
+		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ChiaCertificatesReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&k8schianetv1.ChiaCertificates{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.findCertificatesForSecret)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
+
+// findCertificatesForSecret returns a reconcile request for every ChiaCertificates in a Secret's namespace that uses it as its CA or certificate Secret,
+// or that cert-manager issued it for, so certificates are re-issued as soon as the CA changes, re-created if the certificate Secret is deleted,
+// and copied into the certificate Secret as soon as cert-manager issues them.
+func (r *ChiaCertificatesReconciler) findCertificatesForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	var list k8schianetv1.ChiaCertificatesList
+	if err := r.List(ctx, &list, client.InNamespace(secret.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list ChiaCertificates for Secret", "Secret", secret.GetName())
+		return nil
+	}
+
+	// Secrets cert-manager stores certificates in are labeled with the ChiaCertificates that created them
+	labels := secret.GetLabels()
+	issuedFor := ""
+	if labels["k8s.chia.net/kind"] == string(consts.ChiaCertificatesKind) {
+		issuedFor = labels["app.kubernetes.io/instance"]
+	}
+
+	var requests []reconcile.Request
+	for _, cr := range list.Items {
+		if cr.Spec.CASecretName == secret.GetName() || getChiaCertificatesSecretName(cr) == secret.GetName() || cr.Name == issuedFor {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: cr.Namespace,
+					Name:      cr.Name,
+				},
+			})
+		}
+	}
+	return requests
+}
+
+// restartWorkloads triggers a rolling restart of the Deployments, StatefulSets, and DaemonSets in the ChiaCertificates' namespace that
+// mount the certificate Secret, and haven't been restarted since the certificates were re-issued
+func (r *ChiaCertificatesReconciler) restartWorkloads(ctx context.Context, cr k8schianetv1.ChiaCertificates, secretName string, rotatedAt time.Time) error {
+	restartedAt := rotatedAt.UTC().Format(time.RFC3339)
+
+	var deployments appsv1.DeploymentList
+	if err := r.List(ctx, &deployments, client.InNamespace(cr.Namespace)); err != nil {
+		return fmt.Errorf("error listing Deployments: %w", err)
+	}
+	for i := range deployments.Items {
+		deploy := &deployments.Items[i]
+		if shouldRestartWorkload(deploy.ObjectMeta, deploy.Spec.Template, secretName, rotatedAt) {
+			if err := r.restartWorkload(ctx, cr, "Deployment", deploy, &deploy.Spec.Template, restartedAt); err != nil {
+				return err
+			}
+		}
+	}
+
+	var statefulSets appsv1.StatefulSetList
+	if err := r.List(ctx, &statefulSets, client.InNamespace(cr.Namespace)); err != nil {
+		return fmt.Errorf("error listing StatefulSets: %w", err)
+	}
+	for i := range statefulSets.Items {
+		stateful := &statefulSets.Items[i]
+		if shouldRestartWorkload(stateful.ObjectMeta, stateful.Spec.Template, secretName, rotatedAt) {
+			if err := r.restartWorkload(ctx, cr, "StatefulSet", stateful, &stateful.Spec.Template, restartedAt); err != nil {
+				return err
+			}
+		}
+	}
+
+	var daemonSets appsv1.DaemonSetList
+	if err := r.List(ctx, &daemonSets, client.InNamespace(cr.Namespace)); err != nil {
+		return fmt.Errorf("error listing DaemonSets: %w", err)
+	}
+	for i := range daemonSets.Items {
+		daemonSet := &daemonSets.Items[i]
+		if shouldRestartWorkload(daemonSet.ObjectMeta, daemonSet.Spec.Template, secretName, rotatedAt) {
+			if err := r.restartWorkload(ctx, cr, "DaemonSet", daemonSet, &daemonSet.Spec.Template, restartedAt); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// restartWorkload sets the restartedAt annotation on a workload's Pod template, which rolls out new Pods the same way `kubectl rollout restart` does
+func (r *ChiaCertificatesReconciler) restartWorkload(ctx context.Context, cr k8schianetv1.ChiaCertificates, kind string, obj client.Object, template *corev1.PodTemplateSpec, restartedAt string) error {
+	original := obj.DeepCopyObject().(client.Object)
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[restartedAtAnnotation] = restartedAt
+	if err := r.Patch(ctx, obj, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("error restarting %s \"%s\": %w", kind, obj.GetName(), err)
+	}
+
+	log.FromContext(ctx).Info("Restarted workload to load re-issued certificates", "kind", kind, "name", obj.GetName())
+	r.Recorder.Eventf(&cr, obj, corev1.EventTypeNormal, "Restarted", "Restarted",
+		"Restarted %s %s to load re-issued certificates", kind, obj.GetName())
+	return nil
+}
@@ -6,17 +6,30 @@ package chiacertificates
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 
 	"github.com/chia-network/go-chia-libs/pkg/tls"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
+const (
+	// rotatedAtAnnotation is set on a certificate Secret to the time its certificates were last re-issued
+	rotatedAtAnnotation = "k8s.chia.net/certificates.rotated-at"
+
+	// restartedAtAnnotation is set on the Pod template of workloads that mount a certificate Secret to trigger a rolling restart when the certificates are re-issued
+	restartedAtAnnotation = "k8s.chia.net/certificates.restarted-at"
+)
+
 // getSecret fetches the k8s Secret that matches this ChiaCertificates deployment. Returns true if the Secret exists.
 func (r *ChiaCertificatesReconciler) getSecret(ctx context.Context, namespace, name string) (corev1.Secret, bool, error) {
 	var secret corev1.Secret
@@ -84,3 +97,139 @@ func constructCertMap(allCerts *tls.ChiaCertificates) (map[string]string, error)
 
 	return certMap, nil
 }
+
+// getRenewBefore returns the amount of time before a certificate expires that it should be re-issued
+func getRenewBefore(cr k8schianetv1.ChiaCertificates) time.Duration {
+	if cr.Spec.RenewBefore != nil {
+		return cr.Spec.RenewBefore.Duration
+	}
+	return consts.DefaultCertificateRenewBefore
+}
+
+// restartWorkloadsEnabled returns true if workloads that mount the certificate Secret should be restarted when the certificates are re-issued (defaults to true)
+func restartWorkloadsEnabled(cr k8schianetv1.ChiaCertificates) bool {
+	if cr.Spec.RestartWorkloads == nil {
+		return true
+	}
+	return *cr.Spec.RestartWorkloads
+}
+
+// generateCertMap generates all the chia certificates from a private CA
+func generateCertMap(caCert *x509.Certificate, caKeyPEM []byte) (map[string]string, error) {
+	caKey, err := tls.ParsePemKey(caKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private CA key from Secret: %w", err)
+	}
+
+	allCerts, err := tls.GenerateAllCerts(caCert, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("error generating new certificates: %w", err)
+	}
+
+	certMap, err := constructCertMap(allCerts)
+	if err != nil {
+		return nil, fmt.Errorf("error converting certificates to map: %w", err)
+	}
+	return certMap, nil
+}
+
+// getCertificateStatuses parses every certificate in a certificate Secret's data, and returns their expiry times sorted by name
+func getCertificateStatuses(data map[string][]byte) ([]k8schianetv1.ChiaCertificateStatus, error) {
+	var statuses []k8schianetv1.ChiaCertificateStatus
+	for filenameBase := range certNodes {
+		certPEM, ok := data[filenameBase+".crt"]
+		if !ok {
+			return nil, fmt.Errorf("certificate %s not present in Secret", filenameBase)
+		}
+		if _, ok := data[filenameBase+".key"]; !ok {
+			return nil, fmt.Errorf("key %s not present in Secret", filenameBase)
+		}
+		cert, err := tls.ParsePemCertificate(certPEM)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing certificate %s: %w", filenameBase, err)
+		}
+		statuses = append(statuses, k8schianetv1.ChiaCertificateStatus{
+			Name:     filenameBase,
+			NotAfter: metav1.NewTime(cert.NotAfter),
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses, nil
+}
+
+// getEarliestNotAfter returns the earliest expiry time of the given certificates, or nil if there are none
+func getEarliestNotAfter(statuses []k8schianetv1.ChiaCertificateStatus) *metav1.Time {
+	var earliest *metav1.Time
+	for i := range statuses {
+		if earliest == nil || statuses[i].NotAfter.Before(earliest) {
+			earliest = &statuses[i].NotAfter
+		}
+	}
+	if earliest == nil {
+		return nil
+	}
+	notAfter := *earliest
+	return &notAfter
+}
+
+// getRenewalReason returns the reason the certificates in a certificate Secret need to be re-issued, or an empty string if they don't.
+// Certificates are re-issued if any of them are missing or can't be parsed, are within the renewal window, or the private certificates
+// weren't signed by the current private CA.
+func getRenewalReason(data map[string][]byte, caCert *x509.Certificate, renewBefore time.Duration, now time.Time) string {
+	statuses, err := getCertificateStatuses(data)
+	if err != nil {
+		return err.Error()
+	}
+
+	if notAfter := getEarliestNotAfter(statuses); notAfter != nil && !now.Before(notAfter.Add(-renewBefore)) {
+		return fmt.Sprintf("certificates expire at %s, which is within the renewal window", notAfter.UTC().Format(time.RFC3339))
+	}
+
+	for filenameBase := range certNodes {
+		if !strings.HasPrefix(filenameBase, "private_") {
+			continue
+		}
+		// Parse errors were already checked above
+		cert, _ := tls.ParsePemCertificate(data[filenameBase+".crt"])
+		if err := cert.CheckSignatureFrom(caCert); err != nil {
+			return fmt.Sprintf("certificate %s was not signed by the current private CA", filenameBase)
+		}
+	}
+
+	return ""
+}
+
+// podSpecMountsSecret returns true if any of a Pod spec's volumes contain the given Secret
+func podSpecMountsSecret(spec corev1.PodSpec, secretName string) bool {
+	for _, vol := range spec.Volumes {
+		if vol.Secret != nil && vol.Secret.SecretName == secretName {
+			return true
+		}
+		if vol.Projected != nil {
+			for _, source := range vol.Projected.Sources {
+				if source.Secret != nil && source.Secret.Name == secretName {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// shouldRestartWorkload returns true if a workload's Pod template mounts the certificate Secret and hasn't been restarted since the
+// certificates were re-issued. Workloads created after the certificates were re-issued already mount the new certificates.
+func shouldRestartWorkload(meta metav1.ObjectMeta, template corev1.PodTemplateSpec, secretName string, rotatedAt time.Time) bool {
+	if !podSpecMountsSecret(template.Spec, secretName) {
+		return false
+	}
+	if !meta.CreationTimestamp.Time.Before(rotatedAt) {
+		return false
+	}
+	restartedAt, err := time.Parse(time.RFC3339, template.Annotations[restartedAtAnnotation])
+	if err != nil {
+		return true
+	}
+	return restartedAt.Before(rotatedAt)
+}
@@ -5,11 +5,15 @@ Copyright 2025 Chia Network Inc.
 package chiacertificates
 
 import (
+	"crypto/x509"
 	"testing"
+	"time"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
 	"github.com/chia-network/go-chia-libs/pkg/tls"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -104,3 +108,106 @@ func TestConstructCertMap_NilCertificate(t *testing.T) {
 	assert.Nil(t, certMap)
 	assert.Contains(t, err.Error(), "key pair nil")
 }
+
+// newTestCA generates a new private CA, and returns its certificate and PEM encoded key
+func newTestCA(t *testing.T) (*x509.Certificate, []byte) {
+	caDER, caKey, err := tls.GenerateNewCA()
+	require.NoError(t, err)
+	_, caKeyPEM, err := tls.EncodeCertAndKeyToPEM(caDER, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	return caCert, caKeyPEM
+}
+
+func TestGetRenewalReason(t *testing.T) {
+	caCert, caKeyPEM := newTestCA(t)
+	certMap, err := generateCertMap(caCert, caKeyPEM)
+	require.NoError(t, err)
+	data := stringDataToData(certMap)
+
+	statuses, err := getCertificateStatuses(data)
+	require.NoError(t, err)
+	require.Len(t, statuses, len(certNodes))
+	require.Equal(t, "private_crawler", statuses[0].Name)
+	notAfter := getEarliestNotAfter(statuses)
+	require.NotNil(t, notAfter)
+
+	// Freshly issued certificates don't need to be renewed
+	assert.Empty(t, getRenewalReason(data, caCert, 30*24*time.Hour, time.Now()))
+
+	// Certificates within the renewal window are renewed
+	assert.Contains(t, getRenewalReason(data, caCert, 30*24*time.Hour, notAfter.Add(-24*time.Hour)), "within the renewal window")
+
+	// Certificates from a different CA are renewed
+	otherCACert, _ := newTestCA(t)
+	assert.Contains(t, getRenewalReason(data, otherCACert, 30*24*time.Hour, time.Now()), "was not signed by the current private CA")
+
+	// Missing certificates are renewed
+	delete(data, "private_wallet.key")
+	assert.Contains(t, getRenewalReason(data, caCert, 30*24*time.Hour, time.Now()), "private_wallet not present")
+}
+
+func TestShouldRestartWorkload(t *testing.T) {
+	rotatedAt := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	meta := metav1.ObjectMeta{
+		CreationTimestamp: metav1.NewTime(rotatedAt.Add(-time.Hour)),
+	}
+	template := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{
+					Name: "certs",
+					VolumeSource: corev1.VolumeSource{
+						Projected: &corev1.ProjectedVolumeSource{
+							Sources: []corev1.VolumeProjection{
+								{
+									Secret: &corev1.SecretProjection{
+										LocalObjectReference: corev1.LocalObjectReference{Name: "certs"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	assert.True(t, shouldRestartWorkload(meta, template, "certs", rotatedAt))
+	assert.False(t, shouldRestartWorkload(meta, template, "other-secret", rotatedAt))
+
+	// Workloads created after the rotation already use the new certificates
+	created := meta
+	created.CreationTimestamp = metav1.NewTime(rotatedAt)
+	assert.False(t, shouldRestartWorkload(created, template, "certs", rotatedAt))
+
+	// Workloads already restarted for this rotation aren't restarted again
+	template.Annotations = map[string]string{restartedAtAnnotation: rotatedAt.Format(time.RFC3339)}
+	assert.False(t, shouldRestartWorkload(meta, template, "certs", rotatedAt))
+
+	// Workloads restarted for a previous rotation are restarted
+	template.Annotations[restartedAtAnnotation] = rotatedAt.Add(-24 * time.Hour).Format(time.RFC3339)
+	assert.True(t, shouldRestartWorkload(meta, template, "certs", rotatedAt))
+}
+
+func TestCertDataMatches(t *testing.T) {
+	certMap := map[string]string{
+		"private_full_node.crt": "cert",
+		"private_full_node.key": "key",
+	}
+	assert.True(t, certDataMatches(stringDataToData(certMap), certMap))
+	assert.False(t, certDataMatches(map[string][]byte{"private_full_node.crt": []byte("cert")}, certMap))
+	assert.False(t, certDataMatches(map[string][]byte{"private_full_node.crt": []byte("cert"), "private_full_node.key": []byte("old")}, certMap))
+}
+
+func TestIssuedFrom(t *testing.T) {
+	caCert, caKeyPEM := newTestCA(t)
+	otherCACert, _ := newTestCA(t)
+	certMap, err := generateCertMap(caCert, caKeyPEM)
+	require.NoError(t, err)
+
+	assert.True(t, issuedFrom([]byte(certMap["private_full_node.crt"]), caCert))
+	assert.False(t, issuedFrom([]byte(certMap["private_full_node.crt"]), otherCACert))
+	assert.Equal(t, "my-certs-private-full-node", getCertManagerCertificateName(k8schianetv1.ChiaCertificates{ObjectMeta: metav1.ObjectMeta{Name: "my-certs"}}, "private_full_node"))
+}
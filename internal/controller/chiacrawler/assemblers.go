@@ -11,6 +11,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -249,6 +250,18 @@ func assembleVolumeClaim(crawler k8schianetv1.ChiaCrawler) (*corev1.PersistentVo
 	return &pvc, nil
 }
 
+// assemblePodDisruptionBudget assembles the PodDisruptionBudget resource for a ChiaCrawler CR
+func assemblePodDisruptionBudget(crawler k8schianetv1.ChiaCrawler) policyv1.PodDisruptionBudget {
+	return kube.AssemblePodDisruptionBudget(kube.AssemblePodDisruptionBudgetInputs{
+		Name:           fmt.Sprintf(chiacrawlerNamePattern, crawler.Name),
+		Namespace:      crawler.Namespace,
+		Labels:         kube.GetCommonLabels(crawler.Kind, crawler.ObjectMeta, crawler.Spec.Labels),
+		Annotations:    crawler.Spec.Annotations,
+		SelectorLabels: kube.GetCommonLabels(crawler.Kind, crawler.ObjectMeta),
+		Config:         crawler.Spec.PodDisruptionBudget,
+	})
+}
+
 // assembleDeployment assembles the crawler Deployment resource for a ChiaCrawler CR
 func assembleDeployment(crawler k8schianetv1.ChiaCrawler, fullNodePort int32, networkData *map[string]string) (appsv1.Deployment, error) {
 	var deploy = appsv1.Deployment{
@@ -7,23 +7,23 @@ package chiacrawler
 import (
 	"context"
 	"fmt"
-	"strings"
-	"time"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/component"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/finalizer"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
-	"github.com/chia-network/chia-operator/internal/metrics"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
-	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -32,170 +32,88 @@ type ChiaCrawlerReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder events.EventRecorder
-}
 
-var chiacrawlers = make(map[string]bool)
+	// MaxConcurrentReconciles is the maximum number of ChiaCrawlers that can be reconciled at the same time, which defaults to 1
+	MaxConcurrentReconciles int
+}
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiacrawlers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiacrawlers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiacrawlers/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch
-//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch;update
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is invoked on any event to a controlled Kubernetes resource
 func (r *ChiaCrawlerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := log.FromContext(ctx)
-	log.Info("Running reconciler...")
-
-	// Get the custom resource
 	var crawler k8schianetv1.ChiaCrawler
-	err := r.Get(ctx, req.NamespacedName, &crawler)
-	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chiacrawlers[req.String()]
-		if exists {
-			delete(chiacrawlers, req.String())
-			metrics.ChiaCrawlers.Sub(1.0)
-		}
-		return ctrl.Result{}, nil
-	}
-	if err != nil {
-		log.Error(err, fmt.Sprintf("ChiaCrawlerReconciler ChiaCrawler=%s unable to fetch ChiaCrawler resource", req.NamespacedName))
-		return ctrl.Result{}, client.IgnoreNotFound(err)
-	}
-
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chiacrawlers[req.String()]
-	if !exists {
-		chiacrawlers[req.String()] = true
-		metrics.ChiaCrawlers.Add(1.0)
-	}
-
-	// Check for ChiaNetwork, retrieve matching ConfigMap if specified
-	networkData, err := kube.GetChiaNetworkData(ctx, r.Client, crawler.Spec.ChiaConfig.CommonSpecChia, crawler.Namespace)
-	if err != nil {
-		return ctrl.Result{}, err
-	}
-
-	// Get the full_node Port and handle the error one time instead of in every function that needs it
-	fullNodePort, err := kube.GetFullNodePort(crawler.Spec.ChiaConfig.CommonSpecChia, networkData)
-	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("encountered error retrieving the full_node Port to use: %v", err)
-	}
-
-	// Assemble Peer Service
-	peerSrv := assemblePeerService(crawler, fullNodePort)
-	if err := controllerutil.SetControllerReference(&crawler, &peerSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&crawler, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble crawler peer Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s encountered error assembling peer Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile Peer Service
-	res, err := kube.ReconcileService(ctx, r.Client, crawler.Spec.ChiaConfig.PeerService, peerSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s %v", req.NamespacedName, err)
-	}
-
-	// Assemble All Service
-	allSrv := assembleAllService(crawler, fullNodePort)
-	if err := controllerutil.SetControllerReference(&crawler, &allSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&crawler, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble crawler all-port Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s encountered error assembling all-port Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile All Service
-	res, err = kube.ReconcileService(ctx, r.Client, crawler.Spec.ChiaConfig.AllService, allSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s %v", req.NamespacedName, err)
-	}
-
-	// Assemble Daemon Service
-	daemonSrv := assembleDaemonService(crawler)
-	if err := controllerutil.SetControllerReference(&crawler, &daemonSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&crawler, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble crawler daemon Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s encountered error assembling daemon Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile Daemon Service
-	res, err = kube.ReconcileService(ctx, r.Client, crawler.Spec.ChiaConfig.DaemonService, daemonSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s %v", req.NamespacedName, err)
-	}
-
-	// Assemble RPC Service
-	rpcSrv := assembleRPCService(crawler)
-	if err := controllerutil.SetControllerReference(&crawler, &rpcSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&crawler, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble crawler RPC Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s encountered error assembling RPC Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile RPC Service
-	res, err = kube.ReconcileService(ctx, r.Client, crawler.Spec.ChiaConfig.RPCService, rpcSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s %v", req.NamespacedName, err)
-	}
-
-	// Assemble Chia-Exporter Service
-	exporterSrv := assembleChiaExporterService(crawler)
-	if err := controllerutil.SetControllerReference(&crawler, &exporterSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&crawler, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble crawler chia-exporter Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s encountered error assembling chia-exporter Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile Chia-Exporter Service
-	res, err = kube.ReconcileService(ctx, r.Client, crawler.Spec.ChiaExporterConfig.Service, exporterSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s %v", req.NamespacedName, err)
-	}
-
-	// Creates a persistent volume claim if the GenerateVolumeClaims setting was set to true
-	if kube.ShouldMakeChiaRootVolumeClaim(crawler.Spec.Storage) {
-		pvc, err := assembleVolumeClaim(crawler)
-		if err != nil {
-			r.Recorder.Eventf(&crawler, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble crawler PVC -- Check operator logs.")
-			return reconcile.Result{}, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s %v", req.NamespacedName, err)
-		}
-
-		if pvc != nil {
-			res, err = kube.ReconcilePersistentVolumeClaim(ctx, r.Client, crawler.Spec.Storage, *pvc)
+	engine := component.Engine{
+		Client:   r.Client,
+		Scheme:   r.Scheme,
+		Recorder: r.Recorder,
+		Kind:     consts.ChiaCrawlerKind,
+		Name:     "crawler",
+	}
+	return engine.Reconcile(ctx, req, &crawler, component.Hooks{
+		Describe: func() component.Resource {
+			return component.Resource{
+				Meta:    crawler.ObjectMeta,
+				Chia:    crawler.Spec.ChiaConfig.CommonSpecChia,
+				Suspend: crawler.Spec.Suspend,
+				Status: component.Status{
+					Conditions:         &crawler.Status.Conditions,
+					ObservedGeneration: &crawler.Status.ObservedGeneration,
+					Ready:              &crawler.Status.Ready,
+				},
+				Workload: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf(chiacrawlerNamePattern, crawler.Name), Namespace: crawler.Namespace}},
+			}
+		},
+		FinalizerInputs: func(ctx context.Context) (finalizer.Inputs, error) {
+			return finalizer.Inputs{
+				DeletionPolicy:   crawler.Spec.DeletionPolicy,
+				VolumeClaimNames: getGeneratedVolumeClaimNames(crawler),
+			}, nil
+		},
+		Assemble: func(ctx context.Context, c *component.Reconciler, networkData *map[string]string) (component.Component, error) {
+			fullNodePort, err := kube.GetFullNodePort(crawler.Spec.ChiaConfig.CommonSpecChia, networkData)
 			if err != nil {
-				r.Recorder.Eventf(&crawler, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to create crawler PVC -- Check operator logs.")
-				return res, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s %v", req.NamespacedName, err)
+				return component.Component{}, fmt.Errorf("encountered error retrieving the full_node Port to use: %w", err)
+			}
+			deploy, err := assembleDeployment(crawler, fullNodePort, networkData)
+			if err != nil {
+				c.AssembleFailed("Deployment")
+				return component.Component{}, err
 			}
-		} else {
-			return reconcile.Result{}, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s PVC could not be created", req.NamespacedName)
-		}
-	}
-
-	// Assemble Deployment
-	deploy, err := assembleDeployment(crawler, fullNodePort, networkData)
-	if err != nil {
-		r.Recorder.Eventf(&crawler, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble crawler Deployment -- Check operator logs.")
-		return reconcile.Result{}, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s %v", req.NamespacedName, err)
-	}
-	if err := controllerutil.SetControllerReference(&crawler, &deploy, r.Scheme); err != nil {
-		r.Recorder.Eventf(&crawler, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble crawler Deployment -- Check operator logs.")
-		return reconcile.Result{}, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s %v", req.NamespacedName, err)
-	}
-	// Reconcile Deployment
-	res, err = kube.ReconcileDeployment(ctx, r.Client, deploy)
-	if err != nil {
-		r.Recorder.Eventf(&crawler, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to create crawler Deployment -- Check operator logs.")
-		return res, fmt.Errorf("ChiaCrawlerReconciler ChiaCrawler=%s %v", req.NamespacedName, err)
-	}
-
-	// Update CR status
-	r.Recorder.Eventf(&crawler, nil, corev1.EventTypeNormal, "Created", "Created", "Successfully created ChiaCrawler resources.")
-	crawler.Status.Ready = true
-	err = r.Status().Update(ctx, &crawler)
-	if err != nil {
-		if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
-			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
-		}
-		log.Error(err, fmt.Sprintf("ChiaCrawlerReconciler ChiaCrawler=%s unable to update ChiaCrawler status", req.NamespacedName))
-		return ctrl.Result{}, err
-	}
 
-	return ctrl.Result{}, nil
+			services := []component.Service{
+				{Description: "peer", Desired: assemblePeerService(crawler, fullNodePort), Config: crawler.Spec.ChiaConfig.PeerService, DefaultEnabled: true},
+				{Description: "all-port", Desired: assembleAllService(crawler, fullNodePort), Config: crawler.Spec.ChiaConfig.AllService, DefaultEnabled: true},
+				{Description: "daemon", Desired: assembleDaemonService(crawler), Config: crawler.Spec.ChiaConfig.DaemonService, DefaultEnabled: true},
+				{Description: "RPC", Desired: assembleRPCService(crawler), Config: crawler.Spec.ChiaConfig.RPCService, DefaultEnabled: true},
+				{Description: "chia-exporter", Desired: assembleChiaExporterService(crawler), Config: crawler.Spec.ChiaExporterConfig.Service, DefaultEnabled: true},
+			}
+			return component.Component{
+				Services: services,
+				VolumeClaims: []component.VolumeClaim{{
+					Description: "CHIA_ROOT",
+					Enabled:     kube.ShouldMakeChiaRootVolumeClaim(crawler.Spec.Storage),
+					Storage:     crawler.Spec.Storage,
+					Assemble:    func() (*corev1.PersistentVolumeClaim, error) { return assembleVolumeClaim(crawler) },
+				}},
+				Workload: &deploy,
+				PodDisruptionBudget: component.PodDisruptionBudget{
+					Desired: assemblePodDisruptionBudget(crawler),
+					Config:  crawler.Spec.PodDisruptionBudget,
+				},
+			}, nil
+		},
+	})
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -204,10 +122,12 @@ func (r *ChiaCrawlerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&k8schianetv1.ChiaCrawler{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
 		Watches(
 			&corev1.ConfigMap{},
 			handler.EnqueueRequestsFromMapFunc(r.handleChiaNetworks),
 		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
@@ -92,3 +92,11 @@ func getChiaEnv(crawler k8schianetv1.ChiaCrawler, networkData *map[string]string
 
 	return env, nil
 }
+
+// getGeneratedVolumeClaimNames returns the names of the PersistentVolumeClaims the operator generates for a ChiaCrawler
+func getGeneratedVolumeClaimNames(crawler k8schianetv1.ChiaCrawler) []string {
+	if kube.ShouldMakeChiaRootVolumeClaim(crawler.Spec.Storage) {
+		return []string{fmt.Sprintf(chiacrawlerNamePattern, crawler.Name)}
+	}
+	return nil
+}
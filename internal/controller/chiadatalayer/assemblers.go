@@ -10,6 +10,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -204,6 +205,18 @@ func assembleDataLayerFilesVolumeClaim(datalayer k8schianetv1.ChiaDataLayer) (*c
 	return &pvc, nil
 }
 
+// assemblePodDisruptionBudget assembles the PodDisruptionBudget resource for a ChiaDataLayer CR
+func assemblePodDisruptionBudget(datalayer k8schianetv1.ChiaDataLayer) policyv1.PodDisruptionBudget {
+	return kube.AssemblePodDisruptionBudget(kube.AssemblePodDisruptionBudgetInputs{
+		Name:           fmt.Sprintf(chiadatalayerNamePattern, datalayer.Name),
+		Namespace:      datalayer.Namespace,
+		Labels:         kube.GetCommonLabels(datalayer.Kind, datalayer.ObjectMeta, datalayer.Spec.Labels),
+		Annotations:    datalayer.Spec.Annotations,
+		SelectorLabels: kube.GetCommonLabels(datalayer.Kind, datalayer.ObjectMeta),
+		Config:         datalayer.Spec.PodDisruptionBudget,
+	})
+}
+
 // assembleDeployment assembles the datalayer Deployment resource for a ChiaDataLayer CR
 func assembleDeployment(ctx context.Context, datalayer k8schianetv1.ChiaDataLayer, networkData *map[string]string) (appsv1.Deployment, error) {
 	var deploy = appsv1.Deployment{
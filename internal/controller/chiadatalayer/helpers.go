@@ -233,3 +233,15 @@ func getExistingChiaDatalayerServerVolume(storage *k8schianetv1.StorageConfig) c
 		},
 	}
 }
+
+// getGeneratedVolumeClaimNames returns the names of the PersistentVolumeClaims the operator generates for a ChiaDataLayer
+func getGeneratedVolumeClaimNames(datalayer k8schianetv1.ChiaDataLayer) []string {
+	var names []string
+	if kube.ShouldMakeChiaRootVolumeClaim(datalayer.Spec.Storage) {
+		names = append(names, fmt.Sprintf(chiadatalayerNamePattern, datalayer.Name))
+	}
+	if kube.ShouldMakeDataLayerServerFilesVolumeClaim(datalayer.Spec.Storage) {
+		names = append(names, fmt.Sprintf(chiadatalayerNamePattern, datalayer.Name)+"-server")
+	}
+	return names
+}
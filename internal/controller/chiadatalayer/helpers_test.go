@@ -412,3 +412,20 @@ func TestGetExistingChiaDatalayerServerVolume(t *testing.T) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestGetGeneratedVolumeClaimNames(t *testing.T) {
+	datalayer := k8schianetv1.ChiaDataLayer{
+		ObjectMeta: metav1.ObjectMeta{Name: "testname"},
+	}
+	assert.Empty(t, getGeneratedVolumeClaimNames(datalayer))
+
+	datalayer.Spec.Storage = &k8schianetv1.StorageConfig{
+		ChiaRoot: &k8schianetv1.ChiaRootConfig{
+			PersistentVolumeClaim: &k8schianetv1.PersistentVolumeClaimConfig{GenerateVolumeClaims: true},
+		},
+		DataLayerServerFiles: &k8schianetv1.DataLayerServerFilesConfig{
+			PersistentVolumeClaim: &k8schianetv1.PersistentVolumeClaimConfig{GenerateVolumeClaims: true},
+		},
+	}
+	assert.Equal(t, []string{"testname-datalayer", "testname-datalayer-server"}, getGeneratedVolumeClaimNames(datalayer))
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiafarm
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+const (
+	// chiafarmCASecretNamePattern is the name of the CA Secret of the ChiaCA a farm creates
+	chiafarmCASecretNamePattern = "%s-ca"
+
+	// chiafarmHarvesterNamePattern is the name of a farm's ChiaHarvester, from the farm's name and the harvester's name in the farm
+	chiafarmHarvesterNamePattern = "%s-%s"
+
+	// nodeServiceAddressPattern is the DNS name of a ChiaNode's peer Service, from the ChiaNode's name and namespace
+	nodeServiceAddressPattern = "%s-node.%s.svc.cluster.local"
+
+	// farmerServiceAddressPattern is the DNS name of a ChiaFarmer's peer Service, from the ChiaFarmer's name and namespace
+	farmerServiceAddressPattern = "%s-farmer.%s.svc.cluster.local"
+)
+
+// assembleChiaCA assembles the ChiaCA for a ChiaFarm CR
+func assembleChiaCA(farm k8schianetv1.ChiaFarm, inheritedLabels []string) k8schianetv1.ChiaCA {
+	ca := k8schianetv1.ChiaCA{
+		ObjectMeta: getChildObjectMeta(farm, farm.Name, inheritedLabels),
+	}
+	if farm.Spec.CA != nil {
+		ca.Spec = *farm.Spec.CA.DeepCopy()
+	}
+	ca.Spec.Secret = getCASecretName(farm)
+	return ca
+}
+
+// assembleChiaNode assembles the ChiaNode for a ChiaFarm CR
+func assembleChiaNode(farm k8schianetv1.ChiaFarm, caSecretName string, inheritedLabels []string) k8schianetv1.ChiaNode {
+	spec := farm.Spec.Node.DeepCopy()
+	return k8schianetv1.ChiaNode{
+		ObjectMeta: getChildObjectMeta(farm, farm.Name, inheritedLabels),
+		Spec: k8schianetv1.ChiaNodeSpec{
+			CommonSpec: getCommonSpec(spec.ChiaFarmComponentSpec),
+			ChiaConfig: k8schianetv1.ChiaNodeSpecChia{
+				CommonSpecChia: getChiaConfig(farm.Spec.ChiaConfig, spec.ChiaFarmComponentSpec),
+				CASecretName:   caSecretName,
+			},
+			ChiaHealthcheckConfig: spec.ChiaHealthcheckConfig,
+			ChiaDBPullConfig:      spec.ChiaDBPullConfig,
+			Replicas:              spec.Replicas,
+		},
+	}
+}
+
+// assembleChiaFarmer assembles the ChiaFarmer for a ChiaFarm CR, connected to the farm's full_node
+func assembleChiaFarmer(farm k8schianetv1.ChiaFarm, caSecretName string, nodePeer k8schianetv1.Peer, inheritedLabels []string) k8schianetv1.ChiaFarmer {
+	spec := farm.Spec.Farmer.DeepCopy()
+	peers := []k8schianetv1.Peer{nodePeer}
+	return k8schianetv1.ChiaFarmer{
+		ObjectMeta: getChildObjectMeta(farm, farm.Name, inheritedLabels),
+		Spec: k8schianetv1.ChiaFarmerSpec{
+			CommonSpec: getCommonSpec(spec.ChiaFarmComponentSpec),
+			ChiaConfig: k8schianetv1.ChiaFarmerSpecChia{
+				CommonSpecChia: getChiaConfig(farm.Spec.ChiaConfig, spec.ChiaFarmComponentSpec),
+				CASecretName:   caSecretName,
+				SecretKey:      farm.Spec.ChiaConfig.SecretKey,
+				FullNodePeers:  &peers,
+			},
+			ChiaHealthcheckConfig: spec.ChiaHealthcheckConfig,
+		},
+	}
+}
+
+// assembleChiaWallet assembles the ChiaWallet for a ChiaFarm CR, connected to the farm's full_node
+func assembleChiaWallet(farm k8schianetv1.ChiaFarm, caSecretName string, nodePeer k8schianetv1.Peer, inheritedLabels []string) k8schianetv1.ChiaWallet {
+	spec := farm.Spec.Wallet.DeepCopy()
+	peers := []k8schianetv1.Peer{nodePeer}
+	return k8schianetv1.ChiaWallet{
+		ObjectMeta: getChildObjectMeta(farm, farm.Name, inheritedLabels),
+		Spec: k8schianetv1.ChiaWalletSpec{
+			CommonSpec: getCommonSpec(spec.ChiaFarmComponentSpec),
+			ChiaConfig: k8schianetv1.ChiaWalletSpecChia{
+				CommonSpecChia: getChiaConfig(farm.Spec.ChiaConfig, spec.ChiaFarmComponentSpec),
+				CASecretName:   &caSecretName,
+				SecretKey:      farm.Spec.ChiaConfig.SecretKey,
+				FullNodePeers:  &peers,
+			},
+		},
+	}
+}
+
+// assembleChiaHarvester assembles one of the ChiaHarvesters for a ChiaFarm CR, connected to the farm's farmer
+func assembleChiaHarvester(farm k8schianetv1.ChiaFarm, harvester k8schianetv1.ChiaFarmHarvesterSpec, caSecretName string, inheritedLabels []string) k8schianetv1.ChiaHarvester {
+	spec := harvester.DeepCopy()
+	return k8schianetv1.ChiaHarvester{
+		ObjectMeta: getChildObjectMeta(farm, getHarvesterName(farm, harvester), inheritedLabels),
+		Spec: k8schianetv1.ChiaHarvesterSpec{
+			CommonSpec: getCommonSpec(spec.ChiaFarmComponentSpec),
+			ChiaConfig: k8schianetv1.ChiaHarvesterSpecChia{
+				CommonSpecChia: getChiaConfig(farm.Spec.ChiaConfig, spec.ChiaFarmComponentSpec),
+				CASecretName:   caSecretName,
+				FarmerAddress:  fmt.Sprintf(farmerServiceAddressPattern, farm.Name, farm.Namespace),
+			},
+			ChiaHealthcheckConfig: spec.ChiaHealthcheckConfig,
+		},
+	}
+}
+
+// getChildObjectMeta returns the ObjectMeta for one of a ChiaFarm's child resources.
+// The children only inherit the ChiaFarm's labels with the inherited keys, so they match the same --label-selector as the farm,
+// without copying labels other tools own, like a GitOps tool's tracking label.
+func getChildObjectMeta(farm k8schianetv1.ChiaFarm, name string, inheritedLabels []string) metav1.ObjectMeta {
+	inherited := make(map[string]string, len(inheritedLabels))
+	for _, key := range inheritedLabels {
+		if value, ok := farm.Labels[key]; ok {
+			inherited[key] = value
+		}
+	}
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: farm.Namespace,
+		Labels:    kube.GetCommonLabels(string(consts.ChiaFarmKind), farm.ObjectMeta, inherited),
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiafarm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+var testFarm = k8schianetv1.ChiaFarm{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "mainnet",
+		Namespace: "chia",
+	},
+	Spec: k8schianetv1.ChiaFarmSpec{
+		ChiaConfig: k8schianetv1.ChiaFarmSpecChia{
+			SecretKey: k8schianetv1.ChiaSecretKey{
+				Name: "chiakey",
+				Key:  "key.txt",
+			},
+			Image:    stringPtr("ghcr.io/chia-network/chia:latest"),
+			Timezone: stringPtr("America/New_York"),
+		},
+		Node: k8schianetv1.ChiaFarmNodeSpec{
+			Replicas: 2,
+		},
+		Harvesters: []k8schianetv1.ChiaFarmHarvesterSpec{
+			{
+				Name: "rack-1",
+				ChiaFarmComponentSpec: k8schianetv1.ChiaFarmComponentSpec{
+					Image:        stringPtr("ghcr.io/chia-network/chia:2.5.0"),
+					NodeSelector: map[string]string{"kubernetes.io/hostname": "rack-1"},
+				},
+			},
+		},
+	},
+}
+
+func TestAssembleChiaCA(t *testing.T) {
+	ca := assembleChiaCA(testFarm, nil)
+	require.Equal(t, "mainnet", ca.Name)
+	require.Equal(t, "chia", ca.Namespace)
+	require.Equal(t, "mainnet-ca", ca.Spec.Secret)
+	require.Equal(t, "ChiaFarm", ca.Labels["k8s.chia.net/kind"])
+
+	farm := testFarm.DeepCopy()
+	farm.Spec.CA = &k8schianetv1.ChiaCASpec{Secret: "my-ca"}
+	require.Equal(t, "my-ca", assembleChiaCA(*farm, nil).Spec.Secret)
+
+	// Children only inherit the farm's labels with the inherited keys, and not over the operator's own labels
+	farm.Labels = map[string]string{
+		"k8s.chia.net/shard":          "mainnet",
+		"k8s.chia.net/kind":           "Other",
+		"argocd.argoproj.io/instance": "chia",
+	}
+	ca = assembleChiaCA(*farm, []string{"k8s.chia.net/kind", "k8s.chia.net/shard", "k8s.chia.net/tier"})
+	require.Equal(t, "mainnet", ca.Labels["k8s.chia.net/shard"])
+	require.Equal(t, "ChiaFarm", ca.Labels["k8s.chia.net/kind"])
+	require.NotContains(t, ca.Labels, "argocd.argoproj.io/instance")
+	require.NotContains(t, ca.Labels, "k8s.chia.net/tier")
+}
+
+func TestAssembleChiaNode(t *testing.T) {
+	node := assembleChiaNode(testFarm, "mainnet-ca", nil)
+	require.Equal(t, "mainnet", node.Name)
+	require.Equal(t, "mainnet-ca", node.Spec.ChiaConfig.CASecretName)
+	require.Equal(t, int32(2), node.Spec.Replicas)
+	require.Equal(t, testFarm.Spec.ChiaConfig.Image, node.Spec.ChiaConfig.Image)
+	require.Equal(t, "America/New_York", *node.Spec.ChiaConfig.Timezone)
+}
+
+func TestAssembleChiaFarmer(t *testing.T) {
+	peer := getNodePeer(testFarm, 8444)
+	farmer := assembleChiaFarmer(testFarm, "mainnet-ca", peer, nil)
+	require.Equal(t, "mainnet", farmer.Name)
+	require.Equal(t, "mainnet-ca", farmer.Spec.ChiaConfig.CASecretName)
+	require.Equal(t, testFarm.Spec.ChiaConfig.SecretKey, farmer.Spec.ChiaConfig.SecretKey)
+	require.NotNil(t, farmer.Spec.ChiaConfig.FullNodePeers)
+	require.Equal(t, []k8schianetv1.Peer{{Host: "mainnet-node.chia.svc.cluster.local", Port: 8444}}, *farmer.Spec.ChiaConfig.FullNodePeers)
+}
+
+func TestAssembleChiaWallet(t *testing.T) {
+	peer := getNodePeer(testFarm, 58444)
+	wallet := assembleChiaWallet(testFarm, "mainnet-ca", peer, nil)
+	require.Equal(t, "mainnet", wallet.Name)
+	require.Equal(t, "mainnet-ca", *wallet.Spec.ChiaConfig.CASecretName)
+	require.Equal(t, testFarm.Spec.ChiaConfig.SecretKey, wallet.Spec.ChiaConfig.SecretKey)
+	require.Equal(t, []k8schianetv1.Peer{{Host: "mainnet-node.chia.svc.cluster.local", Port: 58444}}, *wallet.Spec.ChiaConfig.FullNodePeers)
+}
+
+func TestAssembleChiaHarvester(t *testing.T) {
+	harvester := assembleChiaHarvester(testFarm, testFarm.Spec.Harvesters[0], "mainnet-ca", nil)
+	require.Equal(t, "mainnet-rack-1", harvester.Name)
+	require.Equal(t, "mainnet-ca", harvester.Spec.ChiaConfig.CASecretName)
+	require.Equal(t, "mainnet-farmer.chia.svc.cluster.local", harvester.Spec.ChiaConfig.FarmerAddress)
+
+	// The harvester's own settings take precedence over the farm's shared settings
+	require.Equal(t, "ghcr.io/chia-network/chia:2.5.0", *harvester.Spec.ChiaConfig.Image)
+	require.Equal(t, "America/New_York", *harvester.Spec.ChiaConfig.Timezone)
+	require.Equal(t, map[string]string{"kubernetes.io/hostname": "rack-1"}, harvester.Spec.NodeSelector)
+}
@@ -0,0 +1,349 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiafarm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+	"github.com/chia-network/chia-operator/internal/metrics"
+)
+
+// ChiaFarmReconciler reconciles a ChiaFarm object
+type ChiaFarmReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder events.EventRecorder
+
+	// MaxConcurrentReconciles is the maximum number of ChiaFarms that can be reconciled at the same time, which defaults to 1
+	MaxConcurrentReconciles int
+
+	// InheritedLabels are the keys of the labels a ChiaFarm's child resources copy from the ChiaFarm, which are the keys in the
+	// operator's --label-selector, so the children are reconciled by the same operator instance as their farm
+	InheritedLabels []string
+}
+
+// +kubebuilder:rbac:groups=k8s.chia.net,resources=chiafarms,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=k8s.chia.net,resources=chiafarms/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=k8s.chia.net,resources=chiafarms/finalizers,verbs=update
+// +kubebuilder:rbac:groups=k8s.chia.net,resources=chiacas;chianodes;chiafarmers;chiawallets;chiaharvesters,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch;update
+
+// Reconcile is invoked on any event to a controlled Kubernetes resource
+func (r *ChiaFarmReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	klog := log.FromContext(ctx)
+	klog.Info("Running reconciler...")
+
+	// Record how long this reconcile took, and why it failed if it returned an error
+	start := time.Now()
+	defer func() {
+		kube.ObserveReconcile(string(consts.ChiaFarmKind), start, reterr)
+	}()
+
+	// Get the custom resource
+	var farm k8schianetv1.ChiaFarm
+	err := r.Get(ctx, req.NamespacedName, &farm)
+	if err != nil && errors.IsNotFound(err) {
+		// Remove this object from the operator's metrics
+		metrics.Resources.Delete(string(consts.ChiaFarmKind), req.String())
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		klog.Error(err, "unable to fetch ChiaFarm resource")
+		return ctrl.Result{}, err
+	}
+
+	// Don't recreate the farm's child resources while it's being deleted, such as while they're deleted in the foreground first
+	if !farm.DeletionTimestamp.IsZero() {
+		metrics.Resources.Delete(string(consts.ChiaFarmKind), req.String())
+		return ctrl.Result{}, nil
+	}
+
+	// Add this object to the operator's metrics, if it wasn't already added
+	metrics.Resources.Add(string(consts.ChiaFarmKind), req.String())
+
+	// Record the ChiaFarm's Ready condition in the operator's metrics, after any reconciliation error was recorded in it
+	defer func() {
+		if farm.DeletionTimestamp.IsZero() {
+			metrics.Resources.SetReadyCondition(string(consts.ChiaFarmKind), req.String(), kube.GetReadyConditionStatus(farm.Status.Conditions))
+		}
+	}()
+
+	// Record any reconciliation error in the CR's status conditions
+	defer func() {
+		if reterr != nil {
+			kube.SetReconcileFailedConditions(&farm.Status.Conditions, farm.Generation, reterr)
+			farm.Status.Ready = false
+			if err := r.Status().Update(ctx, &farm); err != nil && !strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+				klog.Error(err, "encountered error updating ChiaFarm status conditions")
+			}
+		}
+	}()
+
+	// Stop applying changes while the ChiaFarm is suspended
+	suspended, err := kube.ReconcileSuspension(ctx, r.Client, farm.ObjectMeta, nil, &farm.Status.Conditions, nil)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("ChiaFarmReconciler ChiaFarm=%s %w", req.NamespacedName, err)
+	}
+	if suspended {
+		return kube.UpdateSuspendedStatus(ctx, r.Client, consts.ChiaFarmKind, &farm, farm.Status.Conditions, &farm.Status.ObservedGeneration, &farm.Status.Ready)
+	}
+
+	caSecretName := getCASecretName(farm)
+
+	// Reconcile the farm's ChiaCA, or remove it if the farm now uses an existing CA Secret
+	if shouldCreateCA(farm) {
+		ca := assembleChiaCA(farm, r.InheritedLabels)
+		if res, err := r.reconcileChild(ctx, &farm, consts.ChiaCAKind, &ca); err != nil || !res.IsZero() {
+			return res, err
+		}
+	} else {
+		if err := r.deleteChildIfExists(ctx, &farm, &k8schianetv1.ChiaCA{}, farm.Name); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Reconcile the farm's ChiaNode
+	node := assembleChiaNode(farm, caSecretName, r.InheritedLabels)
+	if res, err := r.reconcileChild(ctx, &farm, consts.ChiaNodeKind, &node); err != nil || !res.IsZero() {
+		return res, err
+	}
+
+	// The farmer and wallet connect to the full_node's peer port, which may be set by a ChiaNetwork
+	networkData, err := kube.GetChiaNetworkData(ctx, r.Client, node.Spec.ChiaConfig.CommonSpecChia, farm.Namespace)
+	if err != nil {
+		return ctrl.Result{}, kube.NewConditionError(k8schianetv1.ConditionTypeNetworkResolved, kube.ReasonChiaNetworkNotFound, err)
+	}
+	fullNodePort, err := kube.GetFullNodePort(node.Spec.ChiaConfig.CommonSpecChia, networkData)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("encountered error determining the farm's full_node port: %w", err)
+	}
+	nodePeer := getNodePeer(farm, fullNodePort)
+
+	// Reconcile the farm's ChiaFarmer
+	farmer := assembleChiaFarmer(farm, caSecretName, nodePeer, r.InheritedLabels)
+	if res, err := r.reconcileChild(ctx, &farm, consts.ChiaFarmerKind, &farmer); err != nil || !res.IsZero() {
+		return res, err
+	}
+
+	// Reconcile the farm's ChiaWallet, or remove it if the wallet was disabled
+	if walletEnabled(farm) {
+		wallet := assembleChiaWallet(farm, caSecretName, nodePeer, r.InheritedLabels)
+		if res, err := r.reconcileChild(ctx, &farm, consts.ChiaWalletKind, &wallet); err != nil || !res.IsZero() {
+			return res, err
+		}
+	} else {
+		if err := r.deleteChildIfExists(ctx, &farm, &k8schianetv1.ChiaWallet{}, farm.Name); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Reconcile the farm's ChiaHarvesters
+	desiredHarvesters := make(map[string]bool, len(farm.Spec.Harvesters))
+	for _, h := range farm.Spec.Harvesters {
+		harvester := assembleChiaHarvester(farm, h, caSecretName, r.InheritedLabels)
+		desiredHarvesters[harvester.Name] = true
+		if res, err := r.reconcileChild(ctx, &farm, consts.ChiaHarvesterKind, &harvester); err != nil || !res.IsZero() {
+			return res, err
+		}
+	}
+
+	// Remove ChiaHarvesters that were removed from the farm
+	var harvesters k8schianetv1.ChiaHarvesterList
+	if err := r.List(ctx, &harvesters, client.InNamespace(farm.Namespace), client.MatchingLabels(kube.GetCommonLabels(string(consts.ChiaFarmKind), farm.ObjectMeta))); err != nil {
+		return ctrl.Result{}, fmt.Errorf("encountered error listing the farm's ChiaHarvesters: %w", err)
+	}
+	for i := range harvesters.Items {
+		harvester := &harvesters.Items[i]
+		if desiredHarvesters[harvester.Name] || !metav1.IsControlledBy(harvester, &farm) {
+			continue
+		}
+		if err := r.Delete(ctx, harvester); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, fmt.Errorf("encountered error deleting ChiaHarvester %q: %w", harvester.Name, err)
+		}
+		r.Recorder.Eventf(&farm, harvester, corev1.EventTypeNormal, "Deleted", "Deleted",
+			"Deleted ChiaHarvester %s/%s that was removed from the farm", harvester.Namespace, harvester.Name)
+	}
+
+	// Aggregate the status of the farm's child resources
+	components, err := r.getComponentStatuses(ctx, farm)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	farm.Status.CASecretName = caSecretName
+	farm.Status.Components = components
+	farm.Status.ObservedGeneration = farm.Generation
+	if notReady := getNotReadyMessage(components); notReady != "" {
+		kube.SetValidConfigCondition(&farm.Status.Conditions, farm.Generation)
+		kube.SetCondition(&farm.Status.Conditions, farm.Generation, k8schianetv1.ConditionTypeReady, metav1.ConditionFalse, kube.ReasonComponentsNotReady, notReady)
+		kube.SetCondition(&farm.Status.Conditions, farm.Generation, k8schianetv1.ConditionTypeProgressing, metav1.ConditionTrue, kube.ReasonComponentsNotReady, notReady)
+		kube.SetCondition(&farm.Status.Conditions, farm.Generation, k8schianetv1.ConditionTypeDegraded, metav1.ConditionFalse, kube.ReasonReconciled, "The farm is not degraded")
+		farm.Status.Ready = false
+	} else {
+		if !farm.Status.Ready {
+			r.Recorder.Eventf(&farm, nil, corev1.EventTypeNormal, "Created", "Created",
+				"All components of the farm %s/%s are ready", farm.Namespace, farm.Name)
+		}
+		kube.SetResourceReadyConditions(&farm.Status.Conditions, farm.Generation, "All of the farm's components are ready")
+		farm.Status.Ready = true
+	}
+	kube.SetNetworkResolvedCondition(&farm.Status.Conditions, farm.Generation, node.Spec.ChiaConfig.CommonSpecChia)
+
+	err = r.Status().Update(ctx, &farm)
+	if err != nil {
+		if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+		klog.Error(err, "encountered error updating ChiaFarm status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileChild sets the farm as the controller of one of its child resources and applies it
+func (r *ChiaFarmReconciler) reconcileChild(ctx context.Context, farm *k8schianetv1.ChiaFarm, kind consts.ChiaKind, child client.Object) (ctrl.Result, error) {
+	if err := controllerutil.SetControllerReference(farm, child, r.Scheme); err != nil {
+		r.Recorder.Eventf(farm, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble farm %s -- Check operator logs.", kind)
+		return ctrl.Result{}, fmt.Errorf("encountered error setting controller reference on %s %q: %w", kind, child.GetName(), err)
+	}
+	res, err := kube.ReconcileChiaResource(ctx, r.Client, kind, child)
+	if err != nil {
+		r.Recorder.Eventf(farm, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to reconcile farm %s -- Check operator logs.", kind)
+		return res, fmt.Errorf("encountered error reconciling %s %q: %w", kind, child.GetName(), err)
+	}
+	return res, nil
+}
+
+// deleteChildIfExists deletes one of the farm's child resources if it exists and is controlled by the farm
+func (r *ChiaFarmReconciler) deleteChildIfExists(ctx context.Context, farm *k8schianetv1.ChiaFarm, child client.Object, name string) error {
+	err := r.Get(ctx, types.NamespacedName{Namespace: farm.Namespace, Name: name}, child)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("encountered error getting %T %q: %w", child, name, err)
+	}
+	if !metav1.IsControlledBy(child, farm) {
+		return nil
+	}
+	if err := r.Delete(ctx, child); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("encountered error deleting %T %q: %w", child, name, err)
+	}
+	return nil
+}
+
+// getComponentStatuses gets the status of each of the farm's child resources
+func (r *ChiaFarmReconciler) getComponentStatuses(ctx context.Context, farm k8schianetv1.ChiaFarm) ([]k8schianetv1.ChiaFarmComponentStatus, error) {
+	var components []k8schianetv1.ChiaFarmComponentStatus
+	// A child resource that doesn't exist yet is reported as not ready
+	get := func(name string, obj client.Object) error {
+		err := r.Get(ctx, types.NamespacedName{Namespace: farm.Namespace, Name: name}, obj)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("encountered error getting %T %q: %w", obj, name, err)
+		}
+		return nil
+	}
+
+	if shouldCreateCA(farm) {
+		var ca k8schianetv1.ChiaCA
+		if err := get(farm.Name, &ca); err != nil {
+			return nil, err
+		}
+		components = append(components, getComponentStatus(consts.ChiaCAKind, farm.Name, ca.Status.Ready, ca.Status.Conditions))
+	}
+
+	var node k8schianetv1.ChiaNode
+	if err := get(farm.Name, &node); err != nil {
+		return nil, err
+	}
+	components = append(components, getComponentStatus(consts.ChiaNodeKind, farm.Name, node.Status.Ready, node.Status.Conditions))
+
+	var farmer k8schianetv1.ChiaFarmer
+	if err := get(farm.Name, &farmer); err != nil {
+		return nil, err
+	}
+	components = append(components, getComponentStatus(consts.ChiaFarmerKind, farm.Name, farmer.Status.Ready, farmer.Status.Conditions))
+
+	if walletEnabled(farm) {
+		var wallet k8schianetv1.ChiaWallet
+		if err := get(farm.Name, &wallet); err != nil {
+			return nil, err
+		}
+		components = append(components, getComponentStatus(consts.ChiaWalletKind, farm.Name, wallet.Status.Ready, wallet.Status.Conditions))
+	}
+
+	for _, h := range farm.Spec.Harvesters {
+		name := getHarvesterName(farm, h)
+		var harvester k8schianetv1.ChiaHarvester
+		if err := get(name, &harvester); err != nil {
+			return nil, err
+		}
+		components = append(components, getComponentStatus(consts.ChiaHarvesterKind, name, harvester.Status.Ready, harvester.Status.Conditions))
+	}
+
+	return components, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ChiaFarmReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&k8schianetv1.ChiaFarm{}).
+		Owns(&k8schianetv1.ChiaCA{}).
+		Owns(&k8schianetv1.ChiaNode{}).
+		Owns(&k8schianetv1.ChiaFarmer{}).
+		Owns(&k8schianetv1.ChiaWallet{}).
+		Owns(&k8schianetv1.ChiaHarvester{}).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.handleChiaNetworks),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
+		Complete(r)
+}
+
+// handleChiaNetworks returns requests for the ChiaFarms whose full_node references a ChiaNetwork's ConfigMap, since the full_node's port may have changed
+func (r *ChiaFarmReconciler) handleChiaNetworks(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := &k8schianetv1.ChiaFarmList{}
+	if err := r.List(ctx, list, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, item := range list.Items {
+		chiaNetwork := item.Spec.ChiaConfig.ChiaNetwork
+		if chiaNetwork != nil && *chiaNetwork == obj.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      item.GetName(),
+					Namespace: item.GetNamespace(),
+				},
+			})
+		}
+	}
+	return requests
+}
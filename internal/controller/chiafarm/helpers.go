@@ -0,0 +1,110 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiafarm
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// getChiaConfig returns a component's chia config, from the component's own settings and the settings shared by the farm.
+// The component's own image and log level take precedence over the farm's.
+func getChiaConfig(shared k8schianetv1.ChiaFarmSpecChia, component k8schianetv1.ChiaFarmComponentSpec) k8schianetv1.CommonSpecChia {
+	spec := component.DeepCopy()
+	chia := k8schianetv1.CommonSpecChia{
+		Image:           shared.Image,
+		ChiaNetwork:     shared.ChiaNetwork,
+		Timezone:        shared.Timezone,
+		LogLevel:        shared.LogLevel,
+		ConfigOverrides: spec.ConfigOverrides,
+		Resources:       spec.Resources,
+	}
+	if spec.Image != nil {
+		chia.Image = spec.Image
+	}
+	if spec.LogLevel != nil {
+		chia.LogLevel = spec.LogLevel
+	}
+	return chia
+}
+
+// getCommonSpec returns the common spec of a component's child resource from its settings in the farm
+func getCommonSpec(component k8schianetv1.ChiaFarmComponentSpec) k8schianetv1.CommonSpec {
+	spec := component.DeepCopy()
+	return k8schianetv1.CommonSpec{
+		Storage:        spec.Storage,
+		NodeSelector:   spec.NodeSelector,
+		Tolerations:    spec.Tolerations,
+		Suspend:        spec.Suspend,
+		DeletionPolicy: spec.DeletionPolicy,
+	}
+}
+
+// shouldCreateCA returns true if the farm creates its own ChiaCA, rather than using an existing CA Secret
+func shouldCreateCA(farm k8schianetv1.ChiaFarm) bool {
+	return strings.TrimSpace(farm.Spec.ChiaConfig.CASecretName) == ""
+}
+
+// getCASecretName returns the name of the CA Secret the farm's components use
+func getCASecretName(farm k8schianetv1.ChiaFarm) string {
+	if !shouldCreateCA(farm) {
+		return farm.Spec.ChiaConfig.CASecretName
+	}
+	if farm.Spec.CA != nil && strings.TrimSpace(farm.Spec.CA.Secret) != "" {
+		return farm.Spec.CA.Secret
+	}
+	return fmt.Sprintf(chiafarmCASecretNamePattern, farm.Name)
+}
+
+// walletEnabled returns true if the farm includes a ChiaWallet, which it does by default
+func walletEnabled(farm k8schianetv1.ChiaFarm) bool {
+	return farm.Spec.Wallet.Enabled == nil || *farm.Spec.Wallet.Enabled
+}
+
+// getHarvesterName returns the name of the ChiaHarvester for one of the farm's harvesters
+func getHarvesterName(farm k8schianetv1.ChiaFarm, harvester k8schianetv1.ChiaFarmHarvesterSpec) string {
+	return fmt.Sprintf(chiafarmHarvesterNamePattern, farm.Name, harvester.Name)
+}
+
+// getNodePeer returns the full_node peer the farm's farmer and wallet connect to
+func getNodePeer(farm k8schianetv1.ChiaFarm, port int32) k8schianetv1.Peer {
+	return k8schianetv1.Peer{
+		Host: fmt.Sprintf(nodeServiceAddressPattern, farm.Name, farm.Namespace),
+		Port: uint16(port),
+	}
+}
+
+// getComponentStatus returns the status of one of the farm's child resources from its Ready condition
+func getComponentStatus(kind consts.ChiaKind, name string, ready bool, conditions []metav1.Condition) k8schianetv1.ChiaFarmComponentStatus {
+	status := k8schianetv1.ChiaFarmComponentStatus{
+		Kind:  string(kind),
+		Name:  name,
+		Ready: ready,
+	}
+	if cond := meta.FindStatusCondition(conditions, k8schianetv1.ConditionTypeReady); cond != nil {
+		status.Message = cond.Message
+	}
+	return status
+}
+
+// getNotReadyMessage returns a message listing the farm's child resources that aren't ready, or an empty string if they're all ready
+func getNotReadyMessage(components []k8schianetv1.ChiaFarmComponentStatus) string {
+	var notReady []string
+	for _, c := range components {
+		if !c.Ready {
+			notReady = append(notReady, fmt.Sprintf("%s %q", c.Kind, c.Name))
+		}
+	}
+	if len(notReady) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Waiting for %s to become ready", strings.Join(notReady, ", "))
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiafarm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+func TestGetChiaConfig(t *testing.T) {
+	shared := k8schianetv1.ChiaFarmSpecChia{
+		Image:       stringPtr("chia:shared"),
+		ChiaNetwork: stringPtr("testnet11"),
+		Timezone:    stringPtr("UTC"),
+		LogLevel:    stringPtr("INFO"),
+	}
+
+	merged := getChiaConfig(shared, k8schianetv1.ChiaFarmComponentSpec{})
+	require.Equal(t, "chia:shared", *merged.Image)
+	require.Equal(t, "testnet11", *merged.ChiaNetwork)
+	require.Equal(t, "UTC", *merged.Timezone)
+	require.Equal(t, "INFO", *merged.LogLevel)
+
+	merged = getChiaConfig(shared, k8schianetv1.ChiaFarmComponentSpec{
+		Image:    stringPtr("chia:component"),
+		LogLevel: stringPtr("DEBUG"),
+	})
+	require.Equal(t, "chia:component", *merged.Image)
+	require.Equal(t, "DEBUG", *merged.LogLevel)
+	require.Equal(t, "testnet11", *merged.ChiaNetwork)
+}
+
+func TestGetCASecretName(t *testing.T) {
+	farm := k8schianetv1.ChiaFarm{ObjectMeta: metav1.ObjectMeta{Name: "farm"}}
+	require.True(t, shouldCreateCA(farm))
+	require.Equal(t, "farm-ca", getCASecretName(farm))
+
+	farm.Spec.CA = &k8schianetv1.ChiaCASpec{Secret: "generated-ca"}
+	require.Equal(t, "generated-ca", getCASecretName(farm))
+
+	farm.Spec.CA = nil
+	farm.Spec.ChiaConfig.CASecretName = "existing-ca"
+	require.False(t, shouldCreateCA(farm))
+	require.Equal(t, "existing-ca", getCASecretName(farm))
+}
+
+func TestGetNotReadyMessage(t *testing.T) {
+	components := []k8schianetv1.ChiaFarmComponentStatus{
+		getComponentStatus(consts.ChiaNodeKind, "farm", true, nil),
+		getComponentStatus(consts.ChiaFarmerKind, "farm", true, nil),
+	}
+	require.Empty(t, getNotReadyMessage(components))
+
+	components = append(components, getComponentStatus(consts.ChiaHarvesterKind, "farm-rack-1", false, []metav1.Condition{
+		{Type: k8schianetv1.ConditionTypeReady, Status: metav1.ConditionFalse, Message: "Waiting for Pods"},
+	}))
+	require.Equal(t, "Waiting for Pods", components[2].Message)
+	require.Equal(t, `Waiting for ChiaHarvester "farm-rack-1" to become ready`, getNotReadyMessage(components))
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
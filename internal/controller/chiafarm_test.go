@@ -0,0 +1,79 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	apiv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+var _ = Describe("ChiaFarm controller", func() {
+	var (
+		timeout  = time.Second * 10
+		interval = time.Millisecond * 250
+	)
+
+	Context("When creating ChiaFarm", func() {
+		It("should create its child resources wired to each other", func() {
+			By("By creating a new ChiaFarm")
+			ctx := context.Background()
+			testFarm := &apiv1.ChiaFarm{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "k8s.chia.net/v1",
+					Kind:       "ChiaFarm",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-chiafarm",
+					Namespace: "default",
+				},
+				Spec: apiv1.ChiaFarmSpec{
+					ChiaConfig: apiv1.ChiaFarmSpecChia{
+						SecretKey: apiv1.ChiaSecretKey{
+							Name: "testkeys",
+							Key:  "key.txt",
+						},
+					},
+					Harvesters: []apiv1.ChiaFarmHarvesterSpec{
+						{Name: "harvester"},
+					},
+				},
+			}
+
+			// Create ChiaFarm
+			Expect(k8sClient.Create(ctx, testFarm)).Should(Succeed())
+
+			// Look up the ChiaFarmer created for the farm
+			createdChiaFarmer := &apiv1.ChiaFarmer{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: testFarm.Name, Namespace: testFarm.Namespace}, createdChiaFarmer)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+			Expect(createdChiaFarmer.Spec.ChiaConfig.CASecretName).Should(Equal("test-chiafarm-ca"))
+			Expect(*createdChiaFarmer.Spec.ChiaConfig.FullNodePeers).Should(Equal([]apiv1.Peer{
+				{Host: "test-chiafarm-node.default.svc.cluster.local", Port: 8444},
+			}))
+
+			// Look up the ChiaHarvester created for the farm
+			createdChiaHarvester := &apiv1.ChiaHarvester{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "test-chiafarm-harvester", Namespace: testFarm.Namespace}, createdChiaHarvester)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+			Expect(createdChiaHarvester.Spec.ChiaConfig.FarmerAddress).Should(Equal("test-chiafarm-farmer.default.svc.cluster.local"))
+
+			createdChiaFarm := &apiv1.ChiaFarm{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: testFarm.Name, Namespace: testFarm.Namespace}, createdChiaFarm)).Should(Succeed())
+			Expect(metav1.IsControlledBy(createdChiaHarvester, createdChiaFarm)).Should(BeTrue())
+		})
+	})
+})
@@ -12,6 +12,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -288,6 +289,18 @@ func assembleVolumeClaim(farmer k8schianetv1.ChiaFarmer) (*corev1.PersistentVolu
 	return &pvc, nil
 }
 
+// assemblePodDisruptionBudget assembles the PodDisruptionBudget resource for a ChiaFarmer CR
+func assemblePodDisruptionBudget(farmer k8schianetv1.ChiaFarmer) policyv1.PodDisruptionBudget {
+	return kube.AssemblePodDisruptionBudget(kube.AssemblePodDisruptionBudgetInputs{
+		Name:           fmt.Sprintf(chiafarmerNamePattern, farmer.Name),
+		Namespace:      farmer.Namespace,
+		Labels:         kube.GetCommonLabels(farmer.Kind, farmer.ObjectMeta, farmer.Spec.Labels),
+		Annotations:    farmer.Spec.Annotations,
+		SelectorLabels: kube.GetCommonLabels(farmer.Kind, farmer.ObjectMeta),
+		Config:         farmer.Spec.PodDisruptionBudget,
+	})
+}
+
 // assembleDeployment assembles the farmer Deployment resource for a ChiaFarmer CR
 func assembleDeployment(ctx context.Context, farmer k8schianetv1.ChiaFarmer, networkData *map[string]string) (appsv1.Deployment, error) {
 	var deploy = appsv1.Deployment{
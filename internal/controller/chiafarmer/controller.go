@@ -7,21 +7,25 @@ package chiafarmer
 import (
 	"context"
 	"fmt"
-	"strings"
+	"slices"
 	"time"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/chiarpc"
+	"github.com/chia-network/chia-operator/internal/controller/common/component"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/finalizer"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
-	"github.com/chia-network/chia-operator/internal/metrics"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -32,178 +36,182 @@ type ChiaFarmerReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder events.EventRecorder
-}
 
-var chiafarmers = make(map[string]bool)
+	// MaxConcurrentReconciles is the maximum number of ChiaFarmers that can be reconciled at the same time, which defaults to 1
+	MaxConcurrentReconciles int
+}
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiafarmers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiafarmers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiafarmers/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch
-//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaharvesters,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch;update
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is invoked on any event to a controlled Kubernetes resource
 func (r *ChiaFarmerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := log.FromContext(ctx)
-	log.Info("Running reconciler...")
-
-	// Get the custom resource
 	var farmer k8schianetv1.ChiaFarmer
-	err := r.Get(ctx, req.NamespacedName, &farmer)
-	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chiafarmers[req.String()]
-		if exists {
-			delete(chiafarmers, req.String())
-			metrics.ChiaFarmers.Sub(1.0)
-		}
-		return ctrl.Result{}, nil
-	}
-	if err != nil {
-		log.Error(err, fmt.Sprintf("ChiaFarmerReconciler ChiaFarmer=%s unable to fetch ChiaFarmer resource", req.NamespacedName))
-		return ctrl.Result{}, client.IgnoreNotFound(err)
-	}
+	engine := component.Engine{
+		Client:   r.Client,
+		Scheme:   r.Scheme,
+		Recorder: r.Recorder,
+		Kind:     consts.ChiaFarmerKind,
+		Name:     "farmer",
+	}
+	return engine.Reconcile(ctx, req, &farmer, component.Hooks{
+		Describe: func() component.Resource {
+			return component.Resource{
+				Meta:    farmer.ObjectMeta,
+				Chia:    farmer.Spec.ChiaConfig.CommonSpecChia,
+				Suspend: farmer.Spec.Suspend,
+				Status: component.Status{
+					Conditions:         &farmer.Status.Conditions,
+					ObservedGeneration: &farmer.Status.ObservedGeneration,
+					Ready:              &farmer.Status.Ready,
+				},
+				Workload: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf(chiafarmerNamePattern, farmer.Name), Namespace: farmer.Namespace}},
+			}
+		},
+		FinalizerInputs: func(ctx context.Context) (finalizer.Inputs, error) {
+			return finalizer.Inputs{
+				DeletionPolicy:   farmer.Spec.DeletionPolicy,
+				VolumeClaimNames: getGeneratedVolumeClaimNames(farmer),
+			}, nil
+		},
+		Assemble: func(ctx context.Context, c *component.Reconciler, networkData *map[string]string) (component.Component, error) {
+			deploy, err := assembleDeployment(ctx, farmer, networkData)
+			if err != nil {
+				c.AssembleFailed("Deployment")
+				return component.Component{}, err
+			}
 
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chiafarmers[req.String()]
-	if !exists {
-		chiafarmers[req.String()] = true
-		metrics.ChiaFarmers.Add(1.0)
-	}
+			services := []component.Service{
+				{Description: "peer", Desired: assemblePeerService(farmer), Config: farmer.Spec.ChiaConfig.PeerService, DefaultEnabled: true},
+				{Description: "all-port", Desired: assembleAllService(farmer), Config: farmer.Spec.ChiaConfig.AllService, DefaultEnabled: true},
+				{Description: "daemon", Desired: assembleDaemonService(farmer), Config: farmer.Spec.ChiaConfig.DaemonService, DefaultEnabled: true},
+				{Description: "RPC", Desired: assembleRPCService(farmer), Config: farmer.Spec.ChiaConfig.RPCService, DefaultEnabled: true},
+				{Description: "chia-exporter", Desired: assembleChiaExporterService(farmer), Config: farmer.Spec.ChiaExporterConfig.Service, DefaultEnabled: true},
+			}
+			if !kube.ShouldRollIntoMainPeerService(farmer.Spec.ChiaHealthcheckConfig.Service) {
+				services = append(services, component.Service{Description: "chia-healthcheck", Desired: assembleChiaHealthcheckService(farmer), Config: farmer.Spec.ChiaHealthcheckConfig.Service})
+			}
+			return component.Component{
+				Services: services,
+				VolumeClaims: []component.VolumeClaim{{
+					Description: "CHIA_ROOT",
+					Enabled:     kube.ShouldMakeChiaRootVolumeClaim(farmer.Spec.Storage),
+					Storage:     farmer.Spec.Storage,
+					Assemble:    func() (*corev1.PersistentVolumeClaim, error) { return assembleVolumeClaim(farmer) },
+				}},
+				Workload: &deploy,
+				PodDisruptionBudget: component.PodDisruptionBudget{
+					Desired: assemblePodDisruptionBudget(farmer),
+					Config:  farmer.Spec.PodDisruptionBudget,
+				},
+			}, nil
+		},
+		Reconciled: func(ctx context.Context, c *component.Reconciler, comp component.Component) (time.Duration, error) {
+			// Record the farmer RPC status, and requeue for its next query
+			rpcRequeueAfter, err := r.updateRPCStatus(ctx, &farmer, *comp.Workload.(*appsv1.Deployment))
+			if err != nil {
+				log.FromContext(ctx).Error(err, fmt.Sprintf("ChiaFarmerReconciler ChiaFarmer=%s unable to query farmer RPC status", req.NamespacedName))
+			}
+			return rpcRequeueAfter, nil
+		},
+	})
+}
 
-	// Check for ChiaNetwork, retrieve matching ConfigMap if specified
-	networkData, err := kube.GetChiaNetworkData(ctx, r.Client, farmer.Spec.ChiaConfig.CommonSpecChia, farmer.Namespace)
-	if err != nil {
-		return ctrl.Result{}, err
+// updateRPCStatus queries the farmer RPC server in the Deployment's Pod and records its connections and farming activity in the ChiaFarmer's status.
+// Returns the amount of time until the next query is due, or 0 if RPC status queries are disabled.
+func (r *ChiaFarmerReconciler) updateRPCStatus(ctx context.Context, farmer *k8schianetv1.ChiaFarmer, deploy appsv1.Deployment) (time.Duration, error) {
+	if !kube.RPCStatusEnabled(farmer.Spec.RPCStatus) {
+		farmer.Status.Farming = nil
+		return 0, nil
 	}
 
-	// Assemble Peer Service
-	peerSrv := assemblePeerService(farmer)
-	if err := controllerutil.SetControllerReference(&farmer, &peerSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&farmer, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble farmer peer Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error assembling peer Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile Peer Service
-	res, err := kube.ReconcileService(ctx, r.Client, farmer.Spec.ChiaConfig.PeerService, peerSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s %v", req.NamespacedName, err)
+	// Only query the RPC server once per interval, since every status update triggers another reconcile
+	interval := kube.GetRPCStatusInterval(farmer.Spec.RPCStatus)
+	now := time.Now()
+	if farmer.Status.Farming != nil {
+		if requeueAfter := kube.GetRPCStatusRequeueAfter(&farmer.Status.Farming.LastChecked, interval, now); requeueAfter > 0 {
+			return requeueAfter, nil
+		}
 	}
 
-	// Assemble All Service
-	allSrv := assembleAllService(farmer)
-	if err := controllerutil.SetControllerReference(&farmer, &allSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&farmer, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble farmer all-port Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error assembling all-port Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile All Service
-	res, err = kube.ReconcileService(ctx, r.Client, farmer.Spec.ChiaConfig.AllService, allSrv, true)
+	rpcClient, err := chiarpc.GetClient(ctx, r.Client, farmer.Namespace, farmer.Spec.ChiaConfig.CASecretName)
 	if err != nil {
-		return res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s %v", req.NamespacedName, err)
+		return interval, err
 	}
 
-	// Assemble Daemon Service
-	daemonSrv := assembleDaemonService(farmer)
-	if err := controllerutil.SetControllerReference(&farmer, &daemonSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&farmer, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble farmer daemon Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error assembling daemon Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile Daemon Service
-	res, err = kube.ReconcileService(ctx, r.Client, farmer.Spec.ChiaConfig.DaemonService, daemonSrv, true)
+	pods, err := kube.ListPods(ctx, r.Client, farmer.Namespace, deploy.Spec.Selector)
 	if err != nil {
-		return res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s %v", req.NamespacedName, err)
-	}
-
-	// Assemble RPC Service
-	rpcSrv := assembleRPCService(farmer)
-	if err := controllerutil.SetControllerReference(&farmer, &rpcSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&farmer, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble farmer RPC Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error assembling RPC Service: %v", req.NamespacedName, err)
+		return interval, err
 	}
-	// Reconcile RPC Service
-	res, err = kube.ReconcileService(ctx, r.Client, farmer.Spec.ChiaConfig.RPCService, rpcSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s %v", req.NamespacedName, err)
+	pod := kube.GetFirstRunningPod(pods)
+	if pod == nil {
+		// Nothing to query yet, check again on the next reconcile
+		return interval, nil
 	}
 
-	// Assemble Chia-Exporter Service
-	exporterSrv := assembleChiaExporterService(farmer)
-	if err := controllerutil.SetControllerReference(&farmer, &exporterSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&farmer, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble farmer chia-exporter Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error assembling chia-exporter Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile Chia-Exporter Service
-	res, err = kube.ReconcileService(ctx, r.Client, farmer.Spec.ChiaExporterConfig.Service, exporterSrv, true)
+	state, err := rpcClient.GetFarmerState(ctx, pod.Status.PodIP)
 	if err != nil {
-		return res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s %v", req.NamespacedName, err)
-	}
-
-	// Assemble Chia-Healthcheck Service
-	healthcheckSrv := assembleChiaHealthcheckService(farmer)
-	if err := controllerutil.SetControllerReference(&farmer, &healthcheckSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&farmer, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble farmer chia-healthcheck Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s encountered error assembling chia-healthcheck Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile Chia-Healthcheck Service
-	if !kube.ShouldRollIntoMainPeerService(farmer.Spec.ChiaHealthcheckConfig.Service) {
-		res, err = kube.ReconcileService(ctx, r.Client, farmer.Spec.ChiaHealthcheckConfig.Service, healthcheckSrv, false)
-		if err != nil {
-			return res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s %v", req.NamespacedName, err)
+		// Keep the last known farming status, but record the error
+		status := k8schianetv1.ChiaFarmerFarmingStatus{}
+		if farmer.Status.Farming != nil {
+			status = *farmer.Status.Farming
 		}
+		status.PodName = pod.Name
+		status.LastChecked = metav1.NewTime(now)
+		status.Error = err.Error()
+		farmer.Status.Farming = &status
+		return interval, nil
 	}
 
-	// Creates a persistent volume claim if the GenerateVolumeClaims setting was set to true
-	if kube.ShouldMakeChiaRootVolumeClaim(farmer.Spec.Storage) {
-		pvc, err := assembleVolumeClaim(farmer)
+	// Look up the ChiaHarvesters in this namespace and their Pods, to find the ones that should be connected to this farmer
+	var harvesters k8schianetv1.ChiaHarvesterList
+	err = r.List(ctx, &harvesters, client.InNamespace(farmer.Namespace))
+	if err != nil {
+		return interval, fmt.Errorf("error listing ChiaHarvesters: %w", err)
+	}
+	harvesterPods := make(map[string][]corev1.Pod)
+	for _, harvester := range harvesters.Items {
+		var podList corev1.PodList
+		err = r.List(ctx, &podList, client.InNamespace(farmer.Namespace), client.MatchingLabels{
+			"app.kubernetes.io/instance": harvester.Name,
+			"k8s.chia.net/kind":          string(consts.ChiaHarvesterKind),
+		})
 		if err != nil {
-			r.Recorder.Eventf(&farmer, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble farmer PVC -- Check operator logs.")
-			return reconcile.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s %v", req.NamespacedName, err)
-		}
-
-		if pvc != nil {
-			res, err = kube.ReconcilePersistentVolumeClaim(ctx, r.Client, farmer.Spec.Storage, *pvc)
-			if err != nil {
-				r.Recorder.Eventf(&farmer, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to create farmer PVC -- Check operator logs.")
-				return res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s %v", req.NamespacedName, err)
-			}
-		} else {
-			return reconcile.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s PVC could not be created", req.NamespacedName)
+			return interval, fmt.Errorf("error listing Pods for ChiaHarvester \"%s\": %w", harvester.Name, err)
 		}
+		harvesterPods[harvester.Name] = podList.Items
 	}
 
-	// Assemble Deployment
-	deploy, err := assembleDeployment(ctx, farmer, networkData)
-	if err != nil {
-		r.Recorder.Eventf(&farmer, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble farmer Deployment -- Check operator logs.")
-		return reconcile.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s %v", req.NamespacedName, err)
-	}
-	if err := controllerutil.SetControllerReference(&farmer, &deploy, r.Scheme); err != nil {
-		r.Recorder.Eventf(&farmer, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble farmer Deployment -- Check operator logs.")
-		return reconcile.Result{}, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s %v", req.NamespacedName, err)
-	}
-	// Reconcile Deployment
-	res, err = kube.ReconcileDeployment(ctx, r.Client, deploy)
-	if err != nil {
-		r.Recorder.Eventf(&farmer, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to create farmer Deployment -- Check operator logs.")
-		return res, fmt.Errorf("ChiaFarmerReconciler ChiaFarmer=%s %v", req.NamespacedName, err)
-	}
+	status := assembleFarmingStatus(*farmer, state, harvesters.Items, harvesterPods)
+	status.PodName = pod.Name
+	status.LastChecked = metav1.NewTime(now)
 
-	// Update CR status
-	r.Recorder.Eventf(&farmer, nil, corev1.EventTypeNormal, "Created", "Created", "Successfully created ChiaFarmer resources.")
-	farmer.Status.Ready = true
-	err = r.Status().Update(ctx, &farmer)
-	if err != nil {
-		if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
-			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+	// Emit an event for each ChiaHarvester that newly became disconnected
+	var previouslyDisconnected []string
+	if farmer.Status.Farming != nil {
+		previouslyDisconnected = farmer.Status.Farming.DisconnectedHarvesters
+	}
+	for _, name := range status.DisconnectedHarvesters {
+		if !slices.Contains(previouslyDisconnected, name) {
+			r.Recorder.Eventf(farmer, nil, corev1.EventTypeWarning, "HarvesterDisconnected", "HarvesterDisconnected", "ChiaHarvester %s points to this farmer but is not connected to it", name)
 		}
-		log.Error(err, fmt.Sprintf("ChiaFarmerReconciler ChiaFarmer=%s unable to update ChiaFarmer status", req.NamespacedName))
-		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	farmer.Status.Farming = &status
+
+	return interval, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -212,10 +220,12 @@ func (r *ChiaFarmerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&k8schianetv1.ChiaFarmer{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
 		Watches(
 			&corev1.ConfigMap{},
 			handler.EnqueueRequestsFromMapFunc(r.handleChiaNetworks),
 		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
@@ -7,9 +7,12 @@ package chiafarmer
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/chia-network/chia-operator/internal/controller/common/chiarpc"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
@@ -119,3 +122,89 @@ func getChiaEnv(ctx context.Context, farmer k8schianetv1.ChiaFarmer, networkData
 
 	return env, nil
 }
+
+// harvesterTargetsFarmer returns true if the ChiaHarvester's farmerAddress resolves to one of the ChiaFarmer's peer Services.
+// The farmerAddress may be a bare Service name, or a Service DNS name like <service>.<namespace>.svc.cluster.local
+func harvesterTargetsFarmer(harvester k8schianetv1.ChiaHarvester, farmer k8schianetv1.ChiaFarmer) bool {
+	labels := strings.Split(strings.TrimSuffix(harvester.Spec.ChiaConfig.FarmerAddress, "."), ".")
+	service := labels[0]
+	namespace := harvester.Namespace
+	if len(labels) > 1 {
+		namespace = labels[1]
+	}
+	if len(labels) > 2 && labels[2] != "svc" {
+		return false
+	}
+	if namespace != farmer.Namespace {
+		return false
+	}
+
+	peerService := fmt.Sprintf(chiafarmerNamePattern, farmer.Name)
+	return service == peerService || service == peerService+"-all"
+}
+
+// assembleFarmingStatus assembles a farmer's farming status from its RPC state.
+// harvesterPods contains the Pods of each ChiaHarvester in the namespace, keyed by ChiaHarvester name, and is used to match connected harvesters to ChiaHarvesters.
+func assembleFarmingStatus(farmer k8schianetv1.ChiaFarmer, state chiarpc.FarmerState, harvesters []k8schianetv1.ChiaHarvester, harvesterPods map[string][]corev1.Pod) k8schianetv1.ChiaFarmerFarmingStatus {
+	// Map Pod IPs to the ChiaHarvester they belong to
+	harvesterByIP := make(map[string]string)
+	for name, pods := range harvesterPods {
+		for _, pod := range pods {
+			if pod.Status.PodIP != "" {
+				harvesterByIP[pod.Status.PodIP] = name
+			}
+		}
+	}
+
+	status := k8schianetv1.ChiaFarmerFarmingStatus{
+		ConnectedHarvesters:     int32(len(state.Harvesters)),
+		RecentProofs:            state.RecentProofs,
+		PartialsFound24h:        state.PartialsFound24h,
+		PartialsAcknowledged24h: state.PartialsAcknowledged24h,
+	}
+
+	connected := make(map[string]bool)
+	for _, h := range state.Harvesters {
+		name := harvesterByIP[h.Connection.Host]
+		if name != "" {
+			connected[name] = true
+		}
+		status.Harvesters = append(status.Harvesters, k8schianetv1.ChiaFarmerHarvesterConnection{
+			NodeID:        h.Connection.NodeID.String(),
+			Host:          h.Connection.Host,
+			PlotCount:     int32(h.Plots),
+			ChiaHarvester: name,
+		})
+	}
+	sort.Slice(status.Harvesters, func(i, j int) bool {
+		return status.Harvesters[i].Host < status.Harvesters[j].Host
+	})
+
+	for _, peer := range state.FullNodePeers {
+		status.FullNodePeers = append(status.FullNodePeers, k8schianetv1.ChiaFarmerPeerConnection{
+			NodeID: peer.NodeID.String(),
+			Host:   peer.PeerHost,
+			Port:   int32(peer.PeerPort),
+		})
+	}
+	sort.Slice(status.FullNodePeers, func(i, j int) bool {
+		return status.FullNodePeers[i].Host < status.FullNodePeers[j].Host
+	})
+
+	for _, harvester := range harvesters {
+		if harvesterTargetsFarmer(harvester, farmer) && !connected[harvester.Name] {
+			status.DisconnectedHarvesters = append(status.DisconnectedHarvesters, harvester.Name)
+		}
+	}
+	sort.Strings(status.DisconnectedHarvesters)
+
+	return status
+}
+
+// getGeneratedVolumeClaimNames returns the names of the PersistentVolumeClaims the operator generates for a ChiaFarmer
+func getGeneratedVolumeClaimNames(farmer k8schianetv1.ChiaFarmer) []string {
+	if kube.ShouldMakeChiaRootVolumeClaim(farmer.Spec.Storage) {
+		return []string{fmt.Sprintf(chiafarmerNamePattern, farmer.Name)}
+	}
+	return nil
+}
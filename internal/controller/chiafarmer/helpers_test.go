@@ -3,6 +3,8 @@ package chiafarmer
 import (
 	"testing"
 
+	"github.com/chia-network/chia-operator/internal/controller/common/chiarpc"
+	"github.com/chia-network/go-chia-libs/pkg/types"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -245,3 +247,85 @@ func TestGetChiaVolumes(t *testing.T) {
 		})
 	}
 }
+
+func TestHarvesterTargetsFarmer(t *testing.T) {
+	farmer := k8schianetv1.ChiaFarmer{
+		ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "chia"},
+	}
+	testCases := []struct {
+		name          string
+		namespace     string
+		farmerAddress string
+		expected      bool
+	}{
+		{name: "Bare Service name", namespace: "chia", farmerAddress: "farmer-farmer", expected: true},
+		{name: "All-port Service", namespace: "chia", farmerAddress: "farmer-farmer-all.chia", expected: true},
+		{name: "Fully qualified Service name", namespace: "other", farmerAddress: "farmer-farmer.chia.svc.cluster.local", expected: true},
+		{name: "Bare Service name in another namespace", namespace: "other", farmerAddress: "farmer-farmer", expected: false},
+		{name: "Another farmer's Service", namespace: "chia", farmerAddress: "other-farmer.chia.svc.cluster.local", expected: false},
+		{name: "External hostname", namespace: "chia", farmerAddress: "farmer-farmer.chia.example.com", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			harvester := k8schianetv1.ChiaHarvester{
+				ObjectMeta: metav1.ObjectMeta{Name: "harvester", Namespace: tc.namespace},
+				Spec: k8schianetv1.ChiaHarvesterSpec{
+					ChiaConfig: k8schianetv1.ChiaHarvesterSpecChia{FarmerAddress: tc.farmerAddress},
+				},
+			}
+			assert.Equal(t, tc.expected, harvesterTargetsFarmer(harvester, farmer))
+		})
+	}
+}
+
+func TestAssembleFarmingStatus(t *testing.T) {
+	farmer := k8schianetv1.ChiaFarmer{
+		ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "chia"},
+	}
+	newHarvester := func(name, farmerAddress string) k8schianetv1.ChiaHarvester {
+		return k8schianetv1.ChiaHarvester{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "chia"},
+			Spec: k8schianetv1.ChiaHarvesterSpec{
+				ChiaConfig: k8schianetv1.ChiaHarvesterSpecChia{FarmerAddress: farmerAddress},
+			},
+		}
+	}
+	harvesters := []k8schianetv1.ChiaHarvester{
+		newHarvester("connected", "farmer-farmer"),
+		newHarvester("disconnected", "farmer-farmer.chia.svc.cluster.local"),
+		newHarvester("elsewhere", "other-farmer"),
+	}
+	harvesterPods := map[string][]corev1.Pod{
+		"connected":    {{Status: corev1.PodStatus{PodIP: "10.0.0.1"}}},
+		"disconnected": {{Status: corev1.PodStatus{PodIP: "10.0.0.2"}}},
+	}
+
+	connectedHarvester := chiarpc.FarmerHarvesterSummary{Plots: 42}
+	connectedHarvester.Connection.Host = "10.0.0.1"
+	unknownHarvester := chiarpc.FarmerHarvesterSummary{Plots: 7}
+	unknownHarvester.Connection.Host = "192.168.1.10"
+	state := chiarpc.FarmerState{
+		Harvesters: []chiarpc.FarmerHarvesterSummary{unknownHarvester, connectedHarvester},
+		FullNodePeers: []types.Connection{
+			{PeerHost: "10.0.1.1", PeerPort: 8444},
+		},
+		RecentProofs:            3,
+		PartialsFound24h:        100,
+		PartialsAcknowledged24h: 98,
+	}
+
+	status := assembleFarmingStatus(farmer, state, harvesters, harvesterPods)
+	assert.Equal(t, int32(2), status.ConnectedHarvesters)
+	assert.Len(t, status.Harvesters, 2)
+	assert.Equal(t, "10.0.0.1", status.Harvesters[0].Host)
+	assert.Equal(t, "connected", status.Harvesters[0].ChiaHarvester)
+	assert.Equal(t, int32(42), status.Harvesters[0].PlotCount)
+	assert.Equal(t, "192.168.1.10", status.Harvesters[1].Host)
+	assert.Equal(t, "", status.Harvesters[1].ChiaHarvester)
+	assert.Equal(t, []k8schianetv1.ChiaFarmerPeerConnection{{NodeID: types.Bytes32{}.String(), Host: "10.0.1.1", Port: 8444}}, status.FullNodePeers)
+	assert.Equal(t, int32(3), status.RecentProofs)
+	assert.Equal(t, int32(100), status.PartialsFound24h)
+	assert.Equal(t, int32(98), status.PartialsAcknowledged24h)
+	assert.Equal(t, []string{"disconnected"}, status.DisconnectedHarvesters)
+}
@@ -6,11 +6,13 @@ package chiaharvester
 
 import (
 	"fmt"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -19,7 +21,33 @@ import (
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 )
 
-const chiaharvesterNamePattern = "%s-harvester"
+const (
+	chiaharvesterNamePattern = "%s-harvester"
+
+	// chiaharvesterPlotVolumeClaimNamePattern is the name of a generated plot PVC, from the harvester's name and the name of the plot volume
+	chiaharvesterPlotVolumeClaimNamePattern = "%s-harvester-plots-%s"
+
+	// chiaharvesterPlotDirectoriesNamePattern is the name of the ConfigMap that lists each node's plot directories, for harvesters ran as a DaemonSet
+	chiaharvesterPlotDirectoriesNamePattern = "%s-harvester-plot-directories"
+)
+
+// plotDiscoveryScript adds each plot directory on the harvester's node to the harvester's config before the harvester starts.
+// Uses the directories from the node's plot directories annotation if it has one, otherwise the harvester's path globs.
+const plotDiscoveryScript = `set -u
+shopt -s nullglob
+chia init > /dev/null
+patterns="${PLOT_DIRECTORY_GLOBS}"
+if [ -s "/plot-directories/${NODE_NAME}" ]; then
+  patterns="$(cat "/plot-directories/${NODE_NAME}")"
+fi
+IFS=$'\n'
+for dir in ${patterns}; do
+  if [ -d "${dir}" ]; then
+    echo "Adding plot directory ${dir}"
+    chia plots add -d "${dir}" > /dev/null || echo "Unable to add plot directory ${dir}"
+  fi
+done
+`
 
 // assemblePeerService assembles the peer Service resource for a ChiaHarvester CR
 func assemblePeerService(harvester k8schianetv1.ChiaHarvester) corev1.Service {
@@ -287,8 +315,58 @@ func assembleVolumeClaim(harvester k8schianetv1.ChiaHarvester) (*corev1.Persiste
 	return &pvc, nil
 }
 
+// assemblePlotVolumeClaim assembles the PVC resource for one of a ChiaHarvester CR's generated plot volumes
+func assemblePlotVolumeClaim(harvester k8schianetv1.ChiaHarvester, vol k8schianetv1.PersistentVolumeClaimConfig) (corev1.PersistentVolumeClaim, error) {
+	resourceReq, err := resource.ParseQuantity(vol.ResourceRequest)
+	if err != nil {
+		return corev1.PersistentVolumeClaim{}, fmt.Errorf("error parsing plot volume \"%s\" resourceRequest: %w", vol.Name, err)
+	}
+
+	accessModes := []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"}
+	if len(vol.AccessModes) != 0 {
+		accessModes = vol.AccessModes
+	}
+
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getPlotVolumeClaimName(harvester, vol),
+			Namespace: harvester.Namespace,
+			Labels:    kube.GetCommonLabels(harvester.Kind, harvester.ObjectMeta, harvester.Spec.Labels),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      accessModes,
+			StorageClassName: &vol.StorageClass,
+			Selector:         vol.Selector,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resourceReq,
+				},
+			},
+		},
+	}
+
+	return pvc, nil
+}
+
+// assemblePodDisruptionBudget assembles the PodDisruptionBudget resource for a ChiaHarvester CR
+func assemblePodDisruptionBudget(harvester k8schianetv1.ChiaHarvester) policyv1.PodDisruptionBudget {
+	return kube.AssemblePodDisruptionBudget(kube.AssemblePodDisruptionBudgetInputs{
+		Name:           fmt.Sprintf(chiaharvesterNamePattern, harvester.Name),
+		Namespace:      harvester.Namespace,
+		Labels:         kube.GetCommonLabels(harvester.Kind, harvester.ObjectMeta, harvester.Spec.Labels),
+		Annotations:    harvester.Spec.Annotations,
+		SelectorLabels: kube.GetCommonLabels(harvester.Kind, harvester.ObjectMeta),
+		Config:         harvester.Spec.PodDisruptionBudget,
+	})
+}
+
 // assembleDeployment assembles the harvester Deployment resource for a ChiaHarvester CR
 func assembleDeployment(harvester k8schianetv1.ChiaHarvester, networkData *map[string]string) (appsv1.Deployment, error) {
+	template, err := assemblePodTemplate(harvester, networkData)
+	if err != nil {
+		return appsv1.Deployment{}, err
+	}
+
 	var deploy = appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        fmt.Sprintf(chiaharvesterNamePattern, harvester.Name),
@@ -300,70 +378,166 @@ func assembleDeployment(harvester k8schianetv1.ChiaHarvester, networkData *map[s
 			Selector: &metav1.LabelSelector{
 				MatchLabels: kube.GetCommonLabels(harvester.Kind, harvester.ObjectMeta),
 			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels:      kube.GetCommonLabels(harvester.Kind, harvester.ObjectMeta, harvester.Spec.Labels),
-					Annotations: harvester.Spec.Annotations,
-				},
-				Spec: corev1.PodSpec{
-					Affinity:                  harvester.Spec.Affinity,
-					TopologySpreadConstraints: harvester.Spec.TopologySpreadConstraints,
-					NodeSelector:              harvester.Spec.NodeSelector,
-					Volumes:                   getChiaVolumes(harvester),
-				},
+			Template: template,
+		},
+	}
+
+	if harvester.Spec.Strategy != nil {
+		deploy.Spec.Strategy = *harvester.Spec.Strategy
+	}
+
+	return deploy, nil
+}
+
+// assembleDaemonSet assembles the harvester DaemonSet resource for a ChiaHarvester CR ran as a DaemonSet
+func assembleDaemonSet(harvester k8schianetv1.ChiaHarvester, networkData *map[string]string) (appsv1.DaemonSet, error) {
+	template, err := assemblePodTemplate(harvester, networkData)
+	if err != nil {
+		return appsv1.DaemonSet{}, err
+	}
+
+	var ds = appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf(chiaharvesterNamePattern, harvester.Name),
+			Namespace:   harvester.Namespace,
+			Labels:      kube.GetCommonLabels(harvester.Kind, harvester.ObjectMeta, harvester.Spec.Labels),
+			Annotations: harvester.Spec.Annotations,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: kube.GetCommonLabels(harvester.Kind, harvester.ObjectMeta),
 			},
+			Template: template,
+		},
+	}
+
+	if harvester.Spec.DaemonSet.UpdateStrategy != nil {
+		ds.Spec.UpdateStrategy = *harvester.Spec.DaemonSet.UpdateStrategy
+	}
+
+	return ds, nil
+}
+
+// assemblePlotDirectoriesConfigMap assembles the ConfigMap that lists each node's plot directories for a ChiaHarvester CR ran as a DaemonSet
+func assemblePlotDirectoriesConfigMap(harvester k8schianetv1.ChiaHarvester, nodes []corev1.Node) corev1.ConfigMap {
+	return corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf(chiaharvesterPlotDirectoriesNamePattern, harvester.Name),
+			Namespace:   harvester.Namespace,
+			Labels:      kube.GetCommonLabels(harvester.Kind, harvester.ObjectMeta, harvester.Spec.Labels),
+			Annotations: harvester.Spec.Annotations,
+		},
+		Data: getNodePlotDirectories(harvester, nodes),
+	}
+}
+
+// assemblePodTemplate assembles the harvester Pod template shared by the harvester's Deployment or DaemonSet
+func assemblePodTemplate(harvester k8schianetv1.ChiaHarvester, networkData *map[string]string) (corev1.PodTemplateSpec, error) {
+	var template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      kube.GetCommonLabels(harvester.Kind, harvester.ObjectMeta, harvester.Spec.Labels),
+			Annotations: harvester.Spec.Annotations,
+		},
+		Spec: corev1.PodSpec{
+			Affinity:                  harvester.Spec.Affinity,
+			TopologySpreadConstraints: harvester.Spec.TopologySpreadConstraints,
+			NodeSelector:              harvester.Spec.NodeSelector,
+			Volumes:                   getChiaVolumes(harvester),
 		},
 	}
 
 	if harvester.Spec.ServiceAccountName != nil && *harvester.Spec.ServiceAccountName != "" {
-		deploy.Spec.Template.Spec.ServiceAccountName = *harvester.Spec.ServiceAccountName
+		template.Spec.ServiceAccountName = *harvester.Spec.ServiceAccountName
 	}
 
 	chiaContainer, err := assembleChiaContainer(harvester, networkData)
 	if err != nil {
-		return appsv1.Deployment{}, err
+		return corev1.PodTemplateSpec{}, err
+	}
+	template.Spec.Containers = append(template.Spec.Containers, chiaContainer)
+
+	// Discover the node's plot directories before any other init containers run
+	if daemonSetEnabled(harvester) {
+		template.Spec.InitContainers = append(template.Spec.InitContainers, assemblePlotDiscoveryContainer(harvester, chiaContainer))
 	}
-	deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, chiaContainer)
 
 	// Get Init Containers
-	deploy.Spec.Template.Spec.InitContainers = kube.GetExtraContainers(harvester.Spec.InitContainers, chiaContainer)
+	template.Spec.InitContainers = append(template.Spec.InitContainers, kube.GetExtraContainers(harvester.Spec.InitContainers, chiaContainer)...)
 	// Add Init Container Volumes
 	for _, init := range harvester.Spec.InitContainers {
-		deploy.Spec.Template.Spec.Volumes = append(deploy.Spec.Template.Spec.Volumes, init.Volumes...)
+		template.Spec.Volumes = append(template.Spec.Volumes, init.Volumes...)
 	}
 
 	// Get Sidecar Containers
-	deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, kube.GetExtraContainers(harvester.Spec.Sidecars, chiaContainer)...)
+	template.Spec.Containers = append(template.Spec.Containers, kube.GetExtraContainers(harvester.Spec.Sidecars, chiaContainer)...)
 	// Add Sidecar Container Volumes
 	for _, sidecar := range harvester.Spec.Sidecars {
-		deploy.Spec.Template.Spec.Volumes = append(deploy.Spec.Template.Spec.Volumes, sidecar.Volumes...)
+		template.Spec.Volumes = append(template.Spec.Volumes, sidecar.Volumes...)
 	}
 
 	if harvester.Spec.ImagePullSecrets != nil && len(*harvester.Spec.ImagePullSecrets) != 0 {
-		deploy.Spec.Template.Spec.ImagePullSecrets = *harvester.Spec.ImagePullSecrets
+		template.Spec.ImagePullSecrets = *harvester.Spec.ImagePullSecrets
 	}
 
 	if kube.ChiaExporterEnabled(harvester.Spec.ChiaExporterConfig) {
-		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, assembleChiaExporterContainer(harvester))
+		template.Spec.Containers = append(template.Spec.Containers, assembleChiaExporterContainer(harvester))
 	}
 
 	if kube.ChiaHealthcheckEnabled(harvester.Spec.ChiaHealthcheckConfig) {
-		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, assembleChiaHealthcheckContainer(harvester))
-	}
-
-	if harvester.Spec.Strategy != nil {
-		deploy.Spec.Strategy = *harvester.Spec.Strategy
+		template.Spec.Containers = append(template.Spec.Containers, assembleChiaHealthcheckContainer(harvester))
 	}
 
 	if harvester.Spec.PodSecurityContext != nil {
-		deploy.Spec.Template.Spec.SecurityContext = harvester.Spec.PodSecurityContext
+		template.Spec.SecurityContext = harvester.Spec.PodSecurityContext
 	}
 
 	if len(harvester.Spec.Tolerations) > 0 {
-		deploy.Spec.Template.Spec.Tolerations = harvester.Spec.Tolerations
+		template.Spec.Tolerations = harvester.Spec.Tolerations
 	}
 
-	return deploy, nil
+	return template, nil
+}
+
+// assemblePlotDiscoveryContainer assembles the init container that adds the plot directories on a harvester's node to its config,
+// for a ChiaHarvester CR ran as a DaemonSet. Uses the chia container's image, since it runs the chia CLI.
+func assemblePlotDiscoveryContainer(harvester k8schianetv1.ChiaHarvester, chiaContainer corev1.Container) corev1.Container {
+	return corev1.Container{
+		Name:            "plot-discovery",
+		Image:           chiaContainer.Image,
+		ImagePullPolicy: chiaContainer.ImagePullPolicy,
+		SecurityContext: chiaContainer.SecurityContext,
+		Command:         []string{"/bin/bash", "-c", plotDiscoveryScript},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "CHIA_ROOT",
+				Value: "/chia-data",
+			},
+			{
+				Name: "NODE_NAME",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath: "spec.nodeName",
+					},
+				},
+			},
+			{
+				Name:  "PLOT_DIRECTORY_GLOBS",
+				Value: strings.Join(getPlotDirectoryGlobs(harvester), "\n"),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "chiaroot",
+				MountPath: "/chia-data",
+			},
+			{
+				Name:      plotDirectoriesVolumeName,
+				ReadOnly:  true,
+				MountPath: "/plot-directories",
+			},
+			getDaemonSetPlotsVolumeMount(harvester),
+		},
+	}
 }
 
 func assembleChiaContainer(harvester k8schianetv1.ChiaHarvester, networkData *map[string]string) (corev1.Container, error) {
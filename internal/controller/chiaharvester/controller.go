@@ -7,23 +7,34 @@ package chiaharvester
 import (
 	"context"
 	"fmt"
-	"strings"
+	"maps"
+	"slices"
 	"time"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/chiarpc"
+	"github.com/chia-network/chia-operator/internal/controller/common/component"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/finalizer"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
-	"github.com/chia-network/chia-operator/internal/metrics"
+	"github.com/chia-network/go-chia-libs/pkg/rpc"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -32,178 +43,339 @@ type ChiaHarvesterReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder events.EventRecorder
-}
 
-var chiaharvesters = make(map[string]bool)
+	// MaxConcurrentReconciles is the maximum number of ChiaHarvesters that can be reconciled at the same time, which defaults to 1
+	MaxConcurrentReconciles int
+}
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaharvesters,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaharvesters/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaharvesters/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch
-//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch;update
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is invoked on any event to a controlled Kubernetes resource
 func (r *ChiaHarvesterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := log.FromContext(ctx)
-	log.Info("Running reconciler...")
-
-	// Get the custom resource
 	var harvester k8schianetv1.ChiaHarvester
-	err := r.Get(ctx, req.NamespacedName, &harvester)
-	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chiaharvesters[req.String()]
-		if exists {
-			delete(chiaharvesters, req.String())
-			metrics.ChiaHarvesters.Sub(1.0)
+	engine := component.Engine{
+		Client:   r.Client,
+		Scheme:   r.Scheme,
+		Recorder: r.Recorder,
+		Kind:     consts.ChiaHarvesterKind,
+		Name:     "harvester",
+	}
+	return engine.Reconcile(ctx, req, &harvester, component.Hooks{
+		Describe: func() component.Resource {
+			// The ChiaHarvester's workload is a Deployment, or a DaemonSet if it runs on every matching node
+			workloadMeta := metav1.ObjectMeta{Name: fmt.Sprintf(chiaharvesterNamePattern, harvester.Name), Namespace: harvester.Namespace}
+			var workload client.Object = &appsv1.Deployment{ObjectMeta: workloadMeta}
+			if daemonSetEnabled(harvester) {
+				workload = &appsv1.DaemonSet{ObjectMeta: workloadMeta}
+			}
+			return component.Resource{
+				Meta:    harvester.ObjectMeta,
+				Chia:    harvester.Spec.ChiaConfig.CommonSpecChia,
+				Suspend: harvester.Spec.Suspend,
+				Status: component.Status{
+					Conditions:         &harvester.Status.Conditions,
+					ObservedGeneration: &harvester.Status.ObservedGeneration,
+					Ready:              &harvester.Status.Ready,
+				},
+				Workload: workload,
+			}
+		},
+		FinalizerInputs: func(ctx context.Context) (finalizer.Inputs, error) {
+			return finalizer.Inputs{
+				DeletionPolicy:   harvester.Spec.DeletionPolicy,
+				VolumeClaimNames: getGeneratedVolumeClaimNames(harvester),
+			}, nil
+		},
+		Assemble: func(ctx context.Context, c *component.Reconciler, networkData *map[string]string) (component.Component, error) {
+			return r.assembleComponent(ctx, c, harvester, networkData)
+		},
+		Reconciled: func(ctx context.Context, c *component.Reconciler, comp component.Component) (time.Duration, error) {
+			return r.afterReconcile(ctx, &harvester, comp.Workload)
+		},
+	})
+}
+
+// assembleComponent reconciles the ChiaHarvester's generated plot PersistentVolumeClaims, and assembles its other resources
+func (r *ChiaHarvesterReconciler) assembleComponent(ctx context.Context, c *component.Reconciler, harvester k8schianetv1.ChiaHarvester, networkData *map[string]string) (component.Component, error) {
+	// Creates persistent volume claims for plot volumes that set GenerateVolumeClaims. They aren't controlled by the harvester,
+	// so they're only deleted according to its deletion policy, and claims removed from the harvester are left alone.
+	if harvester.Spec.Storage != nil && harvester.Spec.Storage.Plots != nil {
+		for _, vol := range harvester.Spec.Storage.Plots.PersistentVolumeClaim {
+			if vol == nil || !vol.GenerateVolumeClaims {
+				continue
+			}
+
+			pvc, err := assemblePlotVolumeClaim(harvester, *vol)
+			if err != nil {
+				return component.Component{}, kube.NewConditionError(k8schianetv1.ConditionTypeConfigValid, kube.ReasonInvalidConfig, err)
+			}
+
+			_, err = kube.ReconcilePlotPersistentVolumeClaim(ctx, r.Client, pvc)
+			if err != nil {
+				c.CreateFailed("plot PVC")
+				return component.Component{}, err
+			}
 		}
-		return ctrl.Result{}, nil
 	}
-	if err != nil {
-		log.Error(err, fmt.Sprintf("ChiaHarvesterReconciler ChiaHarvester=%s unable to fetch ChiaHarvester resource", req.NamespacedName))
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+
+	// Assemble the harvester's workload, which is a DaemonSet on every matching node or a single Deployment
+	var workload client.Object
+	if daemonSetEnabled(harvester) {
+		// Assemble plot directories ConfigMap
+		var nodes corev1.NodeList
+		if err := r.List(ctx, &nodes); err != nil {
+			return component.Component{}, fmt.Errorf("error listing Nodes: %w", err)
+		}
+		plotDirectories := assemblePlotDirectoriesConfigMap(harvester, nodes.Items)
+		if err := controllerutil.SetControllerReference(&harvester, &plotDirectories, r.Scheme); err != nil {
+			c.AssembleFailed("plot directories ConfigMap")
+			return component.Component{}, fmt.Errorf("encountered error assembling plot directories ConfigMap: %w", err)
+		}
+		// Find the nodes whose plot directories changed, since each harvester Pod only reads its own node's plot directories when it starts
+		var changedNodes []string
+		var current corev1.ConfigMap
+		err := r.Get(ctx, client.ObjectKeyFromObject(&plotDirectories), &current)
+		if err != nil && !errors.IsNotFound(err) {
+			return component.Component{}, fmt.Errorf("error getting ConfigMap \"%s\": %w", plotDirectories.Name, err)
+		}
+		if err == nil {
+			changedNodes = getChangedNodes(current.Data, plotDirectories.Data)
+		}
+		// Reconcile plot directories ConfigMap
+		if _, err := kube.ReconcileConfigMap(ctx, r.Client, plotDirectories); err != nil {
+			c.CreateFailed("plot directories ConfigMap")
+			return component.Component{}, err
+		}
+
+		// Assemble DaemonSet
+		ds, err := assembleDaemonSet(harvester, networkData)
+		if err != nil {
+			c.AssembleFailed("DaemonSet")
+			return component.Component{}, err
+		}
+		if err := r.restartNodePods(ctx, harvester, ds.Spec.Selector, changedNodes); err != nil {
+			return component.Component{}, err
+		}
+		workload = &ds
+	} else {
+		// Assemble Deployment
+		deploy, err := assembleDeployment(harvester, networkData)
+		if err != nil {
+			c.AssembleFailed("Deployment")
+			return component.Component{}, err
+		}
+		workload = &deploy
 	}
 
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chiaharvesters[req.String()]
-	if !exists {
-		chiaharvesters[req.String()] = true
-		metrics.ChiaHarvesters.Add(1.0)
+	services := []component.Service{
+		{Description: "peer", Desired: assemblePeerService(harvester), Config: harvester.Spec.ChiaConfig.PeerService, DefaultEnabled: true},
+		{Description: "all-port", Desired: assembleAllService(harvester), Config: harvester.Spec.ChiaConfig.AllService, DefaultEnabled: true},
+		{Description: "daemon", Desired: assembleDaemonService(harvester), Config: harvester.Spec.ChiaConfig.DaemonService, DefaultEnabled: true},
+		{Description: "RPC", Desired: assembleRPCService(harvester), Config: harvester.Spec.ChiaConfig.RPCService, DefaultEnabled: true},
+		{Description: "chia-exporter", Desired: assembleChiaExporterService(harvester), Config: harvester.Spec.ChiaExporterConfig.Service, DefaultEnabled: true},
 	}
+	if !kube.ShouldRollIntoMainPeerService(harvester.Spec.ChiaHealthcheckConfig.Service) {
+		services = append(services, component.Service{Description: "chia-healthcheck", Desired: assembleChiaHealthcheckService(harvester), Config: harvester.Spec.ChiaHealthcheckConfig.Service})
+	}
+	return component.Component{
+		Services: services,
+		VolumeClaims: []component.VolumeClaim{{
+			Description: "CHIA_ROOT",
+			Enabled:     kube.ShouldMakeChiaRootVolumeClaim(harvester.Spec.Storage),
+			Storage:     harvester.Spec.Storage,
+			Assemble:    func() (*corev1.PersistentVolumeClaim, error) { return assembleVolumeClaim(harvester) },
+		}},
+		Workload: workload,
+		PodDisruptionBudget: component.PodDisruptionBudget{
+			Desired: assemblePodDisruptionBudget(harvester),
+			Config:  harvester.Spec.PodDisruptionBudget,
+		},
+	}, nil
+}
 
-	// Check for ChiaNetwork, retrieve matching ConfigMap if specified
-	networkData, err := kube.GetChiaNetworkData(ctx, r.Client, harvester.Spec.ChiaConfig.CommonSpecChia, harvester.Namespace)
-	if err != nil {
-		return ctrl.Result{}, err
+// afterReconcile removes the workload left over from running as the other kind of workload, and records the harvester RPC status
+// and plot PersistentVolumeClaims in the ChiaHarvester's status. Returns the amount of time until the next RPC status query is due.
+func (r *ChiaHarvesterReconciler) afterReconcile(ctx context.Context, harvester *k8schianetv1.ChiaHarvester, workload client.Object) (time.Duration, error) {
+	var selector *metav1.LabelSelector
+	switch w := workload.(type) {
+	case *appsv1.DaemonSet:
+		if err := r.deleteOwnedObject(ctx, *harvester, &appsv1.Deployment{}, workload.GetName()); err != nil {
+			return 0, err
+		}
+		selector = w.Spec.Selector
+	case *appsv1.Deployment:
+		if err := r.deleteOwnedObject(ctx, *harvester, &appsv1.DaemonSet{}, workload.GetName()); err != nil {
+			return 0, err
+		}
+		if err := r.deleteOwnedObject(ctx, *harvester, &corev1.ConfigMap{}, fmt.Sprintf(chiaharvesterPlotDirectoriesNamePattern, harvester.Name)); err != nil {
+			return 0, err
+		}
+		selector = w.Spec.Selector
 	}
 
-	// Assemble Peer Service
-	peerSrv := assemblePeerService(harvester)
-	if err := controllerutil.SetControllerReference(&harvester, &peerSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&harvester, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble harvester peer Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error assembling peer Service: %v", req.NamespacedName, err)
+	rpcRequeueAfter, err := r.updateRPCStatus(ctx, harvester, selector)
+	if err != nil {
+		log.FromContext(ctx).Error(err, fmt.Sprintf("ChiaHarvesterReconciler ChiaHarvester=%s unable to query harvester RPC status", client.ObjectKeyFromObject(harvester)))
 	}
-	// Reconcile Peer Service
-	res, err := kube.ReconcileService(ctx, r.Client, harvester.Spec.ChiaConfig.PeerService, peerSrv, true)
+	plotClaims, err := r.getPlotVolumeClaimStatuses(ctx, *harvester)
 	if err != nil {
-		return res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s %v", req.NamespacedName, err)
+		return 0, err
 	}
+	harvester.Status.PlotVolumeClaims = plotClaims
+	return rpcRequeueAfter, nil
+}
 
-	// Assemble All Service
-	allSrv := assembleAllService(harvester)
-	if err := controllerutil.SetControllerReference(&harvester, &allSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&harvester, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble harvester all-port Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error assembling all-port Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile All Service
-	res, err = kube.ReconcileService(ctx, r.Client, harvester.Spec.ChiaConfig.AllService, allSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s %v", req.NamespacedName, err)
+// updateRPCStatus queries the harvester RPC server in the first running Pod matching the workload's selector and records its plot inventory in the ChiaHarvester's status.
+// For harvesters ran as a DaemonSet, every running Pod is queried and their plot inventories are added up.
+// Returns the amount of time until the next query is due, or 0 if RPC status queries are disabled.
+func (r *ChiaHarvesterReconciler) updateRPCStatus(ctx context.Context, harvester *k8schianetv1.ChiaHarvester, selector *metav1.LabelSelector) (time.Duration, error) {
+	if !kube.RPCStatusEnabled(harvester.Spec.RPCStatus) {
+		harvester.Status.Plots = nil
+		return 0, nil
 	}
 
-	// Assemble Daemon Service
-	daemonSrv := assembleDaemonService(harvester)
-	if err := controllerutil.SetControllerReference(&harvester, &daemonSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&harvester, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble harvester daemon Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error assembling daemon Service: %v", req.NamespacedName, err)
+	// Only query the RPC server once per interval, since every status update triggers another reconcile
+	interval := kube.GetRPCStatusInterval(harvester.Spec.RPCStatus)
+	now := time.Now()
+	if harvester.Status.Plots != nil {
+		if requeueAfter := kube.GetRPCStatusRequeueAfter(&harvester.Status.Plots.LastChecked, interval, now); requeueAfter > 0 {
+			return requeueAfter, nil
+		}
 	}
-	// Reconcile Daemon Service
-	res, err = kube.ReconcileService(ctx, r.Client, harvester.Spec.ChiaConfig.DaemonService, daemonSrv, true)
+
+	rpcClient, err := chiarpc.GetClient(ctx, r.Client, harvester.Namespace, harvester.Spec.ChiaConfig.CASecretName)
 	if err != nil {
-		return res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s %v", req.NamespacedName, err)
+		return interval, err
 	}
 
-	// Assemble RPC Service
-	rpcSrv := assembleRPCService(harvester)
-	if err := controllerutil.SetControllerReference(&harvester, &rpcSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&harvester, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble harvester RPC Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error assembling RPC Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile RPC Service
-	res, err = kube.ReconcileService(ctx, r.Client, harvester.Spec.ChiaConfig.RPCService, rpcSrv, true)
+	pods, err := kube.ListPods(ctx, r.Client, harvester.Namespace, selector)
 	if err != nil {
-		return res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s %v", req.NamespacedName, err)
+		return interval, err
 	}
-
-	// Assemble Chia-Exporter Service
-	exporterSrv := assembleChiaExporterService(harvester)
-	if err := controllerutil.SetControllerReference(&harvester, &exporterSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&harvester, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble harvester chia-exporter Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error assembling chia-exporter Service: %v", req.NamespacedName, err)
+	running := kube.GetRunningPods(pods)
+	if len(running) == 0 {
+		// Nothing to query yet, check again on the next reconcile
+		return interval, nil
 	}
-	// Reconcile Chia-Exporter Service
-	res, err = kube.ReconcileService(ctx, r.Client, harvester.Spec.ChiaExporterConfig.Service, exporterSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s %v", req.NamespacedName, err)
+	// A harvester ran as a DaemonSet has a Pod on each node with that node's plots, so its inventory is the sum of every Pod's inventory
+	daemonSet := daemonSetEnabled(*harvester)
+	if !daemonSet {
+		running = running[:1]
 	}
 
-	// Assemble Chia-Healthcheck Service
-	healthcheckSrv := assembleChiaHealthcheckService(harvester)
-	if err := controllerutil.SetControllerReference(&harvester, &healthcheckSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&harvester, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble harvester chia-healthcheck Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s encountered error assembling chia-healthcheck Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile Chia-Healthcheck Service
-	if !kube.ShouldRollIntoMainPeerService(harvester.Spec.ChiaHealthcheckConfig.Service) {
-		res, err = kube.ReconcileService(ctx, r.Client, harvester.Spec.ChiaHealthcheckConfig.Service, healthcheckSrv, false)
+	var responses []*rpc.HarvesterGetPlotsResponse
+	for _, pod := range running {
+		plots, err := rpcClient.GetHarvesterPlots(ctx, pod.Status.PodIP)
 		if err != nil {
-			return res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s %v", req.NamespacedName, err)
+			// Keep the last known inventory, but record the error
+			status := k8schianetv1.ChiaHarvesterPlotStatus{}
+			if harvester.Status.Plots != nil {
+				status = *harvester.Status.Plots
+			}
+			status.PodName = pod.Name
+			status.LastChecked = metav1.NewTime(now)
+			status.Error = err.Error()
+			harvester.Status.Plots = &status
+			return interval, nil
 		}
+		responses = append(responses, plots)
 	}
 
-	// Creates a persistent volume claim if the GenerateVolumeClaims setting was set to true
-	if kube.ShouldMakeChiaRootVolumeClaim(harvester.Spec.Storage) {
-		pvc, err := assembleVolumeClaim(harvester)
-		if err != nil {
-			r.Recorder.Eventf(&harvester, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble harvester PVC -- Check operator logs.")
-			return reconcile.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s %v", req.NamespacedName, err)
+	status := summarizePlots(mergePlots(responses), getPlotVolumeMounts(*harvester))
+	if !daemonSet {
+		status.PodName = running[0].Name
+	}
+	status.PodCount = int32(len(running))
+	status.LastChecked = metav1.NewTime(now)
+	harvester.Status.Plots = &status
+
+	return interval, nil
+}
+
+// getPlotVolumeClaimStatuses returns the state of each of the harvester's plot PVCs
+func (r *ChiaHarvesterReconciler) getPlotVolumeClaimStatuses(ctx context.Context, harvester k8schianetv1.ChiaHarvester) ([]k8schianetv1.ChiaHarvesterPlotVolumeClaimStatus, error) {
+	if harvester.Spec.Storage == nil || harvester.Spec.Storage.Plots == nil {
+		return nil, nil
+	}
+
+	var statuses []k8schianetv1.ChiaHarvesterPlotVolumeClaimStatus
+	for i, vol := range harvester.Spec.Storage.Plots.PersistentVolumeClaim {
+		if vol == nil {
+			continue
 		}
 
-		if pvc != nil {
-			res, err = kube.ReconcilePersistentVolumeClaim(ctx, r.Client, harvester.Spec.Storage, *pvc)
-			if err != nil {
-				r.Recorder.Eventf(&harvester, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to create harvester PVC -- Check operator logs.")
-				return res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s %v", req.NamespacedName, err)
-			}
+		name := getPlotVolumeClaimName(harvester, *vol)
+		if name == "" {
+			continue
+		}
+		var pvc corev1.PersistentVolumeClaim
+		err := r.Get(ctx, types.NamespacedName{Namespace: harvester.Namespace, Name: name}, &pvc)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("error getting PersistentVolumeClaim \"%s\": %w", name, err)
+		}
+		if err != nil {
+			statuses = append(statuses, getPlotVolumeClaimStatus(harvester, i, *vol, nil))
 		} else {
-			return reconcile.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s PVC could not be created", req.NamespacedName)
+			statuses = append(statuses, getPlotVolumeClaimStatus(harvester, i, *vol, &pvc))
 		}
 	}
+	return statuses, nil
+}
 
-	// Assemble Deployment
-	deploy, err := assembleDeployment(harvester, networkData)
+// restartNodePods deletes the harvester Pods running on the given nodes, so the DaemonSet recreates them and they discover their node's
+// new plot directories. The harvester Pods on other nodes keep running.
+func (r *ChiaHarvesterReconciler) restartNodePods(ctx context.Context, harvester k8schianetv1.ChiaHarvester, selector *metav1.LabelSelector, nodes []string) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+	podSelector, err := metav1.LabelSelectorAsSelector(selector)
 	if err != nil {
-		r.Recorder.Eventf(&harvester, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble harvester Deployment -- Check operator logs.")
-		return reconcile.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s %v", req.NamespacedName, err)
+		return fmt.Errorf("error parsing DaemonSet selector: %w", err)
 	}
-	if err := controllerutil.SetControllerReference(&harvester, &deploy, r.Scheme); err != nil {
-		r.Recorder.Eventf(&harvester, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble harvester Deployment -- Check operator logs.")
-		return reconcile.Result{}, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s %v", req.NamespacedName, err)
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(harvester.Namespace), client.MatchingLabelsSelector{Selector: podSelector}); err != nil {
+		return fmt.Errorf("error listing harvester Pods: %w", err)
 	}
-	// Reconcile Deployment
-	res, err = kube.ReconcileDeployment(ctx, r.Client, deploy)
-	if err != nil {
-		r.Recorder.Eventf(&harvester, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to create harvester Deployment -- Check operator logs.")
-		return res, fmt.Errorf("ChiaHarvesterReconciler ChiaHarvester=%s %v", req.NamespacedName, err)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !slices.Contains(nodes, pod.Spec.NodeName) || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		log.FromContext(ctx).Info(fmt.Sprintf("Restarting harvester Pod %s because the plot directories on node %s changed", pod.Name, pod.Spec.NodeName))
+		if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("error deleting Pod \"%s\": %w", pod.Name, err)
+		}
 	}
+	return nil
+}
 
-	// Update CR status
-	r.Recorder.Eventf(&harvester, nil, corev1.EventTypeNormal, "Created", "Created", "Successfully created ChiaHarvester resources.")
-	harvester.Status.Ready = true
-	err = r.Status().Update(ctx, &harvester)
+// deleteOwnedObject deletes the named object in the harvester's namespace if it exists and is controlled by the harvester
+func (r *ChiaHarvesterReconciler) deleteOwnedObject(ctx context.Context, harvester k8schianetv1.ChiaHarvester, obj client.Object, name string) error {
+	err := r.Get(ctx, types.NamespacedName{Namespace: harvester.Namespace, Name: name}, obj)
 	if err != nil {
-		if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
-			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
-		}
-		log.Error(err, fmt.Sprintf("ChiaHarvesterReconciler ChiaHarvester=%s unable to update ChiaHarvester status", req.NamespacedName))
-		return ctrl.Result{}, err
+		return client.IgnoreNotFound(err)
 	}
-
-	return ctrl.Result{}, nil
+	if !metav1.IsControlledBy(obj, &harvester) {
+		return nil
+	}
+	if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("error deleting %T \"%s\": %w", obj, name, err)
+	}
+	return nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -211,14 +383,72 @@ func (r *ChiaHarvesterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&k8schianetv1.ChiaHarvester{}).
 		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.DaemonSet{}).
 		Owns(&corev1.Service{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
 		Watches(
 			&corev1.ConfigMap{},
 			handler.EnqueueRequestsFromMapFunc(r.handleChiaNetworks),
 		).
+		Watches(
+			&corev1.Node{},
+			handler.EnqueueRequestsFromMapFunc(r.handleNodes),
+			builder.WithPredicates(plotDirectoriesChangedPredicate()),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
+// handleNodes requeues every ChiaHarvester ran as a DaemonSet when a node's plot directories change
+func (r *ChiaHarvesterReconciler) handleNodes(ctx context.Context, _ client.Object) []reconcile.Request {
+	list := &k8schianetv1.ChiaHarvesterList{}
+	err := r.List(ctx, list)
+	if err != nil {
+		return []reconcile.Request{}
+	}
+
+	var requests []reconcile.Request
+	for _, item := range list.Items {
+		if daemonSetEnabled(item) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      item.GetName(),
+					Namespace: item.GetNamespace(),
+				},
+			})
+		}
+	}
+	return requests
+}
+
+// plotDirectoriesChangedPredicate filters Node events down to those that add, change, or remove the plot directories annotation,
+// or change the labels of a node with the annotation, which can change whether a harvester's DaemonSet selects it
+func plotDirectoriesChangedPredicate() predicate.Predicate {
+	hasAnnotation := func(obj client.Object) bool {
+		_, ok := obj.GetAnnotations()[plotDirectoriesAnnotation]
+		return ok
+	}
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return hasAnnotation(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldDirs, oldOk := e.ObjectOld.GetAnnotations()[plotDirectoriesAnnotation]
+			newDirs, newOk := e.ObjectNew.GetAnnotations()[plotDirectoriesAnnotation]
+			if oldOk != newOk || oldDirs != newDirs {
+				return true
+			}
+			return newOk && !maps.Equal(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels())
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return hasAnnotation(e.Object)
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
 func (r *ChiaHarvesterReconciler) handleChiaNetworks(ctx context.Context, obj client.Object) []reconcile.Request {
 	listOps := &client.ListOptions{
 		Namespace: obj.GetNamespace(),
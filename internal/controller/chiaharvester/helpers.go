@@ -6,13 +6,35 @@ package chiaharvester
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
+	"github.com/chia-network/chia-operator/internal/controller/common/chiarpc"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+	"github.com/chia-network/go-chia-libs/pkg/protocols"
+	"github.com/chia-network/go-chia-libs/pkg/rpc"
+	"github.com/samber/mo"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
 	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+)
+
+const (
+	// maxListedPlots is the maximum number of plot filenames listed in each list of a ChiaHarvester's plot status
+	maxListedPlots = 10
+
+	// plotDirectoriesAnnotation is the node annotation that lists the plot directories on a node, for harvesters ran as a DaemonSet
+	plotDirectoriesAnnotation = "k8s.chia.net/plot-directories"
+
+	// daemonSetPlotsVolumeName is the name of the hostPath volume that contains each node's plot directories, for harvesters ran as a DaemonSet
+	daemonSetPlotsVolumeName = "daemonset-plots"
+
+	// plotDirectoriesVolumeName is the name of the ConfigMap volume that contains each node's plot directories, for harvesters ran as a DaemonSet
+	plotDirectoriesVolumeName = "plot-directories"
 )
 
 // getChiaVolumes retrieves the requisite volumes from the Chia config struct
@@ -54,7 +76,7 @@ func getChiaVolumes(harvester k8schianetv1.ChiaHarvester) []corev1.Volume {
 							Name: fmt.Sprintf("pvc-plots-%d", i),
 							VolumeSource: corev1.VolumeSource{
 								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-									ClaimName: vol.ClaimName,
+									ClaimName: getPlotVolumeClaimName(harvester, *vol),
 								},
 							},
 						})
@@ -80,6 +102,33 @@ func getChiaVolumes(harvester k8schianetv1.ChiaHarvester) []corev1.Volume {
 		}
 	}
 
+	// node-local plot volumes
+	if daemonSetEnabled(harvester) {
+		hostPathType := corev1.HostPathDirectory
+		v = append(v, corev1.Volume{
+			Name: daemonSetPlotsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: harvester.Spec.DaemonSet.HostPath,
+					Type: &hostPathType,
+				},
+			},
+		})
+
+		optional := true
+		v = append(v, corev1.Volume{
+			Name: plotDirectoriesVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: fmt.Sprintf(chiaharvesterPlotDirectoriesNamePattern, harvester.Name),
+					},
+					Optional: &optional,
+				},
+			},
+		})
+	}
+
 	return v
 }
 
@@ -106,11 +155,7 @@ func getChiaVolumeMounts(harvester k8schianetv1.ChiaHarvester) []corev1.VolumeMo
 			if harvester.Spec.Storage.Plots.PersistentVolumeClaim != nil {
 				for i, vol := range harvester.Spec.Storage.Plots.PersistentVolumeClaim {
 					if vol != nil {
-						v = append(v, corev1.VolumeMount{
-							Name:      fmt.Sprintf("pvc-plots-%d", i),
-							ReadOnly:  true,
-							MountPath: fmt.Sprintf("/plots/pvc-plots-%d", i),
-						})
+						v = append(v, getPlotVolumeMount(fmt.Sprintf("pvc-plots-%d", i), vol.MountPath, vol.SubPath))
 					}
 				}
 			}
@@ -119,20 +164,47 @@ func getChiaVolumeMounts(harvester k8schianetv1.ChiaHarvester) []corev1.VolumeMo
 			if harvester.Spec.Storage.Plots.HostPathVolume != nil {
 				for i, vol := range harvester.Spec.Storage.Plots.HostPathVolume {
 					if vol != nil {
-						v = append(v, corev1.VolumeMount{
-							Name:      fmt.Sprintf("hostpath-plots-%d", i),
-							ReadOnly:  true,
-							MountPath: fmt.Sprintf("/plots/hostpath-plots-%d", i),
-						})
+						v = append(v, getPlotVolumeMount(fmt.Sprintf("hostpath-plots-%d", i), vol.MountPath, vol.SubPath))
 					}
 				}
 			}
 		}
 	}
 
+	// node-local plot volume mount
+	if daemonSetEnabled(harvester) {
+		v = append(v, getDaemonSetPlotsVolumeMount(harvester))
+	}
+
 	return v
 }
 
+// getPlotVolumeMount returns the read-only volume mount for a plot volume, which is mounted at /plots/<volume name> unless the volume sets a mount path
+func getPlotVolumeMount(name, mountPath, subPath string) corev1.VolumeMount {
+	if mountPath == "" {
+		mountPath = fmt.Sprintf("/plots/%s", name)
+	}
+	return corev1.VolumeMount{
+		Name:      name,
+		ReadOnly:  true,
+		MountPath: mountPath,
+		SubPath:   subPath,
+	}
+}
+
+// getDaemonSetPlotsVolumeMount returns the volume mount for the node-local plot directories of a harvester ran as a DaemonSet.
+// The directory is mounted at the same path as on the node so the paths in node annotations and path globs resolve in the container,
+// and disks mounted on the node after the harvester starts show up in the container.
+func getDaemonSetPlotsVolumeMount(harvester k8schianetv1.ChiaHarvester) corev1.VolumeMount {
+	propagation := corev1.MountPropagationHostToContainer
+	return corev1.VolumeMount{
+		Name:             daemonSetPlotsVolumeName,
+		ReadOnly:         true,
+		MountPath:        harvester.Spec.DaemonSet.HostPath,
+		MountPropagation: &propagation,
+	}
+}
+
 // getChiaEnv retrieves the environment variables from the Chia config struct
 func getChiaEnv(harvester k8schianetv1.ChiaHarvester, networkData *map[string]string) ([]corev1.EnvVar, error) {
 	var env []corev1.EnvVar
@@ -149,13 +221,20 @@ func getChiaEnv(harvester k8schianetv1.ChiaHarvester, networkData *map[string]st
 		Value: "none",
 	})
 
-	// recursive_plot_scan env var -- needed because all plot drives are just mounted as subdirs under `/plots`.
-	// TODO should we make plot mount paths configurable -- making this var optional? Is there a major con to using the recursive plot scan feature?
+	// recursive_plot_scan env var -- enabled by default because plot volumes are mounted as subdirs under `/plots` by default
 	env = append(env, corev1.EnvVar{
 		Name:  "recursive_plot_scan",
-		Value: "true",
+		Value: strconv.FormatBool(recursivePlotScanEnabled(harvester)),
 	})
 
+	// plots_dir env var -- a colon separated list of the directories to add to the harvester's plot_directories config
+	if dirs := getPlotDirectories(harvester); len(dirs) != 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "plots_dir",
+			Value: strings.Join(dirs, ":"),
+		})
+	}
+
 	// farmer peer env vars
 	env = append(env, corev1.EnvVar{
 		Name:  "farmer_address",
@@ -175,3 +254,261 @@ func getChiaEnv(harvester k8schianetv1.ChiaHarvester, networkData *map[string]st
 
 	return env, nil
 }
+
+// recursivePlotScanEnabled returns true if the harvester scans the subdirectories of its plot directories for plots, which it does by default
+func recursivePlotScanEnabled(harvester k8schianetv1.ChiaHarvester) bool {
+	if harvester.Spec.Storage == nil || harvester.Spec.Storage.Plots == nil || harvester.Spec.Storage.Plots.RecursivePlotScan == nil {
+		return true
+	}
+	return *harvester.Spec.Storage.Plots.RecursivePlotScan
+}
+
+// getPlotDirectories returns the directories in the harvester container to add to the harvester's plot_directories config.
+// Uses the harvester's explicit list of plot directories if it has one, otherwise the mount path of each plot volume.
+// Plot directories on the nodes of a harvester ran as a DaemonSet are added separately, when each Pod starts.
+func getPlotDirectories(harvester k8schianetv1.ChiaHarvester) []string {
+	if harvester.Spec.Storage == nil || harvester.Spec.Storage.Plots == nil {
+		return nil
+	}
+	if len(harvester.Spec.Storage.Plots.PlotDirectories) != 0 {
+		return harvester.Spec.Storage.Plots.PlotDirectories
+	}
+
+	var dirs []string
+	for _, mount := range getChiaVolumeMounts(harvester) {
+		if strings.HasPrefix(mount.Name, "pvc-plots-") || strings.HasPrefix(mount.Name, "hostpath-plots-") {
+			dirs = append(dirs, mount.MountPath)
+		}
+	}
+	return dirs
+}
+
+// getPlotVolumeClaimName returns the name of the PVC for one of a harvester's plot volumes, which is generated by the operator if GenerateVolumeClaims is set.
+// Generated PVCs are named after the plot volume's name rather than its position in the list, so reordering the list doesn't change which PVC a volume uses.
+func getPlotVolumeClaimName(harvester k8schianetv1.ChiaHarvester, vol k8schianetv1.PersistentVolumeClaimConfig) string {
+	if vol.GenerateVolumeClaims {
+		return fmt.Sprintf(chiaharvesterPlotVolumeClaimNamePattern, harvester.Name, vol.Name)
+	}
+	return vol.ClaimName
+}
+
+// getPlotVolumeClaimStatus returns the status of one of a harvester's plot PVCs, from the PVC if it exists
+func getPlotVolumeClaimStatus(harvester k8schianetv1.ChiaHarvester, index int, vol k8schianetv1.PersistentVolumeClaimConfig, pvc *corev1.PersistentVolumeClaim) k8schianetv1.ChiaHarvesterPlotVolumeClaimStatus {
+	status := k8schianetv1.ChiaHarvesterPlotVolumeClaimStatus{
+		Name:      fmt.Sprintf("pvc-plots-%d", index),
+		ClaimName: getPlotVolumeClaimName(harvester, vol),
+		Generated: vol.GenerateVolumeClaims,
+	}
+	if pvc != nil {
+		status.Phase = pvc.Status.Phase
+		if capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+			status.Capacity = &capacity
+		}
+	}
+	return status
+}
+
+// daemonSetEnabled returns true if the harvester runs as a DaemonSet instead of as a Deployment
+func daemonSetEnabled(harvester k8schianetv1.ChiaHarvester) bool {
+	return harvester.Spec.DaemonSet != nil && harvester.Spec.DaemonSet.Enabled != nil && *harvester.Spec.DaemonSet.Enabled
+}
+
+// getPlotDirectoryGlobs returns the glob patterns a harvester ran as a DaemonSet uses to find the plot directories on nodes without the plot directories annotation
+func getPlotDirectoryGlobs(harvester k8schianetv1.ChiaHarvester) []string {
+	if len(harvester.Spec.DaemonSet.PathGlobs) != 0 {
+		return harvester.Spec.DaemonSet.PathGlobs
+	}
+	return []string{harvester.Spec.DaemonSet.HostPath}
+}
+
+// getNodePlotDirectories returns the plot directories listed in each node's plot directories annotation, keyed by node name.
+// Each node's directories are separated by newlines. Nodes without the annotation, and nodes the harvester's DaemonSet doesn't
+// select, are left out, so changes to nodes the harvester doesn't run on don't roll its Pods.
+func getNodePlotDirectories(harvester k8schianetv1.ChiaHarvester, nodes []corev1.Node) map[string]string {
+	directories := make(map[string]string)
+	for _, node := range nodes {
+		annotation, ok := node.Annotations[plotDirectoriesAnnotation]
+		if !ok || !nodeSelectedByHarvester(harvester, node) {
+			continue
+		}
+
+		var dirs []string
+		for _, dir := range strings.Split(annotation, ",") {
+			if dir = strings.TrimSpace(dir); dir != "" {
+				dirs = append(dirs, dir)
+			}
+		}
+		if len(dirs) != 0 {
+			directories[node.Name] = strings.Join(dirs, "\n")
+		}
+	}
+	return directories
+}
+
+// nodeSelectedByHarvester returns true if a harvester's nodeSelector and required node affinity select a node.
+// Taints aren't considered, since nodes are tainted and untainted as their conditions change.
+func nodeSelectedByHarvester(harvester k8schianetv1.ChiaHarvester, node corev1.Node) bool {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			NodeSelector: harvester.Spec.NodeSelector,
+			Affinity:     harvester.Spec.Affinity,
+		},
+	}
+	match, err := nodeaffinity.GetRequiredNodeAffinity(pod).Match(&node)
+	return err == nil && match
+}
+
+// getChangedNodes returns the nodes whose plot directories were added, changed, or removed between two versions of the plot directories ConfigMap's data
+func getChangedNodes(current, desired map[string]string) []string {
+	var nodes []string
+	for node, dirs := range desired {
+		if currentDirs, ok := current[node]; !ok || currentDirs != dirs {
+			nodes = append(nodes, node)
+		}
+	}
+	for node := range current {
+		if _, ok := desired[node]; !ok {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// getPlotVolumeMounts returns the volume mounts for the harvester's plot volumes
+func getPlotVolumeMounts(harvester k8schianetv1.ChiaHarvester) []corev1.VolumeMount {
+	var mounts []corev1.VolumeMount
+	for _, mount := range getChiaVolumeMounts(harvester) {
+		if strings.HasPrefix(mount.Name, "pvc-plots-") || strings.HasPrefix(mount.Name, "hostpath-plots-") || mount.Name == daemonSetPlotsVolumeName {
+			mounts = append(mounts, mount)
+		}
+	}
+	return mounts
+}
+
+// mergePlots combines the plot inventories reported by several harvester RPC servers into one
+func mergePlots(responses []*rpc.HarvesterGetPlotsResponse) *rpc.HarvesterGetPlotsResponse {
+	if len(responses) == 1 {
+		return responses[0]
+	}
+	var plots []protocols.Plot
+	var failedToOpen, notFound []string
+	for _, response := range responses {
+		plots = append(plots, response.Plots.OrEmpty()...)
+		failedToOpen = append(failedToOpen, response.FailedToOpenFilenames.OrEmpty()...)
+		notFound = append(notFound, response.NotFoundFilenames.OrEmpty()...)
+	}
+	return &rpc.HarvesterGetPlotsResponse{
+		Plots:                 mo.Some(plots),
+		FailedToOpenFilenames: mo.Some(failedToOpen),
+		NotFoundFilenames:     mo.Some(notFound),
+	}
+}
+
+// summarizePlots assembles a harvester's plot status from its get_plots RPC response, grouping plots by the plot volume they were found in
+func summarizePlots(plots *rpc.HarvesterGetPlotsResponse, mounts []corev1.VolumeMount) k8schianetv1.ChiaHarvesterPlotStatus {
+	type volumeTotals struct {
+		plotCount      int32
+		rawSize        uint64
+		effectiveSize  uint64
+		invalidCount   int32
+		duplicateCount int32
+	}
+	volumes := make([]volumeTotals, len(mounts))
+
+	// Returns the index of the plot volume a plot file is in, or -1 if it isn't in any of them
+	volumeIndex := func(filename string) int {
+		for i, mount := range mounts {
+			if strings.HasPrefix(filename, strings.TrimSuffix(mount.MountPath, "/")+"/") {
+				return i
+			}
+		}
+		return -1
+	}
+
+	var status k8schianetv1.ChiaHarvesterPlotStatus
+	var rawSize, effectiveSize uint64
+
+	loaded := plots.Plots.OrEmpty()
+	plotIDs := make(map[string]int, len(loaded))
+	for _, plot := range loaded {
+		plotIDs[plot.PlotID.String()]++
+	}
+
+	for _, plot := range loaded {
+		status.PlotCount++
+		rawSize += plot.FileSize
+		effectiveSize += chiarpc.EffectivePlotSize(plot.Size)
+
+		duplicate := plotIDs[plot.PlotID.String()] > 1
+		if duplicate {
+			status.DuplicateCount++
+			if len(status.DuplicatePlots) < maxListedPlots {
+				status.DuplicatePlots = append(status.DuplicatePlots, plot.Filename)
+			}
+		}
+
+		if i := volumeIndex(plot.Filename); i >= 0 {
+			volumes[i].plotCount++
+			volumes[i].rawSize += plot.FileSize
+			volumes[i].effectiveSize += chiarpc.EffectivePlotSize(plot.Size)
+			if duplicate {
+				volumes[i].duplicateCount++
+			}
+		}
+	}
+
+	failedToOpen := plots.FailedToOpenFilenames.OrEmpty()
+	notFound := plots.NotFoundFilenames.OrEmpty()
+	status.FailedToOpenCount = int32(len(failedToOpen))
+	status.NotFoundCount = int32(len(notFound))
+	for _, filename := range append(append([]string{}, failedToOpen...), notFound...) {
+		if len(status.InvalidPlots) < maxListedPlots {
+			status.InvalidPlots = append(status.InvalidPlots, filename)
+		}
+		if i := volumeIndex(filename); i >= 0 {
+			volumes[i].invalidCount++
+		}
+	}
+
+	status.TotalRawSize = bytesToQuantity(rawSize)
+	status.TotalEffectiveSize = bytesToQuantity(effectiveSize)
+	for i, mount := range mounts {
+		status.Volumes = append(status.Volumes, k8schianetv1.ChiaHarvesterPlotVolumeStatus{
+			Name:               mount.Name,
+			MountPath:          mount.MountPath,
+			PlotCount:          volumes[i].plotCount,
+			TotalRawSize:       bytesToQuantity(volumes[i].rawSize),
+			TotalEffectiveSize: bytesToQuantity(volumes[i].effectiveSize),
+			InvalidCount:       volumes[i].invalidCount,
+			DuplicateCount:     volumes[i].duplicateCount,
+		})
+	}
+	sort.Strings(status.InvalidPlots)
+	sort.Strings(status.DuplicatePlots)
+
+	return status
+}
+
+// bytesToQuantity converts a number of bytes to a Quantity, rounded down to the nearest mebibyte so it's displayed with a binary suffix
+func bytesToQuantity(bytes uint64) resource.Quantity {
+	const mebibyte = 1024 * 1024
+	return *resource.NewQuantity(int64(bytes/mebibyte*mebibyte), resource.BinarySI)
+}
+
+// getGeneratedVolumeClaimNames returns the names of the PersistentVolumeClaims the operator generates for a ChiaHarvester,
+// including its generated plot PVCs
+func getGeneratedVolumeClaimNames(harvester k8schianetv1.ChiaHarvester) []string {
+	var names []string
+	if kube.ShouldMakeChiaRootVolumeClaim(harvester.Spec.Storage) {
+		names = append(names, fmt.Sprintf(chiaharvesterNamePattern, harvester.Name))
+	}
+	if harvester.Spec.Storage != nil && harvester.Spec.Storage.Plots != nil {
+		for _, vol := range harvester.Spec.Storage.Plots.PersistentVolumeClaim {
+			if vol != nil && vol.GenerateVolumeClaims {
+				names = append(names, getPlotVolumeClaimName(harvester, *vol))
+			}
+		}
+	}
+	return names
+}
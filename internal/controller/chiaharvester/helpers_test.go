@@ -1,14 +1,24 @@
 package chiaharvester
 
 import (
+	"encoding/json"
+	"maps"
 	"testing"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/go-chia-libs/pkg/rpc"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+var (
+	testTrue        = true
+	hostToContainer = corev1.MountPropagationHostToContainer
+)
+
 func TestGetChiaVolumeMounts(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -61,6 +71,55 @@ func TestGetChiaVolumeMounts(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "With Plot Mount Paths",
+			harvester: k8schianetv1.ChiaHarvester{
+				Spec: k8schianetv1.ChiaHarvesterSpec{
+					ChiaConfig: k8schianetv1.ChiaHarvesterSpecChia{
+						CASecretName: "test-ca-secret",
+					},
+					CommonSpec: k8schianetv1.CommonSpec{
+						Storage: &k8schianetv1.StorageConfig{
+							Plots: &k8schianetv1.PlotsConfig{
+								PersistentVolumeClaim: []*k8schianetv1.PersistentVolumeClaimConfig{
+									{
+										ClaimName: "plot-pvc-1",
+										MountPath: "/mnt/farm/disk1",
+										SubPath:   "plots",
+									},
+								},
+								HostPathVolume: []*k8schianetv1.HostPathVolumeConfig{
+									{
+										Path: "/plots/hostpath-1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: []corev1.VolumeMount{
+				{
+					Name:      "secret-ca",
+					MountPath: "/chia-ca",
+				},
+				{
+					Name:      "chiaroot",
+					MountPath: "/chia-data",
+				},
+				{
+					Name:      "pvc-plots-0",
+					ReadOnly:  true,
+					MountPath: "/mnt/farm/disk1",
+					SubPath:   "plots",
+				},
+				{
+					Name:      "hostpath-plots-0",
+					ReadOnly:  true,
+					MountPath: "/plots/hostpath-plots-0",
+				},
+			},
+		},
 		{
 			name: "Without Plot Storage",
 			harvester: k8schianetv1.ChiaHarvester{
@@ -81,6 +140,36 @@ func TestGetChiaVolumeMounts(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "DaemonSet",
+			harvester: k8schianetv1.ChiaHarvester{
+				Spec: k8schianetv1.ChiaHarvesterSpec{
+					ChiaConfig: k8schianetv1.ChiaHarvesterSpecChia{
+						CASecretName: "test-ca-secret",
+					},
+					DaemonSet: &k8schianetv1.ChiaHarvesterDaemonSetConfig{
+						Enabled:  &testTrue,
+						HostPath: "/mnt/plots",
+					},
+				},
+			},
+			want: []corev1.VolumeMount{
+				{
+					Name:      "secret-ca",
+					MountPath: "/chia-ca",
+				},
+				{
+					Name:      "chiaroot",
+					MountPath: "/chia-data",
+				},
+				{
+					Name:             "daemonset-plots",
+					ReadOnly:         true,
+					MountPath:        "/mnt/plots",
+					MountPropagation: &hostToContainer,
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -297,3 +386,277 @@ func TestGetChiaVolumes(t *testing.T) {
 		})
 	}
 }
+
+func TestSummarizePlots(t *testing.T) {
+	const (
+		plotIDA = "0x1111111111111111111111111111111111111111111111111111111111111111"
+		plotIDB = "0x2222222222222222222222222222222222222222222222222222222222222222"
+	)
+	resp := `{
+		"success": true,
+		"plots": [
+			{"filename": "/plots/pvc-plots-0/plot-a.plot", "size": 32, "plot_id": "` + plotIDA + `", "file_size": 1073741824},
+			{"filename": "/plots/pvc-plots-0/plot-b.plot", "size": 32, "plot_id": "` + plotIDB + `", "file_size": 1073741824},
+			{"filename": "/plots/hostpath-plots-0/plot-b-copy.plot", "size": 32, "plot_id": "` + plotIDB + `", "file_size": 1073741824}
+		],
+		"failed_to_open_filenames": ["/plots/hostpath-plots-0/broken.plot"],
+		"not_found_filenames": []
+	}`
+	var plots rpc.HarvesterGetPlotsResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &plots))
+
+	mounts := []corev1.VolumeMount{
+		{Name: "pvc-plots-0", MountPath: "/plots/pvc-plots-0"},
+		{Name: "hostpath-plots-0", MountPath: "/plots/hostpath-plots-0"},
+		{Name: "pvc-plots-1", MountPath: "/plots/pvc-plots-1"},
+	}
+
+	status := summarizePlots(&plots, mounts)
+	assert.Equal(t, int32(3), status.PlotCount)
+	assert.Equal(t, "3Gi", status.TotalRawSize.String())
+	assert.Equal(t, int32(1), status.FailedToOpenCount)
+	assert.Equal(t, int32(0), status.NotFoundCount)
+	assert.Equal(t, int32(2), status.DuplicateCount)
+	assert.Equal(t, []string{"/plots/hostpath-plots-0/broken.plot"}, status.InvalidPlots)
+	assert.Equal(t, []string{"/plots/hostpath-plots-0/plot-b-copy.plot", "/plots/pvc-plots-0/plot-b.plot"}, status.DuplicatePlots)
+
+	require.Len(t, status.Volumes, 3)
+	assert.Equal(t, "pvc-plots-0", status.Volumes[0].Name)
+	assert.Equal(t, int32(2), status.Volumes[0].PlotCount)
+	assert.Equal(t, int32(1), status.Volumes[0].DuplicateCount)
+	assert.Equal(t, "hostpath-plots-0", status.Volumes[1].Name)
+	assert.Equal(t, int32(1), status.Volumes[1].PlotCount)
+	assert.Equal(t, int32(1), status.Volumes[1].InvalidCount)
+	// An empty volume is still listed, so a missing disk is visible
+	assert.Equal(t, "pvc-plots-1", status.Volumes[2].Name)
+	assert.Equal(t, int32(0), status.Volumes[2].PlotCount)
+}
+
+func TestMergePlots(t *testing.T) {
+	var node1, node2 rpc.HarvesterGetPlotsResponse
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"success": true,
+		"plots": [
+			{"filename": "/plots/disk1/plot-a.plot", "size": 32, "plot_id": "0x1111111111111111111111111111111111111111111111111111111111111111", "file_size": 1073741824}
+		],
+		"failed_to_open_filenames": ["/plots/disk1/broken.plot"],
+		"not_found_filenames": []
+	}`), &node1))
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"success": true,
+		"plots": [
+			{"filename": "/plots/disk2/plot-b.plot", "size": 32, "plot_id": "0x2222222222222222222222222222222222222222222222222222222222222222", "file_size": 1073741824},
+			{"filename": "/plots/disk3/plot-c.plot", "size": 32, "plot_id": "0x3333333333333333333333333333333333333333333333333333333333333333", "file_size": 1073741824}
+		],
+		"not_found_filenames": ["/plots/disk2/missing.plot"]
+	}`), &node2))
+
+	require.Same(t, &node1, mergePlots([]*rpc.HarvesterGetPlotsResponse{&node1}))
+
+	// The inventory of a harvester ran as a DaemonSet is the sum of every node's inventory
+	status := summarizePlots(mergePlots([]*rpc.HarvesterGetPlotsResponse{&node1, &node2}), []corev1.VolumeMount{
+		{Name: daemonSetPlotsVolumeName, MountPath: "/plots"},
+	})
+	assert.Equal(t, int32(3), status.PlotCount)
+	assert.Equal(t, "3Gi", status.TotalRawSize.String())
+	assert.Equal(t, int32(1), status.FailedToOpenCount)
+	assert.Equal(t, int32(1), status.NotFoundCount)
+	require.Len(t, status.Volumes, 1)
+	assert.Equal(t, int32(3), status.Volumes[0].PlotCount)
+	assert.Equal(t, int32(2), status.Volumes[0].InvalidCount)
+}
+
+func TestGetNodePlotDirectories(t *testing.T) {
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-1",
+				Annotations: map[string]string{
+					plotDirectoriesAnnotation: "/mnt/disk1/plots, /mnt/disk2/plots,",
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-2",
+				Annotations: map[string]string{
+					plotDirectoriesAnnotation: " ",
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-3",
+			},
+		},
+	}
+
+	require.Equal(t, map[string]string{
+		"node-1": "/mnt/disk1/plots\n/mnt/disk2/plots",
+	}, getNodePlotDirectories(k8schianetv1.ChiaHarvester{}, nodes))
+
+	// Nodes the harvester's DaemonSet doesn't select are left out
+	harvester := k8schianetv1.ChiaHarvester{
+		Spec: k8schianetv1.ChiaHarvesterSpec{
+			CommonSpec: k8schianetv1.CommonSpec{
+				NodeSelector: map[string]string{"plots": "true"},
+			},
+		},
+	}
+	require.Empty(t, getNodePlotDirectories(harvester, nodes))
+}
+
+func TestNodeSelectedByHarvester(t *testing.T) {
+	node := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node-1",
+			Labels: map[string]string{"plots": "true", "disks": "4"},
+		},
+	}
+	harvester := k8schianetv1.ChiaHarvester{}
+	require.True(t, nodeSelectedByHarvester(harvester, node))
+
+	harvester.Spec.NodeSelector = map[string]string{"plots": "false"}
+	require.False(t, nodeSelectedByHarvester(harvester, node))
+
+	harvester.Spec.NodeSelector = map[string]string{"plots": "true"}
+	harvester.Spec.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: "disks", Operator: corev1.NodeSelectorOpGt, Values: []string{"8"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	require.False(t, nodeSelectedByHarvester(harvester, node))
+
+	// Terms are ORed
+	terms := &harvester.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	*terms = append(*terms, corev1.NodeSelectorTerm{
+		MatchFields: []corev1.NodeSelectorRequirement{
+			{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-1"}},
+		},
+	})
+	require.True(t, nodeSelectedByHarvester(harvester, node))
+}
+
+func TestGetChangedNodes(t *testing.T) {
+	current := map[string]string{"node-1": "/mnt/disk1", "node-2": "/mnt/disk2", "node-3": "/mnt/disk3"}
+	require.Empty(t, getChangedNodes(current, maps.Clone(current)))
+
+	desired := map[string]string{"node-1": "/mnt/disk1", "node-2": "/mnt/disk2\n/mnt/disk4", "node-4": "/mnt/disk1"}
+	require.Equal(t, []string{"node-2", "node-3", "node-4"}, getChangedNodes(current, desired))
+}
+
+func TestGetPlotDirectoryGlobs(t *testing.T) {
+	harvester := k8schianetv1.ChiaHarvester{
+		Spec: k8schianetv1.ChiaHarvesterSpec{
+			DaemonSet: &k8schianetv1.ChiaHarvesterDaemonSetConfig{
+				Enabled:  &testTrue,
+				HostPath: "/mnt",
+			},
+		},
+	}
+	require.Equal(t, []string{"/mnt"}, getPlotDirectoryGlobs(harvester))
+
+	harvester.Spec.DaemonSet.PathGlobs = []string{"/mnt/disk*/plots"}
+	require.Equal(t, []string{"/mnt/disk*/plots"}, getPlotDirectoryGlobs(harvester))
+}
+
+func TestGetPlotVolumeClaimName(t *testing.T) {
+	harvester := k8schianetv1.ChiaHarvester{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+	}
+	require.Equal(t, "existing-claim", getPlotVolumeClaimName(harvester, k8schianetv1.PersistentVolumeClaimConfig{ClaimName: "existing-claim"}))
+	require.Equal(t, "test-harvester-plots-disk2", getPlotVolumeClaimName(harvester, k8schianetv1.PersistentVolumeClaimConfig{Name: "disk2", GenerateVolumeClaims: true}))
+}
+
+func TestGetGeneratedVolumeClaimNames(t *testing.T) {
+	harvester := k8schianetv1.ChiaHarvester{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: k8schianetv1.ChiaHarvesterSpec{
+			CommonSpec: k8schianetv1.CommonSpec{
+				Storage: &k8schianetv1.StorageConfig{
+					ChiaRoot: &k8schianetv1.ChiaRootConfig{
+						PersistentVolumeClaim: &k8schianetv1.PersistentVolumeClaimConfig{GenerateVolumeClaims: true},
+					},
+					Plots: &k8schianetv1.PlotsConfig{
+						PersistentVolumeClaim: []*k8schianetv1.PersistentVolumeClaimConfig{
+							{ClaimName: "existing-claim"},
+							{Name: "disk1", GenerateVolumeClaims: true},
+						},
+					},
+				},
+			},
+		},
+	}
+	require.Equal(t, []string{"test-harvester", "test-harvester-plots-disk1"}, getGeneratedVolumeClaimNames(harvester))
+}
+
+func TestGetPlotVolumeClaimStatus(t *testing.T) {
+	harvester := k8schianetv1.ChiaHarvester{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+	}
+	vol := k8schianetv1.PersistentVolumeClaimConfig{Name: "disk1", GenerateVolumeClaims: true}
+
+	require.Equal(t, k8schianetv1.ChiaHarvesterPlotVolumeClaimStatus{
+		Name:      "pvc-plots-1",
+		ClaimName: "test-harvester-plots-disk1",
+		Generated: true,
+	}, getPlotVolumeClaimStatus(harvester, 1, vol, nil))
+
+	capacity := resource.MustParse("18Ti")
+	pvc := &corev1.PersistentVolumeClaim{
+		Status: corev1.PersistentVolumeClaimStatus{
+			Phase: corev1.ClaimBound,
+			Capacity: corev1.ResourceList{
+				corev1.ResourceStorage: capacity,
+			},
+		},
+	}
+	require.Equal(t, k8schianetv1.ChiaHarvesterPlotVolumeClaimStatus{
+		Name:      "pvc-plots-1",
+		ClaimName: "test-harvester-plots-disk1",
+		Generated: true,
+		Phase:     corev1.ClaimBound,
+		Capacity:  &capacity,
+	}, getPlotVolumeClaimStatus(harvester, 1, vol, pvc))
+}
+
+func TestGetPlotDirectories(t *testing.T) {
+	harvester := k8schianetv1.ChiaHarvester{}
+	require.Empty(t, getPlotDirectories(harvester))
+
+	harvester.Spec.Storage = &k8schianetv1.StorageConfig{
+		Plots: &k8schianetv1.PlotsConfig{
+			PersistentVolumeClaim: []*k8schianetv1.PersistentVolumeClaimConfig{
+				{ClaimName: "plot-pvc-1", MountPath: "/mnt/farm/disk1"},
+			},
+			HostPathVolume: []*k8schianetv1.HostPathVolumeConfig{
+				{Path: "/plots/hostpath-1"},
+			},
+		},
+	}
+	require.Equal(t, []string{"/mnt/farm/disk1", "/plots/hostpath-plots-0"}, getPlotDirectories(harvester))
+
+	harvester.Spec.Storage.Plots.PlotDirectories = []string{"/mnt/farm/disk1/k32", "/mnt/farm/disk1/k34"}
+	require.Equal(t, []string{"/mnt/farm/disk1/k32", "/mnt/farm/disk1/k34"}, getPlotDirectories(harvester))
+}
+
+func TestRecursivePlotScanEnabled(t *testing.T) {
+	harvester := k8schianetv1.ChiaHarvester{}
+	require.True(t, recursivePlotScanEnabled(harvester))
+
+	testFalse := false
+	harvester.Spec.Storage = &k8schianetv1.StorageConfig{
+		Plots: &k8schianetv1.PlotsConfig{
+			RecursivePlotScan: &testFalse,
+		},
+	}
+	require.False(t, recursivePlotScanEnabled(harvester))
+}
@@ -11,6 +11,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -203,6 +204,18 @@ func assembleVolumeClaim(introducer k8schianetv1.ChiaIntroducer) (*corev1.Persis
 	return &pvc, nil
 }
 
+// assemblePodDisruptionBudget assembles the PodDisruptionBudget resource for a ChiaIntroducer CR
+func assemblePodDisruptionBudget(introducer k8schianetv1.ChiaIntroducer) policyv1.PodDisruptionBudget {
+	return kube.AssemblePodDisruptionBudget(kube.AssemblePodDisruptionBudgetInputs{
+		Name:           fmt.Sprintf(chiaintroducerNamePattern, introducer.Name),
+		Namespace:      introducer.Namespace,
+		Labels:         kube.GetCommonLabels(introducer.Kind, introducer.ObjectMeta, introducer.Spec.Labels),
+		Annotations:    introducer.Spec.Annotations,
+		SelectorLabels: kube.GetCommonLabels(introducer.Kind, introducer.ObjectMeta),
+		Config:         introducer.Spec.PodDisruptionBudget,
+	})
+}
+
 // assembleDeployment assembles the introducer Deployment resource for a ChiaIntroducer CR
 func assembleDeployment(introducer k8schianetv1.ChiaIntroducer, fullNodePort int32, networkData *map[string]string) (appsv1.Deployment, error) {
 	var deploy = appsv1.Deployment{
@@ -92,3 +92,11 @@ func getChiaEnv(introducer k8schianetv1.ChiaIntroducer, networkData *map[string]
 
 	return env, nil
 }
+
+// getGeneratedVolumeClaimNames returns the names of the PersistentVolumeClaims the operator generates for a ChiaIntroducer
+func getGeneratedVolumeClaimNames(introducer k8schianetv1.ChiaIntroducer) []string {
+	if kube.ShouldMakeChiaRootVolumeClaim(introducer.Spec.Storage) {
+		return []string{fmt.Sprintf(chiaintroducerNamePattern, introducer.Name)}
+	}
+	return nil
+}
@@ -57,7 +57,7 @@ func assembleConfigMapData(network k8schianetv1.ChiaNetwork) (map[string]string,
 	if network.Spec.NetworkConstants != nil {
 		networkConstants, err := marshalNetworkOverride(data["network"], *network.Spec.NetworkConstants)
 		if err != nil {
-			return nil, fmt.Errorf("error marshaling network constants: %v", err)
+			return nil, fmt.Errorf("error marshaling network constants: %w", err)
 		}
 		data["chia.network_overrides.constants"] = networkConstants
 	}
@@ -66,7 +66,7 @@ func assembleConfigMapData(network k8schianetv1.ChiaNetwork) (map[string]string,
 	if network.Spec.NetworkConfig != nil {
 		networkConfig, err := marshalNetworkOverride(data["network"], *network.Spec.NetworkConfig)
 		if err != nil {
-			return nil, fmt.Errorf("error marshaling network config: %v", err)
+			return nil, fmt.Errorf("error marshaling network config: %w", err)
 		}
 		data["chia.network_overrides.config"] = networkConfig
 	}
@@ -20,9 +20,11 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 )
 
 // ChiaNetworkReconciler reconciles a ChiaNetwork object
@@ -30,9 +32,10 @@ type ChiaNetworkReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder events.EventRecorder
-}
 
-var chianetworks = make(map[string]bool)
+	// MaxConcurrentReconciles is the maximum number of ChiaNetworks that can be reconciled at the same time, which defaults to 1
+	MaxConcurrentReconciles int
+}
 
 // +kubebuilder:rbac:groups=k8s.chia.net,resources=chianetworks,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=k8s.chia.net,resources=chianetworks/status,verbs=get;update;patch
@@ -42,20 +45,22 @@ var chianetworks = make(map[string]bool)
 // +kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch;update
 
 // Reconcile is invoked on any event to a controlled Kubernetes resource
-func (r *ChiaNetworkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ChiaNetworkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
 	klog := log.FromContext(ctx)
 	klog.Info("Running reconciler...")
 
+	// Record how long this reconcile took, and why it failed if it returned an error
+	start := time.Now()
+	defer func() {
+		kube.ObserveReconcile(string(consts.ChiaNetworkKind), start, reterr)
+	}()
+
 	// Get the custom resource
 	var network k8schianetv1.ChiaNetwork
 	err := r.Get(ctx, req.NamespacedName, &network)
 	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chianetworks[req.String()]
-		if exists {
-			delete(chianetworks, req.String())
-			metrics.ChiaNetworks.Sub(1.0)
-		}
+		// Remove this object from the operator's metrics
+		metrics.Resources.Delete(string(consts.ChiaNetworkKind), req.String())
 		return ctrl.Result{}, nil
 	}
 	if err != nil {
@@ -63,44 +68,69 @@ func (r *ChiaNetworkReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chianetworks[req.String()]
-	if !exists {
-		chianetworks[req.String()] = true
-		metrics.ChiaNetworks.Add(1.0)
+	// Add this object to the operator's metrics, if it wasn't already added
+	metrics.Resources.Add(string(consts.ChiaNetworkKind), req.String())
+
+	// Record the ChiaNetwork's Ready condition in the operator's metrics, after any reconciliation error was recorded in it
+	defer func() {
+		if network.DeletionTimestamp.IsZero() {
+			metrics.Resources.SetReadyCondition(string(consts.ChiaNetworkKind), req.String(), kube.GetReadyConditionStatus(network.Status.Conditions))
+		}
+	}()
+
+	// Record any reconciliation error in the CR's status conditions
+	defer func() {
+		if reterr != nil {
+			kube.SetReconcileFailedConditions(&network.Status.Conditions, network.Generation, reterr)
+			network.Status.Ready = false
+			if err := r.Status().Update(ctx, &network); err != nil && !strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+				klog.Error(err, "encountered error updating ChiaNetwork status conditions")
+			}
+		}
+	}()
+
+	// Stop applying changes while the ChiaNetwork is suspended
+	suspended, err := kube.ReconcileSuspension(ctx, r.Client, network.ObjectMeta, nil, &network.Status.Conditions, nil)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("ChiaNetworkReconciler ChiaNetwork=%s %w", req.NamespacedName, err)
+	}
+	if suspended {
+		return kube.UpdateSuspendedStatus(ctx, r.Client, consts.ChiaNetworkKind, &network, network.Status.Conditions, &network.Status.ObservedGeneration, &network.Status.Ready)
 	}
 
 	// Assemble configmap
 	configmap, err := assembleConfigMap(network)
 	if err != nil {
 		r.Recorder.Eventf(&network, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble network ConfigMap -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("encountered error assembling network ConfigMap: %v", err)
+		return ctrl.Result{}, fmt.Errorf("encountered error assembling network ConfigMap: %w", err)
 	}
 	if err := controllerutil.SetControllerReference(&network, &configmap, r.Scheme); err != nil {
 		r.Recorder.Eventf(&network, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to set controller reference on network ConfigMap -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("encountered error setting controller reference on network ConfigMap: %v", err)
+		return ctrl.Result{}, fmt.Errorf("encountered error setting controller reference on network ConfigMap: %w", err)
 	}
 
 	// Reconcile configmap
 	res, err := kube.ReconcileConfigMap(ctx, r.Client, configmap)
 	if err != nil {
 		r.Recorder.Eventf(&network, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to reconcile network ConfigMap -- Check operator logs.")
-		return res, fmt.Errorf("encountered error reconciling network ConfigMap: %v", err)
+		return res, fmt.Errorf("encountered error reconciling network ConfigMap: %w", err)
 	}
 
 	if !network.Status.Ready {
 		r.Recorder.Eventf(&network, nil, corev1.EventTypeNormal, "Created", "Created",
 			"Successfully created network ConfigMap in %s/%s", network.Namespace, network.Name)
+	}
 
-		network.Status.Ready = true
-		err = r.Status().Update(ctx, &network)
-		if err != nil {
-			if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
-				return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
-			}
-			klog.Error(err, "encountered error updating ChiaNetwork status")
-			return ctrl.Result{}, err
+	kube.SetResourceReadyConditions(&network.Status.Conditions, network.Generation, fmt.Sprintf("Network ConfigMap %q was reconciled", configmap.Name))
+	network.Status.ObservedGeneration = network.Generation
+	network.Status.Ready = true
+	err = r.Status().Update(ctx, &network)
+	if err != nil {
+		if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 		}
+		klog.Error(err, "encountered error updating ChiaNetwork status")
+		return ctrl.Result{}, err
 	}
 
 	return ctrl.Result{}, nil
@@ -110,5 +140,6 @@ func (r *ChiaNetworkReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 func (r *ChiaNetworkReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&k8schianetv1.ChiaNetwork{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
@@ -8,18 +8,33 @@ import (
 	"context"
 	"fmt"
 
+	"strconv"
+	"time"
+
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
 	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+	"github.com/chia-network/chia-operator/internal/controller/common/volumesnapshot"
 )
 
 const chianodeNamePattern = "%s-node"
 
+// chianodeSnapshotNamePattern is the name of a CHIA_ROOT VolumeSnapshot, from the ChiaNode's name and the time the snapshot was taken
+const chianodeSnapshotNamePattern = "%s-node-%s"
+
+// chianodeReplicaNamePattern is the name of a replica's Pod, and its own peer Service, from the ChiaNode's name and the replica's ordinal
+const chianodeReplicaNamePattern = "%s-node-%d"
+
+// chianodePeakHeightNamePattern is the name of the ConfigMap with the ChiaNode's peak height, read by chia-db-restore
+const chianodePeakHeightNamePattern = "%s-node-peak-height"
+
 // assemblePeerService assembles the peer Service resource for a ChiaNode CR
 func assemblePeerService(node k8schianetv1.ChiaNode, fullNodePort int32) corev1.Service {
 	inputs := kube.AssembleCommonServiceInputs{
@@ -278,6 +293,55 @@ func assembleLocalPeerService(node k8schianetv1.ChiaNode, fullNodePort int32) co
 	return srv
 }
 
+// assembleReplicaService assembles the peer Service of a single replica of a ChiaNode CR's StatefulSet
+func assembleReplicaService(node k8schianetv1.ChiaNode, fullNodePort int32, ordinal int32) corev1.Service {
+	config := node.Spec.ChiaConfig.ReplicaService
+	podName := fmt.Sprintf(chianodeReplicaNamePattern, node.Name, ordinal)
+	inputs := kube.AssembleCommonServiceInputs{
+		Name:      podName,
+		Namespace: node.Namespace,
+		Ports: []corev1.ServicePort{
+			{
+				Port:       fullNodePort,
+				TargetPort: intstr.FromString("peers"),
+				Protocol:   "TCP",
+				Name:       "peers",
+			},
+		},
+	}
+
+	inputs.ServiceType = config.ServiceType
+	inputs.ExternalTrafficPolicy = config.ExternalTrafficPolicy
+	inputs.SessionAffinity = config.SessionAffinity
+	inputs.SessionAffinityConfig = config.SessionAffinityConfig
+	inputs.IPFamilyPolicy = config.IPFamilyPolicy
+	inputs.IPFamilies = config.IPFamilies
+
+	// Labels and annotations, with the replica's own overrides
+	override := getReplicaServiceOverride(config, ordinal)
+	inputs.Labels = kube.GetCommonLabels(node.Kind, node.ObjectMeta, node.Spec.Labels, config.Labels, override.Labels, map[string]string{
+		replicaServiceLabel: "true",
+	})
+	inputs.SelectorLabels = kube.GetCommonLabels(node.Kind, node.ObjectMeta, node.Spec.Labels, map[string]string{
+		appsv1.StatefulSetPodNameLabel: podName,
+	})
+	inputs.Annotations = kube.CombineMaps(node.Spec.Annotations, config.Annotations, override.Annotations)
+
+	return kube.AssembleCommonService(inputs)
+}
+
+// assemblePodDisruptionBudget assembles the PodDisruptionBudget resource for a ChiaNode CR
+func assemblePodDisruptionBudget(node k8schianetv1.ChiaNode) policyv1.PodDisruptionBudget {
+	return kube.AssemblePodDisruptionBudget(kube.AssemblePodDisruptionBudgetInputs{
+		Name:           fmt.Sprintf(chianodeNamePattern, node.Name),
+		Namespace:      node.Namespace,
+		Labels:         kube.GetCommonLabels(node.Kind, node.ObjectMeta, node.Spec.Labels),
+		Annotations:    node.Spec.Annotations,
+		SelectorLabels: kube.GetCommonLabels(node.Kind, node.ObjectMeta),
+		Config:         node.Spec.PodDisruptionBudget,
+	})
+}
+
 // assembleStatefulset assembles the node StatefulSet resource for a ChiaNode CR
 func assembleStatefulset(ctx context.Context, node k8schianetv1.ChiaNode, fullNodePort int32, networkData *map[string]string) (appsv1.StatefulSet, error) {
 	vols, volClaimTemplates := getChiaVolumesAndTemplates(node)
@@ -329,8 +393,28 @@ func assembleStatefulset(ctx context.Context, node k8schianetv1.ChiaNode, fullNo
 	}
 
 	// Append the first-class chia-db-pull init container last so any user-defined init containers run first.
-	if kube.ChiaDBPullEnabled(node.Spec.ChiaDBPullConfig) {
+	if chiaDBPullInitContainerEnabled(node) {
+		// chia-db-pull can't compare an existing database with the peak height, or verify its download before it replaces the existing
+		// database, so it downloads into a staging directory between a check that skips it and an install of its download
+		s3 := node.Spec.ChiaDBPullConfig.S3Prefix != ""
+		if s3 {
+			stateful.Spec.Template.Spec.InitContainers = append(stateful.Spec.Template.Spec.InitContainers, kube.AssembleChiaDBCheckContainer(getChiaDBPullContainerInputs(node, networkData)))
+		}
 		stateful.Spec.Template.Spec.InitContainers = append(stateful.Spec.Template.Spec.InitContainers, assembleChiaDBPullContainer(node, networkData))
+		if s3 {
+			stateful.Spec.Template.Spec.InitContainers = append(stateful.Spec.Template.Spec.InitContainers, kube.AssembleChiaDBInstallContainer(getChiaDBPullContainerInputs(node, networkData)))
+		}
+		if source := node.Spec.ChiaDBPullConfig.PersistentVolumeClaim; source != nil {
+			stateful.Spec.Template.Spec.Volumes = append(stateful.Spec.Template.Spec.Volumes, corev1.Volume{
+				Name: chiaDBPullSourceVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: source.ClaimName,
+						ReadOnly:  true,
+					},
+				},
+			})
+		}
 	}
 
 	// Get Sidecar Containers
@@ -472,6 +556,11 @@ func assembleChiaHealthcheckContainer(node k8schianetv1.ChiaNode) corev1.Contain
 }
 
 func assembleChiaDBPullContainer(node k8schianetv1.ChiaNode, networkData *map[string]string) corev1.Container {
+	return kube.AssembleChiaDBPullContainer(getChiaDBPullContainerInputs(node, networkData))
+}
+
+// getChiaDBPullContainerInputs returns the inputs to the chia-db-pull init container, and the check that runs before it
+func getChiaDBPullContainerInputs(node k8schianetv1.ChiaNode, networkData *map[string]string) kube.AssembleChiaDBPullContainerInputs {
 	input := kube.AssembleChiaDBPullContainerInputs{
 		Image:                node.Spec.ChiaDBPullConfig.Image,
 		ImagePullPolicy:      node.Spec.ImagePullPolicy,
@@ -480,6 +569,19 @@ func assembleChiaDBPullContainer(node k8schianetv1.ChiaNode, networkData *map[st
 		MinHeight:            node.Spec.ChiaDBPullConfig.MinHeight,
 		AWSCredentialsSecret: node.Spec.ChiaDBPullConfig.AWSCredentialsSecret,
 		AdditionalEnv:        node.Spec.ChiaDBPullConfig.AdditionalEnv,
+		MaxHeightLag:         node.Spec.ChiaDBPullConfig.MaxHeightLag,
+	}
+	if node.Spec.ChiaDBPullConfig.MaxHeightLag != nil && kube.RPCStatusEnabled(node.Spec.RPCStatus) {
+		input.PeakHeightConfigMapName = fmt.Sprintf(chianodePeakHeightNamePattern, node.Name)
+	}
+	if node.Spec.ChiaDBPullConfig.HTTP != nil {
+		input.URLs = node.Spec.ChiaDBPullConfig.HTTP.URLs
+		input.SHA256 = node.Spec.ChiaDBPullConfig.HTTP.SHA256
+	}
+	if node.Spec.ChiaDBPullConfig.PersistentVolumeClaim != nil {
+		input.SourceVolumeName = chiaDBPullSourceVolumeName
+		input.SourcePath = node.Spec.ChiaDBPullConfig.PersistentVolumeClaim.Path
+		input.SHA256 = node.Spec.ChiaDBPullConfig.PersistentVolumeClaim.SHA256
 	}
 
 	// If the user didn't explicitly set chiaDBPull.network, derive it from the surrounding chia config
@@ -499,5 +601,74 @@ func assembleChiaDBPullContainer(node k8schianetv1.ChiaNode, networkData *map[st
 		input.ResourceRequirements = *node.Spec.ChiaDBPullConfig.Resources
 	}
 
-	return kube.AssembleChiaDBPullContainer(input)
+	return input
+}
+
+// assemblePeakHeightConfigMap assembles the ConfigMap with a ChiaNode's peak height, which new Pods compare their existing database with
+func assemblePeakHeightConfigMap(node k8schianetv1.ChiaNode) corev1.ConfigMap {
+	cm := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(chianodePeakHeightNamePattern, node.Name),
+			Namespace: node.Namespace,
+			Labels:    kube.GetCommonLabels(node.Kind, node.ObjectMeta, node.Spec.Labels),
+		},
+	}
+	if node.Status.PeakHeight > 0 {
+		cm.Data = map[string]string{
+			kube.ChiaDBPullPeakHeightKey: strconv.FormatUint(uint64(node.Status.PeakHeight), 10),
+		}
+	}
+	return cm
+}
+
+// assembleSnapshot assembles a VolumeSnapshot of a replica's CHIA_ROOT volume
+func assembleSnapshot(node k8schianetv1.ChiaNode, replica k8schianetv1.ChiaNodeReplicaStatus, now time.Time) *unstructured.Unstructured {
+	return volumesnapshot.Assemble(volumesnapshot.Inputs{
+		Name:      fmt.Sprintf(chianodeSnapshotNamePattern, node.Name, now.UTC().Format("20060102150405")),
+		Namespace: node.Namespace,
+		Labels:    kube.GetCommonLabels(node.Kind, node.ObjectMeta),
+		Annotations: map[string]string{
+			snapshotPeakHeightAnnotation: strconv.FormatUint(uint64(replica.PeakHeight), 10),
+			snapshotSourcePodAnnotation:  replica.PodName,
+		},
+		PersistentVolumeClaimName: "chiaroot-" + replica.PodName,
+		VolumeSnapshotClassName:   node.Spec.Snapshots.VolumeSnapshotClassName,
+	})
+}
+
+// assembleRestoredVolumeClaim assembles the CHIA_ROOT PersistentVolumeClaim for a new replica from the StatefulSet's volume claim template,
+// restored from a VolumeSnapshot. The StatefulSet uses the existing claim when it creates the replica.
+func assembleRestoredVolumeClaim(node k8schianetv1.ChiaNode, template corev1.PersistentVolumeClaim, ordinal int32, snapshot unstructured.Unstructured) corev1.PersistentVolumeClaim {
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getReplicaVolumeClaimName(node, ordinal),
+			Namespace: node.Namespace,
+			Labels:    kube.GetCommonLabels(node.Kind, node.ObjectMeta),
+			Annotations: map[string]string{
+				restoredFromSnapshotAnnotation: snapshot.GetName(),
+			},
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+	if height, ok := snapshot.GetAnnotations()[snapshotPeakHeightAnnotation]; ok {
+		pvc.Annotations[snapshotPeakHeightAnnotation] = height
+	}
+
+	apiGroup := volumesnapshot.Group
+	pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{
+		APIGroup: &apiGroup,
+		Kind:     volumesnapshot.Kind,
+		Name:     snapshot.GetName(),
+	}
+
+	// A volume restored from a snapshot must be at least as large as the snapshot
+	if restoreSize := volumesnapshot.GetRestoreSize(snapshot); restoreSize != nil {
+		if request, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; !ok || request.Cmp(*restoreSize) < 0 {
+			pvc.Spec.Resources.Requests = corev1.ResourceList{
+				corev1.ResourceStorage: *restoreSize,
+			}
+		}
+	}
+
+	return pvc
 }
@@ -7,20 +7,28 @@ package chianode
 import (
 	"context"
 	"fmt"
-	"strings"
+	"sort"
 	"time"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/chiarpc"
+	"github.com/chia-network/chia-operator/internal/controller/common/component"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/finalizer"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
-	"github.com/chia-network/chia-operator/internal/metrics"
+	"github.com/chia-network/chia-operator/internal/controller/common/volumesnapshot"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -32,196 +40,468 @@ type ChiaNodeReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder events.EventRecorder
-}
 
-var chianodes = make(map[string]bool)
+	// MaxConcurrentReconciles is the maximum number of ChiaNodes that can be reconciled at the same time, which defaults to 1
+	MaxConcurrentReconciles int
+}
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chianodes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chianodes/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chianodes/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch
-//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch;update
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is invoked on any event to a controlled Kubernetes resource
 func (r *ChiaNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := log.FromContext(ctx)
-	log.Info("Running reconciler...")
-
-	// Get the custom resource
 	var node k8schianetv1.ChiaNode
-	err := r.Get(ctx, req.NamespacedName, &node)
-	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chianodes[req.String()]
-		if exists {
-			delete(chianodes, req.String())
-			metrics.ChiaNodes.Sub(1.0)
-		}
-		return ctrl.Result{}, nil
+	// The ChiaNode's VolumeSnapshots, which are listed before its replicas' volumes are restored from them, and pruned after the StatefulSet is reconciled
+	var snapshots []unstructured.Unstructured
+	engine := component.Engine{
+		Client:   r.Client,
+		Scheme:   r.Scheme,
+		Recorder: r.Recorder,
+		Kind:     consts.ChiaNodeKind,
+		Name:     "node",
 	}
+	return engine.Reconcile(ctx, req, &node, component.Hooks{
+		Describe: func() component.Resource {
+			return component.Resource{
+				Meta:    node.ObjectMeta,
+				Chia:    node.Spec.ChiaConfig.CommonSpecChia,
+				Suspend: node.Spec.Suspend,
+				Status: component.Status{
+					Conditions:         &node.Status.Conditions,
+					ObservedGeneration: &node.Status.ObservedGeneration,
+					Ready:              &node.Status.Ready,
+				},
+				Workload: &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf(chianodeNamePattern, node.Name), Namespace: node.Namespace}},
+			}
+		},
+		FinalizerInputs: func(ctx context.Context) (finalizer.Inputs, error) {
+			claims, err := r.getGeneratedVolumeClaimNames(ctx, node)
+			if err != nil {
+				return finalizer.Inputs{}, err
+			}
+			var snapshotClass *string
+			if node.Spec.Snapshots != nil {
+				snapshotClass = node.Spec.Snapshots.VolumeSnapshotClassName
+			}
+			return finalizer.Inputs{
+				DeletionPolicy:          node.Spec.DeletionPolicy,
+				VolumeClaimNames:        claims,
+				VolumeSnapshotClassName: snapshotClass,
+			}, nil
+		},
+		Validate: func() error {
+			// A chiaDBPull init container needs exactly one database source
+			if sources := kube.GetChiaDBPullSources(node.Spec.ChiaDBPullConfig); kube.ChiaDBPullEnabled(node.Spec.ChiaDBPullConfig) && len(sources) != 1 {
+				return fmt.Errorf("chiaDBPull.enabled is true but chiaDBPull has %d sources, exactly one of s3Prefix, http, persistentVolumeClaim, or chiaNodeSnapshot must be set", len(sources))
+			}
+			return nil
+		},
+		Assemble: func(ctx context.Context, c *component.Reconciler, networkData *map[string]string) (component.Component, error) {
+			var err error
+			if snapshotsEnabled(node) {
+				snapshots, err = volumesnapshot.List(ctx, r.Client, node.Namespace, kube.GetCommonLabels(node.Kind, node.ObjectMeta))
+				if err != nil {
+					return component.Component{}, err
+				}
+			}
+			return r.assembleComponent(ctx, c, node, networkData, snapshots)
+		},
+		Reconciled: func(ctx context.Context, c *component.Reconciler, comp component.Component) (time.Duration, error) {
+			return r.afterReconcile(ctx, c, &node, *comp.Workload.(*appsv1.StatefulSet), snapshots)
+		},
+	})
+}
+
+// assembleComponent reconciles the ChiaNode's replica Services and restores new replicas' volumes from a VolumeSnapshot, and assembles its other resources
+func (r *ChiaNodeReconciler) assembleComponent(ctx context.Context, c *component.Reconciler, node k8schianetv1.ChiaNode, networkData *map[string]string, snapshots []unstructured.Unstructured) (component.Component, error) {
+	// Get the full_node Port and handle the error one time instead of in every function that needs it
+	fullNodePort, err := kube.GetFullNodePort(node.Spec.ChiaConfig.CommonSpecChia, networkData)
 	if err != nil {
-		log.Error(err, fmt.Sprintf("ChiaNodeReconciler ChiaNode=%s unable to fetch ChiaNode resource", req.NamespacedName))
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+		return component.Component{}, fmt.Errorf("encountered error retrieving the full_node Port to use: %w", err)
 	}
 
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chianodes[req.String()]
-	if !exists {
-		chianodes[req.String()] = true
-		metrics.ChiaNodes.Add(1.0)
+	// Assemble StatefulSet
+	stateful, err := assembleStatefulset(ctx, node, fullNodePort, networkData)
+	if err != nil {
+		c.AssembleFailed("StatefulSet")
+		return component.Component{}, err
 	}
 
-	// Validate the chia-db-pull init container config before doing any other work.
-	if kube.ChiaDBPullEnabled(node.Spec.ChiaDBPullConfig) && node.Spec.ChiaDBPullConfig.S3Prefix == "" {
-		err := fmt.Errorf("chiaDBPull.enabled is true but chiaDBPull.s3Prefix is empty")
-		r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "chiaDBPull is enabled but s3Prefix is not set -- the chia-db-pull init container requires an S3 prefix.")
-		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s %v", req.NamespacedName, err)
+	// Reconcile Replica Peer Services
+	if _, err := r.reconcileReplicaServices(ctx, node, fullNodePort); err != nil {
+		c.CreateFailed("replica Services")
+		return component.Component{}, err
 	}
 
-	// Check for ChiaNetwork, retrieve matching ConfigMap if specified
-	networkData, err := kube.GetChiaNetworkData(ctx, r.Client, node.Spec.ChiaConfig.CommonSpecChia, node.Namespace)
+	// Restore new replicas' CHIA_ROOT volumes from the latest VolumeSnapshot before the StatefulSet creates them
+	restoreSnapshot, err := r.getRestoreSnapshot(ctx, node, snapshots)
 	if err != nil {
-		return ctrl.Result{}, err
+		return component.Component{}, err
+	}
+	if restoreSnapshot != nil {
+		if err := r.restoreReplicaVolumeClaims(ctx, node, stateful, *restoreSnapshot); err != nil {
+			r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to restore node PVC from VolumeSnapshot -- Check operator logs.")
+			return component.Component{}, err
+		}
 	}
 
-	// Get the full_node Port and handle the error one time instead of in every function that needs it
-	fullNodePort, err := kube.GetFullNodePort(node.Spec.ChiaConfig.CommonSpecChia, networkData)
-	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("encountered error retrieving the full_node Port to use: %v", err)
+	services := []component.Service{
+		{Description: "peer", Desired: assemblePeerService(node, fullNodePort), Config: node.Spec.ChiaConfig.PeerService, DefaultEnabled: true},
+		{Description: "all-port", Desired: assembleAllService(node, fullNodePort), Config: node.Spec.ChiaConfig.AllService, DefaultEnabled: true},
+		{Description: "headless peer", Desired: assembleHeadlessPeerService(node, fullNodePort), Config: node.Spec.ChiaConfig.PeerService, DefaultEnabled: true},
+		{Description: "local peer", Desired: assembleLocalPeerService(node, fullNodePort), Config: node.Spec.ChiaConfig.PeerService, DefaultEnabled: true},
+		{Description: "daemon", Desired: assembleDaemonService(node), Config: node.Spec.ChiaConfig.DaemonService, DefaultEnabled: true},
+		{Description: "RPC", Desired: assembleRPCService(node), Config: node.Spec.ChiaConfig.RPCService, DefaultEnabled: true},
+		{Description: "chia-exporter", Desired: assembleChiaExporterService(node), Config: node.Spec.ChiaExporterConfig.Service, DefaultEnabled: true},
 	}
+	if !kube.ShouldRollIntoMainPeerService(node.Spec.ChiaHealthcheckConfig.Service) {
+		services = append(services, component.Service{Description: "chia-healthcheck", Desired: assembleChiaHealthcheckService(node), Config: node.Spec.ChiaHealthcheckConfig.Service})
+	}
+	return component.Component{
+		Services: services,
+		Workload: &stateful,
+		PodDisruptionBudget: component.PodDisruptionBudget{
+			Desired:        assemblePodDisruptionBudget(node),
+			Config:         node.Spec.PodDisruptionBudget,
+			DefaultEnabled: node.Spec.Replicas > 1,
+		},
+	}, nil
+}
 
-	// Assemble Peer Service
-	peerSrv := assemblePeerService(node, fullNodePort)
-	if err := controllerutil.SetControllerReference(&node, &peerSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble node peer Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error assembling peer Service: %v", req.NamespacedName, err)
+// afterReconcile records the full_node RPC status, replica Service addresses, and database restore status in the ChiaNode's status,
+// reconciles its peak height ConfigMap, and takes and prunes its VolumeSnapshots.
+// Returns the amount of time until the next RPC status query or VolumeSnapshot is due.
+func (r *ChiaNodeReconciler) afterReconcile(ctx context.Context, c *component.Reconciler, node *k8schianetv1.ChiaNode, stateful appsv1.StatefulSet, snapshots []unstructured.Unstructured) (time.Duration, error) {
+	log := log.FromContext(ctx)
+	key := client.ObjectKeyFromObject(node)
+
+	rpcRequeueAfter, err := r.updateRPCStatus(ctx, node, stateful)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("ChiaNodeReconciler ChiaNode=%s unable to query full_node RPC status", key))
+	}
+	// Reconcile the peak height ConfigMap new Pods compare their existing database with
+	if chiaDBPullInitContainerEnabled(*node) && node.Spec.ChiaDBPullConfig.MaxHeightLag != nil && kube.RPCStatusEnabled(node.Spec.RPCStatus) {
+		peakHeight := assemblePeakHeightConfigMap(*node)
+		if err := controllerutil.SetControllerReference(node, &peakHeight, r.Scheme); err != nil {
+			c.AssembleFailed("peak height ConfigMap")
+			return 0, fmt.Errorf("encountered error assembling peak height ConfigMap: %w", err)
+		}
+		if _, err := kube.ReconcileConfigMap(ctx, r.Client, peakHeight); err != nil {
+			c.CreateFailed("peak height ConfigMap")
+			return 0, err
+		}
+	}
+	if err := r.updateReplicaServiceStatus(ctx, node); err != nil {
+		log.Error(err, fmt.Sprintf("ChiaNodeReconciler ChiaNode=%s unable to get replica Service addresses", key))
 	}
-	// Reconcile Peer Service
-	res, err := kube.ReconcileService(ctx, r.Client, node.Spec.ChiaConfig.PeerService, peerSrv, true)
+	if err := r.updateDatabaseRestoreStatus(ctx, node, stateful); err != nil {
+		log.Error(err, fmt.Sprintf("ChiaNodeReconciler ChiaNode=%s unable to get database restore status", key))
+	}
+	snapshotRequeueAfter, err := r.reconcileSnapshots(ctx, node, snapshots, time.Now())
 	if err != nil {
-		return res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s %v", req.NamespacedName, err)
+		r.Recorder.Eventf(node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to reconcile node VolumeSnapshots -- Check operator logs.")
+		return 0, err
 	}
 
-	// Assemble All Service
-	allSrv := assembleAllService(node, fullNodePort)
-	if err := controllerutil.SetControllerReference(&node, &allSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble node all-port Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error assembling all-port Service: %v", req.NamespacedName, err)
+	// Requeue for the next full_node RPC status query, or the next VolumeSnapshot, whichever is sooner
+	return component.RequeueSooner(ctrl.Result{RequeueAfter: rpcRequeueAfter}, snapshotRequeueAfter).RequeueAfter, nil
+}
+
+// updateRPCStatus queries the full_node RPC server in each of the StatefulSet's Pods and records its sync status in the ChiaNode's status.
+// Returns the amount of time until the next query is due, or 0 if RPC status queries are disabled.
+func (r *ChiaNodeReconciler) updateRPCStatus(ctx context.Context, node *k8schianetv1.ChiaNode, stateful appsv1.StatefulSet) (time.Duration, error) {
+	if !kube.RPCStatusEnabled(node.Spec.RPCStatus) {
+		node.Status.Replicas = nil
+		node.Status.PeakHeight = 0
+		node.Status.SyncedReplicas = 0
+		return 0, nil
 	}
-	// Reconcile All Service
-	res, err = kube.ReconcileService(ctx, r.Client, node.Spec.ChiaConfig.AllService, allSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s %v", req.NamespacedName, err)
+
+	// Only query the RPC servers once per interval, since every status update triggers another reconcile
+	interval := kube.GetRPCStatusInterval(node.Spec.RPCStatus)
+	now := time.Now()
+	if requeueAfter := kube.GetRPCStatusRequeueAfter(getOldestReplicaCheck(node.Status.Replicas), interval, now); requeueAfter > 0 {
+		return requeueAfter, nil
 	}
 
-	// Assemble Headless Peer Service
-	headlessPeerSrv := assembleHeadlessPeerService(node, fullNodePort)
-	if err := controllerutil.SetControllerReference(&node, &headlessPeerSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble node headless peer Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error assembling headless peer Service: %v", req.NamespacedName, err)
+	rpcClient, err := chiarpc.GetClient(ctx, r.Client, node.Namespace, node.Spec.ChiaConfig.CASecretName)
+	if err != nil {
+		return interval, err
 	}
-	// Reconcile Headless Peer Service
-	res, err = kube.ReconcileService(ctx, r.Client, node.Spec.ChiaConfig.PeerService, headlessPeerSrv, true)
+
+	pods, err := kube.ListPods(ctx, r.Client, node.Namespace, stateful.Spec.Selector)
 	if err != nil {
-		return res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s %v", req.NamespacedName, err)
+		return interval, err
 	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+
+	var replicas []k8schianetv1.ChiaNodeReplicaStatus
+	for _, pod := range pods {
+		replica := k8schianetv1.ChiaNodeReplicaStatus{
+			PodName:     pod.Name,
+			LastChecked: metav1.NewTime(now),
+		}
+		if pod.Status.PodIP == "" || pod.Status.Phase != corev1.PodRunning {
+			replica.Error = "Pod is not running"
+			replicas = append(replicas, replica)
+			continue
+		}
 
-	// Assemble Local Peer Service
-	localPeerSrv := assembleLocalPeerService(node, fullNodePort)
-	if err := controllerutil.SetControllerReference(&node, &localPeerSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble node local peer Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error assembling local peer Service: %v", req.NamespacedName, err)
+		state, err := rpcClient.GetFullNodeState(ctx, pod.Status.PodIP)
+		if err != nil {
+			replica.Error = err.Error()
+		}
+		replica.PeakHeight = state.PeakHeight
+		replica.Synced = state.Synced
+		replica.SyncMode = state.SyncMode
+		replica.ConnectedPeers = state.ConnectedPeers
+		replicas = append(replicas, replica)
 	}
-	// Reconcile Local Peer Service
-	res, err = kube.ReconcileService(ctx, r.Client, node.Spec.ChiaConfig.PeerService, localPeerSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s %v", req.NamespacedName, err)
+
+	node.Status.Replicas = replicas
+	node.Status.PeakHeight, node.Status.SyncedReplicas = summarizeReplicaStatuses(replicas)
+
+	return interval, nil
+}
+
+// reconcileReplicaServices applies the peer Service of each replica of a ChiaNode's StatefulSet when they're enabled,
+// and deletes the Services of replicas that were removed
+func (r *ChiaNodeReconciler) reconcileReplicaServices(ctx context.Context, node k8schianetv1.ChiaNode, fullNodePort int32) (ctrl.Result, error) {
+	desired := make(map[string]bool)
+	if kube.ShouldMakeService(node.Spec.ChiaConfig.ReplicaService.Service, false) {
+		for ordinal := int32(0); ordinal < node.Spec.Replicas; ordinal++ {
+			srv := assembleReplicaService(node, fullNodePort, ordinal)
+			if err := controllerutil.SetControllerReference(&node, &srv, r.Scheme); err != nil {
+				return ctrl.Result{}, fmt.Errorf("encountered error assembling replica Service \"%s\": %w", srv.Name, err)
+			}
+			res, err := kube.ReconcileService(ctx, r.Client, node.Spec.ChiaConfig.ReplicaService.Service, srv, false)
+			if err != nil || !res.IsZero() {
+				return res, err
+			}
+			desired[srv.Name] = true
+		}
 	}
 
-	// Assemble Daemon Service
-	daemonSrv := assembleDaemonService(node)
-	if err := controllerutil.SetControllerReference(&node, &daemonSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble node daemon Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error assembling daemon Service: %v", req.NamespacedName, err)
+	var current corev1.ServiceList
+	labels := kube.GetCommonLabels(node.Kind, node.ObjectMeta, map[string]string{replicaServiceLabel: "true"})
+	if err := r.List(ctx, &current, client.InNamespace(node.Namespace), client.MatchingLabels(labels)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error listing replica Services: %w", err)
 	}
-	// Reconcile Daemon Service
-	res, err = kube.ReconcileService(ctx, r.Client, node.Spec.ChiaConfig.DaemonService, daemonSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s %v", req.NamespacedName, err)
+	for i := range current.Items {
+		if desired[current.Items[i].Name] {
+			continue
+		}
+		log.FromContext(ctx).Info("Deleting replica Service because its replica was removed", "Service.Name", current.Items[i].Name)
+		if err := r.Delete(ctx, &current.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("error deleting Service \"%s\": %w", current.Items[i].Name, err)
+		}
 	}
+	return ctrl.Result{}, nil
+}
 
-	// Assemble RPC Service
-	rpcSrv := assembleRPCService(node)
-	if err := controllerutil.SetControllerReference(&node, &rpcSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble node RPC Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error assembling RPC Service: %v", req.NamespacedName, err)
+// updateReplicaServiceStatus records the external address of each replica's own peer Service in the ChiaNode's status
+func (r *ChiaNodeReconciler) updateReplicaServiceStatus(ctx context.Context, node *k8schianetv1.ChiaNode) error {
+	if !kube.ShouldMakeService(node.Spec.ChiaConfig.ReplicaService.Service, false) {
+		node.Status.ReplicaServices = nil
+		return nil
 	}
-	// Reconcile RPC Service
-	res, err = kube.ReconcileService(ctx, r.Client, node.Spec.ChiaConfig.RPCService, rpcSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s %v", req.NamespacedName, err)
+
+	var statuses []k8schianetv1.ChiaNodeReplicaServiceStatus
+	for ordinal := int32(0); ordinal < node.Spec.Replicas; ordinal++ {
+		name := fmt.Sprintf(chianodeReplicaNamePattern, node.Name, ordinal)
+		var srv corev1.Service
+		if err := r.Get(ctx, types.NamespacedName{Namespace: node.Namespace, Name: name}, &srv); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("error getting Service \"%s\": %w", name, err)
+		}
+
+		// NodePort Services are reached on the external IP of the Kubernetes node the replica runs on
+		var k8sNode *corev1.Node
+		if srv.Spec.Type == corev1.ServiceTypeNodePort {
+			var pod corev1.Pod
+			err := r.Get(ctx, types.NamespacedName{Namespace: node.Namespace, Name: name}, &pod)
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("error getting Pod \"%s\": %w", name, err)
+			}
+			if err == nil && pod.Spec.NodeName != "" {
+				var current corev1.Node
+				if err := r.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, &current); err != nil {
+					return fmt.Errorf("error getting Node \"%s\": %w", pod.Spec.NodeName, err)
+				}
+				k8sNode = &current
+			}
+		}
+
+		statuses = append(statuses, getReplicaServiceStatus(name, srv, k8sNode))
 	}
+	node.Status.ReplicaServices = statuses
+	return nil
+}
 
-	// Assemble Chia-Exporter Service
-	exporterSrv := assembleChiaExporterService(node)
-	if err := controllerutil.SetControllerReference(&node, &exporterSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble node chia-exporter Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error assembling chia-exporter Service: %v", req.NamespacedName, err)
+// updateDatabaseRestoreStatus records where each replica's blockchain database was restored from in the ChiaNode's status
+func (r *ChiaNodeReconciler) updateDatabaseRestoreStatus(ctx context.Context, node *k8schianetv1.ChiaNode, stateful appsv1.StatefulSet) error {
+	restoresFromSnapshots := snapshotsEnabled(*node) && restoreNewReplicasEnabled(*node.Spec.Snapshots)
+	if !kube.ChiaDBPullEnabled(node.Spec.ChiaDBPullConfig) && !restoresFromSnapshots {
+		node.Status.DatabaseRestores = nil
+		return nil
 	}
-	// Reconcile Chia-Exporter Service
-	res, err = kube.ReconcileService(ctx, r.Client, node.Spec.ChiaExporterConfig.Service, exporterSrv, true)
+
+	pods, err := kube.ListPods(ctx, r.Client, node.Namespace, stateful.Spec.Selector)
 	if err != nil {
-		return res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s %v", req.NamespacedName, err)
+		return err
 	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
 
-	// Assemble Chia-Healthcheck Service
-	healthcheckSrv := assembleChiaHealthcheckService(node)
-	if err := controllerutil.SetControllerReference(&node, &healthcheckSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble node chia-healthcheck Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s encountered error assembling chia-healthcheck Service: %v", req.NamespacedName, err)
+	var restores []k8schianetv1.ChiaNodeDatabaseRestoreStatus
+	for _, pod := range pods {
+		var pvc *corev1.PersistentVolumeClaim
+		// The only volume claim template is the CHIA_ROOT volume, when it's a PersistentVolumeClaim
+		if len(stateful.Spec.VolumeClaimTemplates) > 0 {
+			var current corev1.PersistentVolumeClaim
+			err := r.Get(ctx, types.NamespacedName{Namespace: node.Namespace, Name: fmt.Sprintf("chiaroot-%s", pod.Name)}, &current)
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("error getting PersistentVolumeClaim for Pod \"%s\": %w", pod.Name, err)
+			}
+			if err == nil {
+				pvc = &current
+			}
+		}
+		if restore := getDatabaseRestoreStatus(*node, pod, pvc); restore != nil {
+			restores = append(restores, *restore)
+		}
 	}
-	// Reconcile Chia-Healthcheck Service
-	if !kube.ShouldRollIntoMainPeerService(node.Spec.ChiaHealthcheckConfig.Service) {
-		res, err = kube.ReconcileService(ctx, r.Client, node.Spec.ChiaHealthcheckConfig.Service, healthcheckSrv, false)
+	node.Status.DatabaseRestores = restores
+	return nil
+}
+
+// getGeneratedVolumeClaimNames returns the names of the CHIA_ROOT PersistentVolumeClaims generated from a ChiaNode's volume claim template
+func (r *ChiaNodeReconciler) getGeneratedVolumeClaimNames(ctx context.Context, node k8schianetv1.ChiaNode) ([]string, error) {
+	if node.Spec.Storage == nil || node.Spec.Storage.ChiaRoot == nil || node.Spec.Storage.ChiaRoot.PersistentVolumeClaim == nil {
+		return nil, nil
+	}
+	var pvcs corev1.PersistentVolumeClaimList
+	if err := r.List(ctx, &pvcs, client.InNamespace(node.Namespace)); err != nil {
+		return nil, fmt.Errorf("error listing PersistentVolumeClaims: %w", err)
+	}
+	return getReplicaVolumeClaimNames(node, pvcs.Items), nil
+}
+
+// getRestoreSnapshot returns the VolumeSnapshot to restore new replicas' CHIA_ROOT volumes from, or nil if there isn't one.
+// This is the latest ready snapshot of this ChiaNode if it restores new replicas from its own snapshots,
+// or of the ChiaNode set as its chia-db-pull source, whichever is at the higher peak height.
+func (r *ChiaNodeReconciler) getRestoreSnapshot(ctx context.Context, node k8schianetv1.ChiaNode, snapshots []unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	var candidates []*unstructured.Unstructured
+	if snapshotsEnabled(node) && restoreNewReplicasEnabled(*node.Spec.Snapshots) {
+		candidates = append(candidates, getLatestReadySnapshot(snapshots))
+	}
+	if kube.ChiaDBPullEnabled(node.Spec.ChiaDBPullConfig) && node.Spec.ChiaDBPullConfig.ChiaNodeSnapshot != nil {
+		source := metav1.ObjectMeta{Name: node.Spec.ChiaDBPullConfig.ChiaNodeSnapshot.Name}
+		sourceSnapshots, err := volumesnapshot.List(ctx, r.Client, node.Namespace, kube.GetCommonLabels(string(consts.ChiaNodeKind), source))
 		if err != nil {
-			return res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s %v", req.NamespacedName, err)
+			return nil, err
 		}
+		candidates = append(candidates, getLatestReadySnapshot(sourceSnapshots))
 	}
+	return getHighestSnapshot(candidates), nil
+}
 
-	// Assemble StatefulSet
-	stateful, err := assembleStatefulset(ctx, node, fullNodePort, networkData)
-	if err != nil {
-		r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble node Deployment -- Check operator logs.")
-		return reconcile.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s %v", req.NamespacedName, err)
+// restoreReplicaVolumeClaims creates the CHIA_ROOT PersistentVolumeClaims of replicas that don't have one yet, restored from a ready VolumeSnapshot.
+// StatefulSet volume claim templates can't be changed, so the claims are created before the StatefulSet creates them from its template.
+func (r *ChiaNodeReconciler) restoreReplicaVolumeClaims(ctx context.Context, node k8schianetv1.ChiaNode, stateful appsv1.StatefulSet, latest unstructured.Unstructured) error {
+	var template *corev1.PersistentVolumeClaim
+	for i := range stateful.Spec.VolumeClaimTemplates {
+		if stateful.Spec.VolumeClaimTemplates[i].Name == "chiaroot" {
+			template = &stateful.Spec.VolumeClaimTemplates[i]
+		}
 	}
-	if err := controllerutil.SetControllerReference(&node, &stateful, r.Scheme); err != nil {
-		r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble seeder Statefulset -- Check operator logs.")
-		return reconcile.Result{}, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s %v", req.NamespacedName, err)
+	if template == nil {
+		return nil
 	}
-	// Reconcile StatefulSet
-	res, err = kube.ReconcileStatefulset(ctx, r.Client, stateful)
-	if err != nil {
-		r.Recorder.Eventf(&node, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to create seeder Statefulset -- Check operator logs.")
-		return res, fmt.Errorf("ChiaNodeReconciler ChiaNode=%s %v", req.NamespacedName, err)
+
+	for ordinal := int32(0); ordinal < node.Spec.Replicas; ordinal++ {
+		name := getReplicaVolumeClaimName(node, ordinal)
+		var current corev1.PersistentVolumeClaim
+		err := r.Get(ctx, types.NamespacedName{Namespace: node.Namespace, Name: name}, &current)
+		if err == nil {
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("error getting PersistentVolumeClaim \"%s\": %w", name, err)
+		}
+
+		pvc := assembleRestoredVolumeClaim(node, *template, ordinal, latest)
+		if err := r.Create(ctx, &pvc); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("error creating PersistentVolumeClaim \"%s\": %w", name, err)
+		}
+		r.Recorder.Eventf(&node, nil, corev1.EventTypeNormal, "Restored", "Restored", "Restored PersistentVolumeClaim %s from VolumeSnapshot %s", name, latest.GetName())
 	}
+	return nil
+}
 
-	// Update CR status
-	r.Recorder.Eventf(&node, nil, corev1.EventTypeNormal, "Created", "Created", "Successfully created ChiaNode resources.")
-	node.Status.Ready = true
-	err = r.Status().Update(ctx, &node)
-	if err != nil {
-		if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
-			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+// reconcileSnapshots takes a VolumeSnapshot of a synced replica's CHIA_ROOT volume when one is due, deletes snapshots past the number to retain,
+// and records the state of the snapshots in the ChiaNode's status. The snapshots must be sorted newest first.
+// Returns the amount of time until the next snapshot is due, or 0 if snapshots are disabled.
+func (r *ChiaNodeReconciler) reconcileSnapshots(ctx context.Context, node *k8schianetv1.ChiaNode, snapshots []unstructured.Unstructured, now time.Time) (time.Duration, error) {
+	if !snapshotsEnabled(*node) {
+		node.Status.Snapshots = nil
+		return 0, nil
+	}
+	interval := getSnapshotInterval(*node.Spec.Snapshots)
+
+	requeueAfter := getSnapshotRequeueAfter(snapshots, interval, now)
+	if requeueAfter == 0 && !snapshotInProgress(snapshots) {
+		source := getSnapshotSourceReplica(node.Status.Replicas)
+		if source == nil {
+			// Wait for a replica to report being synced in the next RPC status query
+			requeueAfter = kube.GetRPCStatusInterval(node.Spec.RPCStatus)
+		} else {
+			snapshot := assembleSnapshot(*node, *source, now)
+			if err := volumesnapshot.Create(ctx, r.Client, r.Scheme, node, snapshot); err != nil {
+				return 0, err
+			}
+			r.Recorder.Eventf(node, nil, corev1.EventTypeNormal, "Created", "Created", "Created VolumeSnapshot %s of Pod %s at peak height %d", snapshot.GetName(), source.PodName, source.PeakHeight)
+			snapshots = append([]unstructured.Unstructured{*snapshot}, snapshots...)
+			requeueAfter = interval
 		}
-		log.Error(err, fmt.Sprintf("ChiaNodeReconciler ChiaNode=%s unable to update ChiaNode status", req.NamespacedName))
-		return ctrl.Result{}, err
+	}
+	if snapshotInProgress(snapshots) && (requeueAfter == 0 || snapshotPendingRequeueInterval < requeueAfter) {
+		requeueAfter = snapshotPendingRequeueInterval
 	}
 
-	return ctrl.Result{}, nil
+	deleted := make(map[string]bool)
+	for _, snapshot := range getStaleSnapshots(snapshots, getSnapshotRetain(*node.Spec.Snapshots)) {
+		if err := r.Delete(ctx, &snapshot); client.IgnoreNotFound(err) != nil {
+			return 0, fmt.Errorf("error deleting VolumeSnapshot \"%s\": %w", snapshot.GetName(), err)
+		}
+		deleted[snapshot.GetName()] = true
+	}
+	var retained []unstructured.Unstructured
+	for _, snapshot := range snapshots {
+		if !deleted[snapshot.GetName()] {
+			retained = append(retained, snapshot)
+		}
+	}
+	node.Status.Snapshots = getSnapshotStatus(retained)
+
+	return requeueAfter, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -230,10 +510,12 @@ func (r *ChiaNodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&k8schianetv1.ChiaNode{}).
 		Owns(&appsv1.StatefulSet{}).
 		Owns(&corev1.Service{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
 		Watches(
 			&corev1.ConfigMap{},
 			handler.EnqueueRequestsFromMapFunc(r.handleChiaNetworks),
 		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
@@ -8,17 +8,45 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+	"github.com/chia-network/chia-operator/internal/controller/common/volumesnapshot"
+	"github.com/chia-network/chia-operator/internal/dbrestore"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
 )
 
+const (
+	// snapshotPeakHeightAnnotation is the VolumeSnapshot annotation that records the peak height of the replica the snapshot was taken from
+	snapshotPeakHeightAnnotation = "k8s.chia.net/peak-height"
+
+	// snapshotSourcePodAnnotation is the VolumeSnapshot annotation that records the Pod of the replica the snapshot was taken from
+	snapshotSourcePodAnnotation = "k8s.chia.net/source-pod"
+
+	// restoredFromSnapshotAnnotation is the PersistentVolumeClaim annotation that records the VolumeSnapshot a replica's CHIA_ROOT volume was restored from
+	restoredFromSnapshotAnnotation = "k8s.chia.net/restored-from-snapshot"
+
+	// replicaServiceLabel is the label set on the peer Services of individual replicas, so Services of removed replicas can be found
+	replicaServiceLabel = "k8s.chia.net/replica-service"
+
+	// chiaDBPullSourceVolumeName is the name of the volume of the chia-db-pull PersistentVolumeClaim source
+	chiaDBPullSourceVolumeName = "chia-db-pull-source"
+
+	// snapshotPendingRequeueInterval is how often a ChiaNode is requeued while a VolumeSnapshot isn't ready to use yet
+	snapshotPendingRequeueInterval = 30 * time.Second
+)
+
 // getChiaVolumes retrieves the requisite volumes from the Chia config struct
 func getChiaVolumesAndTemplates(node k8schianetv1.ChiaNode) ([]corev1.Volume, []corev1.PersistentVolumeClaim) {
 	var v []corev1.Volume
@@ -167,3 +195,323 @@ func getChiaEnv(ctx context.Context, node k8schianetv1.ChiaNode, networkData *ma
 
 	return env, nil
 }
+
+// summarizeReplicaStatuses returns the highest peak height and the number of synced replicas from a list of replica statuses
+func summarizeReplicaStatuses(replicas []k8schianetv1.ChiaNodeReplicaStatus) (uint32, int32) {
+	var peakHeight uint32
+	var synced int32
+	for _, replica := range replicas {
+		if replica.PeakHeight > peakHeight {
+			peakHeight = replica.PeakHeight
+		}
+		if replica.Synced {
+			synced++
+		}
+	}
+	return peakHeight, synced
+}
+
+// getOldestReplicaCheck returns the earliest time any of the replicas' RPC servers were last queried, or nil if there are no replicas
+func getOldestReplicaCheck(replicas []k8schianetv1.ChiaNodeReplicaStatus) *metav1.Time {
+	var oldest *metav1.Time
+	for i := range replicas {
+		if oldest == nil || replicas[i].LastChecked.Before(oldest) {
+			oldest = &replicas[i].LastChecked
+		}
+	}
+	return oldest
+}
+
+// snapshotsEnabled returns true if the operator takes VolumeSnapshots of the ChiaNode's CHIA_ROOT volumes
+func snapshotsEnabled(node k8schianetv1.ChiaNode) bool {
+	return node.Spec.Snapshots != nil && node.Spec.Snapshots.Enabled != nil && *node.Spec.Snapshots.Enabled
+}
+
+// restoreNewReplicasEnabled returns true if new replicas' CHIA_ROOT volumes are restored from the latest VolumeSnapshot, which they are by default
+func restoreNewReplicasEnabled(config k8schianetv1.ChiaNodeSnapshotConfig) bool {
+	return config.RestoreNewReplicas == nil || *config.RestoreNewReplicas
+}
+
+// getSnapshotInterval returns the minimum amount of time between VolumeSnapshots (defaults to 24 hours)
+func getSnapshotInterval(config k8schianetv1.ChiaNodeSnapshotConfig) time.Duration {
+	if config.Interval == nil || config.Interval.Duration <= 0 {
+		return consts.DefaultSnapshotInterval
+	}
+	return config.Interval.Duration
+}
+
+// getSnapshotRetain returns the number of VolumeSnapshots to keep (defaults to 3)
+func getSnapshotRetain(config k8schianetv1.ChiaNodeSnapshotConfig) int {
+	if config.Retain == nil || *config.Retain < 1 {
+		return consts.DefaultSnapshotRetain
+	}
+	return int(*config.Retain)
+}
+
+// getSnapshotSourceReplica returns the synced replica with the highest peak height to take a VolumeSnapshot of, or nil if no replicas are synced.
+// Replicas with the same peak height are chosen in order of their Pod names.
+func getSnapshotSourceReplica(replicas []k8schianetv1.ChiaNodeReplicaStatus) *k8schianetv1.ChiaNodeReplicaStatus {
+	var source *k8schianetv1.ChiaNodeReplicaStatus
+	for i := range replicas {
+		replica := &replicas[i]
+		if !replica.Synced || replica.Error != "" {
+			continue
+		}
+		if source == nil || replica.PeakHeight > source.PeakHeight || (replica.PeakHeight == source.PeakHeight && replica.PodName < source.PodName) {
+			source = replica
+		}
+	}
+	return source
+}
+
+// getReplicaVolumeClaimName returns the name of the CHIA_ROOT PersistentVolumeClaim the StatefulSet creates for a replica
+func getReplicaVolumeClaimName(node k8schianetv1.ChiaNode, ordinal int32) string {
+	return fmt.Sprintf("chiaroot-%s-%d", fmt.Sprintf(chianodeNamePattern, node.Name), ordinal)
+}
+
+// getReplicaVolumeClaimNames returns the names of the CHIA_ROOT PersistentVolumeClaims of a ChiaNode's replicas from a list of claims,
+// including the claims of replicas that were scaled down
+func getReplicaVolumeClaimNames(node k8schianetv1.ChiaNode, pvcs []corev1.PersistentVolumeClaim) []string {
+	prefix := fmt.Sprintf("chiaroot-%s-", fmt.Sprintf(chianodeNamePattern, node.Name))
+	var names []string
+	for _, pvc := range pvcs {
+		ordinal, found := strings.CutPrefix(pvc.Name, prefix)
+		if !found {
+			continue
+		}
+		if _, err := strconv.ParseUint(ordinal, 10, 32); err == nil {
+			names = append(names, pvc.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getLatestReadySnapshot returns the newest VolumeSnapshot that's ready to use, or nil if there isn't one. The snapshots must be sorted newest first.
+func getLatestReadySnapshot(snapshots []unstructured.Unstructured) *unstructured.Unstructured {
+	for i := range snapshots {
+		if volumesnapshot.IsReady(snapshots[i]) {
+			return &snapshots[i]
+		}
+	}
+	return nil
+}
+
+// snapshotInProgress returns true if the newest VolumeSnapshot isn't ready to use yet and hasn't failed. The snapshots must be sorted newest first.
+func snapshotInProgress(snapshots []unstructured.Unstructured) bool {
+	return len(snapshots) != 0 && !volumesnapshot.IsReady(snapshots[0]) && volumesnapshot.GetError(snapshots[0]) == ""
+}
+
+// getStaleSnapshots returns the VolumeSnapshots past the number to retain, except for the latest snapshot that's ready to use.
+// The snapshots must be sorted newest first.
+func getStaleSnapshots(snapshots []unstructured.Unstructured, retain int) []unstructured.Unstructured {
+	latestReady := getLatestReadySnapshot(snapshots)
+	var stale []unstructured.Unstructured
+	for i := retain; i < len(snapshots); i++ {
+		if latestReady != nil && snapshots[i].GetName() == latestReady.GetName() {
+			continue
+		}
+		stale = append(stale, snapshots[i])
+	}
+	return stale
+}
+
+// getSnapshotRequeueAfter returns how long to wait before the next VolumeSnapshot is due, given the existing snapshots sorted newest first.
+// The schedule is based on the newest snapshot that hasn't failed, so a failed snapshot is retried instead of waiting for the next interval.
+// Returns 0 if a snapshot is due now.
+func getSnapshotRequeueAfter(snapshots []unstructured.Unstructured, interval time.Duration, now time.Time) time.Duration {
+	for _, snapshot := range snapshots {
+		if volumesnapshot.GetError(snapshot) != "" {
+			continue
+		}
+		created := snapshot.GetCreationTimestamp()
+		return kube.GetRPCStatusRequeueAfter(&created, interval, now)
+	}
+	return 0
+}
+
+// getSnapshotStatus summarizes a ChiaNode's VolumeSnapshots, sorted newest first, for its status
+func getSnapshotStatus(snapshots []unstructured.Unstructured) *k8schianetv1.ChiaNodeSnapshotStatus {
+	status := &k8schianetv1.ChiaNodeSnapshotStatus{
+		Count: int32(len(snapshots)),
+	}
+	if len(snapshots) != 0 {
+		created := snapshots[0].GetCreationTimestamp()
+		status.LastSnapshotTime = &created
+		status.Error = volumesnapshot.GetError(snapshots[0])
+	}
+	if latest := getLatestReadySnapshot(snapshots); latest != nil {
+		status.LatestReadySnapshot = latest.GetName()
+		if height, err := strconv.ParseUint(latest.GetAnnotations()[snapshotPeakHeightAnnotation], 10, 32); err == nil {
+			status.LatestReadySnapshotPeakHeight = uint32(height)
+		}
+	}
+	return status
+}
+
+// chiaDBPullInitContainerEnabled returns true if a chia-db-pull init container restores the blockchain database.
+// Databases from another ChiaNode's VolumeSnapshots are restored with the replica's PersistentVolumeClaim instead.
+func chiaDBPullInitContainerEnabled(node k8schianetv1.ChiaNode) bool {
+	return kube.ChiaDBPullEnabled(node.Spec.ChiaDBPullConfig) && node.Spec.ChiaDBPullConfig.ChiaNodeSnapshot == nil
+}
+
+// getChiaDBPullSource returns a description of the chia-db-pull source a replica's database was restored from,
+// for sources that don't report it themselves
+func getChiaDBPullSource(config k8schianetv1.SpecChiaDBPull) string {
+	if config.S3Prefix != "" {
+		return config.S3Prefix
+	}
+	return ""
+}
+
+// getRestoreStatus returns the database restore status of a Pod from chia-db-restore's termination message
+func getRestoreStatus(m dbrestore.Message, podName string) *k8schianetv1.ChiaNodeDatabaseRestoreStatus {
+	return &k8schianetv1.ChiaNodeDatabaseRestoreStatus{
+		PodName:  podName,
+		Source:   m.Source,
+		Height:   m.Height,
+		Restored: m.Restored,
+	}
+}
+
+// getDatabaseRestoreStatus returns where a replica's blockchain database was restored from,
+// from its chia-db-pull init container or the VolumeSnapshot its CHIA_ROOT PersistentVolumeClaim was restored from.
+// Returns nil if it isn't known.
+func getDatabaseRestoreStatus(node k8schianetv1.ChiaNode, pod corev1.Pod, pvc *corev1.PersistentVolumeClaim) *k8schianetv1.ChiaNodeDatabaseRestoreStatus {
+	if pvc != nil {
+		if snapshot, ok := pvc.Annotations[restoredFromSnapshotAnnotation]; ok {
+			status := &k8schianetv1.ChiaNodeDatabaseRestoreStatus{
+				PodName:  pod.Name,
+				Source:   fmt.Sprintf("%s/%s", volumesnapshot.Kind, snapshot),
+				Restored: true,
+			}
+			if height, err := strconv.ParseUint(pvc.Annotations[snapshotPeakHeightAnnotation], 10, 32); err == nil {
+				status.Height = uint32(height)
+			}
+			return status
+		}
+	}
+
+	if !chiaDBPullInitContainerEnabled(node) {
+		return nil
+	}
+	// With S3 sources, chia-db-install reports a database it installed, and chia-db-check reports an existing database it kept,
+	// since chia-db-pull doesn't report the database it downloaded
+	if message, ok := getInitContainerMessage(pod, "chia-db-install"); ok && message != nil {
+		status := getRestoreStatus(*message, pod.Name)
+		if status.Restored {
+			status.Source = getChiaDBPullSource(node.Spec.ChiaDBPullConfig)
+		}
+		return status
+	}
+	if message, ok := getInitContainerMessage(pod, "chia-db-check"); ok && message != nil {
+		return getRestoreStatus(*message, pod.Name)
+	}
+	message, ok := getInitContainerMessage(pod, "chia-db-pull")
+	if !ok {
+		return nil
+	}
+	if message == nil {
+		// chia-db-pull doesn't report the database it restored
+		return &k8schianetv1.ChiaNodeDatabaseRestoreStatus{
+			PodName:  pod.Name,
+			Source:   getChiaDBPullSource(node.Spec.ChiaDBPullConfig),
+			Restored: true,
+		}
+	}
+	return getRestoreStatus(*message, pod.Name)
+}
+
+// getInitContainerMessage returns chia-db-restore's termination message from an init container,
+// and whether the init container completed. The message is nil if the container didn't write one.
+func getInitContainerMessage(pod corev1.Pod, name string) (*dbrestore.Message, bool) {
+	for _, container := range pod.Status.InitContainerStatuses {
+		if container.Name != name || container.State.Terminated == nil || container.State.Terminated.ExitCode != 0 {
+			continue
+		}
+		var message dbrestore.Message
+		if err := json.Unmarshal([]byte(container.State.Terminated.Message), &message); err != nil {
+			return nil, true
+		}
+		return &message, true
+	}
+	return nil, false
+}
+
+// getPeakHeightAnnotation returns the peak height recorded in a VolumeSnapshot's annotations, or 0 if there isn't one
+func getPeakHeightAnnotation(snapshot unstructured.Unstructured) uint64 {
+	height, _ := strconv.ParseUint(snapshot.GetAnnotations()[snapshotPeakHeightAnnotation], 10, 32)
+	return height
+}
+
+// getHighestSnapshot returns the VolumeSnapshot with the highest peak height annotation, ignoring nil entries.
+// Snapshots at the same height are preferred in the order they're listed.
+func getHighestSnapshot(snapshots []*unstructured.Unstructured) *unstructured.Unstructured {
+	var highest *unstructured.Unstructured
+	for _, snapshot := range snapshots {
+		if snapshot == nil {
+			continue
+		}
+		if highest == nil || getPeakHeightAnnotation(*snapshot) > getPeakHeightAnnotation(*highest) {
+			highest = snapshot
+		}
+	}
+	return highest
+}
+
+// getReplicaServiceOverride returns the additional metadata for the Service of the replica with the given ordinal
+func getReplicaServiceOverride(config k8schianetv1.ChiaNodeReplicaService, ordinal int32) k8schianetv1.AdditionalMetadata {
+	for _, override := range config.Replicas {
+		if override.Ordinal == ordinal {
+			return override.AdditionalMetadata
+		}
+	}
+	return k8schianetv1.AdditionalMetadata{}
+}
+
+// getReplicaServiceStatus returns the external address a replica can be reached at through its own peer Service.
+// The replica's Kubernetes node is only used for NodePort Services, and may be nil if it isn't known.
+func getReplicaServiceStatus(podName string, srv corev1.Service, node *corev1.Node) k8schianetv1.ChiaNodeReplicaServiceStatus {
+	status := k8schianetv1.ChiaNodeReplicaServiceStatus{
+		PodName:     podName,
+		ServiceName: srv.Name,
+	}
+	var port corev1.ServicePort
+	for _, p := range srv.Spec.Ports {
+		if p.Name == "peers" {
+			port = p
+		}
+	}
+
+	switch srv.Spec.Type {
+	case corev1.ServiceTypeLoadBalancer:
+		status.Port = port.Port
+		for _, ingress := range srv.Status.LoadBalancer.Ingress {
+			if ingress.IP != "" {
+				status.ExternalAddress = ingress.IP
+				break
+			}
+			if ingress.Hostname != "" {
+				status.ExternalAddress = ingress.Hostname
+				break
+			}
+		}
+	case corev1.ServiceTypeNodePort:
+		status.Port = port.NodePort
+		if node != nil {
+			for _, address := range node.Status.Addresses {
+				if address.Type == corev1.NodeExternalIP {
+					status.ExternalAddress = address.Address
+					break
+				}
+			}
+		}
+	default:
+		status.Port = port.Port
+	}
+	if status.ExternalAddress == "" && len(srv.Spec.ExternalIPs) > 0 {
+		status.ExternalAddress = srv.Spec.ExternalIPs[0]
+		status.Port = port.Port
+	}
+	return status
+}
@@ -2,13 +2,17 @@ package chianode
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
 )
 
 func TestGetChiaVolumeMounts(t *testing.T) {
@@ -281,6 +285,11 @@ func stringPtr(s string) *string {
 	return &s
 }
 
+// Helper function to create a bool pointer
+func boolPtr(b bool) *bool {
+	return &b
+}
+
 func TestAssembleChiaDBPullContainer(t *testing.T) {
 	network := "testnet11"
 	minHeight := int64(123456)
@@ -310,7 +319,7 @@ func TestAssembleChiaDBPullContainer(t *testing.T) {
 	for _, e := range cont.Env {
 		envByName[e.Name] = e.Value
 	}
-	assert.Equal(t, "/chia-data", envByName["CHIA_ROOT"])
+	assert.Equal(t, kube.ChiaDBPullStagingRoot, envByName["CHIA_ROOT"], "S3 databases are downloaded into a staging directory")
 	assert.Equal(t, "s3://test/", envByName["S3_PREFIX"])
 	assert.Equal(t, "testnet11", envByName["NETWORK"])
 	assert.Equal(t, "123456", envByName["MIN_HEIGHT"])
@@ -387,3 +396,422 @@ func TestAssembleChiaDBPullContainer_DerivesNetwork(t *testing.T) {
 		assert.False(t, hasNetwork, "NETWORK env should be omitted when no network is resolvable")
 	})
 }
+
+func TestSummarizeReplicaStatuses(t *testing.T) {
+	replicas := []k8schianetv1.ChiaNodeReplicaStatus{
+		{PodName: "node-0", PeakHeight: 100, Synced: true},
+		{PodName: "node-1", PeakHeight: 250, Synced: true},
+		{PodName: "node-2", PeakHeight: 50, SyncMode: true},
+	}
+	peakHeight, synced := summarizeReplicaStatuses(replicas)
+	assert.Equal(t, uint32(250), peakHeight)
+	assert.Equal(t, int32(2), synced)
+
+	peakHeight, synced = summarizeReplicaStatuses(nil)
+	assert.Equal(t, uint32(0), peakHeight)
+	assert.Equal(t, int32(0), synced)
+}
+
+func TestGetOldestReplicaCheck(t *testing.T) {
+	now := time.Now()
+	replicas := []k8schianetv1.ChiaNodeReplicaStatus{
+		{PodName: "node-0", LastChecked: metav1.NewTime(now)},
+		{PodName: "node-1", LastChecked: metav1.NewTime(now.Add(-time.Minute))},
+	}
+	oldest := getOldestReplicaCheck(replicas)
+	assert.NotNil(t, oldest)
+	assert.True(t, oldest.Equal(&replicas[1].LastChecked))
+
+	assert.Nil(t, getOldestReplicaCheck(nil))
+}
+
+func TestGetSnapshotSourceReplica(t *testing.T) {
+	assert.Nil(t, getSnapshotSourceReplica(nil))
+
+	replicas := []k8schianetv1.ChiaNodeReplicaStatus{
+		{PodName: "node-0", PeakHeight: 100, Synced: true},
+		{PodName: "node-1", PeakHeight: 200, Synced: false},
+		{PodName: "node-2", PeakHeight: 150, Synced: true, Error: "timeout"},
+		{PodName: "node-3", PeakHeight: 120, Synced: true},
+		{PodName: "node-4", PeakHeight: 120, Synced: true},
+	}
+	assert.Equal(t, "node-3", getSnapshotSourceReplica(replicas).PodName)
+}
+
+func TestGetReplicaVolumeClaimName(t *testing.T) {
+	node := k8schianetv1.ChiaNode{ObjectMeta: metav1.ObjectMeta{Name: "mainnet"}}
+	assert.Equal(t, "chiaroot-mainnet-node-2", getReplicaVolumeClaimName(node, 2))
+}
+
+// newTestSnapshot returns a VolumeSnapshot with the given name and readiness, created the given amount of time ago
+func newTestSnapshot(name string, age time.Duration, ready bool, now time.Time) unstructured.Unstructured {
+	u := unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"readyToUse": ready,
+		},
+	}}
+	u.SetName(name)
+	u.SetCreationTimestamp(metav1.NewTime(now.Add(-age)))
+	u.SetAnnotations(map[string]string{snapshotPeakHeightAnnotation: "5000000"})
+	return u
+}
+
+func TestGetStaleSnapshots(t *testing.T) {
+	now := time.Now()
+	snapshots := []unstructured.Unstructured{
+		newTestSnapshot("snap-4", 0, false, now),
+		newTestSnapshot("snap-3", time.Hour, false, now),
+		newTestSnapshot("snap-2", 2*time.Hour, true, now),
+		newTestSnapshot("snap-1", 3*time.Hour, true, now),
+	}
+
+	// The latest ready snapshot is kept even if it's past the number to retain
+	stale := getStaleSnapshots(snapshots, 2)
+	assert.Len(t, stale, 1)
+	assert.Equal(t, "snap-1", stale[0].GetName())
+
+	assert.Empty(t, getStaleSnapshots(snapshots, 4))
+	assert.True(t, snapshotInProgress(snapshots))
+	assert.False(t, snapshotInProgress(snapshots[2:]))
+}
+
+func TestGetSnapshotRequeueAfter(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, time.Duration(0), getSnapshotRequeueAfter(nil, time.Hour, now))
+
+	snapshots := []unstructured.Unstructured{newTestSnapshot("snap-1", 15*time.Minute, true, now)}
+	assert.InDelta(t, float64(45*time.Minute), float64(getSnapshotRequeueAfter(snapshots, time.Hour, now)), float64(time.Second))
+	assert.Equal(t, time.Duration(0), getSnapshotRequeueAfter(snapshots, 10*time.Minute, now))
+
+	// A failed snapshot is retried right away, and snapshots in progress keep the schedule
+	failed := newTestSnapshot("snap-2", time.Minute, false, now)
+	assert.NoError(t, unstructured.SetNestedField(failed.Object, "snapshot failed", "status", "error", "message"))
+	assert.Equal(t, time.Duration(0), getSnapshotRequeueAfter([]unstructured.Unstructured{failed}, time.Hour, now))
+	assert.InDelta(t, float64(45*time.Minute), float64(getSnapshotRequeueAfter([]unstructured.Unstructured{failed, snapshots[0]}, time.Hour, now)), float64(time.Second))
+	inProgress := newTestSnapshot("snap-3", 0, false, now)
+	assert.InDelta(t, float64(time.Hour), float64(getSnapshotRequeueAfter([]unstructured.Unstructured{inProgress, failed, snapshots[0]}, time.Hour, now)), float64(time.Second))
+}
+
+func TestGetSnapshotStatus(t *testing.T) {
+	now := time.Now()
+	snapshots := []unstructured.Unstructured{
+		newTestSnapshot("snap-2", 0, false, now),
+		newTestSnapshot("snap-1", time.Hour, true, now),
+	}
+
+	status := getSnapshotStatus(snapshots)
+	assert.Equal(t, int32(2), status.Count)
+	assert.Equal(t, "snap-1", status.LatestReadySnapshot)
+	assert.Equal(t, uint32(5000000), status.LatestReadySnapshotPeakHeight)
+	assert.Equal(t, snapshots[0].GetCreationTimestamp(), *status.LastSnapshotTime)
+}
+
+func TestAssembleRestoredVolumeClaim(t *testing.T) {
+	node := k8schianetv1.ChiaNode{ObjectMeta: metav1.ObjectMeta{Name: "mainnet", Namespace: "chia"}}
+	template := corev1.PersistentVolumeClaim{
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse("250Gi"),
+				},
+			},
+		},
+	}
+	snapshot := newTestSnapshot("mainnet-node-20260101000000", 0, true, time.Now())
+	snapshot.Object["status"].(map[string]interface{})["restoreSize"] = "300Gi"
+
+	pvc := assembleRestoredVolumeClaim(node, template, 1, snapshot)
+	assert.Equal(t, "chiaroot-mainnet-node-1", pvc.Name)
+	assert.Equal(t, "chia", pvc.Namespace)
+	assert.Equal(t, "VolumeSnapshot", pvc.Spec.DataSource.Kind)
+	assert.Equal(t, "snapshot.storage.k8s.io", *pvc.Spec.DataSource.APIGroup)
+	assert.Equal(t, "mainnet-node-20260101000000", pvc.Spec.DataSource.Name)
+	assert.Equal(t, resource.MustParse("300Gi"), pvc.Spec.Resources.Requests[corev1.ResourceStorage])
+	assert.Equal(t, "mainnet-node-20260101000000", pvc.Annotations[restoredFromSnapshotAnnotation])
+	assert.Equal(t, "5000000", pvc.Annotations[snapshotPeakHeightAnnotation])
+	// The template isn't modified
+	assert.Equal(t, resource.MustParse("250Gi"), template.Spec.Resources.Requests[corev1.ResourceStorage])
+}
+
+func TestGetHighestSnapshot(t *testing.T) {
+	now := time.Now()
+	own := newTestSnapshot("own", 0, true, now)
+	source := newTestSnapshot("source", 0, true, now)
+	source.SetAnnotations(map[string]string{snapshotPeakHeightAnnotation: "6000000"})
+
+	assert.Nil(t, getHighestSnapshot(nil))
+	assert.Nil(t, getHighestSnapshot([]*unstructured.Unstructured{nil, nil}))
+	assert.Equal(t, "own", getHighestSnapshot([]*unstructured.Unstructured{&own, nil}).GetName())
+	assert.Equal(t, "source", getHighestSnapshot([]*unstructured.Unstructured{&own, &source}).GetName())
+
+	// Snapshots at the same height are preferred in the order they're listed
+	source.SetAnnotations(map[string]string{snapshotPeakHeightAnnotation: "5000000"})
+	assert.Equal(t, "own", getHighestSnapshot([]*unstructured.Unstructured{&own, &source}).GetName())
+}
+
+func TestAssembleChiaDBPullContainer_Sources(t *testing.T) {
+	node := k8schianetv1.ChiaNode{
+		Spec: k8schianetv1.ChiaNodeSpec{
+			ChiaDBPullConfig: k8schianetv1.SpecChiaDBPull{
+				Enabled: boolPtr(true),
+				HTTP: &k8schianetv1.ChiaDBPullHTTPSource{
+					URLs:   []string{"https://a.example.com/blockchain_v2_mainnet.sqlite.gz", "https://b.example.com/blockchain_v2_mainnet.sqlite.gz"},
+					SHA256: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+				},
+			},
+		},
+	}
+
+	cont := assembleChiaDBPullContainer(node, nil)
+	assert.Equal(t, consts.DefaultChiaOperatorImageName+":"+consts.DefaultChiaOperatorImageTag, cont.Image)
+	assert.Equal(t, []string{"/chia-db-restore"}, cont.Command)
+	envByName := map[string]string{}
+	for _, e := range cont.Env {
+		envByName[e.Name] = e.Value
+	}
+	assert.Equal(t, "https://a.example.com/blockchain_v2_mainnet.sqlite.gz\nhttps://b.example.com/blockchain_v2_mainnet.sqlite.gz", envByName["DB_URLS"])
+	assert.Equal(t, node.Spec.ChiaDBPullConfig.HTTP.SHA256, envByName["DB_SHA256"])
+	assert.NotContains(t, envByName, "S3_PREFIX")
+
+	node.Spec.ChiaDBPullConfig.HTTP = nil
+	node.Spec.ChiaDBPullConfig.PersistentVolumeClaim = &k8schianetv1.ChiaDBPullPersistentVolumeClaimSource{
+		ClaimName: "db-mirror",
+		Path:      "mainnet/blockchain_v2_mainnet.sqlite",
+	}
+	cont = assembleChiaDBPullContainer(node, nil)
+	assert.Len(t, cont.VolumeMounts, 2)
+	assert.Equal(t, chiaDBPullSourceVolumeName, cont.VolumeMounts[1].Name)
+	assert.True(t, cont.VolumeMounts[1].ReadOnly)
+	assert.True(t, chiaDBPullInitContainerEnabled(node))
+
+	// Snapshots of another ChiaNode are restored without an init container
+	node.Spec.ChiaDBPullConfig.PersistentVolumeClaim = nil
+	node.Spec.ChiaDBPullConfig.ChiaNodeSnapshot = &k8schianetv1.ChiaDBPullChiaNodeSnapshotSource{Name: "mainnet"}
+	assert.False(t, chiaDBPullInitContainerEnabled(node))
+}
+
+func TestGetDatabaseRestoreStatus(t *testing.T) {
+	node := k8schianetv1.ChiaNode{
+		Spec: k8schianetv1.ChiaNodeSpec{
+			ChiaDBPullConfig: k8schianetv1.SpecChiaDBPull{
+				Enabled:  boolPtr(true),
+				S3Prefix: "s3://bucket/mainnet/",
+			},
+		},
+	}
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "mainnet-node-0"}}
+	assert.Nil(t, getDatabaseRestoreStatus(node, pod, nil))
+
+	// chia-db-pull doesn't write a termination message, so the S3 prefix is reported
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{
+		{
+			Name: "chia-db-pull",
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{ExitCode: 0},
+			},
+		},
+	}
+	assert.Equal(t, &k8schianetv1.ChiaNodeDatabaseRestoreStatus{
+		PodName:  "mainnet-node-0",
+		Source:   "s3://bucket/mainnet/",
+		Restored: true,
+	}, getDatabaseRestoreStatus(node, pod, nil))
+
+	pod.Status.InitContainerStatuses[0].State.Terminated.Message = `{"source": "https://b.example.com/db.sqlite.gz", "height": 5000000, "restored": true}`
+	assert.Equal(t, &k8schianetv1.ChiaNodeDatabaseRestoreStatus{
+		PodName:  "mainnet-node-0",
+		Source:   "https://b.example.com/db.sqlite.gz",
+		Height:   5000000,
+		Restored: true,
+	}, getDatabaseRestoreStatus(node, pod, nil))
+
+	// Failed restores aren't reported
+	pod.Status.InitContainerStatuses[0].State.Terminated.ExitCode = 1
+	assert.Nil(t, getDatabaseRestoreStatus(node, pod, nil))
+
+	// An existing database kept by chia-db-check is reported instead of chia-db-pull
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{
+		{
+			Name: "chia-db-check",
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: 0,
+					Message:  `{"source": "", "height": 5100000, "restored": false}`,
+				},
+			},
+		},
+		{
+			Name: "chia-db-pull",
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{ExitCode: 0},
+			},
+		},
+	}
+	assert.Equal(t, &k8schianetv1.ChiaNodeDatabaseRestoreStatus{
+		PodName:  "mainnet-node-0",
+		Height:   5100000,
+		Restored: false,
+	}, getDatabaseRestoreStatus(node, pod, nil))
+
+	// A database installed by chia-db-install is reported with the S3 prefix it was downloaded from
+	pod.Status.InitContainerStatuses[0].State.Terminated.Message = ""
+	pod.Status.InitContainerStatuses = append(pod.Status.InitContainerStatuses, corev1.ContainerStatus{
+		Name: "chia-db-install",
+		State: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 0,
+				Message:  `{"source": "", "height": 5200000, "restored": true}`,
+			},
+		},
+	})
+	assert.Equal(t, &k8schianetv1.ChiaNodeDatabaseRestoreStatus{
+		PodName:  "mainnet-node-0",
+		Source:   "s3://bucket/mainnet/",
+		Height:   5200000,
+		Restored: true,
+	}, getDatabaseRestoreStatus(node, pod, nil))
+
+	// Volumes restored from a VolumeSnapshot take precedence
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				restoredFromSnapshotAnnotation: "mainnet-node-20260101000000",
+				snapshotPeakHeightAnnotation:   "6000000",
+			},
+		},
+	}
+	assert.Equal(t, &k8schianetv1.ChiaNodeDatabaseRestoreStatus{
+		PodName:  "mainnet-node-0",
+		Source:   "VolumeSnapshot/mainnet-node-20260101000000",
+		Height:   6000000,
+		Restored: true,
+	}, getDatabaseRestoreStatus(node, pod, pvc))
+}
+
+func TestAssemblePeakHeightConfigMap(t *testing.T) {
+	node := k8schianetv1.ChiaNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "mainnet", Namespace: "chia"},
+	}
+	cm := assemblePeakHeightConfigMap(node)
+	assert.Equal(t, "mainnet-node-peak-height", cm.Name)
+	assert.Empty(t, cm.Data)
+
+	node.Status.PeakHeight = 5000000
+	cm = assemblePeakHeightConfigMap(node)
+	assert.Equal(t, map[string]string{"peakHeight": "5000000"}, cm.Data)
+}
+
+func TestGetChiaDBPullContainerInputs_MaxHeightLag(t *testing.T) {
+	maxHeightLag := int64(1000)
+	node := k8schianetv1.ChiaNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "mainnet"},
+		Spec: k8schianetv1.ChiaNodeSpec{
+			ChiaDBPullConfig: k8schianetv1.SpecChiaDBPull{
+				Enabled:      boolPtr(true),
+				S3Prefix:     "s3://test/",
+				MaxHeightLag: &maxHeightLag,
+			},
+		},
+	}
+	input := getChiaDBPullContainerInputs(node, nil)
+	assert.Equal(t, &maxHeightLag, input.MaxHeightLag)
+	assert.Equal(t, "mainnet-node-peak-height", input.PeakHeightConfigMapName)
+
+	// The peak height is only known when RPC status queries are enabled
+	node.Spec.RPCStatus.Enabled = boolPtr(false)
+	input = getChiaDBPullContainerInputs(node, nil)
+	assert.Empty(t, input.PeakHeightConfigMapName)
+}
+
+func TestAssembleReplicaService(t *testing.T) {
+	serviceType := corev1.ServiceTypeLoadBalancer
+	node := k8schianetv1.ChiaNode{
+		TypeMeta:   metav1.TypeMeta{Kind: "ChiaNode"},
+		ObjectMeta: metav1.ObjectMeta{Name: "mainnet", Namespace: "chia"},
+		Spec: k8schianetv1.ChiaNodeSpec{
+			ChiaConfig: k8schianetv1.ChiaNodeSpecChia{
+				ReplicaService: k8schianetv1.ChiaNodeReplicaService{
+					Service: k8schianetv1.Service{
+						AdditionalMetadata: k8schianetv1.AdditionalMetadata{
+							Annotations: map[string]string{"external-dns.alpha.kubernetes.io/hostname": "node.example.com"},
+						},
+						Enabled:     boolPtr(true),
+						ServiceType: &serviceType,
+					},
+					Replicas: []k8schianetv1.ChiaNodeReplicaServiceOverride{
+						{
+							Ordinal: 1,
+							AdditionalMetadata: k8schianetv1.AdditionalMetadata{
+								Annotations: map[string]string{
+									"external-dns.alpha.kubernetes.io/hostname": "node-1.example.com",
+									"metallb.universe.tf/loadBalancerIPs":       "203.0.113.11",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	srv := assembleReplicaService(node, 8444, 0)
+	assert.Equal(t, "mainnet-node-0", srv.Name)
+	assert.Equal(t, corev1.ServiceTypeLoadBalancer, srv.Spec.Type)
+	assert.Equal(t, "mainnet-node-0", srv.Spec.Selector["statefulset.kubernetes.io/pod-name"])
+	assert.Equal(t, "true", srv.Labels[replicaServiceLabel])
+	assert.Equal(t, int32(8444), srv.Spec.Ports[0].Port)
+	assert.Equal(t, map[string]string{"external-dns.alpha.kubernetes.io/hostname": "node.example.com"}, srv.Annotations)
+
+	// The replica's own annotations override the shared ones
+	srv = assembleReplicaService(node, 8444, 1)
+	assert.Equal(t, "mainnet-node-1", srv.Name)
+	assert.Equal(t, "node-1.example.com", srv.Annotations["external-dns.alpha.kubernetes.io/hostname"])
+	assert.Equal(t, "203.0.113.11", srv.Annotations["metallb.universe.tf/loadBalancerIPs"])
+}
+
+func TestGetReplicaServiceStatus(t *testing.T) {
+	srv := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "mainnet-node-0"},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{
+				{Name: "peers", Port: 8444, NodePort: 30444},
+			},
+		},
+	}
+	assert.Equal(t, k8schianetv1.ChiaNodeReplicaServiceStatus{
+		PodName:     "mainnet-node-0",
+		ServiceName: "mainnet-node-0",
+		Port:        8444,
+	}, getReplicaServiceStatus("mainnet-node-0", srv, nil))
+
+	srv.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}}
+	assert.Equal(t, "lb.example.com", getReplicaServiceStatus("mainnet-node-0", srv, nil).ExternalAddress)
+
+	srv.Spec.Type = corev1.ServiceTypeNodePort
+	k8sNode := &corev1.Node{
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.5"},
+				{Type: corev1.NodeExternalIP, Address: "203.0.113.5"},
+			},
+		},
+	}
+	status := getReplicaServiceStatus("mainnet-node-0", srv, k8sNode)
+	assert.Equal(t, "203.0.113.5", status.ExternalAddress)
+	assert.Equal(t, int32(30444), status.Port)
+}
+
+func TestGetReplicaVolumeClaimNames(t *testing.T) {
+	node := k8schianetv1.ChiaNode{ObjectMeta: metav1.ObjectMeta{Name: "mainnet"}}
+	pvcs := []corev1.PersistentVolumeClaim{
+		{ObjectMeta: metav1.ObjectMeta{Name: "chiaroot-mainnet-node-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "chiaroot-mainnet-node-0"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "chiaroot-mainnet-node-12"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "chiaroot-mainnet-node-1-node-0"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "chiaroot-testnet-node-0"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "mainnet-node"}},
+	}
+	assert.Equal(t, []string{"chiaroot-mainnet-node-0", "chiaroot-mainnet-node-1", "chiaroot-mainnet-node-12"}, getReplicaVolumeClaimNames(node, pvcs))
+}
@@ -11,6 +11,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -311,6 +312,18 @@ func assembleVolumeClaim(seeder k8schianetv1.ChiaSeeder) (*corev1.PersistentVolu
 	return &pvc, nil
 }
 
+// assemblePodDisruptionBudget assembles the PodDisruptionBudget resource for a ChiaSeeder CR
+func assemblePodDisruptionBudget(seeder k8schianetv1.ChiaSeeder) policyv1.PodDisruptionBudget {
+	return kube.AssemblePodDisruptionBudget(kube.AssemblePodDisruptionBudgetInputs{
+		Name:           fmt.Sprintf(chiaseederNamePattern, seeder.Name),
+		Namespace:      seeder.Namespace,
+		Labels:         kube.GetCommonLabels(seeder.Kind, seeder.ObjectMeta, seeder.Spec.Labels),
+		Annotations:    seeder.Spec.Annotations,
+		SelectorLabels: kube.GetCommonLabels(seeder.Kind, seeder.ObjectMeta),
+		Config:         seeder.Spec.PodDisruptionBudget,
+	})
+}
+
 // assembleDeployment assembles the seeder Deployment resource for a ChiaSeeder CR
 func assembleDeployment(seeder k8schianetv1.ChiaSeeder, fullNodePort int32, networkData *map[string]string) (appsv1.Deployment, error) {
 	var deploy = appsv1.Deployment{
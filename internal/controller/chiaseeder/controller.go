@@ -23,23 +23,23 @@ package chiaseeder
 import (
 	"context"
 	"fmt"
-	"strings"
-	"time"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/component"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/finalizer"
 	"github.com/chia-network/chia-operator/internal/controller/common/kube"
-	"github.com/chia-network/chia-operator/internal/metrics"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
-	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -48,184 +48,91 @@ type ChiaSeederReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder events.EventRecorder
-}
 
-var chiaseeders = make(map[string]bool)
+	// MaxConcurrentReconciles is the maximum number of ChiaSeeders that can be reconciled at the same time, which defaults to 1
+	MaxConcurrentReconciles int
+}
 
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaseeders,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaseeders/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.chia.net,resources=chiaseeders/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch
-//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch;update
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is invoked on any event to a controlled Kubernetes resource
 func (r *ChiaSeederReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := log.FromContext(ctx)
-	log.Info("Running reconciler...")
-
-	// Get the custom resource
 	var seeder k8schianetv1.ChiaSeeder
-	err := r.Get(ctx, req.NamespacedName, &seeder)
-	if err != nil && errors.IsNotFound(err) {
-		// Remove this object from the map for tracking and subtract this CR's total metric by 1
-		_, exists := chiaseeders[req.String()]
-		if exists {
-			delete(chiaseeders, req.String())
-			metrics.ChiaSeeders.Sub(1.0)
-		}
-		return ctrl.Result{}, nil
-	}
-	if err != nil {
-		log.Error(err, fmt.Sprintf("ChiaSeederReconciler ChiaSeeder=%s unable to fetch ChiaSeeder resource", req.NamespacedName))
-		return ctrl.Result{}, client.IgnoreNotFound(err)
-	}
-
-	// Add this object to the tracking map and increment the gauge by 1, if it wasn't already added
-	_, exists := chiaseeders[req.String()]
-	if !exists {
-		chiaseeders[req.String()] = true
-		metrics.ChiaSeeders.Add(1.0)
-	}
-
-	// Check for ChiaNetwork, retrieve matching ConfigMap if specified
-	networkData, err := kube.GetChiaNetworkData(ctx, r.Client, seeder.Spec.ChiaConfig.CommonSpecChia, seeder.Namespace)
-	if err != nil {
-		return ctrl.Result{}, err
-	}
-
-	// Get the full_node Port and handle the error one time instead of in every function that needs it
-	fullNodePort, err := kube.GetFullNodePort(seeder.Spec.ChiaConfig.CommonSpecChia, networkData)
-	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("encountered error retrieving the full_node Port to use: %v", err)
-	}
-
-	// Assemble Peer Service
-	peerSrv := assemblePeerService(seeder, fullNodePort)
-	if err := controllerutil.SetControllerReference(&seeder, &peerSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&seeder, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble seeder peer Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error assembling peer Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile Peer Service
-	res, err := kube.ReconcileService(ctx, r.Client, seeder.Spec.ChiaConfig.PeerService, peerSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s %v", req.NamespacedName, err)
-	}
-
-	// Assemble All Service
-	allSrv := assembleAllService(seeder, fullNodePort)
-	if err := controllerutil.SetControllerReference(&seeder, &allSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&seeder, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble seeder all-port Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error assembling all-port Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile All Service
-	res, err = kube.ReconcileService(ctx, r.Client, seeder.Spec.ChiaConfig.AllService, allSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s %v", req.NamespacedName, err)
-	}
-
-	// Assemble Daemon Service
-	daemonSrv := assembleDaemonService(seeder)
-	if err := controllerutil.SetControllerReference(&seeder, &daemonSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&seeder, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble seeder daemon Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error assembling daemon Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile Daemon Service
-	res, err = kube.ReconcileService(ctx, r.Client, seeder.Spec.ChiaConfig.DaemonService, daemonSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s %v", req.NamespacedName, err)
-	}
-
-	// Assemble RPC Service
-	rpcSrv := assembleRPCService(seeder)
-	if err := controllerutil.SetControllerReference(&seeder, &rpcSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&seeder, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble seeder RPC Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error assembling RPC Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile RPC Service
-	res, err = kube.ReconcileService(ctx, r.Client, seeder.Spec.ChiaConfig.RPCService, rpcSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s %v", req.NamespacedName, err)
-	}
-
-	// Assemble Chia-Exporter Service
-	exporterSrv := assembleChiaExporterService(seeder)
-	if err := controllerutil.SetControllerReference(&seeder, &exporterSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&seeder, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble seeder chia-exporter Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error assembling chia-exporter Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile Chia-Exporter Service
-	res, err = kube.ReconcileService(ctx, r.Client, seeder.Spec.ChiaExporterConfig.Service, exporterSrv, true)
-	if err != nil {
-		return res, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s %v", req.NamespacedName, err)
-	}
-
-	// Assemble Chia-Healthcheck Service
-	healthcheckSrv := assembleChiaHealthcheckService(seeder)
-	if err := controllerutil.SetControllerReference(&seeder, &healthcheckSrv, r.Scheme); err != nil {
-		r.Recorder.Eventf(&seeder, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble seeder chia-healthcheck Service -- Check operator logs.")
-		return ctrl.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s encountered error assembling chia-healthcheck Service: %v", req.NamespacedName, err)
-	}
-	// Reconcile Chia-Healthcheck Service
-	if !kube.ShouldRollIntoMainPeerService(seeder.Spec.ChiaHealthcheckConfig.Service) {
-		res, err = kube.ReconcileService(ctx, r.Client, seeder.Spec.ChiaHealthcheckConfig.Service, healthcheckSrv, false)
-		if err != nil {
-			return res, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s %v", req.NamespacedName, err)
-		}
-	}
-
-	// Creates a persistent volume claim if the GenerateVolumeClaims setting was set to true
-	if kube.ShouldMakeChiaRootVolumeClaim(seeder.Spec.Storage) {
-		pvc, err := assembleVolumeClaim(seeder)
-		if err != nil {
-			r.Recorder.Eventf(&seeder, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble seeder PVC -- Check operator logs.")
-			return reconcile.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s %v", req.NamespacedName, err)
-		}
-
-		if pvc != nil {
-			res, err = kube.ReconcilePersistentVolumeClaim(ctx, r.Client, seeder.Spec.Storage, *pvc)
+	engine := component.Engine{
+		Client:   r.Client,
+		Scheme:   r.Scheme,
+		Recorder: r.Recorder,
+		Kind:     consts.ChiaSeederKind,
+		Name:     "seeder",
+	}
+	return engine.Reconcile(ctx, req, &seeder, component.Hooks{
+		Describe: func() component.Resource {
+			return component.Resource{
+				Meta:    seeder.ObjectMeta,
+				Chia:    seeder.Spec.ChiaConfig.CommonSpecChia,
+				Suspend: seeder.Spec.Suspend,
+				Status: component.Status{
+					Conditions:         &seeder.Status.Conditions,
+					ObservedGeneration: &seeder.Status.ObservedGeneration,
+					Ready:              &seeder.Status.Ready,
+				},
+				Workload: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf(chiaseederNamePattern, seeder.Name), Namespace: seeder.Namespace}},
+			}
+		},
+		FinalizerInputs: func(ctx context.Context) (finalizer.Inputs, error) {
+			return finalizer.Inputs{
+				DeletionPolicy:   seeder.Spec.DeletionPolicy,
+				VolumeClaimNames: getGeneratedVolumeClaimNames(seeder),
+			}, nil
+		},
+		Assemble: func(ctx context.Context, c *component.Reconciler, networkData *map[string]string) (component.Component, error) {
+			fullNodePort, err := kube.GetFullNodePort(seeder.Spec.ChiaConfig.CommonSpecChia, networkData)
 			if err != nil {
-				r.Recorder.Eventf(&seeder, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to create seeder PVC -- Check operator logs.")
-				return res, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s %v", req.NamespacedName, err)
+				return component.Component{}, fmt.Errorf("encountered error retrieving the full_node Port to use: %w", err)
+			}
+			deploy, err := assembleDeployment(seeder, fullNodePort, networkData)
+			if err != nil {
+				c.AssembleFailed("Deployment")
+				return component.Component{}, err
 			}
-		} else {
-			return reconcile.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s PVC could not be created", req.NamespacedName)
-		}
-	}
-
-	// Assemble Deployment
-	deploy, err := assembleDeployment(seeder, fullNodePort, networkData)
-	if err != nil {
-		r.Recorder.Eventf(&seeder, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble seeder Deployment -- Check operator logs.")
-		return reconcile.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s %v", req.NamespacedName, err)
-	}
-	if err := controllerutil.SetControllerReference(&seeder, &deploy, r.Scheme); err != nil {
-		r.Recorder.Eventf(&seeder, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble seeder Deployment -- Check operator logs.")
-		return reconcile.Result{}, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s %v", req.NamespacedName, err)
-	}
-	// Reconcile Deployment
-	res, err = kube.ReconcileDeployment(ctx, r.Client, deploy)
-	if err != nil {
-		r.Recorder.Eventf(&seeder, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to create seeder Deployment -- Check operator logs.")
-		return res, fmt.Errorf("ChiaSeederReconciler ChiaSeeder=%s %v", req.NamespacedName, err)
-	}
-
-	// Update CR status
-	r.Recorder.Eventf(&seeder, nil, corev1.EventTypeNormal, "Created", "Created", "Successfully created ChiaSeeder resources.")
-	seeder.Status.Ready = true
-	err = r.Status().Update(ctx, &seeder)
-	if err != nil {
-		if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
-			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
-		}
-		log.Error(err, fmt.Sprintf("ChiaSeederReconciler ChiaSeeder=%s unable to update ChiaSeeder status", req.NamespacedName))
-		return ctrl.Result{}, err
-	}
 
-	return ctrl.Result{}, nil
+			services := []component.Service{
+				{Description: "peer", Desired: assemblePeerService(seeder, fullNodePort), Config: seeder.Spec.ChiaConfig.PeerService, DefaultEnabled: true},
+				{Description: "all-port", Desired: assembleAllService(seeder, fullNodePort), Config: seeder.Spec.ChiaConfig.AllService, DefaultEnabled: true},
+				{Description: "daemon", Desired: assembleDaemonService(seeder), Config: seeder.Spec.ChiaConfig.DaemonService, DefaultEnabled: true},
+				{Description: "RPC", Desired: assembleRPCService(seeder), Config: seeder.Spec.ChiaConfig.RPCService, DefaultEnabled: true},
+				{Description: "chia-exporter", Desired: assembleChiaExporterService(seeder), Config: seeder.Spec.ChiaExporterConfig.Service, DefaultEnabled: true},
+			}
+			if !kube.ShouldRollIntoMainPeerService(seeder.Spec.ChiaHealthcheckConfig.Service) {
+				services = append(services, component.Service{Description: "chia-healthcheck", Desired: assembleChiaHealthcheckService(seeder), Config: seeder.Spec.ChiaHealthcheckConfig.Service})
+			}
+			return component.Component{
+				Services: services,
+				VolumeClaims: []component.VolumeClaim{{
+					Description: "CHIA_ROOT",
+					Enabled:     kube.ShouldMakeChiaRootVolumeClaim(seeder.Spec.Storage),
+					Storage:     seeder.Spec.Storage,
+					Assemble:    func() (*corev1.PersistentVolumeClaim, error) { return assembleVolumeClaim(seeder) },
+				}},
+				Workload: &deploy,
+				PodDisruptionBudget: component.PodDisruptionBudget{
+					Desired: assemblePodDisruptionBudget(seeder),
+					Config:  seeder.Spec.PodDisruptionBudget,
+				},
+			}, nil
+		},
+	})
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -234,10 +141,12 @@ func (r *ChiaSeederReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&k8schianetv1.ChiaSeeder{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
 		Watches(
 			&corev1.ConfigMap{},
 			handler.EnqueueRequestsFromMapFunc(r.handleChiaNetworks),
 		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
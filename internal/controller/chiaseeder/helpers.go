@@ -172,3 +172,11 @@ func getChiaPorts(fullNodePort int32) []corev1.ContainerPort {
 		},
 	}
 }
+
+// getGeneratedVolumeClaimNames returns the names of the PersistentVolumeClaims the operator generates for a ChiaSeeder
+func getGeneratedVolumeClaimNames(seeder k8schianetv1.ChiaSeeder) []string {
+	if kube.ShouldMakeChiaRootVolumeClaim(seeder.Spec.Storage) {
+		return []string{fmt.Sprintf(chiaseederNamePattern, seeder.Name)}
+	}
+	return nil
+}
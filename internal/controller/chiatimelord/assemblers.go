@@ -12,6 +12,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -288,6 +289,18 @@ func assembleVolumeClaim(tl k8schianetv1.ChiaTimelord) (*corev1.PersistentVolume
 	return &pvc, nil
 }
 
+// assemblePodDisruptionBudget assembles the PodDisruptionBudget resource for a ChiaTimelord CR
+func assemblePodDisruptionBudget(tl k8schianetv1.ChiaTimelord) policyv1.PodDisruptionBudget {
+	return kube.AssemblePodDisruptionBudget(kube.AssemblePodDisruptionBudgetInputs{
+		Name:           fmt.Sprintf(chiatimelordNamePattern, tl.Name),
+		Namespace:      tl.Namespace,
+		Labels:         kube.GetCommonLabels(tl.Kind, tl.ObjectMeta, tl.Spec.Labels),
+		Annotations:    tl.Spec.Annotations,
+		SelectorLabels: kube.GetCommonLabels(tl.Kind, tl.ObjectMeta),
+		Config:         tl.Spec.PodDisruptionBudget,
+	})
+}
+
 // assembleDeployment assembles the tl Deployment resource for a ChiaTimelord CR
 func assembleDeployment(ctx context.Context, tl k8schianetv1.ChiaTimelord, networkData *map[string]string) (appsv1.Deployment, error) {
 	var deploy = appsv1.Deployment{
@@ -105,3 +105,11 @@ func getChiaEnv(ctx context.Context, timelord k8schianetv1.ChiaTimelord, network
 
 	return env, nil
 }
+
+// getGeneratedVolumeClaimNames returns the names of the PersistentVolumeClaims the operator generates for a ChiaTimelord
+func getGeneratedVolumeClaimNames(tl k8schianetv1.ChiaTimelord) []string {
+	if kube.ShouldMakeChiaRootVolumeClaim(tl.Spec.Storage) {
+		return []string{fmt.Sprintf(chiatimelordNamePattern, tl.Name)}
+	}
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
@@ -250,6 +251,18 @@ func assembleVolumeClaim(wallet k8schianetv1.ChiaWallet) (*corev1.PersistentVolu
 	return &pvc, nil
 }
 
+// assemblePodDisruptionBudget assembles the PodDisruptionBudget resource for a ChiaWallet CR
+func assemblePodDisruptionBudget(wallet k8schianetv1.ChiaWallet) policyv1.PodDisruptionBudget {
+	return kube.AssemblePodDisruptionBudget(kube.AssemblePodDisruptionBudgetInputs{
+		Name:           fmt.Sprintf(chiawalletNamePattern, wallet.Name),
+		Namespace:      wallet.Namespace,
+		Labels:         kube.GetCommonLabels(wallet.Kind, wallet.ObjectMeta, wallet.Spec.Labels),
+		Annotations:    wallet.Spec.Annotations,
+		SelectorLabels: kube.GetCommonLabels(wallet.Kind, wallet.ObjectMeta),
+		Config:         wallet.Spec.PodDisruptionBudget,
+	})
+}
+
 // assembleDeployment assembles the wallet Deployment resource for a ChiaWallet CR
 func assembleDeployment(ctx context.Context, wallet k8schianetv1.ChiaWallet, networkData *map[string]string) (appsv1.Deployment, error) {
 	var deploy = appsv1.Deployment{
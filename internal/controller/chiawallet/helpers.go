@@ -178,3 +178,11 @@ func getChiaEnv(ctx context.Context, wallet k8schianetv1.ChiaWallet, networkData
 
 	return env, nil
 }
+
+// getGeneratedVolumeClaimNames returns the names of the PersistentVolumeClaims the operator generates for a ChiaWallet
+func getGeneratedVolumeClaimNames(wallet k8schianetv1.ChiaWallet) []string {
+	if kube.ShouldMakeChiaRootVolumeClaim(wallet.Spec.Storage) {
+		return []string{fmt.Sprintf(chiawalletNamePattern, wallet.Name)}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+// Package certmanager assembles and applies cert-manager resources for Chia certificates.
+// cert-manager resources are handled as unstructured objects so the operator doesn't depend on cert-manager's API module,
+// and only needs cert-manager installed when a resource opts in to it.
+package certmanager
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+const (
+	// Group is the API group of cert-manager's resources
+	Group = "cert-manager.io"
+
+	// IssuerKind is the kind of a namespaced cert-manager issuer
+	IssuerKind = "Issuer"
+
+	// CertificateKind is the kind of a cert-manager Certificate
+	CertificateKind = "Certificate"
+)
+
+var (
+	// IssuerGVK is the GroupVersionKind of a cert-manager Issuer
+	IssuerGVK = schema.GroupVersionKind{Group: Group, Version: "v1", Kind: IssuerKind}
+
+	// CertificateGVK is the GroupVersionKind of a cert-manager Certificate
+	CertificateGVK = schema.GroupVersionKind{Group: Group, Version: "v1", Kind: CertificateKind}
+)
+
+// CertificateInputs contains the configuration for a cert-manager Certificate for a Chia certificate
+type CertificateInputs struct {
+	// Name is the name of the Certificate and the Secret cert-manager stores it in
+	Name string
+
+	// Namespace is the namespace of the Certificate
+	Namespace string
+
+	// Labels are set on the Certificate and the Secret cert-manager stores it in
+	Labels map[string]string
+
+	// IssuerRef is the issuer that issues the certificate
+	IssuerRef k8schianetv1.CertManagerIssuerRef
+
+	// IsCA is true for a private CA certificate
+	IsCA bool
+
+	// Duration is how long the certificate is valid for, cert-manager's default is used if nil
+	Duration *metav1.Duration
+
+	// RenewBefore is how long before the certificate expires that cert-manager renews it, cert-manager's default is used if nil
+	RenewBefore *metav1.Duration
+}
+
+// AssembleCertificate assembles a cert-manager Certificate with the same subject and key type as the certificates Chia generates
+func AssembleCertificate(input CertificateInputs) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"secretName": input.Name,
+		"secretTemplate": map[string]interface{}{
+			"labels": toInterfaceMap(input.Labels),
+		},
+		"subject": map[string]interface{}{
+			"organizations":       []interface{}{"Chia"},
+			"organizationalUnits": []interface{}{"Organic Farming Division"},
+		},
+		"privateKey": map[string]interface{}{
+			"algorithm": "RSA",
+			"size":      int64(2048),
+			"encoding":  "PKCS8",
+		},
+		"issuerRef": issuerRefToMap(input.IssuerRef),
+	}
+	if input.IsCA {
+		spec["isCA"] = true
+		spec["commonName"] = "Chia CA"
+		spec["usages"] = []interface{}{"cert sign", "digital signature"}
+	} else {
+		spec["commonName"] = "Chia"
+		spec["dnsNames"] = []interface{}{"chia.net"}
+		spec["usages"] = []interface{}{"digital signature", "key encipherment", "server auth", "client auth"}
+	}
+	if input.Duration != nil {
+		spec["duration"] = input.Duration.Duration.String()
+	}
+	if input.RenewBefore != nil {
+		spec["renewBefore"] = input.RenewBefore.Duration.String()
+	}
+
+	u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	u.SetGroupVersionKind(CertificateGVK)
+	u.SetName(input.Name)
+	u.SetNamespace(input.Namespace)
+	u.SetLabels(input.Labels)
+	return u
+}
+
+// AssembleSelfSignedIssuer assembles a self-signed cert-manager Issuer
+func AssembleSelfSignedIssuer(name, namespace string, labels map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"selfSigned": map[string]interface{}{},
+		},
+	}}
+	u.SetGroupVersionKind(IssuerGVK)
+	u.SetName(name)
+	u.SetNamespace(namespace)
+	u.SetLabels(labels)
+	return u
+}
+
+// AssembleCAIssuer assembles a cert-manager CA Issuer that issues certificates from the CA in the given Secret.
+// The Secret must contain the CA in tls.crt and tls.key.
+func AssembleCAIssuer(name, namespace, secretName string, labels map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ca": map[string]interface{}{
+				"secretName": secretName,
+			},
+		},
+	}}
+	u.SetGroupVersionKind(IssuerGVK)
+	u.SetName(name)
+	u.SetNamespace(namespace)
+	u.SetLabels(labels)
+	return u
+}
+
+// IssuerRefOrDefault returns the given issuer reference with its kind and group defaulted, or a reference to the operator created Issuer with the default name
+func IssuerRefOrDefault(ref *k8schianetv1.CertManagerIssuerRef, defaultName string) k8schianetv1.CertManagerIssuerRef {
+	if ref == nil {
+		return k8schianetv1.CertManagerIssuerRef{Name: defaultName, Kind: IssuerKind, Group: Group}
+	}
+	out := *ref
+	if out.Kind == "" {
+		out.Kind = IssuerKind
+	}
+	if out.Group == "" {
+		out.Group = Group
+	}
+	return out
+}
+
+// Apply sets the owner as the controller of a cert-manager resource and applies it server-side.
+// Returns a ConditionError if cert-manager's CRDs aren't installed.
+func Apply(ctx context.Context, c client.Client, scheme *runtime.Scheme, owner client.Object, obj *unstructured.Unstructured) error {
+	if err := controllerutil.SetControllerReference(owner, obj, scheme); err != nil {
+		return fmt.Errorf("error setting controller reference on %s \"%s\": %w", obj.GetKind(), obj.GetName(), err)
+	}
+	obj.SetManagedFields(nil)
+	err := c.Apply(ctx, client.ApplyConfigurationFromUnstructured(obj), client.ForceOwnership, client.FieldOwner("chia-operator"))
+	if err != nil && meta.IsNoMatchError(err) {
+		return kube.NewConditionError(k8schianetv1.ConditionTypeConfigValid, kube.ReasonInvalidConfig, fmt.Errorf("cert-manager is enabled, but cert-manager's CRDs are not installed: %w", err))
+	}
+	if err != nil {
+		return fmt.Errorf("error applying %s \"%s\": %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
+
+// GetCertificateReady returns whether a cert-manager Certificate has been issued, and the message of its Ready condition
+func GetCertificateReady(ctx context.Context, c client.Client, namespace, name string) (bool, string, error) {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(CertificateGVK)
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, u)
+	if err != nil && errors.IsNotFound(err) {
+		return false, fmt.Sprintf("Certificate %q not found", name), nil
+	}
+	if err != nil {
+		return false, "", fmt.Errorf("error getting Certificate \"%s\": %w", name, err)
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+		message, _ := cond["message"].(string)
+		return cond["status"] == string(metav1.ConditionTrue), message, nil
+	}
+	return false, fmt.Sprintf("Certificate %q has not been issued yet", name), nil
+}
+
+// KeyPairFromSecret returns the PEM encoded certificate and key from a Secret cert-manager stored a Certificate in.
+// Only the first certificate in tls.crt is returned, since Chia expects each certificate file to contain a single certificate.
+func KeyPairFromSecret(secret corev1.Secret) ([]byte, []byte, error) {
+	certPEM, ok := secret.Data[corev1.TLSCertKey]
+	if !ok || len(certPEM) == 0 {
+		return nil, nil, fmt.Errorf("%s not present in Secret %q", corev1.TLSCertKey, secret.Name)
+	}
+	keyPEM, ok := secret.Data[corev1.TLSPrivateKeyKey]
+	if !ok || len(keyPEM) == 0 {
+		return nil, nil, fmt.Errorf("%s not present in Secret %q", corev1.TLSPrivateKeyKey, secret.Name)
+	}
+	certs, err := kube.ParseCACertificates(certPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing %s in Secret %q: %w", corev1.TLSCertKey, secret.Name, err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certs[0].Raw}), keyPEM, nil
+}
+
+// issuerRefToMap converts an issuer reference to its unstructured form
+func issuerRefToMap(ref k8schianetv1.CertManagerIssuerRef) map[string]interface{} {
+	return map[string]interface{}{
+		"name":  ref.Name,
+		"kind":  ref.Kind,
+		"group": ref.Group,
+	}
+}
+
+// toInterfaceMap converts a string map to its unstructured form
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
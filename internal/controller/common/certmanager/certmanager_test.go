@@ -0,0 +1,76 @@
+package certmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chia-network/go-chia-libs/pkg/tls"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+func TestAssembleCertificate(t *testing.T) {
+	cert := AssembleCertificate(CertificateInputs{
+		Name:      "my-certs-private-full-node",
+		Namespace: "default",
+		Labels:    map[string]string{"k8s.chia.net/kind": "ChiaCertificates"},
+		IssuerRef: IssuerRefOrDefault(nil, "my-certs-private-ca"),
+		Duration:  &metav1.Duration{Duration: 90 * 24 * time.Hour},
+	})
+
+	require.Equal(t, CertificateGVK, cert.GroupVersionKind())
+	secretName, _, _ := unstructured.NestedString(cert.Object, "spec", "secretName")
+	require.Equal(t, "my-certs-private-full-node", secretName)
+	secretLabels, _, _ := unstructured.NestedStringMap(cert.Object, "spec", "secretTemplate", "labels")
+	require.Equal(t, map[string]string{"k8s.chia.net/kind": "ChiaCertificates"}, secretLabels)
+	issuerRef, _, _ := unstructured.NestedStringMap(cert.Object, "spec", "issuerRef")
+	require.Equal(t, map[string]string{"name": "my-certs-private-ca", "kind": "Issuer", "group": "cert-manager.io"}, issuerRef)
+	dnsNames, _, _ := unstructured.NestedStringSlice(cert.Object, "spec", "dnsNames")
+	require.Equal(t, []string{"chia.net"}, dnsNames)
+	duration, _, _ := unstructured.NestedString(cert.Object, "spec", "duration")
+	require.Equal(t, "2160h0m0s", duration)
+	_, found, _ := unstructured.NestedBool(cert.Object, "spec", "isCA")
+	require.False(t, found)
+
+	ca := AssembleCertificate(CertificateInputs{
+		Name:      "my-ca-private-ca",
+		Namespace: "default",
+		IssuerRef: IssuerRefOrDefault(&k8schianetv1.CertManagerIssuerRef{Name: "org-ca", Kind: "ClusterIssuer"}, "my-ca-selfsigned"),
+		IsCA:      true,
+	})
+	isCA, _, _ := unstructured.NestedBool(ca.Object, "spec", "isCA")
+	require.True(t, isCA)
+	issuerRef, _, _ = unstructured.NestedStringMap(ca.Object, "spec", "issuerRef")
+	require.Equal(t, map[string]string{"name": "org-ca", "kind": "ClusterIssuer", "group": "cert-manager.io"}, issuerRef)
+}
+
+func TestKeyPairFromSecret(t *testing.T) {
+	caDER, caKey, err := tls.GenerateNewCA()
+	require.NoError(t, err)
+	caPEM, keyPEM, err := tls.EncodeCertAndKeyToPEM(caDER, caKey)
+	require.NoError(t, err)
+	otherDER, otherKey, err := tls.GenerateNewCA()
+	require.NoError(t, err)
+	otherPEM, _, err := tls.EncodeCertAndKeyToPEM(otherDER, otherKey)
+	require.NoError(t, err)
+
+	// Only the first certificate of a chain is returned
+	secret := corev1.Secret{
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       append(append([]byte{}, caPEM...), otherPEM...),
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+	certPEM, gotKeyPEM, err := KeyPairFromSecret(secret)
+	require.NoError(t, err)
+	require.Equal(t, caPEM, certPEM)
+	require.Equal(t, keyPEM, gotKeyPEM)
+
+	delete(secret.Data, corev1.TLSPrivateKeyKey)
+	_, _, err = KeyPairFromSecret(secret)
+	require.Error(t, err)
+}
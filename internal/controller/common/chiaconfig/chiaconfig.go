@@ -0,0 +1,455 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+// Package chiaconfig validates typed chia config overrides against go-chia-libs' config types,
+// and translates them into the chia.* environment variables the chia container applies to its config.yaml on startup.
+package chiaconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chia-network/go-chia-libs/pkg/config"
+	"github.com/chia-network/go-chia-libs/pkg/types"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// EnvPrefix is the prefix of the environment variables that set a field in the chia config at the path that follows it
+const EnvPrefix = "chia."
+
+// testnetName is the network selected by the testnet environment variable
+const testnetName = "testnet11"
+
+var uint128Type = reflect.TypeOf(types.Uint128{})
+
+// firstClassKeys maps the chia config keys that are set by a first-class setting to that setting.
+// Overriding one of these keys would conflict with the setting, so configOverrides can't set them.
+var firstClassKeys = map[string]string{
+	"selected_network":               "spec.chia.network",
+	"self_hostname":                  "spec.chia.selfHostname",
+	"logging.log_level":              "spec.chia.logLevel",
+	"full_node.port":                 "spec.chia.networkPort",
+	"full_node.introducer_peer.port": "spec.chia.networkPort",
+	"full_node.introducer_peer.host": "spec.chia.introducerAddress",
+	"full_node.dns_servers":          "spec.chia.dnsIntroducerAddress",
+	"introducer.port":                "spec.chia.networkPort",
+	"wallet.introducer_peer.port":    "spec.chia.networkPort",
+	"wallet.introducer_peer.host":    "spec.chia.introducerAddress",
+	"wallet.dns_servers":             "spec.chia.dnsIntroducerAddress",
+}
+
+// Validate returns an error if the config overrides contain a key that isn't in the chia config, a key that's set by a first-class setting,
+// or a value of the wrong type
+func Validate(overrides *apiextensionsv1.JSON) error {
+	_, err := GetEnv(overrides)
+	return err
+}
+
+// GetEnv validates the config overrides and returns the environment variables that apply them, sorted by name.
+// Structs in the chia config are flattened into one variable per field, while lists and maps of values are set as a JSON string.
+func GetEnv(overrides *apiextensionsv1.JSON) ([]corev1.EnvVar, error) {
+	if overrides == nil || len(overrides.Raw) == 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(overrides.Raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("error parsing configOverrides: %w", err)
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	vars := map[string]string{}
+	if err := walk(nil, value, reflect.TypeOf(config.ChiaConfig{}), vars); err != nil {
+		return nil, err
+	}
+
+	var env []corev1.EnvVar
+	for name, v := range vars {
+		env = append(env, corev1.EnvVar{
+			Name:  EnvPrefix + name,
+			Value: v,
+		})
+	}
+	sort.Slice(env, func(i, j int) bool {
+		return env[i].Name < env[j].Name
+	})
+	return env, nil
+}
+
+// Rendered is a chia config rendered from the environment variables of a chia container
+type Rendered struct {
+	// Config is the complete config.yaml, which is installed in a CHIA_ROOT that doesn't have a config yet
+	Config []byte
+
+	// Overrides is a config.yaml with only the fields the environment variables set, which is merged into an existing config.yaml
+	Overrides []byte
+
+	// Unrendered are the names of the chia.* environment variables that don't name a field in go-chia-libs' config.
+	// They're left for the chia image's entrypoint to apply.
+	Unrendered []string
+}
+
+// Render renders a complete chia config.yaml from go-chia-libs' initial config, and the environment variables of a chia container.
+// The chia.* variables are applied to the fields they name, along with the variables for the first-class settings shared by every
+// chia component, such as network, network_port, and log_level. Other variables, and variables set from a Secret or ConfigMap,
+// are left for the chia image's entrypoint to apply.
+func Render(env []corev1.EnvVar) (Rendered, error) {
+	cfg, err := config.LoadDefaultConfig()
+	if err != nil {
+		return Rendered{}, fmt.Errorf("error loading initial chia config: %w", err)
+	}
+
+	values := map[string]string{}
+	for _, e := range env {
+		if e.ValueFrom == nil {
+			values[e.Name] = e.Value
+		}
+	}
+
+	// paths are the fields that were set, which are copied into the overrides.
+	// The network is selected in every section of the config, so those paths are found after the config is rendered.
+	var paths [][]string
+	selectsNetwork := false
+	if values["testnet"] == "true" {
+		*cfg.SelectedNetwork = testnetName
+		selectsNetwork = true
+	}
+	if network := values["network"]; network != "" {
+		*cfg.SelectedNetwork = network
+		selectsNetwork = true
+	}
+	if port := values["network_port"]; port != "" {
+		p, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return Rendered{}, fmt.Errorf("invalid network_port %q: %w", port, err)
+		}
+		cfg.FullNode.Port = uint16(p)
+		cfg.FullNode.IntroducerPeer.Port = uint16(p)
+		cfg.Wallet.IntroducerPeer.Port = uint16(p)
+		paths = append(paths, []string{"full_node", "port"}, []string{"full_node", "introducer_peer", "port"}, []string{"wallet", "introducer_peer", "port"})
+	}
+	if introducer := values["introducer_address"]; introducer != "" {
+		cfg.FullNode.IntroducerPeer.Host = introducer
+		cfg.Wallet.IntroducerPeer.Host = introducer
+		paths = append(paths, []string{"full_node", "introducer_peer", "host"}, []string{"wallet", "introducer_peer", "host"})
+	}
+	if dnsIntroducer := values["dns_introducer_address"]; dnsIntroducer != "" {
+		cfg.FullNode.DNSServers = []string{dnsIntroducer}
+		cfg.Wallet.DNSServers = []string{dnsIntroducer}
+		paths = append(paths, []string{"full_node", "dns_servers"}, []string{"wallet", "dns_servers"})
+	}
+	if selfHostname := values["self_hostname"]; selfHostname != "" {
+		cfg.SelfHostname = selfHostname
+		paths = append(paths, []string{"self_hostname"})
+	}
+	if logLevel := values["log_level"]; logLevel != "" {
+		cfg.Logging.LogLevel = logLevel
+		paths = append(paths, []string{"logging", "log_level"})
+	}
+
+	// Apply the chia.* variables in the order the chia container would see them, so later variables win.
+	// SetFieldByPath ignores paths that aren't in the config, so those variables are reported instead.
+	var rendered Rendered
+	for _, e := range env {
+		if e.ValueFrom != nil || !strings.HasPrefix(e.Name, EnvPrefix) {
+			continue
+		}
+		path := strings.Split(strings.TrimPrefix(e.Name, EnvPrefix), ".")
+		if err := cfg.SetFieldByPath(path, e.Value); err != nil {
+			return Rendered{}, fmt.Errorf("error setting chia config field %q: %w", strings.Join(path, "."), err)
+		}
+		if _, err := cfg.GetFieldByPath(path); err != nil {
+			rendered.Unrendered = append(rendered.Unrendered, e.Name)
+			continue
+		}
+		paths = append(paths, path)
+	}
+
+	rendered.Config, err = cfg.SaveBytes()
+	if err != nil {
+		return Rendered{}, fmt.Errorf("error rendering chia config: %w", err)
+	}
+
+	var full map[string]interface{}
+	if err := yaml.Unmarshal(rendered.Config, &full); err != nil {
+		return Rendered{}, fmt.Errorf("error reading rendered chia config: %w", err)
+	}
+	if selectsNetwork {
+		paths = append(paths, findKeys(full, "selected_network")...)
+	}
+	overrides := map[string]interface{}{}
+	for _, path := range paths {
+		copyPath(full, overrides, path)
+	}
+	rendered.Overrides, err = yaml.Marshal(overrides)
+	if err != nil {
+		return Rendered{}, fmt.Errorf("error rendering chia config overrides: %w", err)
+	}
+
+	return rendered, nil
+}
+
+// findKeys returns the paths of a key at the top level of a config, and in each of its sections
+func findKeys(cfg map[string]interface{}, key string) [][]string {
+	var paths [][]string
+	if _, ok := cfg[key]; ok {
+		paths = append(paths, []string{key})
+	}
+	for _, name := range sortedKeys(cfg) {
+		if section, ok := cfg[name].(map[string]interface{}); ok {
+			if _, ok := section[key]; ok {
+				paths = append(paths, []string{name, key})
+			}
+		}
+	}
+	return paths
+}
+
+// copyPath copies the value at a path in a config into overrides. A path into a list copies the whole list,
+// because lists are replaced rather than merged. Does nothing if the config doesn't have the path.
+func copyPath(cfg, overrides map[string]interface{}, path []string) {
+	src, dst := cfg, overrides
+	for i, key := range path {
+		value, ok := src[key]
+		if !ok {
+			return
+		}
+		next, isObject := value.(map[string]interface{})
+		if !isObject || i == len(path)-1 {
+			dst[key] = value
+			return
+		}
+		child, ok := dst[key].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			dst[key] = child
+		}
+		src, dst = next, child
+	}
+}
+
+// walk validates a value against the type of the chia config field at path.
+// If vars is non-nil, the value's environment variables are added to it, keyed by their dotted path.
+func walk(path []string, value interface{}, t reflect.Type, vars map[string]string) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	key := strings.Join(path, ".")
+
+	if t == uint128Type {
+		var s string
+		switch v := value.(type) {
+		case json.Number:
+			s = v.String()
+		case string:
+			s = v
+		}
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok || n.Sign() < 0 || n.BitLen() > 128 {
+			return typeError(key, "an unsigned 128-bit integer")
+		}
+		setVar(vars, key, s)
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return typeError(key, "an object")
+		}
+		for _, name := range sortedKeys(fields) {
+			fieldType, ok := findField(t, name)
+			if !ok {
+				return fmt.Errorf("unknown config key %q", strings.Join(appendPath(path, name), "."))
+			}
+			if err := checkFirstClassKey(appendPath(path, name)); err != nil {
+				return err
+			}
+			if err := walk(appendPath(path, name), fields[name], fieldType, vars); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return typeError(key, "an object")
+		}
+		elem := t.Elem()
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		// Maps of structs, like network_overrides.config, are flattened so each field can be overridden on its own
+		if elem.Kind() == reflect.Struct && elem != uint128Type {
+			for _, name := range sortedKeys(entries) {
+				if err := walk(appendPath(path, name), entries[name], elem, vars); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for _, name := range sortedKeys(entries) {
+			if err := walk(appendPath(path, name), entries[name], elem, nil); err != nil {
+				return err
+			}
+		}
+		return setJSONVar(vars, key, value)
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return typeError(key, "a list")
+		}
+		for i, v := range items {
+			if err := walk(appendPath(path, strconv.Itoa(i)), v, t.Elem(), nil); err != nil {
+				return err
+			}
+		}
+		return setJSONVar(vars, key, value)
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return typeError(key, "a boolean")
+		}
+		setVar(vars, key, strconv.FormatBool(b))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := value.(json.Number)
+		if !ok {
+			return typeError(key, "an integer")
+		}
+		if _, err := strconv.ParseInt(n.String(), 10, t.Bits()); err != nil {
+			return fmt.Errorf("config key %q must be an integer that fits in %d bits", key, t.Bits())
+		}
+		setVar(vars, key, n.String())
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := value.(json.Number)
+		if !ok {
+			return typeError(key, "an unsigned integer")
+		}
+		if _, err := strconv.ParseUint(n.String(), 10, t.Bits()); err != nil {
+			return fmt.Errorf("config key %q must be an unsigned integer that fits in %d bits", key, t.Bits())
+		}
+		setVar(vars, key, n.String())
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, ok := value.(json.Number)
+		if !ok {
+			return typeError(key, "a number")
+		}
+		if _, err := n.Float64(); err != nil {
+			return typeError(key, "a number")
+		}
+		setVar(vars, key, n.String())
+		return nil
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return typeError(key, "a string")
+		}
+		setVar(vars, key, s)
+		return nil
+	case reflect.Interface:
+		if s, ok := value.(string); ok {
+			setVar(vars, key, s)
+			return nil
+		}
+		return setJSONVar(vars, key, value)
+	default:
+		return fmt.Errorf("config key %q can't be overridden", key)
+	}
+}
+
+// findField returns the type of the field in a chia config struct with the given yaml key.
+// Fields of embedded inline structs are included, but a struct's map of unknown fields isn't, so unknown keys are rejected.
+func findField(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("yaml")
+		if tag == ",inline" && field.Anonymous {
+			if fieldType, ok := findField(field.Type, name); ok {
+				return fieldType, true
+			}
+			continue
+		}
+		if tagName := strings.Split(tag, ",")[0]; tagName != "" && tagName != "-" && tagName == name {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// checkFirstClassKey returns an error if a config key is set by a first-class setting, or by the operator itself.
+// The daemon and RPC ports are fixed by the operator, since its Services and sidecars connect to them.
+func checkFirstClassKey(path []string) error {
+	key := strings.Join(path, ".")
+	if setting, ok := firstClassKeys[key]; ok {
+		return fmt.Errorf("config key %q can't be overridden, set %s instead", key, setting)
+	}
+	if key == "daemon_port" || (len(path) == 2 && path[1] == "rpc_port") {
+		return fmt.Errorf("config key %q can't be overridden, it's set by the operator", key)
+	}
+	return nil
+}
+
+// appendPath returns a copy of path with name appended, so sibling keys don't share a backing array
+func appendPath(path []string, name string) []string {
+	next := make([]string, len(path), len(path)+1)
+	copy(next, path)
+	return append(next, name)
+}
+
+// sortedKeys returns the keys of an object in sorted order, so the first invalid key found is always the same
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setVar sets an environment variable's value if vars is non-nil
+func setVar(vars map[string]string, key, value string) {
+	if vars != nil {
+		vars[key] = value
+	}
+}
+
+// setJSONVar sets an environment variable to a JSON encoded value if vars is non-nil
+func setJSONVar(vars map[string]string, key string, value interface{}) error {
+	if vars == nil {
+		return nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error encoding config key %q: %w", key, err)
+	}
+	vars[key] = string(b)
+	return nil
+}
+
+// typeError returns the error for a config key with a value of the wrong type
+func typeError(key, expected string) error {
+	if key == "" {
+		return fmt.Errorf("configOverrides must be %s", expected)
+	}
+	return fmt.Errorf("config key %q must be %s", key, expected)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiaconfig
+
+import (
+	"testing"
+
+	"github.com/chia-network/go-chia-libs/pkg/config"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestGetEnv(t *testing.T) {
+	env, err := GetEnv(nil)
+	require.NoError(t, err)
+	require.Empty(t, env)
+
+	env, err = GetEnv(&apiextensionsv1.JSON{Raw: []byte(`{
+		"inbound_rate_limit_percent": 80,
+		"full_node": {
+			"enable_upnp": false,
+			"target_peer_count": 80,
+			"full_node_peers": [{"host": "node.default.svc.cluster.local", "port": 8444}]
+		},
+		"network_overrides": {
+			"config": {"mainnet": {"address_prefix": "xch"}}
+		}
+	}`)})
+	require.NoError(t, err)
+	require.Equal(t, []corev1.EnvVar{
+		{Name: "chia.full_node.enable_upnp", Value: "false"},
+		{Name: "chia.full_node.full_node_peers", Value: `[{"host":"node.default.svc.cluster.local","port":8444}]`},
+		{Name: "chia.full_node.target_peer_count", Value: "80"},
+		{Name: "chia.inbound_rate_limit_percent", Value: "80"},
+		{Name: "chia.network_overrides.config.mainnet.address_prefix", Value: "xch"},
+	}, env)
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		overrides string
+		err       string
+	}{
+		{
+			name:      "valid",
+			overrides: `{"wallet": {"target_peer_count": 5}, "logging": {"log_stdout": true}}`,
+		},
+		{
+			name:      "unknown top level key",
+			overrides: `{"fullnode": {"enable_upnp": false}}`,
+			err:       `unknown config key "fullnode"`,
+		},
+		{
+			name:      "unknown nested key",
+			overrides: `{"full_node": {"enable_upnpp": false}}`,
+			err:       `unknown config key "full_node.enable_upnpp"`,
+		},
+		{
+			name:      "unknown key in list item",
+			overrides: `{"full_node": {"full_node_peers": [{"hostname": "node"}]}}`,
+			err:       `unknown config key "full_node.full_node_peers.0.hostname"`,
+		},
+		{
+			name:      "wrong type",
+			overrides: `{"full_node": {"enable_upnp": "False"}}`,
+			err:       `config key "full_node.enable_upnp" must be a boolean`,
+		},
+		{
+			name:      "out of range",
+			overrides: `{"full_node": {"target_peer_count": 70000}}`,
+			err:       `config key "full_node.target_peer_count" must be an unsigned integer that fits in 16 bits`,
+		},
+		{
+			name:      "first-class setting",
+			overrides: `{"full_node": {"port": 8444}}`,
+			err:       `config key "full_node.port" can't be overridden, set spec.chia.networkPort instead`,
+		},
+		{
+			name:      "nested first-class setting",
+			overrides: `{"wallet": {"introducer_peer": {"host": "introducer"}}}`,
+			err:       `config key "wallet.introducer_peer.host" can't be overridden, set spec.chia.introducerAddress instead`,
+		},
+		{
+			name:      "top level first-class setting",
+			overrides: `{"selected_network": "testnet11"}`,
+			err:       `config key "selected_network" can't be overridden, set spec.chia.network instead`,
+		},
+		{
+			name:      "RPC port",
+			overrides: `{"farmer": {"rpc_port": 8560}}`,
+			err:       `config key "farmer.rpc_port" can't be overridden, it's set by the operator`,
+		},
+		{
+			name:      "daemon port",
+			overrides: `{"daemon_port": 55401}`,
+			err:       `config key "daemon_port" can't be overridden, it's set by the operator`,
+		},
+		{
+			name:      "not an object",
+			overrides: `["full_node"]`,
+			err:       "configOverrides must be an object",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(&apiextensionsv1.JSON{Raw: []byte(tc.overrides)})
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	rendered, err := Render([]corev1.EnvVar{
+		{Name: "CHIA_ROOT", Value: "/chia-data"},
+		{Name: "network", Value: "testnet11"},
+		{Name: "network_port", Value: "58444"},
+		{Name: "introducer_address", Value: "introducer.default.svc.cluster.local"},
+		{Name: "self_hostname", Value: "0.0.0.0"},
+		{Name: "log_level", Value: "DEBUG"},
+		{Name: "chia.full_node.enable_upnp", Value: "false"},
+		{Name: "chia.full_node.target_peer_count", Value: "80"},
+		{Name: "chia.full_node.target_peer_count", Value: "100"},
+		{Name: "chia.wallet.target_peer_count", ValueFrom: &corev1.EnvVarSource{}},
+		{Name: "chia.full_node.not_a_field", Value: "true"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"chia.full_node.not_a_field"}, rendered.Unrendered)
+
+	cfg, err := config.LoadFromBytes(rendered.Config, "/chia-data")
+	require.NoError(t, err)
+	require.Equal(t, "testnet11", *cfg.SelectedNetwork)
+	require.Equal(t, "testnet11", *cfg.FullNode.SelectedNetwork)
+	require.Equal(t, uint16(58444), cfg.FullNode.Port)
+	require.Equal(t, "introducer.default.svc.cluster.local", cfg.FullNode.IntroducerPeer.Host)
+	require.Equal(t, "0.0.0.0", cfg.SelfHostname)
+	require.Equal(t, "DEBUG", cfg.Logging.LogLevel)
+	require.False(t, cfg.FullNode.EnableUPNP)
+	require.Equal(t, uint16(100), cfg.FullNode.TargetPeerCount)
+
+	// The overrides only contain the fields that were set, including the network selected in each section
+	var overrides map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(rendered.Overrides, &overrides))
+	require.Equal(t, "testnet11", overrides["selected_network"])
+	require.Equal(t, "0.0.0.0", overrides["self_hostname"])
+	require.Equal(t, map[string]interface{}{"log_level": "DEBUG"}, overrides["logging"])
+	require.Equal(t, map[string]interface{}{
+		"port":              58444,
+		"enable_upnp":       false,
+		"target_peer_count": 100,
+		"selected_network":  "testnet11",
+		"introducer_peer":   map[string]interface{}{"host": "introducer.default.svc.cluster.local", "port": 58444},
+	}, overrides["full_node"])
+	require.Equal(t, map[string]interface{}{"selected_network": "testnet11"}, overrides["farmer"])
+	require.NotContains(t, overrides, "network_overrides")
+
+	_, err = Render([]corev1.EnvVar{{Name: "chia.full_node.port", Value: "not-a-port"}})
+	require.Error(t, err)
+}
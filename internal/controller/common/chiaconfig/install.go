@@ -0,0 +1,180 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiaconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ConfigFileName is the name of the complete rendered config in its ConfigMap, and of the config file in CHIA_ROOT
+	ConfigFileName = "config.yaml"
+
+	// OverridesFileName is the name of the rendered config overrides in its ConfigMap
+	OverridesFileName = "overrides.yaml"
+
+	// EnvChiaRoot is the environment variable with the path to CHIA_ROOT
+	EnvChiaRoot = "CHIA_ROOT"
+
+	// EnvRenderedDir is the environment variable with the path of the directory the rendered config's ConfigMap is mounted in
+	EnvRenderedDir = "RENDERED_CONFIG_DIR"
+)
+
+// Install installs a rendered config in CHIA_ROOT. If CHIA_ROOT doesn't have a config.yaml yet, the complete rendered config is copied into it.
+// Otherwise, the rendered overrides are merged into the existing config, so the fields chia wrote to it, like its pool_list and plot
+// directories, are kept. Returns a message describing what it did.
+func Install(chiaRoot, renderedDir string) (string, error) {
+	path := filepath.Join(chiaRoot, "config", ConfigFileName)
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("error reading existing chia config: %w", err)
+	}
+
+	var out []byte
+	var message string
+	if len(bytes.TrimSpace(existing)) == 0 {
+		out, err = os.ReadFile(filepath.Join(renderedDir, ConfigFileName))
+		if err != nil {
+			return "", fmt.Errorf("error reading rendered chia config: %w", err)
+		}
+		message = fmt.Sprintf("Installed rendered chia config at %s", path)
+	} else {
+		overrides, err := os.ReadFile(filepath.Join(renderedDir, OverridesFileName))
+		if err != nil {
+			return "", fmt.Errorf("error reading rendered chia config overrides: %w", err)
+		}
+		out, err = Merge(existing, overrides)
+		if err != nil {
+			return "", err
+		}
+		message = fmt.Sprintf("Merged rendered chia config overrides into %s", path)
+	}
+
+	if err := writeFile(path, out); err != nil {
+		return "", err
+	}
+	return message, nil
+}
+
+// Merge merges overrides into an existing chia config and returns the merged config. Objects are merged key by key, and other values,
+// including lists, are replaced. Keys that aren't in the overrides are kept, including keys go-chia-libs doesn't know about,
+// along with the config's comments and anchors.
+func Merge(existing, overrides []byte) ([]byte, error) {
+	var doc, patch yaml.Node
+	if err := yaml.Unmarshal(existing, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing existing chia config: %w", err)
+	}
+	if err := yaml.Unmarshal(overrides, &patch); err != nil {
+		return nil, fmt.Errorf("error parsing chia config overrides: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("existing chia config isn't an object")
+	}
+	if len(patch.Content) == 0 {
+		return existing, nil
+	}
+	if patch.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("chia config overrides aren't an object")
+	}
+
+	mergeNode(doc.Content[0], patch.Content[0])
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("error writing merged chia config: %w", err)
+	}
+	return out, nil
+}
+
+// mergeNode merges an overrides object into an object in the existing config
+func mergeNode(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		existing := getValue(dst, key.Value)
+		if existing == nil {
+			dst.Content = append(dst.Content, key, value)
+			continue
+		}
+
+		// An object shared with an alias, like a section's *logging, is copied before it's merged into,
+		// so the other sections that share it don't change too
+		if existing.Kind == yaml.AliasNode && existing.Alias != nil && existing.Alias.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			replaceNode(existing, copyNode(existing.Alias))
+		}
+		if existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			mergeNode(existing, value)
+			continue
+		}
+		replaceNode(existing, value)
+	}
+}
+
+// getValue returns the value of a key in an object node, or nil if it doesn't have the key
+func getValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// replaceNode replaces a node's value in place, so aliases of an anchored node see the new value, and keeps its anchor and comments
+func replaceNode(dst, src *yaml.Node) {
+	anchor, head, line, foot := dst.Anchor, dst.HeadComment, dst.LineComment, dst.FootComment
+	*dst = *src
+	dst.Anchor, dst.HeadComment, dst.LineComment, dst.FootComment = anchor, head, line, foot
+}
+
+// copyNode returns a deep copy of a node without its anchors, so the copy doesn't redefine them
+func copyNode(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Anchor = ""
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		c.Content[i] = copyNode(child)
+	}
+	return &c
+}
+
+// writeFile replaces a file by writing a temporary file next to it and renaming it, so chia never reads a partially written config.
+// The existing file's permissions are kept.
+func writeFile(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating chia config directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config.yaml-")
+	if err != nil {
+		return fmt.Errorf("error writing chia config: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error writing chia config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing chia config: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("error writing chia config: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error writing chia config: %w", err)
+	}
+	return nil
+}
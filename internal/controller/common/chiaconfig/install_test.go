@@ -0,0 +1,114 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiaconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const existingConfig = `# chia config
+self_hostname: localhost
+unknown_key: kept
+logging: &logging
+    log_level: WARNING
+    log_stdout: false
+pool:
+    logging: *logging
+farmer:
+    logging: *logging
+    pool_share_threshold: 1000
+harvester:
+    logging: *logging
+    plot_directories:
+        - /plots/1
+    selected_network: mainnet
+full_node:
+    logging: *logging
+    full_node_peers:
+        - host: node-1
+          port: 8444
+pool_list:
+    - launcher_id: "0xabc"
+`
+
+func TestMerge(t *testing.T) {
+	overrides := []byte(`self_hostname: 0.0.0.0
+logging:
+    log_level: INFO
+harvester:
+    selected_network: testnet11
+full_node:
+    logging:
+        log_stdout: true
+    full_node_peers:
+        - host: node-2
+          port: 8444
+`)
+	out, err := Merge([]byte(existingConfig), overrides)
+	require.NoError(t, err)
+
+	var merged map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(out, &merged))
+
+	// Overridden fields are set, and fields that aren't overridden are kept, even ones go-chia-libs doesn't know about
+	require.Equal(t, "0.0.0.0", merged["self_hostname"])
+	require.Equal(t, "kept", merged["unknown_key"])
+	require.Equal(t, []interface{}{map[string]interface{}{"launcher_id": "0xabc"}}, merged["pool_list"])
+	require.Equal(t, []interface{}{"/plots/1"}, merged["harvester"].(map[string]interface{})["plot_directories"])
+	require.Equal(t, "testnet11", merged["harvester"].(map[string]interface{})["selected_network"])
+	require.Equal(t, 1000, merged["farmer"].(map[string]interface{})["pool_share_threshold"])
+	require.Contains(t, string(out), "# chia config")
+
+	// The shared logging config is changed everywhere it's used, except in the section that overrides its own copy
+	require.Equal(t, map[string]interface{}{"log_level": "INFO", "log_stdout": false}, merged["logging"])
+	require.Equal(t, map[string]interface{}{"log_level": "INFO", "log_stdout": false}, merged["farmer"].(map[string]interface{})["logging"])
+	require.Contains(t, string(out), "logging: *logging")
+	require.Equal(t, map[string]interface{}{"log_level": "INFO", "log_stdout": true}, merged["full_node"].(map[string]interface{})["logging"])
+
+	// Lists are replaced
+	require.Equal(t, []interface{}{map[string]interface{}{"host": "node-2", "port": 8444}}, merged["full_node"].(map[string]interface{})["full_node_peers"])
+
+	// Empty overrides leave the config alone
+	out, err = Merge([]byte(existingConfig), []byte("{}\n"))
+	require.NoError(t, err)
+	require.Contains(t, string(out), "unknown_key: kept")
+
+	_, err = Merge([]byte("- not an object\n"), overrides)
+	require.Error(t, err)
+}
+
+func TestInstall(t *testing.T) {
+	chiaRoot := t.TempDir()
+	renderedDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(renderedDir, ConfigFileName), []byte("self_hostname: 0.0.0.0\nselected_network: mainnet\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(renderedDir, OverridesFileName), []byte("self_hostname: 0.0.0.0\n"), 0o644))
+	path := filepath.Join(chiaRoot, "config", ConfigFileName)
+
+	// A new CHIA_ROOT gets the complete rendered config
+	_, err := Install(chiaRoot, renderedDir)
+	require.NoError(t, err)
+	out, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "self_hostname: 0.0.0.0\nselected_network: mainnet\n", string(out))
+
+	// An existing config has the overrides merged into it
+	require.NoError(t, os.WriteFile(path, []byte(existingConfig), 0o600))
+	require.NoError(t, os.Chmod(path, 0o600))
+	_, err = Install(chiaRoot, renderedDir)
+	require.NoError(t, err)
+	out, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(out), "self_hostname: 0.0.0.0")
+	require.Contains(t, string(out), "unknown_key: kept")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chia-network/go-chia-libs/pkg/rpcinterface"
+	chiatls "github.com/chia-network/go-chia-libs/pkg/tls"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+const (
+	// requestTimeout is the maximum amount of time to wait for a response from an RPC server
+	requestTimeout = 10 * time.Second
+
+	// serverName is the DNS name in the certificates chia generates for its services
+	serverName = "chia.net"
+)
+
+// Client is an HTTP client for chia RPC servers. It authenticates with a client certificate signed by a private Chia CA,
+// and only trusts RPC servers presenting a certificate signed by that same CA.
+type Client struct {
+	httpClient *http.Client
+}
+
+// cachedClient is a Client and the resourceVersion of the CA Secret it was created from
+type cachedClient struct {
+	client          *Client
+	resourceVersion string
+}
+
+var (
+	clientsMu sync.Mutex
+	clients   = make(map[string]cachedClient)
+)
+
+// NewClient returns a Client using the given PEM encoded private CA certificate and key
+func NewClient(caCertPEM, caKeyPEM []byte) (*Client, error) {
+	// The CA certificate file may also contain a ChiaCA's previous private CA while it's still trusted
+	caCerts, err := kube.ParseCACertificates(caCertPEM)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private CA certificate: %w", err)
+	}
+	caCert := caCerts[0]
+	caKey, err := chiatls.ParsePemKey(caKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private CA key: %w", err)
+	}
+
+	certDER, certKey, err := chiatls.GenerateCASignedCert(caCert, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("error generating RPC client certificate: %w", err)
+	}
+	certPEM, keyPEM, err := chiatls.EncodeCertAndKeyToPEM(certDER, certKey)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding RPC client certificate: %w", err)
+	}
+	keyPair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("error loading RPC client certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	for _, cert := range caCerts {
+		pool.AddCert(cert)
+	}
+
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					Certificates: []tls.Certificate{keyPair},
+					RootCAs:      pool,
+					ServerName:   serverName,
+					MinVersion:   tls.VersionTLS12,
+				},
+			},
+		},
+	}, nil
+}
+
+// GetClient returns a Client for the private CA in the given CA Secret.
+// Clients are cached, and only regenerated when the CA Secret changes.
+func GetClient(ctx context.Context, c client.Client, namespace, secretName string) (*Client, error) {
+	var secret corev1.Secret
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: secretName}, &secret)
+	if err != nil {
+		return nil, fmt.Errorf("error getting CA Secret \"%s\": %w", secretName, err)
+	}
+
+	key := fmt.Sprintf("%s/%s", namespace, secretName)
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if cached, ok := clients[key]; ok && cached.resourceVersion == secret.ResourceVersion {
+		return cached.client, nil
+	}
+
+	caCert, ok := secret.Data["private_ca.crt"]
+	if !ok {
+		return nil, fmt.Errorf("private CA certificate not present in CA Secret \"%s\"", secretName)
+	}
+	caKey, ok := secret.Data["private_ca.key"]
+	if !ok {
+		return nil, fmt.Errorf("private CA key not present in CA Secret \"%s\"", secretName)
+	}
+
+	rpcClient, err := NewClient(caCert, caKey)
+	if err != nil {
+		return nil, err
+	}
+	clients[key] = cachedClient{
+		client:          rpcClient,
+		resourceVersion: secret.ResourceVersion,
+	}
+
+	return rpcClient, nil
+}
+
+// Do sends a request to an endpoint on the RPC server at the given host and port, and decodes the response into v
+func (c *Client) Do(ctx context.Context, host string, port int, endpoint string, body interface{}, v rpcinterface.IResponse) error {
+	if body == nil {
+		body = map[string]interface{}{}
+	}
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error marshalling request body: %w", err)
+	}
+
+	url := fmt.Sprintf("https://%s/%s", net.JoinHostPort(host, strconv.Itoa(port)), endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("error creating request to %s: %w", url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request to %s: %w", url, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response from %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s returned status code %d", url, resp.StatusCode)
+	}
+
+	err = json.Unmarshal(respBody, v)
+	if err != nil {
+		return fmt.Errorf("error decoding response from %s: %w", url, err)
+	}
+	if !v.IsSuccessful() {
+		return &rpcinterface.ChiaRPCError{Message: v.GetRPCError()}
+	}
+
+	return nil
+}
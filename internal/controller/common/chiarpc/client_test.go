@@ -0,0 +1,119 @@
+package chiarpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	chiatls "github.com/chia-network/go-chia-libs/pkg/tls"
+	"github.com/chia-network/go-chia-libs/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer returns a TLS server using a certificate signed by a new private CA, and the PEM encoded CA cert and key.
+// The server requires clients to present a certificate signed by the same CA, like chia's RPC servers.
+func newTestServer(t *testing.T, handler http.Handler) (*httptest.Server, []byte, []byte) {
+	caDER, caKey, err := chiatls.GenerateNewCA()
+	require.NoError(t, err)
+	caCertPEM, caKeyPEM, err := chiatls.EncodeCertAndKeyToPEM(caDER, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	serverDER, serverKey, err := chiatls.GenerateCASignedCert(caCert, caKey)
+	require.NoError(t, err)
+	serverCertPEM, serverKeyPEM, err := chiatls.EncodeCertAndKeyToPEM(serverDER, serverKey)
+	require.NoError(t, err)
+	serverKeyPair, err := tls.X509KeyPair(serverCertPEM, serverKeyPEM)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverKeyPair},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server, caCertPEM, caKeyPEM
+}
+
+func splitHostPort(t *testing.T, addr string) (string, int) {
+	host, portStr, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+	return host, port
+}
+
+func TestClientDo(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/get_blockchain_state", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success": true, "blockchain_state": {"peak": {"height": 1234}, "sync": {"synced": true, "sync_mode": false}}}`))
+	})
+	mux.HandleFunc("/failure", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success": false, "error": "something went wrong"}`))
+	})
+	server, caCert, caKey := newTestServer(t, mux)
+	host, port := splitHostPort(t, server.Listener.Addr().String())
+
+	c, err := NewClient(caCert, caKey)
+	require.NoError(t, err)
+
+	resp := &struct {
+		successResponse
+		BlockchainState types.BlockchainState `json:"blockchain_state"`
+	}{}
+	err = c.Do(context.TODO(), host, port, "get_blockchain_state", nil, resp)
+	require.NoError(t, err)
+	require.True(t, resp.BlockchainState.Sync.Synced)
+	require.Equal(t, uint32(1234), resp.BlockchainState.Peak.MustGet().Height)
+
+	err = c.Do(context.TODO(), host, port, "failure", nil, &successResponse{})
+	require.EqualError(t, err, "something went wrong")
+}
+
+func TestClientDoUntrustedServer(t *testing.T) {
+	server, _, _ := newTestServer(t, http.NewServeMux())
+	host, port := splitHostPort(t, server.Listener.Addr().String())
+
+	// A client for a different CA should not trust the server
+	caDER, caKey, err := chiatls.GenerateNewCA()
+	require.NoError(t, err)
+	caCert, caKeyPEM, err := chiatls.EncodeCertAndKeyToPEM(caDER, caKey)
+	require.NoError(t, err)
+
+	c, err := NewClient(caCert, caKeyPEM)
+	require.NoError(t, err)
+	err = c.Do(context.TODO(), host, port, "get_blockchain_state", nil, &successResponse{})
+	require.Error(t, err)
+}
+
+// successResponse is a minimal RPC response for tests
+type successResponse struct {
+	Success bool   `json:"success"`
+	Err     string `json:"error"`
+}
+
+func (r *successResponse) IsSuccessful() bool {
+	return r.Success
+}
+
+func (r *successResponse) GetRPCError() string {
+	return r.Err
+}
+
+func TestEffectivePlotSize(t *testing.T) {
+	// k32 plots have an expected size of (2*32 + 1) * 2^31 bytes
+	require.Equal(t, uint64(139586437120), EffectivePlotSize(32))
+	require.Equal(t, uint64(0), EffectivePlotSize(0))
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/go-chia-libs/pkg/rpc"
+	"github.com/chia-network/go-chia-libs/pkg/rpcinterface"
+	"github.com/chia-network/go-chia-libs/pkg/types"
+)
+
+// FarmerHarvesterSummary is a single harvester record returned by the farmer's get_harvesters_summary endpoint
+type FarmerHarvesterSummary struct {
+	Connection struct {
+		NodeID types.Bytes32 `json:"node_id"`
+		Host   string        `json:"host"`
+		Port   uint16        `json:"port"`
+	} `json:"connection"`
+	Plots                 int `json:"plots"`
+	FailedToOpenFilenames int `json:"failed_to_open_filenames"`
+	NoKeyFilenames        int `json:"no_key_filenames"`
+	Duplicates            int `json:"duplicates"`
+}
+
+// farmerGetHarvestersSummaryResponse get_harvesters_summary response format
+type farmerGetHarvestersSummaryResponse struct {
+	rpcinterface.Response
+	Harvesters []FarmerHarvesterSummary `json:"harvesters"`
+}
+
+// farmerGetSignagePointsResponse get_signage_points response format, only including the fields used by the operator
+type farmerGetSignagePointsResponse struct {
+	rpcinterface.Response
+	SignagePoints []struct {
+		Proofs []json.RawMessage `json:"proofs"`
+	} `json:"signage_points"`
+}
+
+// farmerGetPoolStateResponse get_pool_state response format, only including the fields used by the operator
+type farmerGetPoolStateResponse struct {
+	rpcinterface.Response
+	PoolState []struct {
+		PointsFound24h        []json.RawMessage `json:"points_found_24h"`
+		PointsAcknowledged24h []json.RawMessage `json:"points_acknowledged_24h"`
+	} `json:"pool_state"`
+}
+
+// FarmerState contains the connections and recent farming activity of a farmer, as reported by its RPC server
+type FarmerState struct {
+	Harvesters              []FarmerHarvesterSummary
+	FullNodePeers           []types.Connection
+	RecentProofs            int32
+	PartialsFound24h        int32
+	PartialsAcknowledged24h int32
+}
+
+// GetFarmerState queries the farmer RPC server on the given host for its harvesters, full_node peers, proofs, and pool partials
+func (c *Client) GetFarmerState(ctx context.Context, host string) (FarmerState, error) {
+	var state FarmerState
+
+	harvesters := &farmerGetHarvestersSummaryResponse{}
+	err := c.Do(ctx, host, consts.FarmerRPCPort, "get_harvesters_summary", nil, harvesters)
+	if err != nil {
+		return state, err
+	}
+	state.Harvesters = harvesters.Harvesters
+
+	connections := &rpc.GetConnectionsResponse{}
+	err = c.Do(ctx, host, consts.FarmerRPCPort, "get_connections", &rpc.GetConnectionsOptions{NodeType: types.NodeTypeFullNode}, connections)
+	if err != nil {
+		return state, err
+	}
+	for _, conn := range connections.Connections.OrEmpty() {
+		if conn.Type == types.NodeTypeFullNode {
+			state.FullNodePeers = append(state.FullNodePeers, conn)
+		}
+	}
+
+	signagePoints := &farmerGetSignagePointsResponse{}
+	err = c.Do(ctx, host, consts.FarmerRPCPort, "get_signage_points", nil, signagePoints)
+	if err != nil {
+		return state, err
+	}
+	for _, sp := range signagePoints.SignagePoints {
+		state.RecentProofs += int32(len(sp.Proofs))
+	}
+
+	poolState := &farmerGetPoolStateResponse{}
+	err = c.Do(ctx, host, consts.FarmerRPCPort, "get_pool_state", nil, poolState)
+	if err != nil {
+		return state, err
+	}
+	for _, pool := range poolState.PoolState {
+		state.PartialsFound24h += int32(len(pool.PointsFound24h))
+		state.PartialsAcknowledged24h += int32(len(pool.PointsAcknowledged24h))
+	}
+
+	return state, nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+import (
+	"context"
+
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/go-chia-libs/pkg/rpc"
+	"github.com/chia-network/go-chia-libs/pkg/types"
+)
+
+// FullNodeState contains the sync state of a full_node, as reported by its RPC server
+type FullNodeState struct {
+	PeakHeight     uint32
+	Synced         bool
+	SyncMode       bool
+	ConnectedPeers int32
+}
+
+// GetFullNodeState queries the full_node RPC server on the given host for its blockchain state and full_node peer connections
+func (c *Client) GetFullNodeState(ctx context.Context, host string) (FullNodeState, error) {
+	var state FullNodeState
+
+	blockchainState := &rpc.GetBlockchainStateResponse{}
+	err := c.Do(ctx, host, consts.NodeRPCPort, "get_blockchain_state", nil, blockchainState)
+	if err != nil {
+		return state, err
+	}
+	if bs, ok := blockchainState.BlockchainState.Get(); ok {
+		state.Synced = bs.Sync.Synced
+		state.SyncMode = bs.Sync.SyncMode
+		if peak, ok := bs.Peak.Get(); ok {
+			state.PeakHeight = peak.Height
+		}
+	}
+
+	connections := &rpc.GetConnectionsResponse{}
+	err = c.Do(ctx, host, consts.NodeRPCPort, "get_connections", &rpc.GetConnectionsOptions{NodeType: types.NodeTypeFullNode}, connections)
+	if err != nil {
+		return state, err
+	}
+	state.ConnectedPeers = countConnections(connections.Connections.OrEmpty(), types.NodeTypeFullNode)
+
+	return state, nil
+}
+
+// countConnections returns the number of connections to peers of the given node type
+func countConnections(connections []types.Connection, nodeType types.NodeType) int32 {
+	var count int32
+	for _, conn := range connections {
+		if conn.Type == nodeType {
+			count++
+		}
+	}
+	return count
+}
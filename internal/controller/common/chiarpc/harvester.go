@@ -0,0 +1,30 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package chiarpc
+
+import (
+	"context"
+
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/go-chia-libs/pkg/rpc"
+)
+
+// GetHarvesterPlots queries the harvester RPC server on the given host for its plots
+func (c *Client) GetHarvesterPlots(ctx context.Context, host string) (*rpc.HarvesterGetPlotsResponse, error) {
+	plots := &rpc.HarvesterGetPlotsResponse{}
+	err := c.Do(ctx, host, consts.HarvesterRPCPort, "get_plots", nil, plots)
+	if err != nil {
+		return nil, err
+	}
+	return plots, nil
+}
+
+// EffectivePlotSize returns the expected size in bytes of an uncompressed plot with the given k size
+func EffectivePlotSize(k uint8) uint64 {
+	if k == 0 {
+		return 0
+	}
+	return uint64(2*uint64(k)+1) * (uint64(1) << (k - 1))
+}
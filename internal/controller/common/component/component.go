@@ -0,0 +1,313 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+// Package component reconciles the Kubernetes resources that run a Chia component, like a farmer or a full_node.
+// Every Chia component is made up of the same kinds of resources: Services, generated PersistentVolumeClaims, a workload
+// with a rendered chia config ConfigMap, and a PodDisruptionBudget. Each kind's controller assembles its own resources,
+// and describes them in a Component, which is reconciled the same way, with the same events, for every kind. The Engine runs the
+// rest of the reconcile sequence around it, which is also the same for every kind.
+package component
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// Component describes the resources of a Chia component, in the order they're reconciled
+type Component struct {
+	// Services are the component's Services
+	Services []Service
+
+	// VolumeClaims are the PersistentVolumeClaims the operator generates for the component
+	VolumeClaims []VolumeClaim
+
+	// Workload is the component's *appsv1.Deployment, *appsv1.StatefulSet, or *appsv1.DaemonSet.
+	// The chia config ConfigMap is rendered from its chia container, and mounted in its Pods if the config file mode is enabled.
+	Workload client.Object
+
+	// PodDisruptionBudget is the component's PodDisruptionBudget
+	PodDisruptionBudget PodDisruptionBudget
+}
+
+// Service describes one of a component's Services
+type Service struct {
+	// Description names the Service in events and errors, such as "peer" or "chia-exporter"
+	Description string
+
+	// Desired is the assembled Service
+	Desired corev1.Service
+
+	// Config is the Service's configuration in the custom resource's spec
+	Config k8schianetv1.Service
+
+	// DefaultEnabled is whether the Service is created when its configuration doesn't say whether it's enabled
+	DefaultEnabled bool
+}
+
+// VolumeClaim describes a PersistentVolumeClaim the operator generates for a component
+type VolumeClaim struct {
+	// Description names the PersistentVolumeClaim in events and errors, such as "CHIA_ROOT"
+	Description string
+
+	// Enabled is whether the custom resource's storage configuration asks for the PersistentVolumeClaim to be generated
+	Enabled bool
+
+	// Storage is the custom resource's storage configuration
+	Storage *k8schianetv1.StorageConfig
+
+	// Assemble assembles the PersistentVolumeClaim. It's only called if the claim is enabled.
+	Assemble func() (*corev1.PersistentVolumeClaim, error)
+}
+
+// PodDisruptionBudget describes a component's PodDisruptionBudget
+type PodDisruptionBudget struct {
+	// Desired is the assembled PodDisruptionBudget
+	Desired policyv1.PodDisruptionBudget
+
+	// Config is the PodDisruptionBudget's configuration in the custom resource's spec
+	Config *k8schianetv1.PodDisruptionBudgetConfig
+
+	// DefaultEnabled is whether the PodDisruptionBudget is created when its configuration doesn't say whether it's enabled
+	DefaultEnabled bool
+}
+
+// Status points to the fields of a custom resource's status that UpdateStatus sets
+type Status struct {
+	Conditions         *[]metav1.Condition
+	ObservedGeneration *int64
+	Ready              *bool
+}
+
+// Reconciler reconciles the resources of the Chia component that a custom resource describes
+type Reconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder events.EventRecorder
+
+	// Owner is the custom resource that controls the component's resources. Events are recorded on it.
+	Owner client.Object
+
+	// Kind is the Owner's kind
+	Kind consts.ChiaKind
+
+	// Name is the component's name in events, such as "farmer" or "node"
+	Name string
+
+	// Chia is the Owner's chia configuration
+	Chia k8schianetv1.CommonSpecChia
+}
+
+// Reconcile reconciles all of a component's resources, and returns the rollout state of its workload
+func (r *Reconciler) Reconcile(ctx context.Context, component Component) (kube.WorkloadStatus, error) {
+	if err := r.ReconcileServices(ctx, component.Services); err != nil {
+		return kube.WorkloadStatus{}, err
+	}
+
+	for _, claim := range component.VolumeClaims {
+		if err := r.ReconcileVolumeClaim(ctx, claim); err != nil {
+			return kube.WorkloadStatus{}, err
+		}
+	}
+
+	if err := r.ReconcileWorkload(ctx, component.Workload); err != nil {
+		return kube.WorkloadStatus{}, err
+	}
+
+	if err := r.ReconcilePodDisruptionBudget(ctx, component.PodDisruptionBudget); err != nil {
+		return kube.WorkloadStatus{}, err
+	}
+
+	r.Recorder.Eventf(r.Owner, nil, corev1.EventTypeNormal, "Created", "Created", "Successfully created %s resources.", r.Kind)
+	return r.GetWorkloadStatus(ctx, component.Workload)
+}
+
+// ReconcileServices reconciles a component's Services in order
+func (r *Reconciler) ReconcileServices(ctx context.Context, services []Service) error {
+	for _, srv := range services {
+		resource := srv.Description + " Service"
+		if err := controllerutil.SetControllerReference(r.Owner, &srv.Desired, r.Scheme); err != nil {
+			r.AssembleFailed(resource)
+			return fmt.Errorf("encountered error assembling %s: %w", resource, err)
+		}
+		if _, err := kube.ReconcileService(ctx, r.Client, srv.Config, srv.Desired, srv.DefaultEnabled); err != nil {
+			r.CreateFailed(resource)
+			return err
+		}
+	}
+	return nil
+}
+
+// ReconcileVolumeClaim reconciles a PersistentVolumeClaim the operator generates for a component, if it's enabled
+func (r *Reconciler) ReconcileVolumeClaim(ctx context.Context, claim VolumeClaim) error {
+	if !claim.Enabled {
+		return nil
+	}
+
+	resource := claim.Description + " PVC"
+	pvc, err := claim.Assemble()
+	if err != nil {
+		r.AssembleFailed(resource)
+		return err
+	}
+	if pvc == nil {
+		return fmt.Errorf("%s could not be created", resource)
+	}
+
+	if _, err := kube.ReconcilePersistentVolumeClaim(ctx, r.Client, claim.Storage, *pvc); err != nil {
+		r.CreateFailed(resource)
+		return err
+	}
+	return nil
+}
+
+// ReconcileWorkload reconciles a component's Deployment, StatefulSet, or DaemonSet, and its chia config ConfigMap.
+// The workload's Pod template is updated in place to mount the chia config if the config file mode is enabled.
+func (r *Reconciler) ReconcileWorkload(ctx context.Context, workload client.Object) error {
+	template, kind, err := getPodTemplate(workload)
+	if err != nil {
+		return err
+	}
+	if err := controllerutil.SetControllerReference(r.Owner, workload, r.Scheme); err != nil {
+		r.AssembleFailed(kind)
+		return err
+	}
+
+	// Render the chia config from the workload's chia container before the config file is mounted in it
+	chiaConfig, unrendered, err := kube.AssembleChiaConfigMap(kube.AssembleChiaConfigMapInputs{
+		WorkloadName: workload.GetName(),
+		Namespace:    workload.GetNamespace(),
+		Labels:       workload.GetLabels(),
+		Annotations:  workload.GetAnnotations(),
+		Enabled:      kube.ChiaConfigFileEnabled(r.Chia),
+		PodTemplate:  *template,
+	})
+	if err != nil {
+		r.AssembleFailed("chia config ConfigMap")
+		return err
+	}
+	if len(unrendered) > 0 {
+		r.Recorder.Eventf(r.Owner, nil, corev1.EventTypeWarning, "Unrendered", "Unrendered", "Environment variables %s don't name a field in the chia config, so they weren't rendered into the %s config file. The chia image still applies them when it starts.", strings.Join(unrendered, ", "), r.Name)
+	}
+	if err := controllerutil.SetControllerReference(r.Owner, &chiaConfig, r.Scheme); err != nil {
+		r.AssembleFailed("chia config ConfigMap")
+		return err
+	}
+	if _, err := kube.ReconcileChiaConfigMap(ctx, r.Client, r.Chia, chiaConfig); err != nil {
+		r.CreateFailed("chia config ConfigMap")
+		return err
+	}
+	if kube.ChiaConfigFileEnabled(r.Chia) {
+		if err := kube.AddChiaConfigFile(template, chiaConfig); err != nil {
+			r.AssembleFailed(kind)
+			return err
+		}
+	}
+
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		_, err = kube.ReconcileDeployment(ctx, r.Client, *w)
+	case *appsv1.StatefulSet:
+		_, err = kube.ReconcileStatefulset(ctx, r.Client, *w)
+	case *appsv1.DaemonSet:
+		_, err = kube.ReconcileDaemonSet(ctx, r.Client, *w)
+	}
+	if err != nil {
+		r.CreateFailed(kind)
+		return err
+	}
+	return nil
+}
+
+// ReconcilePodDisruptionBudget reconciles a component's PodDisruptionBudget
+func (r *Reconciler) ReconcilePodDisruptionBudget(ctx context.Context, pdb PodDisruptionBudget) error {
+	if err := controllerutil.SetControllerReference(r.Owner, &pdb.Desired, r.Scheme); err != nil {
+		r.AssembleFailed("PodDisruptionBudget")
+		return fmt.Errorf("encountered error assembling PodDisruptionBudget: %w", err)
+	}
+	if _, err := kube.ReconcilePodDisruptionBudget(ctx, r.Client, pdb.Config, pdb.Desired, pdb.DefaultEnabled); err != nil {
+		r.CreateFailed("PodDisruptionBudget")
+		return err
+	}
+	return nil
+}
+
+// GetWorkloadStatus returns the rollout state of a component's live Deployment, StatefulSet, or DaemonSet
+func (r *Reconciler) GetWorkloadStatus(ctx context.Context, workload client.Object) (kube.WorkloadStatus, error) {
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		return kube.GetDeploymentWorkloadStatus(ctx, r.Client, *w)
+	case *appsv1.StatefulSet:
+		return kube.GetStatefulSetWorkloadStatus(ctx, r.Client, *w)
+	case *appsv1.DaemonSet:
+		return kube.GetDaemonSetWorkloadStatus(ctx, r.Client, *w)
+	}
+	return kube.WorkloadStatus{}, fmt.Errorf("unsupported workload type %T", workload)
+}
+
+// UpdateStatus records the rollout state of a component's workload in its custom resource's status, once all of its resources were reconciled.
+// The returned result requeues the custom resource until the rollout has finished, since Pod failures don't trigger a reconcile.
+func (r *Reconciler) UpdateStatus(ctx context.Context, status Status, ws kube.WorkloadStatus) (ctrl.Result, error) {
+	kube.SetReconciledConditions(status.Conditions, r.Owner.GetGeneration(), r.Chia, ws)
+	*status.ObservedGeneration = r.Owner.GetGeneration()
+	*status.Ready = ws.Ready
+	if err := r.Status().Update(ctx, r.Owner); err != nil {
+		if strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	var result ctrl.Result
+	if !ws.Ready {
+		result.RequeueAfter = kube.WorkloadNotReadyRequeueInterval
+	}
+	return result, nil
+}
+
+// AssembleFailed records a Warning event on the custom resource for one of its component's resources that couldn't be assembled
+func (r *Reconciler) AssembleFailed(resource string) {
+	r.Recorder.Eventf(r.Owner, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to assemble %s %s -- Check operator logs.", r.Name, resource)
+}
+
+// CreateFailed records a Warning event on the custom resource for one of its component's resources that couldn't be created or updated
+func (r *Reconciler) CreateFailed(resource string) {
+	r.Recorder.Eventf(r.Owner, nil, corev1.EventTypeWarning, "Failed", "Failed", "Failed to create %s %s -- Check operator logs.", r.Name, resource)
+}
+
+// RequeueSooner returns the result with its RequeueAfter lowered to requeueAfter, if requeueAfter is set and sooner
+func RequeueSooner(result ctrl.Result, requeueAfter time.Duration) ctrl.Result {
+	if requeueAfter > 0 && (result.RequeueAfter == 0 || requeueAfter < result.RequeueAfter) {
+		result.RequeueAfter = requeueAfter
+	}
+	return result
+}
+
+// getPodTemplate returns the Pod template and kind of a Deployment, StatefulSet, or DaemonSet
+func getPodTemplate(workload client.Object) (*corev1.PodTemplateSpec, string, error) {
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		return &w.Spec.Template, "Deployment", nil
+	case *appsv1.StatefulSet:
+		return &w.Spec.Template, "StatefulSet", nil
+	case *appsv1.DaemonSet:
+		return &w.Spec.Template, "DaemonSet", nil
+	}
+	return nil, "", fmt.Errorf("unsupported workload type %T", workload)
+}
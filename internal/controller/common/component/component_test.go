@@ -0,0 +1,182 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package component
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+func newTestReconciler(t *testing.T, scheme *runtime.Scheme, chia k8schianetv1.CommonSpecChia) (*Reconciler, *events.FakeRecorder) {
+	t.Helper()
+	farmer := &k8schianetv1.ChiaFarmer{
+		ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "default", UID: types.UID("farmer-uid")},
+	}
+	recorder := events.NewFakeRecorder(10)
+	return &Reconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(farmer).WithStatusSubresource(farmer).Build(),
+		Scheme:   scheme,
+		Recorder: recorder,
+		Owner:    farmer,
+		Kind:     consts.ChiaFarmerKind,
+		Name:     "farmer",
+		Chia:     chia,
+	}, recorder
+}
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, k8schianetv1.AddToScheme(scheme))
+	return scheme
+}
+
+func newTestDeployment() appsv1.Deployment {
+	return appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "default", Labels: map[string]string{"app": "farmer"}},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "farmer"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "farmer"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:         "chia",
+						Image:        "ghcr.io/chia-network/chia:latest",
+						VolumeMounts: []corev1.VolumeMount{{Name: "chiaroot", MountPath: "/chia-data"}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	enabled := true
+	r, recorder := newTestReconciler(t, newTestScheme(t), k8schianetv1.CommonSpecChia{
+		ConfigFile: &k8schianetv1.ChiaConfigFileConfig{Enabled: &enabled},
+	})
+	ctx := context.Background()
+
+	deploy := newTestDeployment()
+	_, err := r.Reconcile(ctx, Component{
+		Services: []Service{
+			{Description: "peer", Desired: corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "default"}}, DefaultEnabled: true},
+			{Description: "chia-healthcheck", Desired: corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "farmer-healthcheck", Namespace: "default"}}},
+		},
+		Workload: &deploy,
+		PodDisruptionBudget: PodDisruptionBudget{
+			Desired: policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "default"}},
+		},
+	})
+	require.NoError(t, err)
+
+	// The enabled Service is created and controlled by the custom resource, and the disabled one isn't created
+	var srv corev1.Service
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "farmer"}, &srv))
+	require.Equal(t, types.UID("farmer-uid"), metav1.GetControllerOf(&srv).UID)
+	err = r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "farmer-healthcheck"}, &srv)
+	require.Error(t, err)
+
+	// The chia config is rendered into a ConfigMap and mounted in the Deployment's Pods
+	var configMap corev1.ConfigMap
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Namespace: "default", Name: kube.GetChiaConfigMapName("farmer")}, &configMap))
+	var current appsv1.Deployment
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "farmer"}, &current))
+	require.Equal(t, types.UID("farmer-uid"), metav1.GetControllerOf(&current).UID)
+	require.Len(t, current.Spec.Template.Spec.InitContainers, 1)
+	require.Contains(t, current.Spec.Template.Annotations, kube.ChiaConfigChecksumAnnotation)
+
+	require.Equal(t, "Normal Created Successfully created ChiaFarmer resources.", <-recorder.Events)
+}
+
+func TestReconcileServicesAssembleFailed(t *testing.T) {
+	// The custom resource's kind isn't registered in the scheme, so its Services can't be controlled by it
+	r, recorder := newTestReconciler(t, newTestScheme(t), k8schianetv1.CommonSpecChia{})
+	r.Scheme = runtime.NewScheme()
+
+	err := r.ReconcileServices(context.Background(), []Service{
+		{Description: "peer", Desired: corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "default"}}, DefaultEnabled: true},
+	})
+	require.ErrorContains(t, err, "encountered error assembling peer Service")
+	require.Equal(t, "Warning Failed Failed to assemble farmer peer Service -- Check operator logs.", <-recorder.Events)
+}
+
+func TestReconcileVolumeClaim(t *testing.T) {
+	r, recorder := newTestReconciler(t, newTestScheme(t), k8schianetv1.CommonSpecChia{})
+	ctx := context.Background()
+
+	// Disabled claims aren't assembled
+	err := r.ReconcileVolumeClaim(ctx, VolumeClaim{
+		Description: "CHIA_ROOT",
+		Assemble: func() (*corev1.PersistentVolumeClaim, error) {
+			t.Fatal("disabled PVC was assembled")
+			return nil, nil
+		},
+	})
+	require.NoError(t, err)
+
+	err = r.ReconcileVolumeClaim(ctx, VolumeClaim{
+		Description: "CHIA_ROOT",
+		Enabled:     true,
+		Assemble: func() (*corev1.PersistentVolumeClaim, error) {
+			return nil, nil
+		},
+	})
+	require.EqualError(t, err, "CHIA_ROOT PVC could not be created")
+	require.Empty(t, recorder.Events)
+}
+
+func TestUpdateStatus(t *testing.T) {
+	r, _ := newTestReconciler(t, newTestScheme(t), k8schianetv1.CommonSpecChia{})
+	farmer := r.Owner.(*k8schianetv1.ChiaFarmer)
+	farmer.Generation = 2
+	status := Status{
+		Conditions:         &farmer.Status.Conditions,
+		ObservedGeneration: &farmer.Status.ObservedGeneration,
+		Ready:              &farmer.Status.Ready,
+	}
+
+	result, err := r.UpdateStatus(context.Background(), status, kube.WorkloadStatus{Progressing: true, Reason: kube.ReasonRolloutInProgress})
+	require.NoError(t, err)
+	require.Equal(t, kube.WorkloadNotReadyRequeueInterval, result.RequeueAfter)
+	require.Equal(t, int64(2), farmer.Status.ObservedGeneration)
+	require.False(t, farmer.Status.Ready)
+
+	result, err = r.UpdateStatus(context.Background(), status, kube.WorkloadStatus{Ready: true, Reason: kube.ReasonRolloutComplete})
+	require.NoError(t, err)
+	require.Zero(t, result.RequeueAfter)
+	require.True(t, farmer.Status.Ready)
+}
+
+func TestRequeueSooner(t *testing.T) {
+	require.Equal(t, ctrl.Result{RequeueAfter: time.Minute}, RequeueSooner(ctrl.Result{}, time.Minute))
+	require.Equal(t, ctrl.Result{RequeueAfter: time.Second}, RequeueSooner(ctrl.Result{RequeueAfter: time.Second}, time.Minute))
+	require.Equal(t, ctrl.Result{RequeueAfter: time.Second}, RequeueSooner(ctrl.Result{RequeueAfter: time.Minute}, time.Second))
+	require.Equal(t, ctrl.Result{RequeueAfter: time.Minute}, RequeueSooner(ctrl.Result{RequeueAfter: time.Minute}, 0))
+}
+
+func TestGetWorkloadStatusUnsupported(t *testing.T) {
+	r, _ := newTestReconciler(t, newTestScheme(t), k8schianetv1.CommonSpecChia{})
+	_, err := r.GetWorkloadStatus(context.Background(), &corev1.Pod{})
+	require.EqualError(t, err, "unsupported workload type *v1.Pod")
+}
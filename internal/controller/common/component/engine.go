@@ -0,0 +1,220 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package component
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/chiaconfig"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/finalizer"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+	"github.com/chia-network/chia-operator/internal/metrics"
+)
+
+// Engine runs the reconcile sequence that's the same for every kind of Chia component. It records the reconcile in the operator's
+// metrics, finalizes the custom resource when it's deleted, stops applying changes while it's suspended, validates its chia config,
+// reconciles its component's resources, and updates its status. Each kind's controller fills in the parts that differ with Hooks.
+type Engine struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder events.EventRecorder
+
+	// Kind is the kind of custom resource the engine reconciles
+	Kind consts.ChiaKind
+
+	// Name is the component's name in events, such as "farmer" or "node"
+	Name string
+}
+
+// Resource describes the parts of a fetched custom resource that the engine reads and sets
+type Resource struct {
+	// Meta is the custom resource's ObjectMeta
+	Meta metav1.ObjectMeta
+
+	// Chia is the custom resource's chia configuration
+	Chia k8schianetv1.CommonSpecChia
+
+	// Suspend is the custom resource's suspend configuration
+	Suspend *k8schianetv1.SuspendConfig
+
+	// Status points to the fields of the custom resource's status that the engine sets
+	Status Status
+
+	// Workload is the component's Deployment, StatefulSet, or DaemonSet, with only its name and namespace set. It's scaled to zero
+	// while the custom resource is suspended, unless it's a DaemonSet, and deleted before final VolumeSnapshots are taken.
+	Workload client.Object
+}
+
+// Hooks are the parts of the reconcile sequence that differ between kinds. They're called after the custom resource was fetched,
+// so they can read it. Validate and Reconciled are optional.
+type Hooks struct {
+	// Describe returns the parts of the custom resource that the engine reads and sets
+	Describe func() Resource
+
+	// FinalizerInputs returns the deletion policy and generated storage of the deleted custom resource.
+	// The engine sets the returned inputs' Kind and Workload.
+	FinalizerInputs func(ctx context.Context) (finalizer.Inputs, error)
+
+	// Validate validates the parts of the custom resource's configuration that are specific to its kind
+	Validate func() error
+
+	// Assemble assembles the component's resources from the custom resource and its ChiaNetwork's data. It can reconcile
+	// the other resources the component depends on first, and records events for them with the component Reconciler.
+	Assemble func(ctx context.Context, c *Reconciler, networkData *map[string]string) (Component, error)
+
+	// Reconciled is called once the component's resources were reconciled, before the custom resource's status is updated.
+	// It reconciles the kind's other resources and sets its own status fields. Returns how soon the custom resource should be
+	// reconciled again, or 0.
+	Reconciled func(ctx context.Context, c *Reconciler, component Component) (time.Duration, error)
+}
+
+// Reconcile fetches the custom resource a request is for into obj, and reconciles it
+func (e *Engine) Reconcile(ctx context.Context, req ctrl.Request, obj client.Object, hooks Hooks) (_ ctrl.Result, reterr error) {
+	log := log.FromContext(ctx)
+	log.Info("Running reconciler...")
+	kind := string(e.Kind)
+
+	// Record how long this reconcile took, and why it failed if it returned an error
+	start := time.Now()
+	defer func() {
+		kube.ObserveReconcile(kind, start, reterr)
+	}()
+
+	// Get the custom resource
+	err := e.Get(ctx, req.NamespacedName, obj)
+	if err != nil && errors.IsNotFound(err) {
+		// Remove this object from the operator's metrics
+		metrics.Resources.Delete(kind, req.String())
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		log.Error(err, fmt.Sprintf("%sReconciler %s=%s unable to fetch %s resource", kind, kind, req.NamespacedName, kind))
+		return ctrl.Result{}, err
+	}
+	resource := hooks.Describe()
+	wrap := func(err error) error {
+		return fmt.Errorf("%sReconciler %s=%s %w", kind, kind, req.NamespacedName, err)
+	}
+
+	// Add this object to the operator's metrics, if it wasn't already added
+	metrics.Resources.Add(kind, req.String())
+
+	// Record the custom resource's Ready condition in the operator's metrics, after any reconciliation error was recorded in it
+	defer func() {
+		if obj.GetDeletionTimestamp().IsZero() {
+			metrics.Resources.SetReadyCondition(kind, req.String(), kube.GetReadyConditionStatus(*resource.Status.Conditions))
+		}
+	}()
+
+	// Record any reconciliation error in the CR's status conditions
+	defer func() {
+		if reterr != nil {
+			kube.SetReconcileFailedConditions(resource.Status.Conditions, obj.GetGeneration(), reterr)
+			*resource.Status.Ready = false
+			if err := e.Status().Update(ctx, obj); err != nil && !strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+				log.Error(err, fmt.Sprintf("%sReconciler %s=%s unable to update %s status conditions", kind, kind, req.NamespacedName, kind))
+			}
+		}
+	}()
+
+	// Clean up the custom resource's generated storage and remove its finalizer when it's deleted
+	if !obj.GetDeletionTimestamp().IsZero() {
+		metrics.Resources.Delete(kind, req.String())
+		inputs, err := hooks.FinalizerInputs(ctx)
+		if err != nil {
+			return ctrl.Result{}, wrap(err)
+		}
+		inputs.Kind = kind
+		inputs.Workload = resource.Workload
+		res, err := finalizer.Finalize(ctx, e.Client, e.Recorder, obj, resource.Status.Conditions, inputs)
+		if err != nil {
+			return ctrl.Result{}, wrap(err)
+		}
+		return res, nil
+	}
+	if err := finalizer.Ensure(ctx, e.Client, obj); err != nil {
+		return ctrl.Result{}, wrap(err)
+	}
+
+	// Stop applying changes while the custom resource is suspended. DaemonSets can't be scaled to zero.
+	suspendWorkload := resource.Workload
+	if _, ok := suspendWorkload.(*appsv1.DaemonSet); ok {
+		suspendWorkload = nil
+	}
+	suspended, err := kube.ReconcileSuspension(ctx, e.Client, resource.Meta, resource.Suspend, resource.Status.Conditions, suspendWorkload)
+	if err != nil {
+		return ctrl.Result{}, wrap(err)
+	}
+	if suspended {
+		return kube.UpdateSuspendedStatus(ctx, e.Client, e.Kind, obj, *resource.Status.Conditions, resource.Status.ObservedGeneration, resource.Status.Ready)
+	}
+
+	// Validate the custom resource's configuration before doing any other work
+	if hooks.Validate != nil {
+		if err := hooks.Validate(); err != nil {
+			e.Recorder.Eventf(obj, nil, corev1.EventTypeWarning, "Failed", "Failed", "Invalid %s configuration -- %s", e.Name, err.Error())
+			return ctrl.Result{}, kube.NewConditionError(k8schianetv1.ConditionTypeConfigValid, kube.ReasonInvalidConfig, wrap(err))
+		}
+	}
+	if err := chiaconfig.Validate(resource.Chia.ConfigOverrides); err != nil {
+		e.Recorder.Eventf(obj, nil, corev1.EventTypeWarning, "Failed", "Failed", "Invalid chia configOverrides -- "+err.Error())
+		return ctrl.Result{}, kube.NewConditionError(k8schianetv1.ConditionTypeConfigValid, kube.ReasonInvalidConfig, wrap(err))
+	}
+
+	// Check for ChiaNetwork, retrieve matching ConfigMap if specified
+	networkData, err := kube.GetChiaNetworkData(ctx, e.Client, resource.Chia, obj.GetNamespace())
+	if err != nil {
+		return ctrl.Result{}, kube.NewConditionError(k8schianetv1.ConditionTypeNetworkResolved, kube.ReasonChiaNetworkNotFound, err)
+	}
+
+	// Assemble and reconcile the component's resources
+	c := Reconciler{
+		Client:   e.Client,
+		Scheme:   e.Scheme,
+		Recorder: e.Recorder,
+		Owner:    obj,
+		Kind:     e.Kind,
+		Name:     e.Name,
+		Chia:     resource.Chia,
+	}
+	component, err := hooks.Assemble(ctx, &c, networkData)
+	if err != nil {
+		return ctrl.Result{}, wrap(err)
+	}
+	workloadStatus, err := c.Reconcile(ctx, component)
+	if err != nil {
+		return ctrl.Result{}, wrap(err)
+	}
+	var requeueAfter time.Duration
+	if hooks.Reconciled != nil {
+		requeueAfter, err = hooks.Reconciled(ctx, &c, component)
+		if err != nil {
+			return ctrl.Result{}, wrap(err)
+		}
+	}
+
+	// Update CR status
+	result, err := c.UpdateStatus(ctx, resource.Status, workloadStatus)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("%sReconciler %s=%s unable to update %s status", kind, kind, req.NamespacedName, kind))
+		return ctrl.Result{}, err
+	}
+	return RequeueSooner(result, requeueAfter), nil
+}
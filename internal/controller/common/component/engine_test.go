@@ -0,0 +1,148 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package component
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/finalizer"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+func newTestEngine(t *testing.T, farmer *k8schianetv1.ChiaFarmer) (*Engine, *events.FakeRecorder) {
+	t.Helper()
+	scheme := newTestScheme(t)
+	recorder := events.NewFakeRecorder(10)
+	return &Engine{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(farmer).WithStatusSubresource(farmer).Build(),
+		Scheme:   scheme,
+		Recorder: recorder,
+		Kind:     consts.ChiaFarmerKind,
+		Name:     "farmer",
+	}, recorder
+}
+
+// newTestHooks returns Hooks for a ChiaFarmer that's fetched into farmer. assembled counts the calls to Assemble.
+func newTestHooks(farmer *k8schianetv1.ChiaFarmer, validateErr error, assembled *int) Hooks {
+	return Hooks{
+		Describe: func() Resource {
+			return Resource{
+				Meta:    farmer.ObjectMeta,
+				Chia:    farmer.Spec.ChiaConfig.CommonSpecChia,
+				Suspend: farmer.Spec.Suspend,
+				Status: Status{
+					Conditions:         &farmer.Status.Conditions,
+					ObservedGeneration: &farmer.Status.ObservedGeneration,
+					Ready:              &farmer.Status.Ready,
+				},
+				Workload: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "default"}},
+			}
+		},
+		FinalizerInputs: func(ctx context.Context) (finalizer.Inputs, error) {
+			return finalizer.Inputs{DeletionPolicy: farmer.Spec.DeletionPolicy}, nil
+		},
+		Validate: func() error {
+			return validateErr
+		},
+		Assemble: func(ctx context.Context, c *Reconciler, networkData *map[string]string) (Component, error) {
+			*assembled++
+			deploy := newTestDeployment()
+			return Component{
+				Workload: &deploy,
+				PodDisruptionBudget: PodDisruptionBudget{
+					Desired: policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "default"}},
+				},
+			}, nil
+		},
+		Reconciled: func(ctx context.Context, c *Reconciler, component Component) (time.Duration, error) {
+			return time.Second, nil
+		},
+	}
+}
+
+func TestEngineReconcile(t *testing.T) {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "farmer"}}
+	e, recorder := newTestEngine(t, &k8schianetv1.ChiaFarmer{
+		ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "default", Generation: 3},
+	})
+
+	var farmer k8schianetv1.ChiaFarmer
+	var assembled int
+	result, err := e.Reconcile(ctx, req, &farmer, newTestHooks(&farmer, nil, &assembled))
+	require.NoError(t, err)
+	require.Equal(t, 1, assembled)
+
+	// The Reconciled hook's requeue is sooner than the workload's rollout requeue
+	require.Equal(t, time.Second, result.RequeueAfter)
+	require.Equal(t, "Normal Created Successfully created ChiaFarmer resources.", <-recorder.Events)
+
+	// The finalizer is added, the workload is created, and the status is updated
+	var current k8schianetv1.ChiaFarmer
+	require.NoError(t, e.Get(ctx, req.NamespacedName, &current))
+	require.True(t, controllerutil.ContainsFinalizer(&current, finalizer.Name))
+	require.Equal(t, int64(3), current.Status.ObservedGeneration)
+	var deploy appsv1.Deployment
+	require.NoError(t, e.Get(ctx, req.NamespacedName, &deploy))
+
+	// A custom resource that doesn't exist isn't assembled
+	var missing k8schianetv1.ChiaFarmer
+	_, err = e.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "missing"}}, &missing, newTestHooks(&missing, nil, &assembled))
+	require.NoError(t, err)
+	require.Equal(t, 1, assembled)
+}
+
+func TestEngineReconcileInvalid(t *testing.T) {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "farmer"}}
+	e, recorder := newTestEngine(t, &k8schianetv1.ChiaFarmer{
+		ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "default"},
+	})
+
+	var farmer k8schianetv1.ChiaFarmer
+	var assembled int
+	_, err := e.Reconcile(ctx, req, &farmer, newTestHooks(&farmer, errors.New("bad config"), &assembled))
+	require.EqualError(t, err, "ChiaFarmerReconciler ChiaFarmer=default/farmer bad config")
+	require.Equal(t, kube.ReasonInvalidConfig, kube.GetReconcileErrorReason(err))
+	require.Zero(t, assembled)
+	require.Equal(t, "Warning Failed Invalid farmer configuration -- bad config", <-recorder.Events)
+
+	// The error is recorded in the custom resource's status conditions
+	var current k8schianetv1.ChiaFarmer
+	require.NoError(t, e.Get(ctx, req.NamespacedName, &current))
+	require.True(t, apimeta.IsStatusConditionFalse(current.Status.Conditions, k8schianetv1.ConditionTypeConfigValid))
+	require.True(t, apimeta.IsStatusConditionFalse(current.Status.Conditions, k8schianetv1.ConditionTypeReady))
+}
+
+func TestEngineReconcileSuspended(t *testing.T) {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "farmer"}}
+	e, _ := newTestEngine(t, &k8schianetv1.ChiaFarmer{
+		ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "default", Annotations: map[string]string{kube.SuspendAnnotation: "true"}},
+	})
+
+	// A suspended custom resource isn't validated or assembled
+	var farmer k8schianetv1.ChiaFarmer
+	var assembled int
+	_, err := e.Reconcile(ctx, req, &farmer, newTestHooks(&farmer, errors.New("bad config"), &assembled))
+	require.NoError(t, err)
+	require.Zero(t, assembled)
+}
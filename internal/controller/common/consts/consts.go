@@ -4,6 +4,8 @@ Copyright 2023 Chia Network Inc.
 
 package consts
 
+import "time"
+
 // ChiaKind enumerates the list of Chia component custom resources this operator controls
 type ChiaKind string
 
@@ -11,9 +13,18 @@ const (
 	// ChiaCAKind is the API Kind for Chia certificate authorities
 	ChiaCAKind ChiaKind = "ChiaCA"
 
+	// ChiaCertificatesKind is the API Kind for Chia certificates
+	ChiaCertificatesKind ChiaKind = "ChiaCertificates"
+
 	// ChiaCrawlerKind is the API Kind for Chia crawlers
 	ChiaCrawlerKind ChiaKind = "ChiaCrawler"
 
+	// ChiaDataLayerKind is the API Kind for Chia data_layer services
+	ChiaDataLayerKind ChiaKind = "ChiaDataLayer"
+
+	// ChiaFarmKind is the API Kind for Chia farms composed of a full_node, farmer, wallet, and harvesters
+	ChiaFarmKind ChiaKind = "ChiaFarm"
+
 	// ChiaFarmerKind is the API Kind for Chia farmers
 	ChiaFarmerKind ChiaKind = "ChiaFarmer"
 
@@ -23,6 +34,9 @@ const (
 	// ChiaIntroducerKind is the API Kind for Chia introducers
 	ChiaIntroducerKind ChiaKind = "ChiaIntroducer"
 
+	// ChiaNetworkKind is the API Kind for Chia network configurations
+	ChiaNetworkKind ChiaKind = "ChiaNetwork"
+
 	// ChiaNodeKind is the API Kind for Chia full_nodes
 	ChiaNodeKind ChiaKind = "ChiaNode"
 
@@ -36,6 +50,21 @@ const (
 	ChiaWalletKind ChiaKind = "ChiaWallet"
 )
 
+// DefaultRPCStatusInterval is the default minimum amount of time between RPC status queries to a chia service
+const DefaultRPCStatusInterval = 1 * time.Minute
+
+// DefaultCertificateRenewBefore is the default amount of time before a certificate expires that the operator re-issues it
+const DefaultCertificateRenewBefore = 30 * 24 * time.Hour
+
+// DefaultCAOverlapPeriod is the default amount of time the previous private CA is still trusted after a ChiaCA's private CA changes
+const DefaultCAOverlapPeriod = 7 * 24 * time.Hour
+
+// DefaultSnapshotInterval is the default minimum amount of time between a ChiaNode's CHIA_ROOT VolumeSnapshots
+const DefaultSnapshotInterval = 24 * time.Hour
+
+// DefaultSnapshotRetain is the default number of a ChiaNode's CHIA_ROOT VolumeSnapshots to keep
+const DefaultSnapshotRetain = 3
+
 // API default image constants
 var (
 	// DefaultChiaImageName contains the default image name for the chia-docker image
@@ -61,6 +90,12 @@ var (
 
 	// DefaultChiaDBPullImageTag contains the default tag name for the chia-db-pull init container image
 	DefaultChiaDBPullImageTag = "latest"
+
+	// DefaultChiaOperatorImageName contains the default image name for the operator's own init containers, chia-db-restore and chia-config
+	DefaultChiaOperatorImageName = "ghcr.io/chia-network/chia-operator"
+
+	// DefaultChiaOperatorImageTag contains the default tag name for the operator's own init containers, which is the operator's version
+	DefaultChiaOperatorImageTag = "latest"
 )
 
 const (
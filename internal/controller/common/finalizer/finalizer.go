@@ -0,0 +1,236 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+// Package finalizer manages the finalizer the operator adds to Chia custom resources that generate PersistentVolumeClaims,
+// and cleans up the PersistentVolumeClaims the operator generated for a resource when it's deleted.
+package finalizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+	"github.com/chia-network/chia-operator/internal/controller/common/volumesnapshot"
+)
+
+const (
+	// Name is the finalizer the operator adds to Chia custom resources that generate PersistentVolumeClaims
+	Name = "k8s.chia.net/finalizer"
+
+	// finalSnapshotOfLabel is set on the VolumeSnapshots taken of a resource's generated PersistentVolumeClaims when it's deleted,
+	// and contains the name of the deleted resource
+	finalSnapshotOfLabel = "k8s.chia.net/final-snapshot-of"
+
+	// snapshotRequeueInterval is how long to wait before checking on a deleted resource's workload or VolumeSnapshots again
+	snapshotRequeueInterval = 10 * time.Second
+)
+
+// Inputs contains the configuration for cleaning up a deleted resource's generated storage
+type Inputs struct {
+	// Kind is the kind of the deleted resource
+	Kind string
+
+	// DeletionPolicy is the deleted resource's deletion policy for its generated storage, which defaults to Retain
+	DeletionPolicy *k8schianetv1.DeletionPolicy
+
+	// VolumeClaimNames are the names of the PersistentVolumeClaims the operator generated for the resource
+	VolumeClaimNames []string
+
+	// Workload is the Deployment, StatefulSet, or DaemonSet whose pods mount the claims. When the deletion policy is Snapshot,
+	// it's deleted and its pods are stopped before the snapshots are taken, so the snapshots aren't taken of volumes in use.
+	Workload client.Object
+
+	// VolumeSnapshotClassName is the VolumeSnapshotClass to take snapshots with when the deletion policy is Snapshot.
+	// The cluster's default is used if nil.
+	VolumeSnapshotClassName *string
+}
+
+// Ensure adds the finalizer to a resource if it doesn't have it yet
+func Ensure(ctx context.Context, c client.Client, obj client.Object) error {
+	if controllerutil.ContainsFinalizer(obj, Name) {
+		return nil
+	}
+	original := obj.DeepCopyObject().(client.Object)
+	controllerutil.AddFinalizer(obj, Name)
+	if err := c.Patch(ctx, obj, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("error adding finalizer: %w", err)
+	}
+	return nil
+}
+
+// Finalize cleans up a deleted resource's generated storage according to its deletion policy, records what happened to it in an event,
+// and removes the resource's finalizer once it's done. While it's waiting for its workload to stop or for VolumeSnapshots to be ready to use,
+// the resource's Ready condition is updated to explain what it's waiting for, and a non-zero result is returned.
+func Finalize(ctx context.Context, c client.Client, recorder events.EventRecorder, obj client.Object, conditions *[]metav1.Condition, input Inputs) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(obj, Name) {
+		return ctrl.Result{}, nil
+	}
+
+	done, message, err := cleanupVolumeClaims(ctx, c, obj, input)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !done {
+		kube.SetCondition(conditions, obj.GetGeneration(), k8schianetv1.ConditionTypeReady, metav1.ConditionFalse, kube.ReasonDeleting, message)
+		if err := c.Status().Update(ctx, obj); err != nil && !strings.Contains(err.Error(), kube.ObjectModifiedTryAgainError) {
+			log.FromContext(ctx).Error(err, fmt.Sprintf("%sReconciler %s=%s unable to update %s status", input.Kind, input.Kind, client.ObjectKeyFromObject(obj), input.Kind))
+		}
+		return ctrl.Result{RequeueAfter: snapshotRequeueInterval}, nil
+	}
+	if message != "" {
+		recorder.Eventf(obj, nil, corev1.EventTypeNormal, "Deleted", "Deleted", message)
+	}
+
+	original := obj.DeepCopyObject().(client.Object)
+	controllerutil.RemoveFinalizer(obj, Name)
+	if err := c.Patch(ctx, obj, client.MergeFrom(original)); client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, fmt.Errorf("error removing finalizer: %w", err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// cleanupVolumeClaims applies a deleted resource's deletion policy to the generated PersistentVolumeClaims that still exist.
+// Returns true once the resource's finalizer can be removed, along with a message explaining what happened to its claims.
+func cleanupVolumeClaims(ctx context.Context, c client.Client, obj client.Object, input Inputs) (bool, string, error) {
+	klog := log.FromContext(ctx)
+
+	var claims []string
+	for _, name := range input.VolumeClaimNames {
+		var pvc corev1.PersistentVolumeClaim
+		err := c.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}, &pvc)
+		if err != nil && errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return false, "", fmt.Errorf("error getting PersistentVolumeClaim \"%s\": %w", name, err)
+		}
+		claims = append(claims, name)
+	}
+	if len(claims) == 0 {
+		return true, "", nil
+	}
+
+	policy := GetDeletionPolicy(input.DeletionPolicy)
+	switch policy {
+	case k8schianetv1.DeletionPolicyDelete:
+		if err := deleteVolumeClaims(ctx, c, obj.GetNamespace(), claims); err != nil {
+			return false, "", err
+		}
+		return true, fmt.Sprintf("Deleted PersistentVolumeClaims %s because the deletionPolicy is Delete", strings.Join(claims, ", ")), nil
+	case k8schianetv1.DeletionPolicySnapshot:
+		stopped, err := deleteWorkload(ctx, c, input.Workload)
+		if err != nil {
+			return false, "", err
+		}
+		if !stopped {
+			return false, fmt.Sprintf("Waiting for %s to be deleted before taking VolumeSnapshots of PersistentVolumeClaims", input.Workload.GetName()), nil
+		}
+
+		var pending, snapshots []string
+		for _, claim := range claims {
+			name := getFinalSnapshotName(obj, claim)
+			snapshot, err := volumesnapshot.Get(ctx, c, obj.GetNamespace(), name)
+			if err != nil {
+				return false, "", err
+			}
+			if snapshot == nil {
+				klog.Info("Taking VolumeSnapshot of PersistentVolumeClaim before deleting it", "PersistentVolumeClaim", claim, "VolumeSnapshot", name)
+				snapshot = volumesnapshot.Assemble(volumesnapshot.Inputs{
+					Name:      name,
+					Namespace: obj.GetNamespace(),
+					Labels: map[string]string{
+						"app.kubernetes.io/managed-by": "chia-operator",
+						"k8s.chia.net/kind":            input.Kind,
+						finalSnapshotOfLabel:           obj.GetName(),
+					},
+					PersistentVolumeClaimName: claim,
+					VolumeSnapshotClassName:   input.VolumeSnapshotClassName,
+				})
+				if err := volumesnapshot.Create(ctx, c, nil, nil, snapshot); err != nil {
+					return false, "", err
+				}
+			}
+			if message := volumesnapshot.GetError(*snapshot); message != "" {
+				return false, fmt.Sprintf("VolumeSnapshot %s of PersistentVolumeClaim %s failed: %s", name, claim, message), nil
+			}
+			if !volumesnapshot.IsReady(*snapshot) {
+				pending = append(pending, name)
+			}
+			snapshots = append(snapshots, name)
+		}
+		if len(pending) > 0 {
+			return false, fmt.Sprintf("Waiting for VolumeSnapshots %s to be ready to use before deleting PersistentVolumeClaims", strings.Join(pending, ", ")), nil
+		}
+		if err := deleteVolumeClaims(ctx, c, obj.GetNamespace(), claims); err != nil {
+			return false, "", err
+		}
+		return true, fmt.Sprintf("Deleted PersistentVolumeClaims %s after taking VolumeSnapshots %s", strings.Join(claims, ", "), strings.Join(snapshots, ", ")), nil
+	default:
+		return true, fmt.Sprintf("Kept PersistentVolumeClaims %s because the deletionPolicy is Retain", strings.Join(claims, ", ")), nil
+	}
+}
+
+// deleteWorkload deletes a deleted resource's workload, waiting for its pods to be deleted with it.
+// Returns true once the workload is gone, or if there is no workload.
+func deleteWorkload(ctx context.Context, c client.Client, workload client.Object) (bool, error) {
+	if workload == nil {
+		return true, nil
+	}
+	err := c.Get(ctx, client.ObjectKeyFromObject(workload), workload)
+	if err != nil && errors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error getting workload \"%s\": %w", workload.GetName(), err)
+	}
+	if workload.GetDeletionTimestamp().IsZero() {
+		log.FromContext(ctx).Info("Deleting workload before taking VolumeSnapshots of its PersistentVolumeClaims", "Workload", workload.GetName())
+		if err := c.Delete(ctx, workload, client.PropagationPolicy(metav1.DeletePropagationForeground)); client.IgnoreNotFound(err) != nil {
+			return false, fmt.Errorf("error deleting workload \"%s\": %w", workload.GetName(), err)
+		}
+	}
+	return false, nil
+}
+
+// deleteVolumeClaims deletes PersistentVolumeClaims, ignoring claims that were already deleted
+func deleteVolumeClaims(ctx context.Context, c client.Client, namespace string, names []string) error {
+	for _, name := range names {
+		pvc := corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+		if err := c.Delete(ctx, &pvc); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("error deleting PersistentVolumeClaim \"%s\": %w", name, err)
+		}
+	}
+	return nil
+}
+
+// GetDeletionPolicy returns the deletion policy for a resource's generated storage, which defaults to Retain
+func GetDeletionPolicy(policy *k8schianetv1.DeletionPolicy) k8schianetv1.DeletionPolicy {
+	if policy == nil || *policy == "" {
+		return k8schianetv1.DeletionPolicyRetain
+	}
+	return *policy
+}
+
+// getFinalSnapshotName returns the name of the VolumeSnapshot taken of a deleted resource's PersistentVolumeClaim.
+// The name includes part of the resource's UID, so a recreated resource of the same name takes new snapshots when it's deleted.
+func getFinalSnapshotName(obj client.Object, claim string) string {
+	uid := string(obj.GetUID())
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+	return fmt.Sprintf("%s-final-%s", claim, uid)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package finalizer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+)
+
+func TestGetDeletionPolicy(t *testing.T) {
+	require.Equal(t, k8schianetv1.DeletionPolicyRetain, GetDeletionPolicy(nil))
+
+	policy := k8schianetv1.DeletionPolicy("")
+	require.Equal(t, k8schianetv1.DeletionPolicyRetain, GetDeletionPolicy(&policy))
+
+	policy = k8schianetv1.DeletionPolicySnapshot
+	require.Equal(t, k8schianetv1.DeletionPolicySnapshot, GetDeletionPolicy(&policy))
+}
+
+func TestGetFinalSnapshotName(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "mainnet-node", UID: "0123456789abcdef"}}
+	require.Equal(t, "chiaroot-mainnet-node-0-final-01234567", getFinalSnapshotName(pvc, "chiaroot-mainnet-node-0"))
+
+	pvc.UID = "0123"
+	require.Equal(t, "chiaroot-mainnet-node-0-final-0123", getFinalSnapshotName(pvc, "chiaroot-mainnet-node-0"))
+}
+
+func TestDeleteWorkload(t *testing.T) {
+	ctx := context.TODO()
+	s := runtime.NewScheme()
+	require.NoError(t, appsv1.AddToScheme(s))
+	sts := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "mainnet-node", Namespace: "default"}}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(sts).Build()
+
+	// There's nothing to wait for without a workload
+	stopped, err := deleteWorkload(ctx, c, nil)
+	require.NoError(t, err)
+	require.True(t, stopped)
+
+	// The workload is deleted, and waited on until it's gone
+	stopped, err = deleteWorkload(ctx, c, &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "mainnet-node", Namespace: "default"}})
+	require.NoError(t, err)
+	require.False(t, stopped)
+
+	stopped, err = deleteWorkload(ctx, c, &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "mainnet-node", Namespace: "default"}})
+	require.NoError(t, err)
+	require.True(t, stopped)
+}
@@ -2,13 +2,20 @@ package kube
 
 import (
 	"fmt"
+	"path"
 	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
 
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/chiaconfig"
 	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/dbrestore"
 )
 
 // AssembleCommonServiceInputs contains configuration inputs to the AssembleCommonService function
@@ -66,6 +73,46 @@ func AssembleCommonService(input AssembleCommonServiceInputs) corev1.Service {
 	return srv
 }
 
+// AssemblePodDisruptionBudgetInputs contains configuration inputs to the AssemblePodDisruptionBudget function
+type AssemblePodDisruptionBudgetInputs struct {
+	Name           string
+	Namespace      string
+	Labels         map[string]string
+	Annotations    map[string]string
+	SelectorLabels map[string]string
+	Config         *k8schianetv1.PodDisruptionBudgetConfig
+}
+
+// AssemblePodDisruptionBudget assembles a PodDisruptionBudget for a resource's Pods.
+// Pods can be evicted one at a time if the budget doesn't set minAvailable or maxUnavailable.
+func AssemblePodDisruptionBudget(input AssemblePodDisruptionBudgetInputs) policyv1.PodDisruptionBudget {
+	pdb := policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        input.Name,
+			Namespace:   input.Namespace,
+			Labels:      input.Labels,
+			Annotations: input.Annotations,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: input.SelectorLabels,
+			},
+		},
+	}
+
+	if input.Config != nil {
+		pdb.Spec.MinAvailable = input.Config.MinAvailable
+		pdb.Spec.MaxUnavailable = input.Config.MaxUnavailable
+		pdb.Spec.UnhealthyPodEvictionPolicy = input.Config.UnhealthyPodEvictionPolicy
+	}
+	if pdb.Spec.MinAvailable == nil && pdb.Spec.MaxUnavailable == nil {
+		maxUnavailable := intstr.FromInt32(1)
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+	}
+
+	return pdb
+}
+
 // AssembleChiaContainerInputs contains configuration inputs to the AssembleChiaContainer function
 type AssembleChiaContainerInputs struct {
 	Image                *string
@@ -80,6 +127,137 @@ type AssembleChiaContainerInputs struct {
 	ResourceRequirements *corev1.ResourceRequirements
 }
 
+// AssembleChiaConfigMapInputs contains configuration inputs to the AssembleChiaConfigMap function
+type AssembleChiaConfigMapInputs struct {
+	// WorkloadName is the name of the Deployment, StatefulSet, or DaemonSet that mounts the ConfigMap
+	WorkloadName string
+	Namespace    string
+	Labels       map[string]string
+	Annotations  map[string]string
+
+	// Enabled is whether the config file mode is enabled. If false, the config isn't rendered,
+	// and the ConfigMap only identifies a ConfigMap left over from when it was enabled.
+	Enabled bool
+
+	// PodTemplate is the workload's Pod template. The config is rendered from the environment of its chia container.
+	PodTemplate corev1.PodTemplateSpec
+}
+
+// AssembleChiaConfigMap renders the chia config.yaml for a workload's chia container into a ConfigMap, along with the overrides
+// that are merged into an existing config.yaml. Also returns the names of the chia.* environment variables that weren't rendered,
+// because they don't name a field in go-chia-libs' config.
+func AssembleChiaConfigMap(input AssembleChiaConfigMapInputs) (corev1.ConfigMap, []string, error) {
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        GetChiaConfigMapName(input.WorkloadName),
+			Namespace:   input.Namespace,
+			Labels:      input.Labels,
+			Annotations: input.Annotations,
+		},
+	}
+	if !input.Enabled {
+		return configMap, nil, nil
+	}
+
+	chia := getChiaContainer(&input.PodTemplate)
+	if chia == nil {
+		return corev1.ConfigMap{}, nil, fmt.Errorf("error rendering chia config: Pod template has no chia container")
+	}
+	rendered, err := chiaconfig.Render(chia.Env)
+	if err != nil {
+		return corev1.ConfigMap{}, nil, err
+	}
+	configMap.Data = map[string]string{
+		chiaconfig.ConfigFileName:    string(rendered.Config),
+		chiaconfig.OverridesFileName: string(rendered.Overrides),
+	}
+
+	return configMap, rendered.Unrendered, nil
+}
+
+// AddChiaConfigFile mounts a rendered chia config ConfigMap in a Pod template, and adds an init container that installs it in CHIA_ROOT
+// each time the Pod starts. The config is copied into a CHIA_ROOT without a config.yaml, and otherwise its overrides are merged into the
+// existing config.yaml, so the fields chia wrote to it are kept. It isn't mounted directly, because chia and the chia image's entrypoint write to it.
+// The Pod template is annotated with a checksum of the config, so its Pods roll when the config changes.
+func AddChiaConfigFile(template *corev1.PodTemplateSpec, configMap corev1.ConfigMap) error {
+	chia := getChiaContainer(template)
+	if chia == nil {
+		return fmt.Errorf("error mounting chia config: Pod template has no chia container")
+	}
+
+	var chiaRoot *corev1.VolumeMount
+	for i := range chia.VolumeMounts {
+		if chia.VolumeMounts[i].MountPath == "/chia-data" {
+			chiaRoot = &chia.VolumeMounts[i]
+			break
+		}
+	}
+	if chiaRoot == nil {
+		return fmt.Errorf("error mounting chia config: chia container has no CHIA_ROOT volume mount")
+	}
+
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: chiaConfigVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: configMap.Name,
+				},
+			},
+		},
+	})
+
+	// The config is installed before any other init containers run, so they see the same config as the chia container.
+	// chia-config runs as the same user as the chia container, which is root in the chia image, so the config keeps its owner.
+	securityContext := chia.SecurityContext
+	if securityContext == nil {
+		securityContext = &corev1.SecurityContext{
+			RunAsUser: ptr.To[int64](0),
+		}
+	}
+	initContainer := corev1.Container{
+		Name:            chiaConfigVolumeName,
+		Image:           fmt.Sprintf("%s:%s", consts.DefaultChiaOperatorImageName, consts.DefaultChiaOperatorImageTag),
+		Command:         []string{chiaConfigCommand},
+		SecurityContext: securityContext,
+		Env: []corev1.EnvVar{
+			{
+				Name:  chiaconfig.EnvChiaRoot,
+				Value: "/chia-data",
+			},
+			{
+				Name:  chiaconfig.EnvRenderedDir,
+				Value: "/chia-config",
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			*chiaRoot,
+			{
+				Name:      chiaConfigVolumeName,
+				MountPath: "/chia-config",
+				ReadOnly:  true,
+			},
+		},
+	}
+	template.Spec.InitContainers = append([]corev1.Container{initContainer}, template.Spec.InitContainers...)
+
+	template.Annotations = CombineMaps(template.Annotations, map[string]string{
+		ChiaConfigChecksumAnnotation: getChiaConfigChecksum(configMap.Data[chiaconfig.ConfigFileName] + configMap.Data[chiaconfig.OverridesFileName]),
+	})
+
+	return nil
+}
+
+// getChiaContainer returns the chia container in a Pod template, or nil if it doesn't have one
+func getChiaContainer(template *corev1.PodTemplateSpec) *corev1.Container {
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == "chia" {
+			return &template.Spec.Containers[i]
+		}
+	}
+	return nil
+}
+
 // validProbeOrNil returns the probe if it specifies a handler, or nil otherwise.
 // An empty probe ({}) in a CR means "explicitly no probe" but Kubernetes rejects
 // probes without a handler type, so we treat them as nil.
@@ -313,6 +491,23 @@ func AssembleChiaHealthcheckProbe(input AssembleChiaHealthcheckProbeInputs) *cor
 	return &probe
 }
 
+const (
+	// ChiaDBPullSourceMountPath is the path the volume of the chia-db-pull PersistentVolumeClaim source is mounted at
+	ChiaDBPullSourceMountPath = "/chia-db-pull-source"
+
+	// ChiaDBPullStagingRoot is the CHIA_ROOT chia-db-pull downloads databases from S3 into, on the chiaroot volume.
+	// chia-db-install replaces the database in CHIA_ROOT with the download once it's verified.
+	ChiaDBPullStagingRoot = "/chia-data/.chia-db-pull"
+
+	// ChiaDBPullPeakHeightKey is the key of the peak height in the ConfigMap chia-db-restore reads it from
+	ChiaDBPullPeakHeightKey = "peakHeight"
+
+	// chiaDBRestoreCommand is the path to chia-db-restore in the operator's image. It restores the blockchain database into CHIA_ROOT
+	// from a list of HTTP(S) URLs or a mounted file, which chia-db-pull doesn't support, and checks whether an existing database
+	// is recent enough to keep.
+	chiaDBRestoreCommand = "/chia-db-restore"
+)
+
 // AssembleChiaDBPullContainerInputs contains configuration inputs to the AssembleChiaDBPullContainer function
 type AssembleChiaDBPullContainerInputs struct {
 	Image                *string
@@ -324,11 +519,31 @@ type AssembleChiaDBPullContainerInputs struct {
 	AdditionalEnv        *[]corev1.EnvVar
 	ResourceRequirements corev1.ResourceRequirements
 	SecurityContext      *corev1.SecurityContext
+
+	// URLs are HTTP(S) mirrors of the database, restored by chia-db-restore instead of chia-db-pull when set
+	URLs []string
+
+	// SourceVolumeName is the name of a volume that contains the database file, restored by chia-db-restore instead of chia-db-pull when set
+	SourceVolumeName string
+
+	// SourcePath is the path to the database file within the source volume
+	SourcePath string
+
+	// SHA256 is the checksum of the database file downloaded from a URL or copied from the source volume
+	SHA256 string
+
+	// MaxHeightLag is how many blocks behind MinHeight or the peak height an existing database can be and still be kept
+	MaxHeightLag *int64
+
+	// PeakHeightConfigMapName is the name of a ConfigMap with the ChiaNode's peak height, compared to existing databases when MaxHeightLag is set
+	PeakHeightConfigMapName string
 }
 
 // AssembleChiaDBPullContainer assembles the chia-db-pull init container spec.
 // The container shares the chiaroot volume with the main chia container so that the downloaded
 // blockchain database lands in CHIA_ROOT before chia starts.
+// Databases from URLs or a volume are restored by chia-db-restore. Databases from S3 are downloaded by chia-db-pull into ChiaDBPullStagingRoot,
+// which needs the containers from AssembleChiaDBCheckContainer and AssembleChiaDBInstallContainer around it.
 func AssembleChiaDBPullContainer(input AssembleChiaDBPullContainerInputs) corev1.Container {
 	container := corev1.Container{
 		Name:            "chia-db-pull",
@@ -336,13 +551,9 @@ func AssembleChiaDBPullContainer(input AssembleChiaDBPullContainerInputs) corev1
 		ImagePullPolicy: input.ImagePullPolicy,
 		Env: []corev1.EnvVar{
 			{
-				Name:  "CHIA_ROOT",
+				Name:  dbrestore.EnvChiaRoot,
 				Value: "/chia-data",
 			},
-			{
-				Name:  "S3_PREFIX",
-				Value: input.S3Prefix,
-			},
 		},
 		Resources: input.ResourceRequirements,
 		VolumeMounts: []corev1.VolumeMount{
@@ -353,10 +564,48 @@ func AssembleChiaDBPullContainer(input AssembleChiaDBPullContainerInputs) corev1
 		},
 	}
 
-	if input.Image != nil && *input.Image != "" {
-		container.Image = *input.Image
+	if len(input.URLs) != 0 || input.SourceVolumeName != "" {
+		if input.Image != nil && *input.Image != "" {
+			container.Image = *input.Image
+		} else {
+			container.Image = fmt.Sprintf("%s:%s", consts.DefaultChiaOperatorImageName, consts.DefaultChiaOperatorImageTag)
+		}
+		container.Command = []string{chiaDBRestoreCommand}
+		container.SecurityContext = getChiaDBRestoreSecurityContext(input)
+		if len(input.URLs) != 0 {
+			container.Env = append(container.Env, corev1.EnvVar{
+				Name:  dbrestore.EnvURLs,
+				Value: strings.Join(input.URLs, "\n"),
+			})
+		}
+		if input.SourceVolumeName != "" {
+			container.Env = append(container.Env, corev1.EnvVar{
+				Name:  dbrestore.EnvFile,
+				Value: path.Join(ChiaDBPullSourceMountPath, input.SourcePath),
+			})
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      input.SourceVolumeName,
+				MountPath: ChiaDBPullSourceMountPath,
+				ReadOnly:  true,
+			})
+		}
+		if input.SHA256 != "" {
+			container.Env = append(container.Env, corev1.EnvVar{
+				Name:  dbrestore.EnvSHA256,
+				Value: input.SHA256,
+			})
+		}
 	} else {
-		container.Image = fmt.Sprintf("%s:%s", consts.DefaultChiaDBPullImageName, consts.DefaultChiaDBPullImageTag)
+		container.Env[0].Value = ChiaDBPullStagingRoot
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "S3_PREFIX",
+			Value: input.S3Prefix,
+		})
+		if input.Image != nil && *input.Image != "" {
+			container.Image = *input.Image
+		} else {
+			container.Image = fmt.Sprintf("%s:%s", consts.DefaultChiaDBPullImageName, consts.DefaultChiaDBPullImageTag)
+		}
 	}
 
 	if input.Network != nil && *input.Network != "" {
@@ -373,6 +622,10 @@ func AssembleChiaDBPullContainer(input AssembleChiaDBPullContainerInputs) corev1
 		})
 	}
 
+	if len(input.URLs) != 0 || input.SourceVolumeName != "" {
+		container.Env = append(container.Env, getChiaDBMaxHeightLagEnv(input)...)
+	}
+
 	if input.AWSCredentialsSecret != nil && *input.AWSCredentialsSecret != "" {
 		container.EnvFrom = append(container.EnvFrom, corev1.EnvFromSource{
 			SecretRef: &corev1.SecretEnvSource{
@@ -389,3 +642,107 @@ func AssembleChiaDBPullContainer(input AssembleChiaDBPullContainerInputs) corev1
 
 	return container
 }
+
+// AssembleChiaDBCheckContainer assembles an init container that runs chia-db-restore before chia-db-pull for S3 sources.
+// It empties ChiaDBPullStagingRoot, and links an existing database in CHIA_ROOT that's recent enough into it, so chia-db-pull
+// doesn't download a new one. It writes the height of a database it keeps to its termination message.
+func AssembleChiaDBCheckContainer(input AssembleChiaDBPullContainerInputs) corev1.Container {
+	container := assembleChiaDBRestoreStagingContainer("chia-db-check", dbrestore.ModeCheck, input)
+	container.Env = append(container.Env, getChiaDBMaxHeightLagEnv(input)...)
+	return container
+}
+
+// AssembleChiaDBInstallContainer assembles an init container that runs chia-db-restore after chia-db-pull for S3 sources.
+// It verifies the database chia-db-pull downloaded into ChiaDBPullStagingRoot, and replaces the database in CHIA_ROOT with it.
+// It writes the height of the database it installed to its termination message.
+func AssembleChiaDBInstallContainer(input AssembleChiaDBPullContainerInputs) corev1.Container {
+	return assembleChiaDBRestoreStagingContainer("chia-db-install", dbrestore.ModeInstall, input)
+}
+
+// assembleChiaDBRestoreStagingContainer assembles a container that runs chia-db-restore in a mode that uses ChiaDBPullStagingRoot
+func assembleChiaDBRestoreStagingContainer(name, mode string, input AssembleChiaDBPullContainerInputs) corev1.Container {
+	container := corev1.Container{
+		Name:            name,
+		Image:           fmt.Sprintf("%s:%s", consts.DefaultChiaOperatorImageName, consts.DefaultChiaOperatorImageTag),
+		SecurityContext: getChiaDBRestoreSecurityContext(input),
+		ImagePullPolicy: input.ImagePullPolicy,
+		Command:         []string{chiaDBRestoreCommand},
+		Env: []corev1.EnvVar{
+			{
+				Name:  dbrestore.EnvChiaRoot,
+				Value: "/chia-data",
+			},
+			{
+				Name:  dbrestore.EnvMode,
+				Value: mode,
+			},
+			{
+				Name:  dbrestore.EnvStagingRoot,
+				Value: ChiaDBPullStagingRoot,
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "chiaroot",
+				MountPath: "/chia-data",
+			},
+		},
+	}
+
+	if input.Network != nil && *input.Network != "" {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  dbrestore.EnvNetwork,
+			Value: *input.Network,
+		})
+	}
+
+	if input.MinHeight != nil {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  dbrestore.EnvMinHeight,
+			Value: strconv.FormatInt(*input.MinHeight, 10),
+		})
+	}
+
+	return container
+}
+
+// getChiaDBRestoreSecurityContext returns the security context of a container that runs chia-db-restore.
+// The operator's image runs as a non-root user, but CHIA_ROOT is written by chia as root, so chia-db-restore runs as root by default.
+func getChiaDBRestoreSecurityContext(input AssembleChiaDBPullContainerInputs) *corev1.SecurityContext {
+	if input.SecurityContext != nil {
+		return input.SecurityContext
+	}
+	return &corev1.SecurityContext{
+		RunAsUser: ptr.To[int64](0),
+	}
+}
+
+// getChiaDBMaxHeightLagEnv returns the environment variables chia-db-restore compares an existing database's height with.
+// The peak height is read from a ConfigMap when the container starts, so the Pod template doesn't change with the peak height.
+func getChiaDBMaxHeightLagEnv(input AssembleChiaDBPullContainerInputs) []corev1.EnvVar {
+	if input.MaxHeightLag == nil {
+		return nil
+	}
+
+	env := []corev1.EnvVar{
+		{
+			Name:  dbrestore.EnvMaxHeightLag,
+			Value: strconv.FormatInt(*input.MaxHeightLag, 10),
+		},
+	}
+	if input.PeakHeightConfigMapName != "" {
+		env = append(env, corev1.EnvVar{
+			Name: dbrestore.EnvPeakHeight,
+			ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: input.PeakHeightConfigMapName,
+					},
+					Key:      ChiaDBPullPeakHeightKey,
+					Optional: ptr.To(true),
+				},
+			},
+		})
+	}
+	return env
+}
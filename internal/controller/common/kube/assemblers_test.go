@@ -3,11 +3,13 @@ package kube
 import (
 	"testing"
 
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/ptr"
 
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
 	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 
 	"github.com/stretchr/testify/require"
@@ -128,6 +130,180 @@ func TestAssembleCommonService_Full(t *testing.T) {
 	require.Equal(t, expected, actual)
 }
 
+func TestAssemblePodDisruptionBudget_Minimal(t *testing.T) {
+	maxUnavailable := intstr.FromInt32(1)
+	expected := policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-node",
+			Namespace: "default",
+			Labels: map[string]string{
+				"app.kubernetes.io/name": "test",
+			},
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app.kubernetes.io/name": "test",
+				},
+			},
+		},
+	}
+	actual := AssemblePodDisruptionBudget(AssemblePodDisruptionBudgetInputs{
+		Name:           expected.Name,
+		Namespace:      expected.Namespace,
+		Labels:         expected.Labels,
+		SelectorLabels: expected.Spec.Selector.MatchLabels,
+	})
+	require.Equal(t, expected, actual)
+}
+
+func TestAssemblePodDisruptionBudget_Full(t *testing.T) {
+	minAvailable := intstr.FromString("50%")
+	policy := policyv1.AlwaysAllow
+	expected := policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-node",
+			Namespace: "default",
+			Labels: map[string]string{
+				"app.kubernetes.io/name": "test",
+			},
+			Annotations: map[string]string{
+				"test-annotation": "testing",
+			},
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app.kubernetes.io/name": "test",
+				},
+			},
+			UnhealthyPodEvictionPolicy: &policy,
+		},
+	}
+	actual := AssemblePodDisruptionBudget(AssemblePodDisruptionBudgetInputs{
+		Name:           expected.Name,
+		Namespace:      expected.Namespace,
+		Labels:         expected.Labels,
+		Annotations:    expected.Annotations,
+		SelectorLabels: expected.Spec.Selector.MatchLabels,
+		Config: &k8schianetv1.PodDisruptionBudgetConfig{
+			Enabled:                    ptr.To(true),
+			MinAvailable:               &minAvailable,
+			UnhealthyPodEvictionPolicy: &policy,
+		},
+	})
+	require.Equal(t, expected, actual)
+}
+
+func TestAssembleChiaConfigMap(t *testing.T) {
+	template := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "chia",
+					Env: []corev1.EnvVar{
+						{Name: "network", Value: "testnet11"},
+						{Name: "chia.full_node.enable_upnp", Value: "false"},
+						{Name: "chia.full_node.not_a_field", Value: "true"},
+					},
+				},
+			},
+		},
+	}
+
+	// Disabled
+	configMap, unrendered, err := AssembleChiaConfigMap(AssembleChiaConfigMapInputs{
+		WorkloadName: "test-node",
+		Namespace:    "default",
+		PodTemplate:  template,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "test-node-config", configMap.Name)
+	require.Equal(t, "default", configMap.Namespace)
+	require.Nil(t, configMap.Data)
+	require.Empty(t, unrendered)
+
+	// Enabled
+	configMap, unrendered, err = AssembleChiaConfigMap(AssembleChiaConfigMapInputs{
+		WorkloadName: "test-node",
+		Namespace:    "default",
+		Enabled:      true,
+		PodTemplate:  template,
+	})
+	require.NoError(t, err)
+	require.Contains(t, configMap.Data["config.yaml"], "selected_network: testnet11")
+	require.Contains(t, configMap.Data["config.yaml"], "enable_upnp: false")
+	require.Contains(t, configMap.Data["overrides.yaml"], "enable_upnp: false")
+	require.NotContains(t, configMap.Data["overrides.yaml"], "pool_list")
+	require.Equal(t, []string{"chia.full_node.not_a_field"}, unrendered)
+
+	// No chia container
+	_, _, err = AssembleChiaConfigMap(AssembleChiaConfigMapInputs{
+		WorkloadName: "test-node",
+		Enabled:      true,
+	})
+	require.Error(t, err)
+}
+
+func TestAddChiaConfigFile(t *testing.T) {
+	template := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "chia-db-pull"},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "chia",
+					Image: "ghcr.io/chia-network/chia:latest",
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "chiaroot", MountPath: "/chia-data"},
+					},
+				},
+			},
+		},
+	}
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-node-config"},
+		Data:       map[string]string{"config.yaml": "selected_network: mainnet\n", "overrides.yaml": "selected_network: mainnet\n"},
+	}
+
+	err := AddChiaConfigFile(&template, configMap)
+	require.NoError(t, err)
+
+	require.Len(t, template.Spec.InitContainers, 2)
+	initContainer := template.Spec.InitContainers[0]
+	require.Equal(t, "chia-config", initContainer.Name)
+	require.Equal(t, consts.DefaultChiaOperatorImageName+":"+consts.DefaultChiaOperatorImageTag, initContainer.Image)
+	require.Equal(t, []string{"/chia-config"}, initContainer.Command)
+	require.Equal(t, ptr.To[int64](0), initContainer.SecurityContext.RunAsUser)
+	require.Equal(t, []corev1.EnvVar{
+		{Name: "CHIA_ROOT", Value: "/chia-data"},
+		{Name: "RENDERED_CONFIG_DIR", Value: "/chia-config"},
+	}, initContainer.Env)
+	require.Equal(t, []corev1.VolumeMount{
+		{Name: "chiaroot", MountPath: "/chia-data"},
+		{Name: "chia-config", MountPath: "/chia-config", ReadOnly: true},
+	}, initContainer.VolumeMounts)
+
+	require.Equal(t, []corev1.Volume{
+		{
+			Name: "chia-config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "test-node-config"},
+				},
+			},
+		},
+	}, template.Spec.Volumes)
+	require.Equal(t, getChiaConfigChecksum("selected_network: mainnet\nselected_network: mainnet\n"), template.Annotations[ChiaConfigChecksumAnnotation])
+
+	// Pod template without a chia container
+	err = AddChiaConfigFile(&corev1.PodTemplateSpec{}, configMap)
+	require.Error(t, err)
+}
+
 func TestAssembleChiaContainer_Minimal(t *testing.T) {
 	expected := corev1.Container{
 		Name:            "chia",
@@ -583,7 +759,7 @@ func TestAssembleChiaDBPullContainer_Minimal(t *testing.T) {
 		Env: []corev1.EnvVar{
 			{
 				Name:  "CHIA_ROOT",
-				Value: "/chia-data",
+				Value: ChiaDBPullStagingRoot,
 			},
 			{
 				Name:  "S3_PREFIX",
@@ -612,6 +788,154 @@ func TestAssembleChiaDBPullContainer_DefaultImage(t *testing.T) {
 	require.Equal(t, consts.DefaultChiaDBPullImageName+":"+consts.DefaultChiaDBPullImageTag, actual.Image)
 }
 
+func TestAssembleChiaDBPullContainer_URLSources(t *testing.T) {
+	image := "mirror.example.com/chia-operator:test"
+	actual := AssembleChiaDBPullContainer(AssembleChiaDBPullContainerInputs{
+		Image:  &image,
+		URLs:   []string{"https://a.example.com/db.sqlite.gz", "https://b.example.com/db.sqlite.gz"},
+		SHA256: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+	})
+	require.Equal(t, image, actual.Image)
+	require.Equal(t, []string{"/chia-db-restore"}, actual.Command)
+	require.Equal(t, &corev1.SecurityContext{RunAsUser: ptr.To[int64](0)}, actual.SecurityContext)
+	require.Equal(t, []corev1.EnvVar{
+		{
+			Name:  "CHIA_ROOT",
+			Value: "/chia-data",
+		},
+		{
+			Name:  "DB_URLS",
+			Value: "https://a.example.com/db.sqlite.gz\nhttps://b.example.com/db.sqlite.gz",
+		},
+		{
+			Name:  "DB_SHA256",
+			Value: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		},
+	}, actual.Env)
+
+	// A configured security context replaces the default one
+	securityContext := &corev1.SecurityContext{RunAsUser: ptr.To[int64](1000)}
+	actual = AssembleChiaDBPullContainer(AssembleChiaDBPullContainerInputs{
+		SourceVolumeName: "chia-db-pull-source",
+		SourcePath:       "mainnet/db.sqlite",
+		SecurityContext:  securityContext,
+	})
+	require.Equal(t, consts.DefaultChiaOperatorImageName+":"+consts.DefaultChiaOperatorImageTag, actual.Image)
+	require.Equal(t, securityContext, actual.SecurityContext)
+	require.Contains(t, actual.Env, corev1.EnvVar{
+		Name:  "DB_FILE",
+		Value: "/chia-db-pull-source/mainnet/db.sqlite",
+	})
+	require.Equal(t, []corev1.VolumeMount{
+		{
+			Name:      "chiaroot",
+			MountPath: "/chia-data",
+		},
+		{
+			Name:      "chia-db-pull-source",
+			MountPath: ChiaDBPullSourceMountPath,
+			ReadOnly:  true,
+		},
+	}, actual.VolumeMounts)
+}
+
+func TestAssembleChiaDBCheckContainer(t *testing.T) {
+	network := "testnet11"
+	minHeight := int64(123456)
+	maxHeightLag := int64(1000)
+	input := AssembleChiaDBPullContainerInputs{
+		S3Prefix:                "s3://test/",
+		Network:                 &network,
+		MinHeight:               &minHeight,
+		MaxHeightLag:            &maxHeightLag,
+		PeakHeightConfigMapName: "node-peak-height",
+	}
+	actual := AssembleChiaDBCheckContainer(input)
+	require.Equal(t, "chia-db-check", actual.Name)
+	require.Equal(t, consts.DefaultChiaOperatorImageName+":"+consts.DefaultChiaOperatorImageTag, actual.Image)
+	require.Equal(t, []string{"/chia-db-restore"}, actual.Command)
+	require.Equal(t, []corev1.EnvVar{
+		{
+			Name:  "CHIA_ROOT",
+			Value: "/chia-data",
+		},
+		{
+			Name:  "MODE",
+			Value: "check",
+		},
+		{
+			Name:  "STAGING_ROOT",
+			Value: ChiaDBPullStagingRoot,
+		},
+		{
+			Name:  "NETWORK",
+			Value: network,
+		},
+		{
+			Name:  "MIN_HEIGHT",
+			Value: "123456",
+		},
+		{
+			Name:  "MAX_HEIGHT_LAG",
+			Value: "1000",
+		},
+		{
+			Name: "PEAK_HEIGHT",
+			ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: "node-peak-height",
+					},
+					Key:      ChiaDBPullPeakHeightKey,
+					Optional: ptr.To(true),
+				},
+			},
+		},
+	}, actual.Env)
+
+	// chia-db-pull doesn't use the height lag itself, the check container handles it
+	pull := AssembleChiaDBPullContainer(input)
+	for _, env := range pull.Env {
+		require.NotEqual(t, "MAX_HEIGHT_LAG", env.Name)
+	}
+
+	// The install container verifies chia-db-pull's download, and doesn't compare it with the height lag
+	install := AssembleChiaDBInstallContainer(input)
+	require.Equal(t, "chia-db-install", install.Name)
+	require.Equal(t, []string{"/chia-db-restore"}, install.Command)
+	require.Equal(t, []corev1.EnvVar{
+		{
+			Name:  "CHIA_ROOT",
+			Value: "/chia-data",
+		},
+		{
+			Name:  "MODE",
+			Value: "install",
+		},
+		{
+			Name:  "STAGING_ROOT",
+			Value: ChiaDBPullStagingRoot,
+		},
+		{
+			Name:  "NETWORK",
+			Value: network,
+		},
+		{
+			Name:  "MIN_HEIGHT",
+			Value: "123456",
+		},
+	}, install.Env)
+
+	// chia-db-restore compares existing databases itself
+	input.S3Prefix = ""
+	input.URLs = []string{"https://example.com/db.sqlite"}
+	pull = AssembleChiaDBPullContainer(input)
+	require.Contains(t, pull.Env, corev1.EnvVar{
+		Name:  "MAX_HEIGHT_LAG",
+		Value: "1000",
+	})
+}
+
 func TestAssembleChiaDBPullContainer_Full(t *testing.T) {
 	network := "testnet11"
 	minHeight := int64(123456)
@@ -623,7 +947,7 @@ func TestAssembleChiaDBPullContainer_Full(t *testing.T) {
 		Env: []corev1.EnvVar{
 			{
 				Name:  "CHIA_ROOT",
-				Value: "/chia-data",
+				Value: ChiaDBPullStagingRoot,
 			},
 			{
 				Name:  "S3_PREFIX",
@@ -0,0 +1,425 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package kube
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/metrics"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WorkloadNotReadyRequeueInterval is how long to wait before reconciling a CR again while its workload is not ready
+const WorkloadNotReadyRequeueInterval = 30 * time.Second
+
+// Reasons used in the status conditions of Chia custom resources
+const (
+	// ReasonReconciled is used when the operator successfully reconciled all the resources for a CR
+	ReasonReconciled = "Reconciled"
+
+	// ReasonReconcileFailed is used when the operator encountered an error reconciling a CR
+	ReasonReconcileFailed = "ReconcileFailed"
+
+	// ReasonRolloutComplete is used when a workload has the desired number of updated and available replicas
+	ReasonRolloutComplete = "RolloutComplete"
+
+	// ReasonRolloutInProgress is used when a workload is still rolling out
+	ReasonRolloutInProgress = "RolloutInProgress"
+
+	// ReasonProgressDeadlineExceeded is used when a Deployment rollout has exceeded its progress deadline
+	ReasonProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+
+	// ReasonContainerFailing is used when a container in a workload's Pods is crash-looping or unable to start
+	ReasonContainerFailing = "ContainerFailing"
+
+	// ReasonValidConfig is used when the operator found no configuration errors in a CR's spec
+	ReasonValidConfig = "ValidConfig"
+
+	// ReasonInvalidConfig is used when a CR's spec contains a configuration error
+	ReasonInvalidConfig = "InvalidConfig"
+
+	// ReasonChiaNetworkResolved is used when a CR's ChiaNetwork was resolved, or the CR does not reference one
+	ReasonChiaNetworkResolved = "ChiaNetworkResolved"
+
+	// ReasonCASecretNotFound is used when the CA Secret referenced by a CR could not be found
+	ReasonCASecretNotFound = "CASecretNotFound"
+
+	// ReasonCertificatePending is used when the operator is waiting for cert-manager to issue a certificate
+	ReasonCertificatePending = "CertificatePending"
+
+	// ReasonComponentsNotReady is used when some of the child resources of a composite CR are not ready yet
+	ReasonComponentsNotReady = "ComponentsNotReady"
+
+	// ReasonChiaNetworkNotFound is used when the ChiaNetwork referenced by a CR could not be retrieved
+	ReasonChiaNetworkNotFound = "ChiaNetworkNotFound"
+
+	// ReasonSuspended is used when a CR is suspended, and the operator is not applying changes to its resources
+	ReasonSuspended = "Suspended"
+
+	// ReasonScaledToZero is used when a CR is suspended, and its workload was scaled to zero replicas
+	ReasonScaledToZero = "ScaledToZero"
+
+	// ReasonNotSuspended is used when a CR is not suspended
+	ReasonNotSuspended = "NotSuspended"
+
+	// ReasonDeleting is used while the operator cleans up the generated storage of a CR that's being deleted
+	ReasonDeleting = "Deleting"
+
+	// ReasonCertificateGenerationFailed is used when the operator failed to generate a CA or certificates for a CR
+	ReasonCertificateGenerationFailed = "CertificateGenerationFailed"
+
+	// ReasonApplyConflict is used when a change to one of a CR's resources conflicted with another change to it
+	ReasonApplyConflict = "ApplyConflict"
+)
+
+// failingContainerReasons is a list of container waiting reasons that indicate a Pod will not become ready without intervention
+var failingContainerReasons = []string{
+	"CrashLoopBackOff",
+	"ImagePullBackOff",
+	"ErrImagePull",
+	"InvalidImageName",
+	"CreateContainerConfigError",
+	"CreateContainerError",
+	"RunContainerError",
+}
+
+// ConditionError is an error that should be surfaced on a specific status condition of a CR, in addition to the Ready condition
+type ConditionError struct {
+	// ConditionType is the type of condition that will be set to False for this error
+	ConditionType string
+
+	// Reason is the CamelCase reason set on the condition
+	Reason string
+
+	// Err is the underlying error
+	Err error
+}
+
+// NewConditionError returns a ConditionError for the given condition type and reason
+func NewConditionError(conditionType, reason string, err error) *ConditionError {
+	return &ConditionError{
+		ConditionType: conditionType,
+		Reason:        reason,
+		Err:           err,
+	}
+}
+
+// Error implements the error interface
+func (e *ConditionError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ConditionError) Unwrap() error {
+	return e.Err
+}
+
+// WorkloadStatus summarizes the rollout state of a Deployment or StatefulSet
+type WorkloadStatus struct {
+	Ready       bool
+	Progressing bool
+	Degraded    bool
+	Reason      string
+	Message     string
+}
+
+// SetCondition sets a condition on a list of conditions, only updating the transition time if the status changed
+func SetCondition(conditions *[]metav1.Condition, generation int64, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// SetReconcileFailedConditions sets the Ready condition to False with the error's reason and message.
+// If the error is a ConditionError, the condition it refers to is also set to False.
+func SetReconcileFailedConditions(conditions *[]metav1.Condition, generation int64, err error) {
+	reason := ReasonReconcileFailed
+	var condErr *ConditionError
+	if stdErrors.As(err, &condErr) {
+		reason = condErr.Reason
+		SetCondition(conditions, generation, condErr.ConditionType, metav1.ConditionFalse, condErr.Reason, condErr.Error())
+	}
+	SetCondition(conditions, generation, k8schianetv1.ConditionTypeReady, metav1.ConditionFalse, reason, err.Error())
+}
+
+// GetReconcileErrorReason returns the reason a reconcile failed with an error, for the operator's reconcile error metrics
+func GetReconcileErrorReason(err error) string {
+	var condErr *ConditionError
+	if stdErrors.As(err, &condErr) {
+		return condErr.Reason
+	}
+	if apierrors.IsConflict(err) || strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
+		return ReasonApplyConflict
+	}
+	return ReasonReconcileFailed
+}
+
+// GetReadyConditionStatus returns the status of the Ready condition in a list of conditions, or Unknown if it isn't set
+func GetReadyConditionStatus(conditions []metav1.Condition) string {
+	condition := meta.FindStatusCondition(conditions, k8schianetv1.ConditionTypeReady)
+	if condition == nil {
+		return string(metav1.ConditionUnknown)
+	}
+	return string(condition.Status)
+}
+
+// ObserveReconcile records the duration of a reconcile of a CR that started at start, and the reason it failed if it returned an error
+func ObserveReconcile(kind string, start time.Time, err error) {
+	var reason string
+	if err != nil {
+		reason = GetReconcileErrorReason(err)
+	}
+	metrics.ObserveReconcile(kind, start, reason)
+}
+
+// SetValidConfigCondition sets the ConfigValid condition to True
+func SetValidConfigCondition(conditions *[]metav1.Condition, generation int64) {
+	SetCondition(conditions, generation, k8schianetv1.ConditionTypeConfigValid, metav1.ConditionTrue, ReasonValidConfig, "The resource's configuration is valid")
+}
+
+// SetNetworkResolvedCondition sets the NetworkResolved condition to True for the given CommonSpecChia
+func SetNetworkResolvedCondition(conditions *[]metav1.Condition, generation int64, chia k8schianetv1.CommonSpecChia) {
+	message := "No ChiaNetwork is referenced"
+	if chia.ChiaNetwork != nil && *chia.ChiaNetwork != "" {
+		message = fmt.Sprintf("ChiaNetwork %q was resolved", *chia.ChiaNetwork)
+	}
+	SetCondition(conditions, generation, k8schianetv1.ConditionTypeNetworkResolved, metav1.ConditionTrue, ReasonChiaNetworkResolved, message)
+}
+
+// SetWorkloadConditions sets the Ready, Progressing, and Degraded conditions from a WorkloadStatus
+func SetWorkloadConditions(conditions *[]metav1.Condition, generation int64, ws WorkloadStatus) {
+	if ws.Ready {
+		SetCondition(conditions, generation, k8schianetv1.ConditionTypeReady, metav1.ConditionTrue, ws.Reason, ws.Message)
+	} else {
+		SetCondition(conditions, generation, k8schianetv1.ConditionTypeReady, metav1.ConditionFalse, ws.Reason, ws.Message)
+	}
+
+	if ws.Progressing {
+		SetCondition(conditions, generation, k8schianetv1.ConditionTypeProgressing, metav1.ConditionTrue, ReasonRolloutInProgress, ws.Message)
+	} else {
+		SetCondition(conditions, generation, k8schianetv1.ConditionTypeProgressing, metav1.ConditionFalse, ReasonRolloutComplete, "The workload is not rolling out")
+	}
+
+	if ws.Degraded {
+		SetCondition(conditions, generation, k8schianetv1.ConditionTypeDegraded, metav1.ConditionTrue, ws.Reason, ws.Message)
+	} else {
+		SetCondition(conditions, generation, k8schianetv1.ConditionTypeDegraded, metav1.ConditionFalse, ReasonReconciled, "The workload is not degraded")
+	}
+}
+
+// SetReconciledConditions sets the status conditions for a CR whose resources were all reconciled successfully
+func SetReconciledConditions(conditions *[]metav1.Condition, generation int64, chia k8schianetv1.CommonSpecChia, ws WorkloadStatus) {
+	SetValidConfigCondition(conditions, generation)
+	SetNetworkResolvedCondition(conditions, generation, chia)
+	SetWorkloadConditions(conditions, generation, ws)
+}
+
+// SetResourceReadyConditions sets the status conditions for a CR that doesn't manage a workload, and whose resources were all reconciled successfully
+func SetResourceReadyConditions(conditions *[]metav1.Condition, generation int64, message string) {
+	SetValidConfigCondition(conditions, generation)
+	SetCondition(conditions, generation, k8schianetv1.ConditionTypeReady, metav1.ConditionTrue, ReasonReconciled, message)
+	SetCondition(conditions, generation, k8schianetv1.ConditionTypeProgressing, metav1.ConditionFalse, ReasonReconciled, message)
+	SetCondition(conditions, generation, k8schianetv1.ConditionTypeDegraded, metav1.ConditionFalse, ReasonReconciled, message)
+}
+
+// SetSuspendedCondition sets the Suspended condition. If the CR's workload was scaled to zero, the Ready condition is also set to False.
+func SetSuspendedCondition(conditions *[]metav1.Condition, generation int64, suspended, scaledToZero bool) {
+	switch {
+	case scaledToZero:
+		SetCondition(conditions, generation, k8schianetv1.ConditionTypeSuspended, metav1.ConditionTrue, ReasonScaledToZero, "The operator is not applying changes to this resource, and its workload was scaled to zero replicas")
+		SetCondition(conditions, generation, k8schianetv1.ConditionTypeReady, metav1.ConditionFalse, ReasonScaledToZero, "The resource is suspended, and its workload was scaled to zero replicas")
+	case suspended:
+		SetCondition(conditions, generation, k8schianetv1.ConditionTypeSuspended, metav1.ConditionTrue, ReasonSuspended, "The operator is not applying changes to this resource")
+	default:
+		SetCondition(conditions, generation, k8schianetv1.ConditionTypeSuspended, metav1.ConditionFalse, ReasonNotSuspended, "The resource is not suspended")
+	}
+}
+
+// GetDeploymentStatus determines the rollout state of a Deployment, using its Pods to detect failing containers
+func GetDeploymentStatus(deploy appsv1.Deployment, pods []corev1.Pod) WorkloadStatus {
+	if failing, message := getPodFailure(pods); failing {
+		return WorkloadStatus{Degraded: true, Progressing: true, Reason: ReasonContainerFailing, Message: message}
+	}
+
+	for _, cond := range deploy.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			return WorkloadStatus{Degraded: true, Reason: ReasonProgressDeadlineExceeded, Message: fmt.Sprintf("Deployment %q exceeded its progress deadline", deploy.Name)}
+		}
+		if cond.Type == appsv1.DeploymentReplicaFailure && cond.Status == corev1.ConditionTrue {
+			return WorkloadStatus{Degraded: true, Progressing: true, Reason: cond.Reason, Message: cond.Message}
+		}
+	}
+
+	desired := int32(1)
+	if deploy.Spec.Replicas != nil {
+		desired = *deploy.Spec.Replicas
+	}
+
+	switch {
+	case deploy.Generation > deploy.Status.ObservedGeneration:
+		return rolloutInProgress(fmt.Sprintf("Waiting for Deployment %q spec update to be observed", deploy.Name))
+	case deploy.Status.UpdatedReplicas < desired:
+		return rolloutInProgress(fmt.Sprintf("Waiting for Deployment %q rollout to finish: %d of %d updated replicas are available", deploy.Name, deploy.Status.UpdatedReplicas, desired))
+	case deploy.Status.Replicas > deploy.Status.UpdatedReplicas:
+		return rolloutInProgress(fmt.Sprintf("Waiting for Deployment %q rollout to finish: %d old replicas are pending termination", deploy.Name, deploy.Status.Replicas-deploy.Status.UpdatedReplicas))
+	case deploy.Status.AvailableReplicas < deploy.Status.UpdatedReplicas:
+		return rolloutInProgress(fmt.Sprintf("Waiting for Deployment %q rollout to finish: %d of %d updated replicas are available", deploy.Name, deploy.Status.AvailableReplicas, deploy.Status.UpdatedReplicas))
+	}
+
+	return WorkloadStatus{Ready: true, Reason: ReasonRolloutComplete, Message: fmt.Sprintf("Deployment %q has %d available replicas", deploy.Name, deploy.Status.AvailableReplicas)}
+}
+
+// GetStatefulSetStatus determines the rollout state of a StatefulSet, using its Pods to detect failing containers
+func GetStatefulSetStatus(sts appsv1.StatefulSet, pods []corev1.Pod) WorkloadStatus {
+	if failing, message := getPodFailure(pods); failing {
+		return WorkloadStatus{Degraded: true, Progressing: true, Reason: ReasonContainerFailing, Message: message}
+	}
+
+	desired := int32(1)
+	if sts.Spec.Replicas != nil {
+		desired = *sts.Spec.Replicas
+	}
+
+	switch {
+	case sts.Generation > sts.Status.ObservedGeneration:
+		return rolloutInProgress(fmt.Sprintf("Waiting for StatefulSet %q spec update to be observed", sts.Name))
+	case sts.Status.ReadyReplicas < desired:
+		return rolloutInProgress(fmt.Sprintf("Waiting for StatefulSet %q rollout to finish: %d of %d replicas are ready", sts.Name, sts.Status.ReadyReplicas, desired))
+	case sts.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType && sts.Status.UpdateRevision != sts.Status.CurrentRevision:
+		return rolloutInProgress(fmt.Sprintf("Waiting for StatefulSet %q rollout to finish: %d of %d replicas are updated", sts.Name, sts.Status.UpdatedReplicas, desired))
+	}
+
+	return WorkloadStatus{Ready: true, Reason: ReasonRolloutComplete, Message: fmt.Sprintf("StatefulSet %q has %d ready replicas", sts.Name, sts.Status.ReadyReplicas)}
+}
+
+// GetDaemonSetStatus determines the rollout state of a DaemonSet, using its Pods to detect failing containers
+func GetDaemonSetStatus(ds appsv1.DaemonSet, pods []corev1.Pod) WorkloadStatus {
+	if failing, message := getPodFailure(pods); failing {
+		return WorkloadStatus{Degraded: true, Progressing: true, Reason: ReasonContainerFailing, Message: message}
+	}
+
+	desired := ds.Status.DesiredNumberScheduled
+
+	switch {
+	case ds.Generation > ds.Status.ObservedGeneration:
+		return rolloutInProgress(fmt.Sprintf("Waiting for DaemonSet %q spec update to be observed", ds.Name))
+	case desired == 0:
+		return rolloutInProgress(fmt.Sprintf("Waiting for DaemonSet %q to be scheduled: no nodes match its node selector, affinity, and tolerations", ds.Name))
+	case ds.Spec.UpdateStrategy.Type != appsv1.OnDeleteDaemonSetStrategyType && ds.Status.UpdatedNumberScheduled < desired:
+		return rolloutInProgress(fmt.Sprintf("Waiting for DaemonSet %q rollout to finish: %d of %d pods are updated", ds.Name, ds.Status.UpdatedNumberScheduled, desired))
+	case ds.Status.NumberAvailable < desired:
+		return rolloutInProgress(fmt.Sprintf("Waiting for DaemonSet %q rollout to finish: %d of %d pods are available", ds.Name, ds.Status.NumberAvailable, desired))
+	}
+
+	return WorkloadStatus{Ready: true, Reason: ReasonRolloutComplete, Message: fmt.Sprintf("DaemonSet %q has %d available pods", ds.Name, ds.Status.NumberAvailable)}
+}
+
+// GetDeploymentWorkloadStatus fetches the live Deployment matching the desired Deployment and its Pods, and returns its rollout state
+func GetDeploymentWorkloadStatus(ctx context.Context, c client.Client, desired appsv1.Deployment) (WorkloadStatus, error) {
+	var current appsv1.Deployment
+	err := c.Get(ctx, types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, &current)
+	if err != nil {
+		return WorkloadStatus{}, fmt.Errorf("error getting Deployment \"%s\": %w", desired.Name, err)
+	}
+
+	pods, err := ListPods(ctx, c, current.Namespace, current.Spec.Selector)
+	if err != nil {
+		return WorkloadStatus{}, err
+	}
+
+	return GetDeploymentStatus(current, pods), nil
+}
+
+// GetStatefulSetWorkloadStatus fetches the live StatefulSet matching the desired StatefulSet and its Pods, and returns its rollout state
+func GetStatefulSetWorkloadStatus(ctx context.Context, c client.Client, desired appsv1.StatefulSet) (WorkloadStatus, error) {
+	var current appsv1.StatefulSet
+	err := c.Get(ctx, types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, &current)
+	if err != nil {
+		return WorkloadStatus{}, fmt.Errorf("error getting StatefulSet \"%s\": %w", desired.Name, err)
+	}
+
+	pods, err := ListPods(ctx, c, current.Namespace, current.Spec.Selector)
+	if err != nil {
+		return WorkloadStatus{}, err
+	}
+
+	return GetStatefulSetStatus(current, pods), nil
+}
+
+// GetDaemonSetWorkloadStatus fetches the live DaemonSet matching the desired DaemonSet and its Pods, and returns its rollout state
+func GetDaemonSetWorkloadStatus(ctx context.Context, c client.Client, desired appsv1.DaemonSet) (WorkloadStatus, error) {
+	var current appsv1.DaemonSet
+	err := c.Get(ctx, types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, &current)
+	if err != nil {
+		return WorkloadStatus{}, fmt.Errorf("error getting DaemonSet \"%s\": %w", desired.Name, err)
+	}
+
+	pods, err := ListPods(ctx, c, current.Namespace, current.Spec.Selector)
+	if err != nil {
+		return WorkloadStatus{}, err
+	}
+
+	return GetDaemonSetStatus(current, pods), nil
+}
+
+// ListPods lists the Pods in a namespace matching a workload's label selector
+func ListPods(ctx context.Context, c client.Client, namespace string, selector *metav1.LabelSelector) ([]corev1.Pod, error) {
+	if selector == nil {
+		return nil, nil
+	}
+	var pods corev1.PodList
+	err := c.List(ctx, &pods, client.InNamespace(namespace), client.MatchingLabels(selector.MatchLabels))
+	if err != nil {
+		return nil, fmt.Errorf("error listing Pods: %w", err)
+	}
+	return pods.Items, nil
+}
+
+// rolloutInProgress returns a WorkloadStatus for a workload that is still rolling out
+func rolloutInProgress(message string) WorkloadStatus {
+	return WorkloadStatus{Progressing: true, Reason: ReasonRolloutInProgress, Message: message}
+}
+
+// getPodFailure returns true and a message if any container in the given Pods is waiting for a reason that indicates it is failing
+func getPodFailure(pods []corev1.Pod) (bool, string) {
+	var failures []string
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			if status.State.Waiting == nil {
+				continue
+			}
+			for _, reason := range failingContainerReasons {
+				if status.State.Waiting.Reason == reason {
+					failures = append(failures, fmt.Sprintf("Pod %q container %q is in %s", pod.Name, status.Name, reason))
+				}
+			}
+		}
+	}
+
+	if len(failures) == 0 {
+		return false, ""
+	}
+	return true, strings.Join(failures, "; ")
+}
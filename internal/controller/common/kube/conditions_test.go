@@ -0,0 +1,323 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestGetDeploymentStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		deploy   appsv1.Deployment
+		pods     []corev1.Pod
+		expected WorkloadStatus
+	}{
+		{
+			name: "Rollout complete",
+			deploy: appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+			},
+			expected: WorkloadStatus{Ready: true, Reason: ReasonRolloutComplete, Message: "Deployment \"test\" has 1 available replicas"},
+		},
+		{
+			name: "Spec update not yet observed",
+			deploy: appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 3},
+				Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+			},
+			expected: WorkloadStatus{Progressing: true, Reason: ReasonRolloutInProgress, Message: "Waiting for Deployment \"test\" spec update to be observed"},
+		},
+		{
+			name: "Old replicas pending termination",
+			deploy: appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 1},
+			},
+			expected: WorkloadStatus{Progressing: true, Reason: ReasonRolloutInProgress, Message: "Waiting for Deployment \"test\" rollout to finish: 1 old replicas are pending termination"},
+		},
+		{
+			name: "Progress deadline exceeded",
+			deploy: appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 2,
+					Replicas:           1,
+					Conditions: []appsv1.DeploymentCondition{
+						{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"},
+					},
+				},
+			},
+			expected: WorkloadStatus{Degraded: true, Reason: ReasonProgressDeadlineExceeded, Message: "Deployment \"test\" exceeded its progress deadline"},
+		},
+		{
+			name: "Crash looping container",
+			deploy: appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1},
+			},
+			pods: []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-abc"},
+					Status: corev1.PodStatus{
+						ContainerStatuses: []corev1.ContainerStatus{
+							{Name: "chia", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+						},
+					},
+				},
+			},
+			expected: WorkloadStatus{Degraded: true, Progressing: true, Reason: ReasonContainerFailing, Message: "Pod \"test-abc\" container \"chia\" is in CrashLoopBackOff"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, GetDeploymentStatus(tt.deploy, tt.pods))
+		})
+	}
+}
+
+func TestGetStatefulSetStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		sts      appsv1.StatefulSet
+		pods     []corev1.Pod
+		expected WorkloadStatus
+	}{
+		{
+			name: "Rollout complete",
+			sts: appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 1},
+				Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(2)},
+				Status:     appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 2, CurrentRevision: "a", UpdateRevision: "a"},
+			},
+			expected: WorkloadStatus{Ready: true, Reason: ReasonRolloutComplete, Message: "StatefulSet \"test\" has 2 ready replicas"},
+		},
+		{
+			name: "Replicas not ready",
+			sts: appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 1},
+				Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(2)},
+				Status:     appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 1, CurrentRevision: "a", UpdateRevision: "a"},
+			},
+			expected: WorkloadStatus{Progressing: true, Reason: ReasonRolloutInProgress, Message: "Waiting for StatefulSet \"test\" rollout to finish: 1 of 2 replicas are ready"},
+		},
+		{
+			name: "Revision update in progress",
+			sts: appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 1},
+				Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(2)},
+				Status:     appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 2, UpdatedReplicas: 1, CurrentRevision: "a", UpdateRevision: "b"},
+			},
+			expected: WorkloadStatus{Progressing: true, Reason: ReasonRolloutInProgress, Message: "Waiting for StatefulSet \"test\" rollout to finish: 1 of 2 replicas are updated"},
+		},
+		{
+			name: "Image pull failure in init container",
+			sts: appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 1},
+				Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(1)},
+				Status:     appsv1.StatefulSetStatus{ObservedGeneration: 1},
+			},
+			pods: []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-0"},
+					Status: corev1.PodStatus{
+						InitContainerStatuses: []corev1.ContainerStatus{
+							{Name: "chia-db-pull", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+						},
+					},
+				},
+			},
+			expected: WorkloadStatus{Degraded: true, Progressing: true, Reason: ReasonContainerFailing, Message: "Pod \"test-0\" container \"chia-db-pull\" is in ImagePullBackOff"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, GetStatefulSetStatus(tt.sts, tt.pods))
+		})
+	}
+}
+
+func TestGetDaemonSetStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		ds       appsv1.DaemonSet
+		pods     []corev1.Pod
+		expected WorkloadStatus
+	}{
+		{
+			name: "Rollout complete",
+			ds: appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 1},
+				Status:     appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3},
+			},
+			expected: WorkloadStatus{Ready: true, Reason: ReasonRolloutComplete, Message: "DaemonSet \"test\" has 3 available pods"},
+		},
+		{
+			name: "No nodes scheduled",
+			ds: appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 1},
+				Status:     appsv1.DaemonSetStatus{ObservedGeneration: 1},
+			},
+			expected: WorkloadStatus{Progressing: true, Reason: ReasonRolloutInProgress, Message: "Waiting for DaemonSet \"test\" to be scheduled: no nodes match its node selector, affinity, and tolerations"},
+		},
+		{
+			name: "Update in progress",
+			ds: appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 2},
+				Spec:       appsv1.DaemonSetSpec{UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType}},
+				Status:     appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 1, NumberAvailable: 3},
+			},
+			expected: WorkloadStatus{Progressing: true, Reason: ReasonRolloutInProgress, Message: "Waiting for DaemonSet \"test\" rollout to finish: 1 of 3 pods are updated"},
+		},
+		{
+			name: "OnDelete update waits only for available pods",
+			ds: appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 2},
+				Spec:       appsv1.DaemonSetSpec{UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType}},
+				Status:     appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 1, NumberAvailable: 3},
+			},
+			expected: WorkloadStatus{Ready: true, Reason: ReasonRolloutComplete, Message: "DaemonSet \"test\" has 3 available pods"},
+		},
+		{
+			name: "Pods not available",
+			ds: appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 1},
+				Status:     appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2},
+			},
+			expected: WorkloadStatus{Progressing: true, Reason: ReasonRolloutInProgress, Message: "Waiting for DaemonSet \"test\" rollout to finish: 2 of 3 pods are available"},
+		},
+		{
+			name: "Crash looping plot discovery",
+			ds: appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 1},
+				Status:     appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 1},
+			},
+			pods: []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-abcde"},
+					Status: corev1.PodStatus{
+						InitContainerStatuses: []corev1.ContainerStatus{
+							{Name: "plot-discovery", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+						},
+					},
+				},
+			},
+			expected: WorkloadStatus{Degraded: true, Progressing: true, Reason: ReasonContainerFailing, Message: "Pod \"test-abcde\" container \"plot-discovery\" is in CrashLoopBackOff"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, GetDaemonSetStatus(tt.ds, tt.pods))
+		})
+	}
+}
+
+func TestSetReconcileFailedConditions(t *testing.T) {
+	var conditions []metav1.Condition
+	err := NewConditionError(k8schianetv1.ConditionTypeNetworkResolved, ReasonChiaNetworkNotFound, errors.New("network not found"))
+	SetReconcileFailedConditions(&conditions, 4, err)
+
+	network := meta.FindStatusCondition(conditions, k8schianetv1.ConditionTypeNetworkResolved)
+	require.NotNil(t, network)
+	require.Equal(t, metav1.ConditionFalse, network.Status)
+	require.Equal(t, ReasonChiaNetworkNotFound, network.Reason)
+	require.Equal(t, "network not found", network.Message)
+	require.Equal(t, int64(4), network.ObservedGeneration)
+
+	ready := meta.FindStatusCondition(conditions, k8schianetv1.ConditionTypeReady)
+	require.NotNil(t, ready)
+	require.Equal(t, metav1.ConditionFalse, ready.Status)
+	require.Equal(t, ReasonChiaNetworkNotFound, ready.Reason)
+
+	// A plain error only sets the Ready condition
+	conditions = nil
+	SetReconcileFailedConditions(&conditions, 1, errors.New("boom"))
+	require.Len(t, conditions, 1)
+	require.Equal(t, ReasonReconcileFailed, conditions[0].Reason)
+}
+
+func TestGetReconcileErrorReason(t *testing.T) {
+	err := fmt.Errorf("ChiaNodeReconciler ChiaNode=default/node %w", NewConditionError(k8schianetv1.ConditionTypeNetworkResolved, ReasonChiaNetworkNotFound, errors.New("network not found")))
+	require.Equal(t, ReasonChiaNetworkNotFound, GetReconcileErrorReason(err))
+
+	err = apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "statefulsets"}, "node", errors.New("conflict"))
+	require.Equal(t, ReasonApplyConflict, GetReconcileErrorReason(err))
+
+	// Conflicts are found through the errors the reconcilers wrap them in
+	err = fmt.Errorf("ChiaNodeReconciler ChiaNode=default/node %w", fmt.Errorf("error updating StatefulSet \"node\": %w", err))
+	require.Equal(t, ReasonApplyConflict, GetReconcileErrorReason(err))
+
+	err = fmt.Errorf("error updating Secret \"ca\": Operation cannot be fulfilled on secrets \"ca\": %s", ObjectModifiedTryAgainError)
+	require.Equal(t, ReasonApplyConflict, GetReconcileErrorReason(err))
+
+	require.Equal(t, ReasonReconcileFailed, GetReconcileErrorReason(errors.New("boom")))
+}
+
+func TestGetReadyConditionStatus(t *testing.T) {
+	require.Equal(t, "Unknown", GetReadyConditionStatus(nil))
+
+	var conditions []metav1.Condition
+	SetCondition(&conditions, 1, k8schianetv1.ConditionTypeReady, metav1.ConditionFalse, ReasonRolloutInProgress, "rolling out")
+	require.Equal(t, "False", GetReadyConditionStatus(conditions))
+
+	SetCondition(&conditions, 1, k8schianetv1.ConditionTypeReady, metav1.ConditionTrue, ReasonRolloutComplete, "rolled out")
+	require.Equal(t, "True", GetReadyConditionStatus(conditions))
+}
+
+func TestSetSuspendedCondition(t *testing.T) {
+	var conditions []metav1.Condition
+	SetSuspendedCondition(&conditions, 1, false, false)
+	require.Len(t, conditions, 1)
+	require.Equal(t, metav1.ConditionFalse, conditions[0].Status)
+	require.Equal(t, ReasonNotSuspended, conditions[0].Reason)
+
+	SetSuspendedCondition(&conditions, 2, true, false)
+	require.Len(t, conditions, 1)
+	require.Equal(t, metav1.ConditionTrue, conditions[0].Status)
+	require.Equal(t, ReasonSuspended, conditions[0].Reason)
+
+	SetSuspendedCondition(&conditions, 3, true, true)
+	suspended := meta.FindStatusCondition(conditions, k8schianetv1.ConditionTypeSuspended)
+	require.NotNil(t, suspended)
+	require.Equal(t, ReasonScaledToZero, suspended.Reason)
+	ready := meta.FindStatusCondition(conditions, k8schianetv1.ConditionTypeReady)
+	require.NotNil(t, ready)
+	require.Equal(t, metav1.ConditionFalse, ready.Status)
+	require.Equal(t, ReasonScaledToZero, ready.Reason)
+}
+
+func TestSetWorkloadConditions(t *testing.T) {
+	var conditions []metav1.Condition
+	SetWorkloadConditions(&conditions, 1, WorkloadStatus{Ready: true, Reason: ReasonRolloutComplete, Message: "done"})
+	require.True(t, meta.IsStatusConditionTrue(conditions, k8schianetv1.ConditionTypeReady))
+	require.True(t, meta.IsStatusConditionFalse(conditions, k8schianetv1.ConditionTypeProgressing))
+	require.True(t, meta.IsStatusConditionFalse(conditions, k8schianetv1.ConditionTypeDegraded))
+
+	SetWorkloadConditions(&conditions, 2, WorkloadStatus{Degraded: true, Progressing: true, Reason: ReasonContainerFailing, Message: "failing"})
+	require.True(t, meta.IsStatusConditionFalse(conditions, k8schianetv1.ConditionTypeReady))
+	require.True(t, meta.IsStatusConditionTrue(conditions, k8schianetv1.ConditionTypeProgressing))
+	require.True(t, meta.IsStatusConditionTrue(conditions, k8schianetv1.ConditionTypeDegraded))
+	require.Equal(t, int64(2), meta.FindStatusCondition(conditions, k8schianetv1.ConditionTypeReady).ObservedGeneration)
+}
@@ -6,23 +6,53 @@ package kube
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"maps"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/chiaconfig"
 	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+const (
+	// SuspendAnnotation suspends a CR when set to "true", or suspends it and scales its workload to zero when set to "scale-to-zero"
+	SuspendAnnotation = "k8s.chia.net/suspend"
+
+	// suspendScaleToZero is the value of the suspend annotation that also scales a CR's workload to zero
+	suspendScaleToZero = "scale-to-zero"
+
+	// suspendedReplicasAnnotation is set on a workload that was scaled to zero while its CR was suspended, and contains the number of replicas to restore
+	suspendedReplicasAnnotation = "k8s.chia.net/suspended-replicas"
+
+	// ChiaConfigChecksumAnnotation is the Pod template annotation that rolls a workload's Pods when its rendered chia config changes
+	ChiaConfigChecksumAnnotation = "k8s.chia.net/chia-config.checksum"
+
+	// chiaConfigNamePattern is the name of the ConfigMap that contains a workload's rendered chia config, from the workload's name
+	chiaConfigNamePattern = "%s-config"
+
+	// chiaConfigVolumeName is the name of the volume and init container that install the rendered chia config in CHIA_ROOT
+	chiaConfigVolumeName = "chia-config"
+
+	// chiaConfigCommand is the path to chia-config in the operator's image. It installs the rendered chia config in CHIA_ROOT.
+	chiaConfigCommand = "/chia-config"
+)
+
 // GetCommonLabels gives some common labels for chia-operator related objects
 func GetCommonLabels(kind string, meta metav1.ObjectMeta, additionalLabels ...map[string]string) map[string]string {
 	labels := CombineMaps(additionalLabels...)
@@ -59,6 +89,27 @@ func ShouldMakeDataLayerServerFilesVolumeClaim(storage *k8schianetv1.StorageConf
 	return false
 }
 
+// ShouldMakePodDisruptionBudget returns true if a PodDisruptionBudget was configured to be made, or defaultEnabled if it wasn't configured either way
+func ShouldMakePodDisruptionBudget(config *k8schianetv1.PodDisruptionBudgetConfig, defaultEnabled bool) bool {
+	if config != nil && config.Enabled != nil {
+		return *config.Enabled
+	}
+	return defaultEnabled
+}
+
+// GetSuspendState returns whether a CR is suspended by its suspend config or the k8s.chia.net/suspend annotation,
+// and whether its workload should be scaled to zero while it is
+func GetSuspendState(meta metav1.ObjectMeta, config *k8schianetv1.SuspendConfig) (suspended bool, scaleToZero bool) {
+	annotation := strings.ToLower(strings.TrimSpace(meta.Annotations[SuspendAnnotation]))
+	suspended = annotation == "true" || annotation == suspendScaleToZero
+	scaleToZero = annotation == suspendScaleToZero
+	if config != nil {
+		suspended = suspended || (config.Enabled != nil && *config.Enabled)
+		scaleToZero = scaleToZero || (config.ScaleToZero != nil && *config.ScaleToZero)
+	}
+	return suspended, suspended && scaleToZero
+}
+
 // ShouldMakeService returns true if the related Service was configured to be made, otherwise returns the specified default value
 func ShouldMakeService(srv k8schianetv1.Service, def bool) bool {
 	if srv.Enabled != nil {
@@ -119,7 +170,7 @@ func GetFullNodePort(chia k8schianetv1.CommonSpecChia, networkData *map[string]s
 			// so this should never return an error, but we'll check it anyway.
 			i, err := strconv.Atoi(port)
 			if err != nil {
-				return 0, fmt.Errorf("failed to convert network_port \"%s\" to an integer value: %v", port, err)
+				return 0, fmt.Errorf("failed to convert network_port \"%s\" to an integer value: %w", port, err)
 			}
 			return int32(i), nil
 		}
@@ -287,6 +338,13 @@ func GetCommonChiaEnv(commonSpecChia k8schianetv1.CommonSpecChia, networkData *m
 		}
 	}
 
+	// Add chia config overrides, which overwrite first-class settings for the same config fields
+	overrideEnv, err := chiaconfig.GetEnv(commonSpecChia.ConfigOverrides)
+	if err != nil {
+		return []corev1.EnvVar{}, err
+	}
+	env = append(env, overrideEnv...)
+
 	// Need to alphabetize the env slice because if the order of environment variables
 	// changes but none of the values changed, it still triggers a StatefulSet rollout.
 	// When the StatefulSet rolls out, it triggers another reconcile run, which can cause another StatefulSet rollout.
@@ -313,9 +371,9 @@ func GetChiaNetworkData(ctx context.Context, c client.Client, config k8schianetv
 			Namespace: namespace,
 		}, &chianetworkConfig)
 		if err != nil && errors.IsNotFound(err) {
-			return nil, fmt.Errorf("ChiaNetwork specified but its ConfigMap was not found: %v", err)
+			return nil, fmt.Errorf("ChiaNetwork specified but its ConfigMap was not found: %w", err)
 		} else if err != nil {
-			return nil, fmt.Errorf("error getting specified ChiaNetwork's ConfigMap: %v", err)
+			return nil, fmt.Errorf("error getting specified ChiaNetwork's ConfigMap: %w", err)
 		}
 
 		return &chianetworkConfig.Data, nil
@@ -372,8 +430,24 @@ func ChiaExporterEnabled(in k8schianetv1.SpecChiaExporter) bool {
 	return *in.Enabled
 }
 
+// ChiaConfigFileEnabled returns true if the operator should render the chia config.yaml into a ConfigMap (defaults to disabled)
+func ChiaConfigFileEnabled(chia k8schianetv1.CommonSpecChia) bool {
+	return chia.ConfigFile != nil && chia.ConfigFile.Enabled != nil && *chia.ConfigFile.Enabled
+}
+
+// GetChiaConfigMapName returns the name of the ConfigMap that contains the rendered chia config for a workload
+func GetChiaConfigMapName(workloadName string) string {
+	return fmt.Sprintf(chiaConfigNamePattern, workloadName)
+}
+
+// getChiaConfigChecksum returns a checksum of a rendered chia config
+func getChiaConfigChecksum(config string) string {
+	sum := sha256.Sum256([]byte(config))
+	return hex.EncodeToString(sum[:])
+}
+
 // ChiaDBPullEnabled returns true if the first-class chia-db-pull init container was enabled.
-// Defaults to disabled, since this init container only makes sense when an S3 prefix is configured.
+// Defaults to disabled, since this init container only makes sense when a database source is configured.
 func ChiaDBPullEnabled(in k8schianetv1.SpecChiaDBPull) bool {
 	if in.Enabled == nil {
 		return false
@@ -381,6 +455,79 @@ func ChiaDBPullEnabled(in k8schianetv1.SpecChiaDBPull) bool {
 	return *in.Enabled
 }
 
+// GetChiaDBPullSources returns the names of the sources set in a chia-db-pull config, which must have exactly one source when enabled
+func GetChiaDBPullSources(in k8schianetv1.SpecChiaDBPull) []string {
+	var sources []string
+	if in.S3Prefix != "" {
+		sources = append(sources, "s3Prefix")
+	}
+	if in.HTTP != nil {
+		sources = append(sources, "http")
+	}
+	if in.PersistentVolumeClaim != nil {
+		sources = append(sources, "persistentVolumeClaim")
+	}
+	if in.ChiaNodeSnapshot != nil {
+		sources = append(sources, "chiaNodeSnapshot")
+	}
+	return sources
+}
+
+// RPCStatusEnabled returns true if the operator should query a resource's RPC server for status (defaults to enabled)
+func RPCStatusEnabled(in k8schianetv1.RPCStatusConfig) bool {
+	if in.Enabled == nil {
+		return true
+	}
+	return *in.Enabled
+}
+
+// GetRPCStatusInterval returns the minimum amount of time between RPC status queries (defaults to 1 minute)
+func GetRPCStatusInterval(in k8schianetv1.RPCStatusConfig) time.Duration {
+	if in.Interval == nil || in.Interval.Duration <= 0 {
+		return consts.DefaultRPCStatusInterval
+	}
+	return in.Interval.Duration
+}
+
+// GetRPCStatusRequeueAfter returns how long to wait before the next RPC status query, given the last time one was made.
+// Returns 0 if a query is due now.
+func GetRPCStatusRequeueAfter(lastChecked *metav1.Time, interval time.Duration, now time.Time) time.Duration {
+	if lastChecked == nil || lastChecked.IsZero() {
+		return 0
+	}
+	elapsed := now.Sub(lastChecked.Time)
+	if elapsed >= interval || elapsed < 0 {
+		return 0
+	}
+	return interval - elapsed
+}
+
+// GetFirstRunningPod returns the first Pod in the list that is running, has an IP address, and isn't being deleted, or nil if there isn't one
+func GetFirstRunningPod(pods []corev1.Pod) *corev1.Pod {
+	for i := range pods {
+		if podRunning(pods[i]) {
+			return &pods[i]
+		}
+	}
+	return nil
+}
+
+// GetRunningPods returns the Pods in the list that are running, have an IP address, and aren't being deleted
+func GetRunningPods(pods []corev1.Pod) []corev1.Pod {
+	var running []corev1.Pod
+	for _, pod := range pods {
+		if podRunning(pod) {
+			running = append(running, pod)
+		}
+	}
+	return running
+}
+
+// podRunning returns true if a Pod is running, has an IP address, and isn't being deleted
+func podRunning(pod corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" && pod.DeletionTimestamp == nil
+}
+
 // ResolveChiaNetwork returns the chia network name implied by a CommonSpecChia and optional
 // ChiaNetwork ConfigMap data, in the same priority order the chia container's "network" env var
 // is resolved from: a "network" key in the ChiaNetwork ConfigMap overrides the inline
@@ -397,3 +544,42 @@ func ResolveChiaNetwork(commonSpecChia k8schianetv1.CommonSpecChia, networkData
 	}
 	return ""
 }
+
+// CertificateFingerprint returns the SHA-256 fingerprint of a certificate, formatted as colon separated hex bytes like openssl formats them
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	hexBytes := make([]string, len(sum))
+	for i, b := range sum {
+		hexBytes[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hexBytes, ":")
+}
+
+// ParseCACertificates parses a PEM encoded private CA certificate file. The file may contain more than one certificate while a
+// ChiaCA's previous private CA is still trusted, the first certificate is the current private CA that signs new certificates.
+func ParseCACertificates(certPEM []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := certPEM
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block type %q in CA certificate file", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("failed to decode CA certificate PEM")
+	}
+	if len(strings.TrimSpace(string(rest))) != 0 {
+		return nil, fmt.Errorf("cert file had extra data at the end")
+	}
+	return certs, nil
+}
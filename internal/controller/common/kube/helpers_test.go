@@ -2,13 +2,16 @@ package kube
 
 import (
 	"testing"
+	"time"
 
+	"github.com/chia-network/go-chia-libs/pkg/tls"
 	"github.com/stretchr/testify/assert"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
 	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -133,6 +136,48 @@ func TestShouldMakeService(t *testing.T) {
 	require.Equal(t, false, actual, "expected should not make Service, defaulted to false with Enabled=false")
 }
 
+func TestShouldMakePodDisruptionBudget(t *testing.T) {
+	require.True(t, ShouldMakePodDisruptionBudget(nil, true), "expected should make PodDisruptionBudget, defaulted to true")
+	require.False(t, ShouldMakePodDisruptionBudget(nil, false), "expected should not make PodDisruptionBudget, defaulted to false")
+	require.False(t, ShouldMakePodDisruptionBudget(&k8schianetv1.PodDisruptionBudgetConfig{}, false), "expected should not make PodDisruptionBudget, defaulted to false with Enabled=nil")
+	require.True(t, ShouldMakePodDisruptionBudget(&k8schianetv1.PodDisruptionBudgetConfig{Enabled: boolPtr(true)}, false), "expected should make PodDisruptionBudget with Enabled=true")
+	require.False(t, ShouldMakePodDisruptionBudget(&k8schianetv1.PodDisruptionBudgetConfig{Enabled: boolPtr(false)}, true), "expected should not make PodDisruptionBudget with Enabled=false")
+}
+
+func TestGetSuspendState(t *testing.T) {
+	suspended, scaleToZero := GetSuspendState(metav1.ObjectMeta{}, nil)
+	require.False(t, suspended)
+	require.False(t, scaleToZero)
+
+	suspended, scaleToZero = GetSuspendState(metav1.ObjectMeta{}, &k8schianetv1.SuspendConfig{Enabled: boolPtr(true)})
+	require.True(t, suspended)
+	require.False(t, scaleToZero)
+
+	suspended, scaleToZero = GetSuspendState(metav1.ObjectMeta{}, &k8schianetv1.SuspendConfig{Enabled: boolPtr(true), ScaleToZero: boolPtr(true)})
+	require.True(t, suspended)
+	require.True(t, scaleToZero)
+
+	// scaleToZero does nothing unless the resource is suspended
+	suspended, scaleToZero = GetSuspendState(metav1.ObjectMeta{}, &k8schianetv1.SuspendConfig{ScaleToZero: boolPtr(true)})
+	require.False(t, suspended)
+	require.False(t, scaleToZero)
+
+	suspended, scaleToZero = GetSuspendState(metav1.ObjectMeta{Annotations: map[string]string{SuspendAnnotation: "true"}}, nil)
+	require.True(t, suspended)
+	require.False(t, scaleToZero)
+
+	suspended, scaleToZero = GetSuspendState(metav1.ObjectMeta{Annotations: map[string]string{SuspendAnnotation: "true"}}, &k8schianetv1.SuspendConfig{ScaleToZero: boolPtr(true)})
+	require.True(t, suspended)
+	require.True(t, scaleToZero)
+
+	suspended, scaleToZero = GetSuspendState(metav1.ObjectMeta{Annotations: map[string]string{SuspendAnnotation: "scale-to-zero"}}, &k8schianetv1.SuspendConfig{Enabled: boolPtr(false)})
+	require.True(t, suspended)
+	require.True(t, scaleToZero)
+
+	suspended, _ = GetSuspendState(metav1.ObjectMeta{Annotations: map[string]string{SuspendAnnotation: "false"}}, nil)
+	require.False(t, suspended)
+}
+
 func TestShouldRollIntoMainPeerService(t *testing.T) {
 	enabled := true
 	disabled := false
@@ -453,6 +498,66 @@ func TestChiaDBPullEnabled(t *testing.T) {
 	require.Equal(t, false, actual, "expected chia-db-pull disabled, set to false")
 }
 
+func TestRPCStatusEnabled(t *testing.T) {
+	assert.True(t, RPCStatusEnabled(k8schianetv1.RPCStatusConfig{}))
+	assert.True(t, RPCStatusEnabled(k8schianetv1.RPCStatusConfig{Enabled: boolPtr(true)}))
+	assert.False(t, RPCStatusEnabled(k8schianetv1.RPCStatusConfig{Enabled: boolPtr(false)}))
+}
+
+func TestGetRPCStatusInterval(t *testing.T) {
+	assert.Equal(t, consts.DefaultRPCStatusInterval, GetRPCStatusInterval(k8schianetv1.RPCStatusConfig{}))
+	assert.Equal(t, 5*time.Minute, GetRPCStatusInterval(k8schianetv1.RPCStatusConfig{Interval: &metav1.Duration{Duration: 5 * time.Minute}}))
+}
+
+func TestGetRPCStatusRequeueAfter(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name        string
+		lastChecked *metav1.Time
+		expected    time.Duration
+	}{
+		{
+			name:        "Never checked",
+			lastChecked: nil,
+			expected:    0,
+		},
+		{
+			name:        "Checked recently",
+			lastChecked: &metav1.Time{Time: now.Add(-20 * time.Second)},
+			expected:    40 * time.Second,
+		},
+		{
+			name:        "Interval elapsed",
+			lastChecked: &metav1.Time{Time: now.Add(-2 * time.Minute)},
+			expected:    0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetRPCStatusRequeueAfter(tc.lastChecked, time.Minute, now))
+		})
+	}
+}
+
+func TestGetRunningPods(t *testing.T) {
+	now := metav1.Now()
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pending"}, Status: corev1.PodStatus{Phase: corev1.PodPending}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "running-1"}, Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "no-ip"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "deleting", DeletionTimestamp: &now}, Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.2"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "running-2"}, Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.3"}},
+	}
+
+	running := GetRunningPods(pods)
+	require.Len(t, running, 2)
+	assert.Equal(t, "running-1", running[0].Name)
+	assert.Equal(t, "running-2", running[1].Name)
+	assert.Equal(t, "running-1", GetFirstRunningPod(pods).Name)
+	assert.Empty(t, GetRunningPods(pods[:1]))
+}
+
 func TestGetCommonChiaEnv(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -618,6 +723,45 @@ func TestGetCommonChiaEnv(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Config Overrides",
+			spec: k8schianetv1.CommonSpecChia{
+				ConfigOverrides: &apiextensionsv1.JSON{Raw: []byte(`{"full_node":{"enable_upnp":false}}`)},
+				AdditionalEnv: &[]corev1.EnvVar{
+					{
+						Name:  "chia.full_node.enable_upnp",
+						Value: "true",
+					},
+				},
+			},
+			networkData: nil,
+			expectedEnv: []corev1.EnvVar{
+				{
+					Name:  "CHIA_ROOT",
+					Value: "/chia-data",
+				},
+				{
+					Name:  "ca",
+					Value: "/chia-ca",
+				},
+				{
+					Name:  "chia.full_node.enable_upnp",
+					Value: "false",
+				},
+				{
+					Name:  "network_port",
+					Value: "8444",
+				},
+				{
+					Name:  "self_hostname",
+					Value: "0.0.0.0",
+				},
+				{
+					Name:  "chia.full_node.enable_upnp",
+					Value: "true",
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -632,3 +776,30 @@ func TestGetCommonChiaEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCACertificates(t *testing.T) {
+	currentDER, currentKey, err := tls.GenerateNewCA()
+	require.NoError(t, err)
+	currentPEM, _, err := tls.EncodeCertAndKeyToPEM(currentDER, currentKey)
+	require.NoError(t, err)
+	previousDER, previousKey, err := tls.GenerateNewCA()
+	require.NoError(t, err)
+	previousPEM, _, err := tls.EncodeCertAndKeyToPEM(previousDER, previousKey)
+	require.NoError(t, err)
+
+	certs, err := ParseCACertificates(currentPEM)
+	require.NoError(t, err)
+	require.Len(t, certs, 1)
+
+	// The first certificate in a bundle is the current CA
+	certs, err = ParseCACertificates(append(append([]byte{}, currentPEM...), previousPEM...))
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	require.Equal(t, currentDER, certs[0].Raw)
+	require.Equal(t, previousDER, certs[1].Raw)
+	require.NotEqual(t, CertificateFingerprint(certs[0]), CertificateFingerprint(certs[1]))
+	require.Len(t, CertificateFingerprint(certs[0]), 95)
+
+	_, err = ParseCACertificates([]byte("not a certificate"))
+	require.Error(t, err)
+}
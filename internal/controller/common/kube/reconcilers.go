@@ -8,18 +8,25 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/metrics"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -148,7 +155,7 @@ func ReconcileService(ctx context.Context, c client.Client, service k8schianetv1
 			if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
 				return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 			}
-			return ctrl.Result{}, fmt.Errorf("error applying Service \"%s\": %v", desired.Name, err)
+			return ctrl.Result{}, fmt.Errorf("error applying Service \"%s\": %w", desired.Name, err)
 		}
 		return ctrl.Result{}, nil
 	}
@@ -162,12 +169,12 @@ func ReconcileService(ctx context.Context, c client.Client, service k8schianetv1
 		if errors.IsNotFound(err) {
 			return ctrl.Result{}, nil
 		}
-		return ctrl.Result{}, fmt.Errorf("error getting Service \"%s\": %v", desired.Name, err)
+		return ctrl.Result{}, fmt.Errorf("error getting Service \"%s\": %w", desired.Name, err)
 	}
 
 	klog.Info("Deleting Service because it was disabled")
 	if err := c.Delete(ctx, &current); err != nil {
-		return ctrl.Result{}, fmt.Errorf("error deleting Service \"%s\": %v", desired.Name, err)
+		return ctrl.Result{}, fmt.Errorf("error deleting Service \"%s\": %w", desired.Name, err)
 	}
 
 	return ctrl.Result{}, nil
@@ -183,7 +190,7 @@ func ReconcileDeployment(ctx context.Context, c client.Client, desired appsv1.De
 		Namespace: desired.Namespace,
 	}, &current)
 	if err != nil && !errors.IsNotFound(err) {
-		return ctrl.Result{}, fmt.Errorf("error getting Deployment \"%s\": %v", desired.Name, err)
+		return ctrl.Result{}, fmt.Errorf("error getting Deployment \"%s\": %w", desired.Name, err)
 	}
 
 	if err == nil {
@@ -194,8 +201,9 @@ func ReconcileDeployment(ctx context.Context, c client.Client, desired appsv1.De
 				if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
 					return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 				}
-				return ctrl.Result{}, fmt.Errorf("error deleting Deployment \"%s\": %v", current.Name, err)
+				return ctrl.Result{}, fmt.Errorf("error deleting Deployment \"%s\": %w", current.Name, err)
 			}
+			metrics.WorkloadRecreations.WithLabelValues("Deployment").Inc()
 
 			for {
 				var tmp appsv1.Deployment
@@ -207,7 +215,7 @@ func ReconcileDeployment(ctx context.Context, c client.Client, desired appsv1.De
 					if client.IgnoreNotFound(err) == nil {
 						break
 					}
-					return ctrl.Result{}, fmt.Errorf("error waiting for Deployment to be deleted \"%s\": %v", desired.Name, err)
+					return ctrl.Result{}, fmt.Errorf("error waiting for Deployment to be deleted \"%s\": %w", desired.Name, err)
 				}
 				time.Sleep(2 * time.Second)
 			}
@@ -216,7 +224,7 @@ func ReconcileDeployment(ctx context.Context, c client.Client, desired appsv1.De
 				if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
 					return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 				}
-				return ctrl.Result{}, fmt.Errorf("error removing stale containers from Deployment \"%s\": %v", current.Name, err)
+				return ctrl.Result{}, fmt.Errorf("error removing stale containers from Deployment \"%s\": %w", current.Name, err)
 			}
 		}
 	}
@@ -225,7 +233,7 @@ func ReconcileDeployment(ctx context.Context, c client.Client, desired appsv1.De
 		if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
 			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 		}
-		return ctrl.Result{}, fmt.Errorf("error applying Deployment \"%s\": %v", desired.Name, err)
+		return ctrl.Result{}, fmt.Errorf("error applying Deployment \"%s\": %w", desired.Name, err)
 	}
 
 	return ctrl.Result{}, nil
@@ -241,7 +249,7 @@ func ReconcileStatefulset(ctx context.Context, c client.Client, desired appsv1.S
 		Namespace: desired.Namespace,
 	}, &current)
 	if err != nil && !errors.IsNotFound(err) {
-		return ctrl.Result{}, fmt.Errorf("error getting StatefulSet \"%s\": %v", desired.Name, err)
+		return ctrl.Result{}, fmt.Errorf("error getting StatefulSet \"%s\": %w", desired.Name, err)
 	}
 
 	if err == nil {
@@ -252,8 +260,9 @@ func ReconcileStatefulset(ctx context.Context, c client.Client, desired appsv1.S
 				if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
 					return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 				}
-				return ctrl.Result{}, fmt.Errorf("error deleting StatefulSet \"%s\": %v", current.Name, err)
+				return ctrl.Result{}, fmt.Errorf("error deleting StatefulSet \"%s\": %w", current.Name, err)
 			}
+			metrics.WorkloadRecreations.WithLabelValues("StatefulSet").Inc()
 
 			for {
 				var tmp appsv1.StatefulSet
@@ -265,7 +274,7 @@ func ReconcileStatefulset(ctx context.Context, c client.Client, desired appsv1.S
 					if client.IgnoreNotFound(err) == nil {
 						break
 					}
-					return ctrl.Result{}, fmt.Errorf("error waiting for StatefulSet to be deleted \"%s\": %v", desired.Name, err)
+					return ctrl.Result{}, fmt.Errorf("error waiting for StatefulSet to be deleted \"%s\": %w", desired.Name, err)
 				}
 				time.Sleep(2 * time.Second)
 			}
@@ -274,7 +283,7 @@ func ReconcileStatefulset(ctx context.Context, c client.Client, desired appsv1.S
 				if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
 					return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 				}
-				return ctrl.Result{}, fmt.Errorf("error removing stale containers from StatefulSet \"%s\": %v", current.Name, err)
+				return ctrl.Result{}, fmt.Errorf("error removing stale containers from StatefulSet \"%s\": %w", current.Name, err)
 			}
 		}
 	}
@@ -283,7 +292,66 @@ func ReconcileStatefulset(ctx context.Context, c client.Client, desired appsv1.S
 		if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
 			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 		}
-		return ctrl.Result{}, fmt.Errorf("error applying StatefulSet \"%s\": %v", desired.Name, err)
+		return ctrl.Result{}, fmt.Errorf("error applying StatefulSet \"%s\": %w", desired.Name, err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// ReconcileDaemonSet uses the controller-runtime client to determine if the DaemonSet resource needs to be created or updated
+func ReconcileDaemonSet(ctx context.Context, c client.Client, desired appsv1.DaemonSet) (reconcile.Result, error) {
+	klog := log.FromContext(ctx).WithValues("DaemonSet.Namespace", desired.Namespace, "DaemonSet.Name", desired.Name)
+
+	var current appsv1.DaemonSet
+	err := c.Get(ctx, types.NamespacedName{
+		Name:      desired.Name,
+		Namespace: desired.Namespace,
+	}, &current)
+	if err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, fmt.Errorf("error getting DaemonSet \"%s\": %w", desired.Name, err)
+	}
+
+	if err == nil {
+		if !reflect.DeepEqual(current.Spec.Selector.MatchLabels, desired.Spec.Selector.MatchLabels) {
+			klog.Info("Recreating DaemonSet for new Selector labels -- selector labels are immutable")
+
+			if err := c.Delete(ctx, &current); err != nil {
+				if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
+					return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+				}
+				return ctrl.Result{}, fmt.Errorf("error deleting DaemonSet \"%s\": %w", current.Name, err)
+			}
+			metrics.WorkloadRecreations.WithLabelValues("DaemonSet").Inc()
+
+			for {
+				var tmp appsv1.DaemonSet
+				err = c.Get(ctx, types.NamespacedName{
+					Namespace: current.Namespace,
+					Name:      current.Name,
+				}, &tmp)
+				if err != nil {
+					if client.IgnoreNotFound(err) == nil {
+						break
+					}
+					return ctrl.Result{}, fmt.Errorf("error waiting for DaemonSet to be deleted \"%s\": %w", desired.Name, err)
+				}
+				time.Sleep(2 * time.Second)
+			}
+		} else {
+			if err := removeStaleWorkloadContainers(ctx, c, &current, &current.Spec.Template.Spec, &desired.Spec.Template.Spec); err != nil {
+				if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
+					return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+				}
+				return ctrl.Result{}, fmt.Errorf("error removing stale containers from DaemonSet \"%s\": %w", current.Name, err)
+			}
+		}
+	}
+
+	if err := serverSideApply(ctx, c, &desired, "DaemonSet", "apps/v1"); err != nil {
+		if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("error applying DaemonSet \"%s\": %w", desired.Name, err)
 	}
 
 	return ctrl.Result{}, nil
@@ -299,7 +367,19 @@ func ReconcilePersistentVolumeClaim(ctx context.Context, c client.Client, storag
 		if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
 			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 		}
-		return ctrl.Result{}, fmt.Errorf("error applying PersistentVolumeClaim \"%s\": %v", desired.Name, err)
+		return ctrl.Result{}, fmt.Errorf("error applying PersistentVolumeClaim \"%s\": %w", desired.Name, err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// ReconcilePlotPersistentVolumeClaim uses the controller-runtime client to determine if a harvester's generated plot PVC resource needs to be created or updated
+func ReconcilePlotPersistentVolumeClaim(ctx context.Context, c client.Client, desired corev1.PersistentVolumeClaim) (reconcile.Result, error) {
+	if err := serverSideApply(ctx, c, &desired, "PersistentVolumeClaim", "v1"); err != nil {
+		if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("error applying PersistentVolumeClaim \"%s\": %w", desired.Name, err)
 	}
 
 	return ctrl.Result{}, nil
@@ -311,12 +391,234 @@ func ReconcileConfigMap(ctx context.Context, c client.Client, desired corev1.Con
 		if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
 			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 		}
-		return ctrl.Result{}, fmt.Errorf("error applying ConfigMap \"%s\": %v", desired.Name, err)
+		return ctrl.Result{}, fmt.Errorf("error applying ConfigMap \"%s\": %w", desired.Name, err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// ReconcileChiaConfigMap applies a workload's rendered chia config ConfigMap if the config file mode is enabled, or deletes it if it was disabled
+func ReconcileChiaConfigMap(ctx context.Context, c client.Client, chia k8schianetv1.CommonSpecChia, desired corev1.ConfigMap) (reconcile.Result, error) {
+	klog := log.FromContext(ctx).WithValues("ConfigMap.Namespace", desired.Namespace, "ConfigMap.Name", desired.Name)
+
+	if ChiaConfigFileEnabled(chia) {
+		return ReconcileConfigMap(ctx, c, desired)
+	}
+
+	var current corev1.ConfigMap
+	err := c.Get(ctx, types.NamespacedName{
+		Name:      desired.Name,
+		Namespace: desired.Namespace,
+	}, &current)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("error getting ConfigMap \"%s\": %w", desired.Name, err)
+	}
+
+	// Only delete the ConfigMap if it's controlled by the same CR, so a ConfigMap of the same name created by someone else is left alone
+	currentOwner, desiredOwner := metav1.GetControllerOfNoCopy(&current), metav1.GetControllerOfNoCopy(&desired)
+	if currentOwner == nil || desiredOwner == nil || currentOwner.UID != desiredOwner.UID {
+		return ctrl.Result{}, nil
+	}
+
+	klog.Info("Deleting chia config ConfigMap because the config file mode was disabled")
+	if err := c.Delete(ctx, &current); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error deleting ConfigMap \"%s\": %w", desired.Name, err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// ReconcileSecret uses the controller-runtime client to determine if the Secret resource needs to be created or updated
+func ReconcileSecret(ctx context.Context, c client.Client, desired corev1.Secret) (reconcile.Result, error) {
+	if err := serverSideApply(ctx, c, &desired, "Secret", "v1"); err != nil {
+		if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("error applying Secret \"%s\": %w", desired.Name, err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// ReconcileChiaResource uses the controller-runtime client to determine if a Chia custom resource needs to be created or updated.
+// The resource's status is left to the resource's own controller.
+func ReconcileChiaResource(ctx context.Context, c client.Client, kind consts.ChiaKind, desired client.Object) (reconcile.Result, error) {
+	objMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("error converting %s \"%s\": %w", kind, desired.GetName(), err)
+	}
+	u := &unstructured.Unstructured{Object: objMap}
+	unstructured.RemoveNestedField(u.Object, "status")
+
+	if err := serverSideApply(ctx, c, u, string(kind), k8schianetv1.GroupVersion.String()); err != nil {
+		if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("error applying %s \"%s\": %w", kind, desired.GetName(), err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// ReconcilePodDisruptionBudget uses the controller-runtime client to determine if the PodDisruptionBudget resource needs to be created, updated, or deleted
+func ReconcilePodDisruptionBudget(ctx context.Context, c client.Client, config *k8schianetv1.PodDisruptionBudgetConfig, desired policyv1.PodDisruptionBudget, defaultEnabled bool) (reconcile.Result, error) {
+	klog := log.FromContext(ctx).WithValues("PodDisruptionBudget.Namespace", desired.Namespace, "PodDisruptionBudget.Name", desired.Name)
+
+	if ShouldMakePodDisruptionBudget(config, defaultEnabled) {
+		if err := serverSideApply(ctx, c, &desired, "PodDisruptionBudget", "policy/v1"); err != nil {
+			if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
+				return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+			}
+			return ctrl.Result{}, fmt.Errorf("error applying PodDisruptionBudget \"%s\": %w", desired.Name, err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	var current policyv1.PodDisruptionBudget
+	err := c.Get(ctx, types.NamespacedName{
+		Name:      desired.Name,
+		Namespace: desired.Namespace,
+	}, &current)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("error getting PodDisruptionBudget \"%s\": %w", desired.Name, err)
+	}
+
+	klog.Info("Deleting PodDisruptionBudget because it was disabled")
+	if err := c.Delete(ctx, &current); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error deleting PodDisruptionBudget \"%s\": %w", desired.Name, err)
 	}
 
 	return ctrl.Result{}, nil
 }
 
+// ReconcileSuspension scales a CR's Deployment or StatefulSet to zero replicas while the CR is suspended and configured to scale to zero,
+// restores the workload's replicas once the CR is no longer suspended, and sets the CR's Suspended condition.
+// workload only needs a name and namespace, and can be nil for CRs that don't have a workload to scale.
+// Returns true if the CR is suspended, and the rest of its resources should not be reconciled.
+func ReconcileSuspension(ctx context.Context, c client.Client, meta metav1.ObjectMeta, config *k8schianetv1.SuspendConfig, conditions *[]metav1.Condition, workload client.Object) (bool, error) {
+	suspended, scaleToZero := GetSuspendState(meta, config)
+	scaleToZero = scaleToZero && workload != nil
+	if workload != nil {
+		var err error
+		if scaleToZero {
+			err = scaleWorkloadToZero(ctx, c, workload)
+		} else if !suspended {
+			err = restoreWorkloadReplicas(ctx, c, workload)
+		}
+		if err != nil {
+			return suspended, err
+		}
+	}
+
+	SetSuspendedCondition(conditions, meta.Generation, suspended, scaleToZero)
+	return suspended, nil
+}
+
+// UpdateSuspendedStatus updates the status of a CR that ReconcileSuspension reported as suspended, in place of reconciling its other resources.
+// observedGeneration and ready point to the CR's status fields, which are set from its generation and Ready condition.
+func UpdateSuspendedStatus(ctx context.Context, c client.Client, kind consts.ChiaKind, obj client.Object, conditions []metav1.Condition, observedGeneration *int64, ready *bool) (ctrl.Result, error) {
+	logr := log.FromContext(ctx)
+	logr.Info(fmt.Sprintf("%s is suspended, skipping reconciliation", kind))
+
+	*observedGeneration = obj.GetGeneration()
+	*ready = apimeta.IsStatusConditionTrue(conditions, k8schianetv1.ConditionTypeReady)
+	if err := c.Status().Update(ctx, obj); err != nil {
+		if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+		logr.Error(err, fmt.Sprintf("%sReconciler %s=%s unable to update %s status", kind, kind, client.ObjectKeyFromObject(obj), kind))
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// scaleWorkloadToZero scales a Deployment or StatefulSet to zero replicas, saving its current number of replicas in an annotation. Does nothing if it doesn't exist.
+func scaleWorkloadToZero(ctx context.Context, c client.Client, workload client.Object) error {
+	klog := log.FromContext(ctx).WithValues("Workload.Namespace", workload.GetNamespace(), "Workload.Name", workload.GetName())
+
+	replicas, err := getWorkload(ctx, c, workload)
+	if err != nil || replicas == nil || (*replicas != nil && **replicas == 0) {
+		return err
+	}
+
+	original := workload.DeepCopyObject().(client.Object)
+	current := int32(1)
+	if *replicas != nil {
+		current = **replicas
+	}
+	annotations := workload.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[suspendedReplicasAnnotation] = strconv.Itoa(int(current))
+	workload.SetAnnotations(annotations)
+	*replicas = ptr.To(int32(0))
+
+	klog.Info("Scaling workload to zero replicas because its resource was suspended")
+	if err := c.Patch(ctx, workload, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("error scaling \"%s\" to zero replicas: %w", workload.GetName(), err)
+	}
+	return nil
+}
+
+// restoreWorkloadReplicas restores the replicas of a Deployment or StatefulSet that was scaled to zero by scaleWorkloadToZero. Does nothing if it wasn't.
+func restoreWorkloadReplicas(ctx context.Context, c client.Client, workload client.Object) error {
+	klog := log.FromContext(ctx).WithValues("Workload.Namespace", workload.GetNamespace(), "Workload.Name", workload.GetName())
+
+	replicas, err := getWorkload(ctx, c, workload)
+	if err != nil || replicas == nil {
+		return err
+	}
+	value, ok := workload.GetAnnotations()[suspendedReplicasAnnotation]
+	if !ok {
+		return nil
+	}
+	count, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return fmt.Errorf("error parsing the %s annotation on \"%s\": %w", suspendedReplicasAnnotation, workload.GetName(), err)
+	}
+
+	original := workload.DeepCopyObject().(client.Object)
+	annotations := workload.GetAnnotations()
+	delete(annotations, suspendedReplicasAnnotation)
+	workload.SetAnnotations(annotations)
+	*replicas = ptr.To(int32(count))
+
+	klog.Info("Restoring workload replicas because its resource is no longer suspended", "Replicas", count)
+	if err := c.Patch(ctx, workload, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("error restoring \"%s\" to %d replicas: %w", workload.GetName(), count, err)
+	}
+	return nil
+}
+
+// getWorkload gets the current state of a Deployment or StatefulSet, and returns a pointer to its replicas field. Returns nil if it doesn't exist.
+func getWorkload(ctx context.Context, c client.Client, workload client.Object) (**int32, error) {
+	err := c.Get(ctx, types.NamespacedName{
+		Name:      workload.GetName(),
+		Namespace: workload.GetNamespace(),
+	}, workload)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting \"%s\": %w", workload.GetName(), err)
+	}
+
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		return &w.Spec.Replicas, nil
+	case *appsv1.StatefulSet:
+		return &w.Spec.Replicas, nil
+	}
+	return nil, fmt.Errorf("can not scale %T \"%s\"", workload, workload.GetName())
+}
+
 // ReconcileIngress uses the controller-runtime client to determine if the Ingress resource needs to be created or updated
 func ReconcileIngress(ctx context.Context, c client.Client, ingress k8schianetv1.IngressConfig, desired networkingv1.Ingress) (reconcile.Result, error) {
 	klog := log.FromContext(ctx).WithValues("Ingress.Namespace", desired.Namespace, "Ingress.Name", desired.Name)
@@ -331,7 +633,7 @@ func ReconcileIngress(ctx context.Context, c client.Client, ingress k8schianetv1
 			if strings.Contains(err.Error(), ObjectModifiedTryAgainError) {
 				return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 			}
-			return ctrl.Result{}, fmt.Errorf("error applying Ingress \"%s\": %v", desired.Name, err)
+			return ctrl.Result{}, fmt.Errorf("error applying Ingress \"%s\": %w", desired.Name, err)
 		}
 		return ctrl.Result{}, nil
 	}
@@ -345,12 +647,12 @@ func ReconcileIngress(ctx context.Context, c client.Client, ingress k8schianetv1
 		if errors.IsNotFound(err) {
 			return ctrl.Result{}, nil
 		}
-		return ctrl.Result{}, fmt.Errorf("error getting Ingress \"%s\": %v", desired.Name, err)
+		return ctrl.Result{}, fmt.Errorf("error getting Ingress \"%s\": %w", desired.Name, err)
 	}
 
 	klog.Info("Deleting Ingress because it was disabled")
 	if err := c.Delete(ctx, &current); err != nil {
-		return ctrl.Result{}, fmt.Errorf("error deleting Ingress \"%s\": %v", desired.Name, err)
+		return ctrl.Result{}, fmt.Errorf("error deleting Ingress \"%s\": %w", desired.Name, err)
 	}
 
 	return ctrl.Result{}, nil
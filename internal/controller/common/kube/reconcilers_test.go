@@ -9,17 +9,21 @@ import (
 	"testing"
 
 	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -596,6 +600,85 @@ var _ = Describe("ReconcileIngress", func() {
 	})
 })
 
+// ---------------------------------------------------------------------------
+// ReconcileSuspension
+// ---------------------------------------------------------------------------
+
+var _ = Describe("ReconcileSuspension", func() {
+	It("should scale a Deployment to zero and restore its replicas", func() {
+		ctx := context.Background()
+		labels := map[string]string{"app": "chia-suspend"}
+		replicas := int32(2)
+		deploy := appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-suspend",
+				Namespace: "default",
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "chia", Image: "ghcr.io/chia-network/chia:latest"}},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &deploy)).To(Succeed())
+
+		var conditions []metav1.Condition
+		enabled := true
+		config := &k8schianetv1.SuspendConfig{Enabled: &enabled, ScaleToZero: &enabled}
+		workload := func() *appsv1.Deployment {
+			return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "test-suspend", Namespace: "default"}}
+		}
+
+		suspended, err := ReconcileSuspension(ctx, k8sClient, metav1.ObjectMeta{Name: "test"}, config, &conditions, workload())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(suspended).To(BeTrue())
+
+		var fetched appsv1.Deployment
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "test-suspend", Namespace: "default"}, &fetched)).To(Succeed())
+		Expect(*fetched.Spec.Replicas).To(Equal(int32(0)))
+		Expect(fetched.Annotations).To(HaveKeyWithValue(suspendedReplicasAnnotation, "2"))
+
+		suspended, err = ReconcileSuspension(ctx, k8sClient, metav1.ObjectMeta{Name: "test"}, nil, &conditions, workload())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(suspended).To(BeFalse())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "test-suspend", Namespace: "default"}, &fetched)).To(Succeed())
+		Expect(*fetched.Spec.Replicas).To(Equal(int32(2)))
+		Expect(fetched.Annotations).NotTo(HaveKey(suspendedReplicasAnnotation))
+	})
+
+	It("should be a noop when the workload does not exist", func() {
+		ctx := context.Background()
+		var conditions []metav1.Condition
+		meta := metav1.ObjectMeta{Name: "test", Annotations: map[string]string{SuspendAnnotation: "scale-to-zero"}}
+		suspended, err := ReconcileSuspension(ctx, k8sClient, meta, nil, &conditions, &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "test-suspend-missing", Namespace: "default"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(suspended).To(BeTrue())
+	})
+})
+
+func TestUpdateSuspendedStatus(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, k8schianetv1.AddToScheme(s))
+	farmer := &k8schianetv1.ChiaFarmer{ObjectMeta: metav1.ObjectMeta{Name: "farmer", Namespace: "default", Generation: 3}}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(farmer).WithStatusSubresource(farmer).Build()
+
+	SetCondition(&farmer.Status.Conditions, 3, k8schianetv1.ConditionTypeReady, metav1.ConditionTrue, ReasonRolloutComplete, "")
+	result, err := UpdateSuspendedStatus(context.Background(), c, consts.ChiaFarmerKind, farmer, farmer.Status.Conditions, &farmer.Status.ObservedGeneration, &farmer.Status.Ready)
+	require.NoError(t, err)
+	require.Zero(t, result.RequeueAfter)
+
+	var fetched k8schianetv1.ChiaFarmer
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(farmer), &fetched))
+	require.Equal(t, int64(3), fetched.Status.ObservedGeneration)
+	require.True(t, fetched.Status.Ready)
+}
+
 // ---------------------------------------------------------------------------
 // ReconcileDeployment
 // ---------------------------------------------------------------------------
@@ -0,0 +1,180 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+// Package volumesnapshot assembles and manages CSI VolumeSnapshots.
+// VolumeSnapshots are handled as unstructured objects so the operator doesn't depend on the external-snapshotter's API module,
+// and only needs the VolumeSnapshot CRDs installed when a resource opts in to snapshots.
+package volumesnapshot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+const (
+	// Group is the API group of VolumeSnapshots
+	Group = "snapshot.storage.k8s.io"
+
+	// Kind is the kind of a VolumeSnapshot
+	Kind = "VolumeSnapshot"
+)
+
+var (
+	// GVK is the GroupVersionKind of a VolumeSnapshot
+	GVK = schema.GroupVersionKind{Group: Group, Version: "v1", Kind: Kind}
+
+	// ListGVK is the GroupVersionKind of a list of VolumeSnapshots
+	ListGVK = schema.GroupVersionKind{Group: Group, Version: "v1", Kind: Kind + "List"}
+)
+
+// Inputs contains the configuration for a VolumeSnapshot of a PersistentVolumeClaim
+type Inputs struct {
+	// Name is the name of the VolumeSnapshot
+	Name string
+
+	// Namespace is the namespace of the VolumeSnapshot and the PersistentVolumeClaim
+	Namespace string
+
+	// Labels are set on the VolumeSnapshot
+	Labels map[string]string
+
+	// Annotations are set on the VolumeSnapshot
+	Annotations map[string]string
+
+	// PersistentVolumeClaimName is the name of the PersistentVolumeClaim to take a snapshot of
+	PersistentVolumeClaimName string
+
+	// VolumeSnapshotClassName is the VolumeSnapshotClass to take the snapshot with, the cluster's default is used if nil
+	VolumeSnapshotClassName *string
+}
+
+// Assemble assembles a VolumeSnapshot of a PersistentVolumeClaim
+func Assemble(input Inputs) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": input.PersistentVolumeClaimName,
+		},
+	}
+	if input.VolumeSnapshotClassName != nil && *input.VolumeSnapshotClassName != "" {
+		spec["volumeSnapshotClassName"] = *input.VolumeSnapshotClassName
+	}
+
+	u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	u.SetGroupVersionKind(GVK)
+	u.SetName(input.Name)
+	u.SetNamespace(input.Namespace)
+	u.SetLabels(input.Labels)
+	u.SetAnnotations(input.Annotations)
+	return u
+}
+
+// Create sets the owner as the controller of a VolumeSnapshot and creates it.
+// The owner can be nil for a VolumeSnapshot that should outlive the resource it was taken for.
+// Returns a ConditionError if the VolumeSnapshot CRDs aren't installed.
+func Create(ctx context.Context, c client.Client, scheme *runtime.Scheme, owner client.Object, obj *unstructured.Unstructured) error {
+	if owner != nil {
+		if err := controllerutil.SetControllerReference(owner, obj, scheme); err != nil {
+			return fmt.Errorf("error setting controller reference on VolumeSnapshot \"%s\": %w", obj.GetName(), err)
+		}
+	}
+	err := c.Create(ctx, obj)
+	if err != nil && meta.IsNoMatchError(err) {
+		return notInstalledError(err)
+	}
+	if err != nil {
+		return fmt.Errorf("error creating VolumeSnapshot \"%s\": %w", obj.GetName(), err)
+	}
+	return nil
+}
+
+// Get returns a VolumeSnapshot, or nil if it doesn't exist.
+// Returns a ConditionError if the VolumeSnapshot CRDs aren't installed.
+func Get(ctx context.Context, c client.Client, namespace, name string) (*unstructured.Unstructured, error) {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(GVK)
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, u)
+	if err != nil && meta.IsNoMatchError(err) {
+		return nil, notInstalledError(err)
+	}
+	if err != nil && errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting VolumeSnapshot \"%s\": %w", name, err)
+	}
+	return u, nil
+}
+
+// List returns the VolumeSnapshots in a namespace with the given labels, newest first.
+// Returns a ConditionError if the VolumeSnapshot CRDs aren't installed.
+func List(ctx context.Context, c client.Client, namespace string, labels map[string]string) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(ListGVK)
+	err := c.List(ctx, list, client.InNamespace(namespace), client.MatchingLabels(labels))
+	if err != nil && meta.IsNoMatchError(err) {
+		return nil, notInstalledError(err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error listing VolumeSnapshots: %w", err)
+	}
+
+	snapshots := list.Items
+	SortNewestFirst(snapshots)
+	return snapshots, nil
+}
+
+// SortNewestFirst sorts VolumeSnapshots by their creation time, newest first. Snapshots created at the same time are sorted by name.
+func SortNewestFirst(snapshots []unstructured.Unstructured) {
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		ti, tj := snapshots[i].GetCreationTimestamp(), snapshots[j].GetCreationTimestamp()
+		if ti.Equal(&tj) {
+			return snapshots[i].GetName() > snapshots[j].GetName()
+		}
+		return tj.Before(&ti)
+	})
+}
+
+// IsReady returns true if a VolumeSnapshot is ready to restore volumes from
+func IsReady(snapshot unstructured.Unstructured) bool {
+	ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	return ready
+}
+
+// GetError returns the message of the error a VolumeSnapshot failed with, or an empty string if it hasn't failed
+func GetError(snapshot unstructured.Unstructured) string {
+	message, _, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message")
+	return message
+}
+
+// GetRestoreSize returns the minimum size of a volume restored from a VolumeSnapshot, or nil if it isn't known yet
+func GetRestoreSize(snapshot unstructured.Unstructured) *resource.Quantity {
+	size, found, _ := unstructured.NestedString(snapshot.Object, "status", "restoreSize")
+	if !found {
+		return nil
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return nil
+	}
+	return &quantity
+}
+
+// notInstalledError returns the ConditionError for a resource that uses VolumeSnapshots when the VolumeSnapshot CRDs aren't installed
+func notInstalledError(err error) error {
+	return kube.NewConditionError(k8schianetv1.ConditionTypeConfigValid, kube.ReasonInvalidConfig, fmt.Errorf("snapshots are enabled, but the VolumeSnapshot CRDs are not installed: %w", err))
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package volumesnapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAssemble(t *testing.T) {
+	className := "csi-snapclass"
+	snapshot := Assemble(Inputs{
+		Name:                      "mainnet-node-20260101000000",
+		Namespace:                 "default",
+		Labels:                    map[string]string{"k8s.chia.net/kind": "ChiaNode"},
+		PersistentVolumeClaimName: "chiaroot-mainnet-node-0",
+		VolumeSnapshotClassName:   &className,
+	})
+
+	require.Equal(t, GVK, snapshot.GroupVersionKind())
+	require.Equal(t, "mainnet-node-20260101000000", snapshot.GetName())
+	require.Equal(t, map[string]string{"k8s.chia.net/kind": "ChiaNode"}, snapshot.GetLabels())
+	pvcName, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+	require.Equal(t, "chiaroot-mainnet-node-0", pvcName)
+	class, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+	require.Equal(t, "csi-snapclass", class)
+
+	snapshot = Assemble(Inputs{Name: "mainnet-node-20260101000000", PersistentVolumeClaimName: "chiaroot-mainnet-node-0"})
+	_, found, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+	require.False(t, found)
+}
+
+func TestSnapshotStatus(t *testing.T) {
+	snapshot := unstructured.Unstructured{Object: map[string]interface{}{}}
+	require.False(t, IsReady(snapshot))
+	require.Empty(t, GetError(snapshot))
+	require.Nil(t, GetRestoreSize(snapshot))
+
+	snapshot.Object["status"] = map[string]interface{}{
+		"readyToUse":  true,
+		"restoreSize": "300Gi",
+		"error": map[string]interface{}{
+			"message": "snapshot failed",
+		},
+	}
+	require.True(t, IsReady(snapshot))
+	require.Equal(t, "snapshot failed", GetError(snapshot))
+	size := resource.MustParse("300Gi")
+	require.Equal(t, &size, GetRestoreSize(snapshot))
+}
+
+func TestSortNewestFirst(t *testing.T) {
+	now := time.Now()
+	newSnapshot := func(name string, created time.Time) unstructured.Unstructured {
+		u := unstructured.Unstructured{Object: map[string]interface{}{}}
+		u.SetName(name)
+		u.SetCreationTimestamp(metav1.NewTime(created))
+		return u
+	}
+
+	snapshots := []unstructured.Unstructured{
+		newSnapshot("a", now.Add(-2*time.Hour)),
+		newSnapshot("b", now),
+		newSnapshot("c", now.Add(-time.Hour)),
+		newSnapshot("d", now),
+	}
+	SortNewestFirst(snapshots)
+
+	var names []string
+	for _, s := range snapshots {
+		names = append(names, s.GetName())
+	}
+	require.Equal(t, []string{"d", "b", "c", "a"}, names)
+}
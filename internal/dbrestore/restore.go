@@ -0,0 +1,465 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+// Package dbrestore restores a Chia blockchain database into CHIA_ROOT from HTTP(S) URLs or a mounted file, which chia-db-pull
+// doesn't support, and checks whether an existing database is recent enough to keep. It's run by the chia-db-restore command
+// in a ChiaNode's chia-db-pull init container, and in the chia-db-check and chia-db-install init containers around chia-db-pull
+// for S3 sources.
+package dbrestore
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables chia-db-restore is configured with
+const (
+	// EnvChiaRoot is the path to CHIA_ROOT
+	EnvChiaRoot = "CHIA_ROOT"
+
+	// EnvNetwork is the name of the network whose database is restored, which defaults to mainnet
+	EnvNetwork = "NETWORK"
+
+	// EnvURLs is a newline separated list of HTTP(S) URLs to download the database from, tried in order
+	EnvURLs = "DB_URLS"
+
+	// EnvFile is the path to a database file to copy, tried after the URLs
+	EnvFile = "DB_FILE"
+
+	// EnvSHA256 is the checksum of the downloaded or copied file
+	EnvSHA256 = "DB_SHA256"
+
+	// EnvMinHeight is the minimum peak height of a restored database
+	EnvMinHeight = "MIN_HEIGHT"
+
+	// EnvMaxHeightLag is how many blocks behind MIN_HEIGHT or PEAK_HEIGHT an existing database can be and still be kept
+	EnvMaxHeightLag = "MAX_HEIGHT_LAG"
+
+	// EnvPeakHeight is the peak height an existing database is compared with
+	EnvPeakHeight = "PEAK_HEIGHT"
+
+	// EnvMode is what chia-db-restore does, one of ModeRestore, ModeCheck, or ModeInstall. Defaults to ModeRestore.
+	EnvMode = "MODE"
+
+	// EnvStagingRoot is the CHIA_ROOT chia-db-pull downloads into, used by ModeCheck and ModeInstall
+	EnvStagingRoot = "STAGING_ROOT"
+)
+
+const (
+	// ModeRestore keeps an existing database that's recent enough, or restores one from the URLs or file
+	ModeRestore = "restore"
+
+	// ModeCheck runs before chia-db-pull. It links an existing database that's recent enough into the staging root,
+	// so chia-db-pull skips downloading a new one.
+	ModeCheck = "check"
+
+	// ModeInstall runs after chia-db-pull. It verifies the database chia-db-pull downloaded into the staging root,
+	// and replaces the existing database with it.
+	ModeInstall = "install"
+)
+
+// httpTimeout is how long to wait to connect to a URL and for its response headers
+const httpTimeout = 60 * time.Second
+
+// Config is the configuration of a database restore
+type Config struct {
+	ChiaRoot     string
+	Network      string
+	URLs         []string
+	File         string
+	SHA256       string
+	MinHeight    uint64
+	MaxHeightLag *uint64
+	PeakHeight   uint64
+	Mode         string
+	StagingRoot  string
+}
+
+// ConfigFromEnv reads a database restore's configuration from environment variables
+func ConfigFromEnv(getenv func(string) string) (Config, error) {
+	config := Config{
+		ChiaRoot:    getenv(EnvChiaRoot),
+		Network:     getenv(EnvNetwork),
+		File:        getenv(EnvFile),
+		SHA256:      strings.ToLower(getenv(EnvSHA256)),
+		Mode:        getenv(EnvMode),
+		StagingRoot: getenv(EnvStagingRoot),
+	}
+	if config.ChiaRoot == "" {
+		return Config{}, fmt.Errorf("%s is required", EnvChiaRoot)
+	}
+	if config.Network == "" {
+		config.Network = "mainnet"
+	}
+	switch config.Mode {
+	case "":
+		config.Mode = ModeRestore
+	case ModeRestore:
+	case ModeCheck, ModeInstall:
+		if config.StagingRoot == "" {
+			return Config{}, fmt.Errorf("%s is required in %s mode", EnvStagingRoot, config.Mode)
+		}
+	default:
+		return Config{}, fmt.Errorf("invalid %s %q", EnvMode, config.Mode)
+	}
+	for _, url := range strings.Split(getenv(EnvURLs), "\n") {
+		if url = strings.TrimSpace(url); url != "" {
+			config.URLs = append(config.URLs, url)
+		}
+	}
+
+	var err error
+	if config.MinHeight, err = parseHeight(getenv, EnvMinHeight); err != nil {
+		return Config{}, err
+	}
+	if config.PeakHeight, err = parseHeight(getenv, EnvPeakHeight); err != nil {
+		return Config{}, err
+	}
+	if getenv(EnvMaxHeightLag) != "" {
+		lag, err := parseHeight(getenv, EnvMaxHeightLag)
+		if err != nil {
+			return Config{}, err
+		}
+		config.MaxHeightLag = &lag
+	}
+	return config, nil
+}
+
+// parseHeight parses a block height from an environment variable, which is 0 if it's unset
+func parseHeight(getenv func(string) string, name string) (uint64, error) {
+	value := getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	height, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %v", name, value, err)
+	}
+	return height, nil
+}
+
+// DatabasePath returns the path to the blockchain database in CHIA_ROOT
+func (c Config) DatabasePath() string {
+	return filepath.Join(c.ChiaRoot, "db", fmt.Sprintf("blockchain_v2_%s.sqlite", c.Network))
+}
+
+// StagedDatabasePath returns the path to the blockchain database chia-db-pull downloads into the staging root
+func (c Config) StagedDatabasePath() string {
+	return filepath.Join(c.StagingRoot, "db", fmt.Sprintf("blockchain_v2_%s.sqlite", c.Network))
+}
+
+// isRecent returns true if an existing database at the given height is recent enough to keep
+func (c Config) isRecent(height uint64) bool {
+	if c.MaxHeightLag == nil {
+		return true
+	}
+	if c.MinHeight == 0 && c.PeakHeight == 0 {
+		return true
+	}
+	if c.MinHeight != 0 && height+*c.MaxHeightLag >= c.MinHeight {
+		return true
+	}
+	return c.PeakHeight != 0 && height+*c.MaxHeightLag >= c.PeakHeight
+}
+
+// Message is the termination message written by chia-db-restore, which the operator reads to report
+// where a ChiaNode replica's database came from
+type Message struct {
+	Source   string `json:"source"`
+	Height   uint32 `json:"height"`
+	Restored bool   `json:"restored"`
+}
+
+// Restorer restores a blockchain database into CHIA_ROOT
+type Restorer struct {
+	Config Config
+
+	// Client downloads databases from URLs
+	Client *http.Client
+
+	// Log receives progress messages
+	Log io.Writer
+
+	// TerminationMessagePath is where the termination message is written, if set
+	TerminationMessagePath string
+}
+
+// NewHTTPClient returns an HTTP client for downloading databases. Downloads aren't limited in length,
+// but connecting and waiting for the response headers time out.
+func NewHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: httpTimeout}).DialContext,
+			TLSHandshakeTimeout:   httpTimeout,
+			ResponseHeaderTimeout: httpTimeout,
+		},
+	}
+}
+
+// Run runs the configured mode
+func (r *Restorer) Run(ctx context.Context) error {
+	switch r.Config.Mode {
+	case ModeCheck:
+		return r.check()
+	case ModeInstall:
+		return r.install()
+	default:
+		return r.restoreFromSources(ctx)
+	}
+}
+
+// restoreFromSources keeps an existing database that's recent enough, or restores the database from the first source that can be restored,
+// verified against its checksum, and is at least at the minimum height. A readable existing database is kept if no source can be restored.
+func (r *Restorer) restoreFromSources(ctx context.Context) error {
+	dbPath := r.Config.DatabasePath()
+
+	existing, exists, readable := r.existingHeight(dbPath)
+	if readable && r.Config.isRecent(existing) {
+		return r.finish(Message{Height: uint32(existing)})
+	}
+	if readable {
+		r.logf("The existing database at height %d is more than %d blocks behind, replacing it", existing, *r.Config.MaxHeightLag)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return fmt.Errorf("error creating database directory: %v", err)
+	}
+	sources := r.Config.URLs
+	if r.Config.File != "" {
+		sources = append(sources, r.Config.File)
+	}
+	for _, source := range sources {
+		r.logf("Restoring the blockchain database from %s", source)
+		height, err := r.restore(ctx, source, dbPath)
+		if err != nil {
+			r.logf("Unable to restore the blockchain database from %s: %v", source, err)
+			continue
+		}
+		return r.finish(Message{Source: source, Height: uint32(height), Restored: true})
+	}
+	if readable {
+		r.logf("Keeping the existing database")
+		return r.finish(Message{Height: uint32(existing)})
+	}
+	if exists {
+		return errors.New("unable to restore the blockchain database from any source, and the existing database can't be read")
+	}
+	return errors.New("unable to restore the blockchain database from any source")
+}
+
+// check empties the staging root, and links an existing database that's recent enough into it.
+// chia-db-pull doesn't download a database when its CHIA_ROOT already contains one, so this skips the download.
+func (r *Restorer) check() error {
+	if err := os.RemoveAll(r.Config.StagingRoot); err != nil {
+		return fmt.Errorf("error removing staging root: %v", err)
+	}
+
+	dbPath := r.Config.DatabasePath()
+	existing, _, readable := r.existingHeight(dbPath)
+	if !readable {
+		return nil
+	}
+	if !r.Config.isRecent(existing) {
+		r.logf("The existing database at height %d is more than %d blocks behind, replacing it", existing, *r.Config.MaxHeightLag)
+		return nil
+	}
+
+	staged := r.Config.StagedDatabasePath()
+	if err := os.MkdirAll(filepath.Dir(staged), 0o755); err != nil {
+		return fmt.Errorf("error creating staging database directory: %v", err)
+	}
+	if err := os.Link(dbPath, staged); err != nil {
+		return fmt.Errorf("error linking the existing database into the staging root: %v", err)
+	}
+	return r.finish(Message{Height: uint32(existing)})
+}
+
+// install verifies the database chia-db-pull downloaded into the staging root, and replaces the existing database with it.
+// Nothing is replaced if chia-db-pull was skipped. A readable existing database is kept if the download can't be verified.
+func (r *Restorer) install() error {
+	defer os.RemoveAll(r.Config.StagingRoot)
+
+	dbPath := r.Config.DatabasePath()
+	staged := r.Config.StagedDatabasePath()
+	stagedInfo, err := os.Stat(staged)
+	if err != nil {
+		return r.keepExisting(dbPath, fmt.Errorf("chia-db-pull didn't download a database: %v", err))
+	}
+	if info, err := os.Stat(dbPath); err == nil && os.SameFile(info, stagedInfo) {
+		// chia-db-check linked the existing database into the staging root, so chia-db-pull was skipped
+		return nil
+	}
+
+	height, err := PeakHeight(staged)
+	if err != nil {
+		return r.keepExisting(dbPath, fmt.Errorf("unable to read the downloaded database: %v", err))
+	}
+	if height < r.Config.MinHeight {
+		return r.keepExisting(dbPath, fmt.Errorf("database height %d is below the minimum height %d", height, r.Config.MinHeight))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return fmt.Errorf("error creating database directory: %v", err)
+	}
+	if err := removeDatabase(dbPath); err != nil {
+		return err
+	}
+	if _, err := os.Stat(staged + "-wal"); err == nil {
+		if err := os.Rename(staged+"-wal", dbPath+"-wal"); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(staged, dbPath); err != nil {
+		return err
+	}
+	return r.finish(Message{Height: uint32(height), Restored: true})
+}
+
+// keepExisting keeps the existing database when a download can't be installed, or returns the error if there isn't a readable one
+func (r *Restorer) keepExisting(dbPath string, err error) error {
+	existing, _, readable := r.existingHeight(dbPath)
+	if !readable {
+		return err
+	}
+	r.logf("Keeping the existing database: %v", err)
+	return r.finish(Message{Height: uint32(existing)})
+}
+
+// existingHeight returns the peak height of the existing database, whether there is one, and whether it could be read.
+// A database that can't be read is never kept.
+func (r *Restorer) existingHeight(dbPath string) (height uint64, exists bool, readable bool) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return 0, false, false
+	}
+	height, err := PeakHeight(dbPath)
+	if err != nil {
+		r.logf("Unable to read the peak height of the existing database, replacing it: %v", err)
+		return 0, true, false
+	}
+	return height, true, true
+}
+
+// restore downloads or copies a database from a source into a temporary file, verifies it, and replaces the database with it.
+// Returns the restored database's peak height.
+func (r *Restorer) restore(ctx context.Context, source, dbPath string) (uint64, error) {
+	tmpPath := dbPath + ".tmp"
+	defer os.Remove(tmpPath)
+
+	raw, err := r.open(ctx, source)
+	if err != nil {
+		return 0, err
+	}
+	defer raw.Close()
+
+	// The checksum is of the file as it's downloaded, before it's decompressed
+	hash := sha256.New()
+	reader := io.TeeReader(raw, hash)
+	src := reader
+	if strings.HasSuffix(source, ".gz") {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return 0, err
+		}
+		src = gz
+	}
+
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return 0, err
+	}
+	if err := out.Close(); err != nil {
+		return 0, err
+	}
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return 0, err
+	}
+
+	if sum := hex.EncodeToString(hash.Sum(nil)); r.Config.SHA256 != "" && sum != r.Config.SHA256 {
+		return 0, fmt.Errorf("checksum %s does not match %s", sum, r.Config.SHA256)
+	}
+	height, err := PeakHeight(tmpPath)
+	if err != nil {
+		return 0, err
+	}
+	if height < r.Config.MinHeight {
+		return 0, fmt.Errorf("database height %d is below the minimum height %d", height, r.Config.MinHeight)
+	}
+
+	if err := removeDatabase(dbPath); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+// open opens a source for reading, which is an HTTP(S) URL or a path to a file
+func (r *Restorer) open(ctx context.Context, source string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.Open(source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP status %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// finish logs the termination message, and writes it to the termination message path
+func (r *Restorer) finish(message Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	r.logf("%s", data)
+	if r.TerminationMessagePath == "" {
+		return nil
+	}
+	return os.WriteFile(r.TerminationMessagePath, data, 0o644)
+}
+
+func (r *Restorer) logf(format string, args ...interface{}) {
+	if r.Log != nil {
+		fmt.Fprintf(r.Log, format+"\n", args...)
+	}
+}
+
+// removeDatabase removes the database, its write-ahead log, and the caches chia builds from it
+func removeDatabase(dbPath string) error {
+	dir := filepath.Dir(dbPath)
+	for _, path := range []string{dbPath, dbPath + "-wal", dbPath + "-shm", filepath.Join(dir, "height-to-hash"), filepath.Join(dir, "sub-epoch-summaries")} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error removing %s: %v", path, err)
+		}
+	}
+	return nil
+}
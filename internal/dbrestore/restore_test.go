@@ -0,0 +1,282 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package dbrestore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func newTestRestorer(t *testing.T, config Config) (*Restorer, string) {
+	t.Helper()
+	dir := t.TempDir()
+	config.ChiaRoot = filepath.Join(dir, "chia")
+	if config.Network == "" {
+		config.Network = "mainnet"
+	}
+	return &Restorer{
+		Config:                 config,
+		Client:                 NewHTTPClient(),
+		TerminationMessagePath: filepath.Join(dir, "termination-log"),
+	}, filepath.Join(dir, "termination-log")
+}
+
+func readMessage(t *testing.T, path string) Message {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var message Message
+	require.NoError(t, json.Unmarshal(data, &message))
+	return message
+}
+
+// writeExisting writes the fixture as the existing database
+func writeExisting(t *testing.T, r *Restorer) {
+	t.Helper()
+	dbPath := r.Config.DatabasePath()
+	require.NoError(t, os.MkdirAll(filepath.Dir(dbPath), 0o755))
+	require.NoError(t, os.WriteFile(dbPath, readTestDatabase(t), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(dbPath), "height-to-hash"), []byte("cache"), 0o644))
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		EnvChiaRoot:     "/chia-data",
+		EnvNetwork:      "testnet11",
+		EnvURLs:         "https://example.com/a.sqlite.gz\n\n https://example.com/b.sqlite \n",
+		EnvSHA256:       "ABC",
+		EnvMinHeight:    "100",
+		EnvMaxHeightLag: "0",
+		EnvMode:         ModeCheck,
+		EnvStagingRoot:  "/chia-data/.chia-db-pull",
+	}
+	config, err := ConfigFromEnv(func(name string) string { return env[name] })
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com/a.sqlite.gz", "https://example.com/b.sqlite"}, config.URLs)
+	require.Equal(t, "abc", config.SHA256)
+	require.Equal(t, uint64(100), config.MinHeight)
+	require.Equal(t, uint64(0), *config.MaxHeightLag)
+	require.Zero(t, config.PeakHeight)
+	require.Equal(t, ModeCheck, config.Mode)
+	require.Equal(t, "/chia-data/db/blockchain_v2_testnet11.sqlite", config.DatabasePath())
+	require.Equal(t, "/chia-data/.chia-db-pull/db/blockchain_v2_testnet11.sqlite", config.StagedDatabasePath())
+
+	config, err = ConfigFromEnv(func(name string) string { return map[string]string{EnvChiaRoot: "/chia-data"}[name] })
+	require.NoError(t, err)
+	require.Equal(t, "mainnet", config.Network)
+	require.Nil(t, config.MaxHeightLag)
+	require.Equal(t, ModeRestore, config.Mode)
+
+	delete(env, EnvStagingRoot)
+	_, err = ConfigFromEnv(func(name string) string { return env[name] })
+	require.ErrorContains(t, err, "STAGING_ROOT is required in check mode")
+	env[EnvStagingRoot] = "/chia-data/.chia-db-pull"
+
+	env[EnvPeakHeight] = "abc"
+	_, err = ConfigFromEnv(func(name string) string { return env[name] })
+	require.ErrorContains(t, err, "invalid PEAK_HEIGHT")
+
+	_, err = ConfigFromEnv(func(string) string { return "" })
+	require.ErrorContains(t, err, "CHIA_ROOT is required")
+}
+
+func TestIsRecent(t *testing.T) {
+	lag := uint64(100)
+	require.True(t, Config{MinHeight: 5000}.isRecent(0), "no lag keeps any database")
+	require.True(t, Config{MaxHeightLag: &lag}.isRecent(0), "no reference height keeps any database")
+	require.True(t, Config{MaxHeightLag: &lag, MinHeight: 1000}.isRecent(900))
+	require.False(t, Config{MaxHeightLag: &lag, MinHeight: 1000}.isRecent(899))
+	require.True(t, Config{MaxHeightLag: &lag, PeakHeight: 1000}.isRecent(900))
+	require.False(t, Config{MaxHeightLag: &lag, PeakHeight: 1000}.isRecent(899))
+}
+
+func TestRunFile(t *testing.T) {
+	data := readTestDatabase(t)
+	r, messagePath := newTestRestorer(t, Config{SHA256: checksum(data), MinHeight: 2000})
+	file := filepath.Join(t.TempDir(), "blockchain.sqlite")
+	require.NoError(t, os.WriteFile(file, data, 0o644))
+	r.Config.File = file
+
+	require.NoError(t, r.Run(context.Background()))
+	require.Equal(t, Message{Source: file, Height: testPeakHeight, Restored: true}, readMessage(t, messagePath))
+	restored, err := os.ReadFile(r.Config.DatabasePath())
+	require.NoError(t, err)
+	require.Equal(t, data, restored)
+	require.NoFileExists(t, r.Config.DatabasePath()+".tmp")
+}
+
+func TestRunURLs(t *testing.T) {
+	data := readTestDatabase(t)
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/blockchain.sqlite.gz" {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(gz.Bytes())
+	}))
+	defer server.Close()
+
+	// The checksum is of the compressed file, and the first URL isn't found
+	r, messagePath := newTestRestorer(t, Config{
+		URLs:   []string{server.URL + "/missing.sqlite", server.URL + "/blockchain.sqlite.gz"},
+		SHA256: checksum(gz.Bytes()),
+	})
+	require.NoError(t, r.Run(context.Background()))
+	require.Equal(t, Message{Source: server.URL + "/blockchain.sqlite.gz", Height: testPeakHeight, Restored: true}, readMessage(t, messagePath))
+}
+
+func TestRunChecksumMismatch(t *testing.T) {
+	r, _ := newTestRestorer(t, Config{SHA256: checksum([]byte("something else"))})
+	file := filepath.Join(t.TempDir(), "blockchain.sqlite")
+	require.NoError(t, os.WriteFile(file, readTestDatabase(t), 0o644))
+	r.Config.File = file
+
+	require.ErrorContains(t, r.Run(context.Background()), "unable to restore")
+	require.NoFileExists(t, r.Config.DatabasePath())
+	require.NoFileExists(t, r.Config.DatabasePath()+".tmp")
+}
+
+func TestRunBelowMinHeightKeepsExisting(t *testing.T) {
+	lag := uint64(10)
+	r, messagePath := newTestRestorer(t, Config{MinHeight: 5000, MaxHeightLag: &lag})
+	writeExisting(t, r)
+	file := filepath.Join(t.TempDir(), "blockchain.sqlite")
+	require.NoError(t, os.WriteFile(file, readTestDatabase(t), 0o644))
+	r.Config.File = file
+
+	// The source is below the minimum height, so the existing database is kept even though it's too far behind
+	require.NoError(t, r.Run(context.Background()))
+	require.Equal(t, Message{Height: testPeakHeight}, readMessage(t, messagePath))
+	require.FileExists(t, r.Config.DatabasePath())
+}
+
+func TestRunRecentExisting(t *testing.T) {
+	lag := uint64(100)
+	r, messagePath := newTestRestorer(t, Config{PeakHeight: 3050, MaxHeightLag: &lag, URLs: []string{"http://127.0.0.1:0/unused"}})
+	writeExisting(t, r)
+
+	require.NoError(t, r.Run(context.Background()))
+	require.Equal(t, Message{Height: testPeakHeight}, readMessage(t, messagePath))
+}
+
+func TestRunCorruptExisting(t *testing.T) {
+	r, messagePath := newTestRestorer(t, Config{})
+	writeExisting(t, r)
+	require.NoError(t, os.WriteFile(r.Config.DatabasePath(), []byte("corrupt"), 0o644))
+
+	// An unreadable database isn't kept, even without a height lag
+	require.ErrorContains(t, r.Run(context.Background()), "the existing database can't be read")
+	require.NoFileExists(t, messagePath)
+
+	file := filepath.Join(t.TempDir(), "blockchain.sqlite")
+	require.NoError(t, os.WriteFile(file, readTestDatabase(t), 0o644))
+	r.Config.File = file
+	require.NoError(t, r.Run(context.Background()))
+	require.Equal(t, Message{Source: file, Height: testPeakHeight, Restored: true}, readMessage(t, messagePath))
+}
+
+// newTestStagingRestorer returns a restorer for the chia-db-check and chia-db-install steps around chia-db-pull
+func newTestStagingRestorer(t *testing.T, config Config) (*Restorer, string) {
+	t.Helper()
+	r, messagePath := newTestRestorer(t, config)
+	r.Config.StagingRoot = filepath.Join(r.Config.ChiaRoot, ".chia-db-pull")
+	return r, messagePath
+}
+
+// stageDownload writes the fixture where chia-db-pull downloads the database into the staging root
+func stageDownload(t *testing.T, r *Restorer) {
+	t.Helper()
+	staged := r.Config.StagedDatabasePath()
+	require.NoError(t, os.MkdirAll(filepath.Dir(staged), 0o755))
+	require.NoError(t, os.WriteFile(staged, readTestDatabase(t), 0o644))
+}
+
+func TestRunCheckRecent(t *testing.T) {
+	lag := uint64(100)
+	r, messagePath := newTestStagingRestorer(t, Config{PeakHeight: 3050, MaxHeightLag: &lag, Mode: ModeCheck})
+	writeExisting(t, r)
+	require.NoError(t, os.MkdirAll(filepath.Join(r.Config.StagingRoot, "partial"), 0o755))
+
+	// The existing database is linked into the emptied staging root, so chia-db-pull skips downloading one
+	require.NoError(t, r.Run(context.Background()))
+	require.Equal(t, Message{Height: testPeakHeight}, readMessage(t, messagePath))
+	require.NoDirExists(t, filepath.Join(r.Config.StagingRoot, "partial"))
+	existing, err := os.Stat(r.Config.DatabasePath())
+	require.NoError(t, err)
+	staged, err := os.Stat(r.Config.StagedDatabasePath())
+	require.NoError(t, err)
+	require.True(t, os.SameFile(existing, staged))
+
+	// The skipped download leaves the existing database in place
+	r.Config.Mode = ModeInstall
+	require.NoError(t, os.Remove(messagePath))
+	require.NoError(t, r.Run(context.Background()))
+	require.FileExists(t, r.Config.DatabasePath())
+	require.NoDirExists(t, r.Config.StagingRoot)
+	require.NoFileExists(t, messagePath)
+}
+
+func TestRunCheckBehind(t *testing.T) {
+	lag := uint64(100)
+	r, messagePath := newTestStagingRestorer(t, Config{PeakHeight: 5000, MaxHeightLag: &lag, Mode: ModeCheck})
+	writeExisting(t, r)
+
+	// A database too far behind the peak is left in place until a new one is downloaded and verified
+	require.NoError(t, r.Run(context.Background()))
+	require.FileExists(t, r.Config.DatabasePath())
+	require.NoFileExists(t, r.Config.StagedDatabasePath())
+	require.NoFileExists(t, messagePath)
+
+	stageDownload(t, r)
+	staged, err := os.ReadFile(r.Config.StagedDatabasePath())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(r.Config.DatabasePath(), []byte("old"), 0o644))
+	r.Config.Mode = ModeInstall
+	require.NoError(t, r.Run(context.Background()))
+	require.Equal(t, Message{Height: testPeakHeight, Restored: true}, readMessage(t, messagePath))
+	installed, err := os.ReadFile(r.Config.DatabasePath())
+	require.NoError(t, err)
+	require.Equal(t, staged, installed)
+	require.NoFileExists(t, filepath.Join(filepath.Dir(r.Config.DatabasePath()), "height-to-hash"))
+	require.NoDirExists(t, r.Config.StagingRoot)
+}
+
+func TestRunInstallInvalidDownload(t *testing.T) {
+	r, messagePath := newTestStagingRestorer(t, Config{MinHeight: 5000, Mode: ModeInstall})
+
+	// Without an existing database, a download below the minimum height fails the init container
+	stageDownload(t, r)
+	require.ErrorContains(t, r.Run(context.Background()), "below the minimum height")
+	require.NoFileExists(t, r.Config.DatabasePath())
+
+	// A readable existing database is kept instead
+	writeExisting(t, r)
+	stageDownload(t, r)
+	require.NoError(t, r.Run(context.Background()))
+	require.Equal(t, Message{Height: testPeakHeight}, readMessage(t, messagePath))
+	require.FileExists(t, r.Config.DatabasePath())
+}
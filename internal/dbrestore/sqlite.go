@@ -0,0 +1,41 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package dbrestore
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	// Registers the pure Go "sqlite" database/sql driver, so chia-db-restore can still be built without cgo
+	_ "modernc.org/sqlite"
+)
+
+// peakHeightQuery selects the height of the highest block in the main chain, with the main_chain index Chia creates on its full_blocks table
+const peakHeightQuery = "SELECT MAX(height) FROM full_blocks WHERE in_main_chain=1"
+
+// PeakHeight returns the height of the highest block in the main chain of the Chia blockchain database at path,
+// including blocks committed to the database's write-ahead log. Returns 0 if the database doesn't contain any blocks.
+// The database is opened read-only, so it's never modified or checkpointed.
+func PeakHeight(path string) (uint64, error) {
+	dsn := url.URL{Scheme: "file", Path: path, RawQuery: "mode=ro"}
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var height sql.NullInt64
+	if err := db.QueryRow(peakHeightQuery).Scan(&height); err != nil {
+		return 0, fmt.Errorf("error querying peak height: %w", err)
+	}
+	if !height.Valid {
+		return 0, nil
+	}
+	if height.Int64 < 0 {
+		return 0, fmt.Errorf("database contains a negative peak height %d", height.Int64)
+	}
+	return uint64(height.Int64), nil
+}
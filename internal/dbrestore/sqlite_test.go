@@ -0,0 +1,123 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package dbrestore
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testPeakHeight is the peak height of the databases created by createTestDatabase
+const testPeakHeight = 2999
+
+// createTestDatabase creates a database at path with the tables and indexes of a Chia v2 blockchain database, with main chain
+// blocks at heights 0 to testPeakHeight, and an orphaned block above the peak. Returns the open database, which uses a
+// write-ahead log that isn't checkpointed, so further blocks can be committed to the log.
+func createTestDatabase(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file://"+path+"?_pragma=journal_mode(WAL)&_pragma=wal_autocheckpoint(0)")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	for _, stmt := range []string{
+		"CREATE TABLE full_blocks(header_hash blob PRIMARY KEY,prev_hash blob,height bigint,sub_epoch_summary blob," +
+			"is_fully_compactified tinyint,in_main_chain tinyint,block blob,block_record blob)",
+		"CREATE TABLE current_peak(key int PRIMARY KEY, hash blob)",
+		"CREATE INDEX height on full_blocks(height)",
+		"CREATE INDEX is_fully_compactified ON full_blocks(is_fully_compactified, in_main_chain) WHERE in_main_chain=1",
+		"CREATE INDEX main_chain ON full_blocks(height, in_main_chain) WHERE in_main_chain=1",
+	} {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+	insertTestBlocks(t, db, 0, testPeakHeight+1)
+	_, err = db.Exec("INSERT INTO full_blocks VALUES(?, ?, ?, NULL, 0, 0, ?, ?)", rand.Text(), rand.Text(), testPeakHeight+10, rand.Text(), rand.Text())
+	require.NoError(t, err)
+	_, err = db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	require.NoError(t, err)
+	return db
+}
+
+// insertTestBlocks commits main chain blocks from height start up to, but not including, end
+func insertTestBlocks(t *testing.T, db *sql.DB, start, end int) {
+	t.Helper()
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	for height := start; height < end; height++ {
+		_, err := tx.Exec("INSERT INTO full_blocks VALUES(?, ?, ?, NULL, 0, 1, ?, ?)", rand.Text(), rand.Text(), height, rand.Text(), rand.Text())
+		require.NoError(t, err)
+	}
+	require.NoError(t, tx.Commit())
+}
+
+// readTestDatabase returns the contents of a checkpointed test database
+func readTestDatabase(t *testing.T) []byte {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "blockchain.sqlite")
+	require.NoError(t, createTestDatabase(t, path).Close())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return data
+}
+
+func TestPeakHeight(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blockchain.sqlite")
+	db := createTestDatabase(t, path)
+
+	// The orphaned block above the peak isn't in the main chain
+	height, err := PeakHeight(path)
+	require.NoError(t, err)
+	require.Equal(t, uint64(testPeakHeight), height)
+
+	// Blocks committed to the write-ahead log are read
+	insertTestBlocks(t, db, testPeakHeight+1, testPeakHeight+201)
+	height, err = PeakHeight(path)
+	require.NoError(t, err)
+	require.Equal(t, uint64(testPeakHeight+200), height)
+	_, err = os.Stat(path + "-wal")
+	require.NoError(t, err)
+}
+
+func TestPeakHeightEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blockchain.sqlite")
+	db := createTestDatabase(t, path)
+	_, err := db.Exec("DELETE FROM full_blocks")
+	require.NoError(t, err)
+
+	height, err := PeakHeight(path)
+	require.NoError(t, err)
+	require.Zero(t, height)
+}
+
+func TestPeakHeightInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	// A missing database isn't created
+	missing := filepath.Join(dir, "missing.sqlite")
+	_, err := PeakHeight(missing)
+	require.Error(t, err)
+	require.NoFileExists(t, missing)
+
+	garbage := filepath.Join(dir, "garbage.sqlite")
+	require.NoError(t, os.WriteFile(garbage, []byte("this is not a database"), 0o644))
+	_, err = PeakHeight(garbage)
+	require.Error(t, err)
+
+	// A database without Chia's tables isn't a blockchain database
+	other := filepath.Join(dir, "other.sqlite")
+	db, err := sql.Open("sqlite", "file://"+other)
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE peers(node_id text)")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	_, err = PeakHeight(other)
+	require.Error(t, err)
+}
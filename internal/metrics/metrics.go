@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
@@ -38,6 +40,14 @@ var (
 		},
 	)
 
+	// ChiaFarms is a gauge metric that keeps a running total of deployed ChiaFarms
+	ChiaFarms = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "chia_operator_chiafarm_total",
+			Help: "Number of ChiaFarm objects controlled by this operator",
+		},
+	)
+
 	// ChiaFarmers is a gauge metric that keeps a running total of deployed ChiaFarmers
 	ChiaFarmers = prometheus.NewGauge(
 		prometheus.GaugeOpts{
@@ -101,6 +111,43 @@ var (
 			Help: "Number of ChiaWallet objects controlled by this operator",
 		},
 	)
+
+	// ReconcileDuration is a histogram metric of how long each reconcile took, by the kind of custom resource reconciled
+	ReconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "chia_operator_reconcile_duration_seconds",
+			Help:    "Duration of reconciles of objects controlled by this operator",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"kind"},
+	)
+
+	// ReconcileErrors is a counter metric of reconciles that returned an error, by the kind of custom resource reconciled and the reason it failed
+	ReconcileErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chia_operator_reconcile_errors_total",
+			Help: "Number of reconciles of objects controlled by this operator that returned an error",
+		},
+		[]string{"kind", "reason"},
+	)
+
+	// ReadyConditions is a gauge metric that keeps a running total of custom resources by the status of their Ready condition
+	ReadyConditions = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "chia_operator_ready_condition",
+			Help: "Number of objects controlled by this operator by the status of their Ready condition",
+		},
+		[]string{"kind", "status"},
+	)
+
+	// WorkloadRecreations is a counter metric of workloads that were deleted and recreated because their selector labels changed
+	WorkloadRecreations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chia_operator_workload_recreations_total",
+			Help: "Number of Deployments, StatefulSets, and DaemonSets recreated by this operator because their selector labels changed",
+		},
+		[]string{"kind"},
+	)
 )
 
 func init() {
@@ -109,12 +156,27 @@ func init() {
 		ChiaCertificates,
 		ChiaCrawlers,
 		ChiaDataLayers,
+		ChiaFarms,
 		ChiaFarmers,
 		ChiaHarvesters,
 		ChiaIntroducers,
 		ChiaNodes,
 		ChiaNetworks,
+		ChiaSeeders,
 		ChiaTimelords,
 		ChiaWallets,
+		ReconcileDuration,
+		ReconcileErrors,
+		ReadyConditions,
+		WorkloadRecreations,
 	)
 }
+
+// ObserveReconcile records the duration of a reconcile that started at start.
+// If the reconcile failed, reason is the reason it failed, and the reconcile is also counted in ReconcileErrors.
+func ObserveReconcile(kind string, start time.Time, reason string) {
+	ReconcileDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+	if reason != "" {
+		ReconcileErrors.WithLabelValues(kind, reason).Inc()
+	}
+}
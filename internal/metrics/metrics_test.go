@@ -1,9 +1,13 @@
 package metrics
 
 import (
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -14,6 +18,7 @@ func TestMetricsRegistration(t *testing.T) {
 		ChiaCertificates,
 		ChiaCrawlers,
 		ChiaDataLayers,
+		ChiaFarms,
 		ChiaFarmers,
 		ChiaHarvesters,
 		ChiaIntroducers,
@@ -22,6 +27,10 @@ func TestMetricsRegistration(t *testing.T) {
 		ChiaSeeders,
 		ChiaTimelords,
 		ChiaWallets,
+		ReconcileDuration,
+		ReconcileErrors,
+		ReadyConditions,
+		WorkloadRecreations,
 	}
 
 	for _, metric := range metrics {
@@ -40,6 +49,7 @@ func TestGaugeOperations(t *testing.T) {
 		{"ChiaCertificates", ChiaCertificates, 10},
 		{"ChiaCrawlers", ChiaCrawlers, 3},
 		{"ChiaDataLayers", ChiaDataLayers, 2},
+		{"ChiaFarms", ChiaFarms, 1},
 		{"ChiaFarmers", ChiaFarmers, 4},
 		{"ChiaHarvesters", ChiaHarvesters, 6},
 		{"ChiaIntroducers", ChiaIntroducers, 1},
@@ -59,3 +69,70 @@ func TestGaugeOperations(t *testing.T) {
 		})
 	}
 }
+
+func TestObserveReconcile(t *testing.T) {
+	ObserveReconcile("ChiaNode", time.Now(), "")
+	assert.Equal(t, 1, testutil.CollectAndCount(ReconcileDuration))
+	assert.Equal(t, float64(0), testutil.ToFloat64(ReconcileErrors.WithLabelValues("ChiaNode", "ChiaNetworkNotFound")))
+
+	ObserveReconcile("ChiaNode", time.Now(), "ChiaNetworkNotFound")
+	assert.Equal(t, float64(1), testutil.ToFloat64(ReconcileErrors.WithLabelValues("ChiaNode", "ChiaNetworkNotFound")))
+}
+
+func newTestRegistry() (*ResourceRegistry, prometheus.Gauge, *prometheus.GaugeVec) {
+	total := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_total"})
+	ready := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_ready_condition"}, []string{"kind", "status"})
+	return NewResourceRegistry(map[string]prometheus.Gauge{"ChiaFarmer": total}, ready), total, ready
+}
+
+func TestResourceRegistry(t *testing.T) {
+	registry, total, ready := newTestRegistry()
+
+	registry.Add("ChiaFarmer", "default/farmer-1")
+	registry.Add("ChiaFarmer", "default/farmer-1")
+	registry.SetReadyCondition("ChiaFarmer", "default/farmer-2", "False")
+	assert.Equal(t, 2, registry.Count("ChiaFarmer"))
+	assert.Equal(t, float64(2), testutil.ToFloat64(total))
+	assert.Equal(t, float64(1), testutil.ToFloat64(ready.WithLabelValues("ChiaFarmer", "False")))
+
+	// Setting the same status again doesn't count the resource twice
+	registry.SetReadyCondition("ChiaFarmer", "default/farmer-1", "False")
+	registry.SetReadyCondition("ChiaFarmer", "default/farmer-1", "False")
+	assert.Equal(t, float64(2), testutil.ToFloat64(ready.WithLabelValues("ChiaFarmer", "False")))
+
+	registry.SetReadyCondition("ChiaFarmer", "default/farmer-1", "True")
+	assert.Equal(t, float64(1), testutil.ToFloat64(ready.WithLabelValues("ChiaFarmer", "False")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(ready.WithLabelValues("ChiaFarmer", "True")))
+
+	registry.Delete("ChiaFarmer", "default/farmer-1")
+	registry.Delete("ChiaFarmer", "default/farmer-1")
+	assert.Equal(t, 1, registry.Count("ChiaFarmer"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(total))
+	assert.Equal(t, float64(0), testutil.ToFloat64(ready.WithLabelValues("ChiaFarmer", "True")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(ready.WithLabelValues("ChiaFarmer", "False")))
+
+	// Kinds without a total gauge are still tracked
+	registry.SetReadyCondition("ChiaWallet", "default/wallet", "True")
+	assert.Equal(t, 1, registry.Count("ChiaWallet"))
+}
+
+func TestResourceRegistryConcurrency(t *testing.T) {
+	registry, total, ready := newTestRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("default/farmer-%d", i%10)
+			registry.Add("ChiaFarmer", name)
+			registry.SetReadyCondition("ChiaFarmer", name, "False")
+			registry.SetReadyCondition("ChiaFarmer", name, "True")
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, registry.Count("ChiaFarmer"))
+	assert.Equal(t, float64(10), testutil.ToFloat64(total))
+	assert.Equal(t, float64(10), testutil.ToFloat64(ready.WithLabelValues("ChiaFarmer", "True"))+testutil.ToFloat64(ready.WithLabelValues("ChiaFarmer", "False")))
+}
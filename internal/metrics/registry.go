@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// Resources keeps track of the custom resources controlled by this operator for the per-kind total and Ready condition gauges
+var Resources = NewResourceRegistry(map[string]prometheus.Gauge{
+	string(consts.ChiaCAKind):           ChiaCAs,
+	string(consts.ChiaCertificatesKind): ChiaCertificates,
+	string(consts.ChiaCrawlerKind):      ChiaCrawlers,
+	string(consts.ChiaDataLayerKind):    ChiaDataLayers,
+	string(consts.ChiaFarmKind):         ChiaFarms,
+	string(consts.ChiaFarmerKind):       ChiaFarmers,
+	string(consts.ChiaHarvesterKind):    ChiaHarvesters,
+	string(consts.ChiaIntroducerKind):   ChiaIntroducers,
+	string(consts.ChiaNodeKind):         ChiaNodes,
+	string(consts.ChiaNetworkKind):      ChiaNetworks,
+	string(consts.ChiaSeederKind):       ChiaSeeders,
+	string(consts.ChiaTimelordKind):     ChiaTimelords,
+	string(consts.ChiaWalletKind):       ChiaWallets,
+}, ReadyConditions)
+
+// ResourceRegistry keeps track of the custom resources controlled by this operator, and the status of their Ready conditions,
+// and keeps the gauges that count them in sync. It's safe to use from concurrent reconciles.
+//
+// The registry starts out empty, and every existing custom resource is added to it when the operator reconciles it after it starts,
+// or after it becomes the leader, so the gauges don't carry counts over from a previous operator.
+type ResourceRegistry struct {
+	mu sync.Mutex
+
+	// totals maps each kind of custom resource to the gauge that counts them
+	totals map[string]prometheus.Gauge
+
+	// ready counts custom resources by their kind and the status of their Ready condition
+	ready *prometheus.GaugeVec
+
+	// resources maps each kind of custom resource to the namespaced names of the resources of that kind,
+	// and the status of their Ready conditions. The status is empty until it's recorded.
+	resources map[string]map[string]string
+}
+
+// NewResourceRegistry returns a ResourceRegistry that counts custom resources in the given per-kind total gauges,
+// and a gauge with kind and status labels for their Ready conditions
+func NewResourceRegistry(totals map[string]prometheus.Gauge, ready *prometheus.GaugeVec) *ResourceRegistry {
+	return &ResourceRegistry{
+		totals:    totals,
+		ready:     ready,
+		resources: make(map[string]map[string]string),
+	}
+}
+
+// Add records that a custom resource exists, if it wasn't already recorded
+func (r *ResourceRegistry) Add(kind, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(kind, name)
+}
+
+// SetReadyCondition records the status of a custom resource's Ready condition, and adds the resource if it wasn't already recorded
+func (r *ResourceRegistry) SetReadyCondition(kind, name, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(kind, name)
+
+	current := r.resources[kind][name]
+	if current == status {
+		return
+	}
+	if current != "" {
+		r.ready.WithLabelValues(kind, current).Dec()
+	}
+	r.resources[kind][name] = status
+	r.ready.WithLabelValues(kind, status).Inc()
+}
+
+// Delete removes a deleted custom resource from the registry
+func (r *ResourceRegistry) Delete(kind, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status, exists := r.resources[kind][name]
+	if !exists {
+		return
+	}
+	if status != "" {
+		r.ready.WithLabelValues(kind, status).Dec()
+	}
+	delete(r.resources[kind], name)
+	r.setTotal(kind)
+}
+
+// Count returns the number of custom resources of a kind in the registry
+func (r *ResourceRegistry) Count(kind string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.resources[kind])
+}
+
+// add records that a custom resource exists. The caller must hold the registry's lock.
+func (r *ResourceRegistry) add(kind, name string) {
+	if r.resources[kind] == nil {
+		r.resources[kind] = make(map[string]string)
+	}
+	if _, exists := r.resources[kind][name]; exists {
+		return
+	}
+	r.resources[kind][name] = ""
+	r.setTotal(kind)
+}
+
+// setTotal sets a kind's total gauge to the number of resources of that kind in the registry.
+// The caller must hold the registry's lock.
+func (r *ResourceRegistry) setTotal(kind string) {
+	if gauge, ok := r.totals[kind]; ok {
+		gauge.Set(float64(len(r.resources[kind])))
+	}
+}
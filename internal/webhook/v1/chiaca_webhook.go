@@ -0,0 +1,129 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// SetupChiaCAWebhookWithManager registers the ChiaCA defaulting and validating webhooks with the manager
+func SetupChiaCAWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaCA{}).
+		WithDefaulter(&ChiaCACustomDefaulter{}).
+		WithValidator(&ChiaCACustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chiaca,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiacas,verbs=create;update,versions=v1,name=mchiaca-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaCACustomDefaulter sets the defaults the operator would otherwise use for a ChiaCA
+type ChiaCACustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaCACustomDefaulter) Default(_ context.Context, ca *k8schianetv1.ChiaCA) error {
+	// The CA Secret is named after the ChiaCA if unset. The name may not be set yet if the ChiaCA uses generateName.
+	if strings.TrimSpace(ca.Spec.Secret) == "" && ca.Name != "" {
+		ca.Spec.Secret = ca.Name
+	}
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chiaca,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiacas,verbs=create;update,versions=v1,name=vchiaca-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaCACustomValidator validates ChiaCA specs
+type ChiaCACustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaCACustomValidator) ValidateCreate(_ context.Context, ca *k8schianetv1.ChiaCA) (admission.Warnings, error) {
+	return nil, validateChiaCA(ca)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaCACustomValidator) ValidateUpdate(_ context.Context, _, ca *k8schianetv1.ChiaCA) (admission.Warnings, error) {
+	if ca.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaCA(ca)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaCACustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaCA) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaCA(ca *k8schianetv1.ChiaCA) error {
+	spec := field.NewPath("spec")
+
+	var errs field.ErrorList
+	if strings.TrimSpace(ca.Spec.Secret) != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(ca.Spec.Secret) {
+			errs = append(errs, field.Invalid(spec.Child("secret"), ca.Spec.Secret, msg))
+		}
+	}
+
+	if ca.Spec.Import != nil {
+		importPath := spec.Child("import")
+		secretName := ca.Spec.Import.SecretName
+		configMapName := ca.Spec.Import.ConfigMapName
+		switch {
+		case secretName == "" && configMapName == "":
+			errs = append(errs, field.Required(importPath, "one of secretName or configMapName is required"))
+		case secretName != "" && configMapName != "":
+			errs = append(errs, field.Forbidden(importPath.Child("configMapName"), "secretName and configMapName are mutually exclusive"))
+		}
+		if secretName != "" {
+			for _, msg := range validation.IsDNS1123Subdomain(secretName) {
+				errs = append(errs, field.Invalid(importPath.Child("secretName"), secretName, msg))
+			}
+			caSecretName := ca.Spec.Secret
+			if strings.TrimSpace(caSecretName) == "" {
+				caSecretName = ca.Name
+			}
+			if secretName == caSecretName {
+				errs = append(errs, field.Invalid(importPath.Child("secretName"), secretName, "must not be the CA Secret the operator manages"))
+			}
+		}
+		if configMapName != "" {
+			for _, msg := range validation.IsDNS1123Subdomain(configMapName) {
+				errs = append(errs, field.Invalid(importPath.Child("configMapName"), configMapName, msg))
+			}
+		}
+		if ca.Spec.Rotation != nil {
+			errs = append(errs, field.Forbidden(spec.Child("rotation"), "rotation is not supported for imported CAs, update the import source instead"))
+		}
+	}
+
+	if ca.Spec.CertManager != nil {
+		certManagerPath := spec.Child("certManager")
+		errs = append(errs, validateCertManagerIssuerRef(ca.Spec.CertManager.IssuerRef, certManagerPath.Child("issuerRef"))...)
+		if ca.Spec.Import != nil {
+			errs = append(errs, field.Forbidden(spec.Child("import"), "import and certManager are mutually exclusive"))
+		}
+		if ca.Spec.Rotation != nil {
+			errs = append(errs, field.Forbidden(spec.Child("rotation"), "rotation is not supported when cert-manager issues the CA, cert-manager renews it instead"))
+		}
+		if ca.Name != "" && strings.TrimSpace(ca.Spec.Secret) == ca.Name+"-private-ca" {
+			errs = append(errs, field.Invalid(spec.Child("secret"), ca.Spec.Secret, "must not be the Secret cert-manager stores the private CA in"))
+		}
+	}
+
+	if ca.Spec.Rotation != nil && ca.Spec.Rotation.RenewBefore != nil && ca.Spec.Rotation.RenewBefore.Duration <= 0 {
+		errs = append(errs, field.Invalid(spec.Child("rotation", "renewBefore"), ca.Spec.Rotation.RenewBefore.Duration.String(), "must be greater than 0"))
+	}
+	if ca.Spec.OverlapPeriod != nil && ca.Spec.OverlapPeriod.Duration < 0 {
+		errs = append(errs, field.Invalid(spec.Child("overlapPeriod"), ca.Spec.OverlapPeriod.Duration.String(), "must not be negative"))
+	}
+
+	return invalidError(consts.ChiaCAKind, ca.Name, errs)
+}
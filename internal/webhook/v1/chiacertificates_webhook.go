@@ -0,0 +1,93 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// SetupChiaCertificatesWebhookWithManager registers the ChiaCertificates defaulting and validating webhooks with the manager
+func SetupChiaCertificatesWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaCertificates{}).
+		WithDefaulter(&ChiaCertificatesCustomDefaulter{}).
+		WithValidator(&ChiaCertificatesCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chiacertificates,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiacertificates,verbs=create;update,versions=v1,name=mchiacertificates-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaCertificatesCustomDefaulter sets the defaults the operator would otherwise use for a ChiaCertificates
+type ChiaCertificatesCustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaCertificatesCustomDefaulter) Default(_ context.Context, cr *k8schianetv1.ChiaCertificates) error {
+	// The certificate Secret is named after the ChiaCertificates if unset. The name may not be set yet if the ChiaCertificates uses generateName.
+	if strings.TrimSpace(cr.Spec.Secret) == "" && cr.Name != "" {
+		cr.Spec.Secret = cr.Name
+	}
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chiacertificates,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiacertificates,verbs=create;update,versions=v1,name=vchiacertificates-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaCertificatesCustomValidator validates ChiaCertificates specs
+type ChiaCertificatesCustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaCertificatesCustomValidator) ValidateCreate(_ context.Context, cr *k8schianetv1.ChiaCertificates) (admission.Warnings, error) {
+	return nil, validateChiaCertificates(cr)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaCertificatesCustomValidator) ValidateUpdate(_ context.Context, _, cr *k8schianetv1.ChiaCertificates) (admission.Warnings, error) {
+	if cr.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaCertificates(cr)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaCertificatesCustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaCertificates) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaCertificates(cr *k8schianetv1.ChiaCertificates) error {
+	spec := field.NewPath("spec")
+
+	var errs field.ErrorList
+	if strings.TrimSpace(cr.Spec.Secret) != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(cr.Spec.Secret) {
+			errs = append(errs, field.Invalid(spec.Child("secret"), cr.Spec.Secret, msg))
+		}
+	}
+	if strings.TrimSpace(cr.Spec.CASecretName) == "" {
+		errs = append(errs, field.Required(spec.Child("caSecretName"), "the name of a CA Secret is required"))
+	}
+
+	secretName := cr.Name
+	if strings.TrimSpace(cr.Spec.Secret) != "" {
+		secretName = cr.Spec.Secret
+	}
+	if secretName == cr.Spec.CASecretName {
+		errs = append(errs, field.Invalid(spec.Child("secret"), secretName, "certificate Secret cannot be the same name as the CA Secret"))
+	}
+
+	if cr.Spec.CertManager != nil {
+		certManagerPath := spec.Child("certManager")
+		errs = append(errs, validateCertManagerIssuerRef(cr.Spec.CertManager.PrivateIssuerRef, certManagerPath.Child("privateIssuerRef"))...)
+		errs = append(errs, validateCertManagerIssuerRef(cr.Spec.CertManager.PublicIssuerRef, certManagerPath.Child("publicIssuerRef"))...)
+	}
+
+	return invalidError(consts.ChiaCertificatesKind, cr.Name, errs)
+}
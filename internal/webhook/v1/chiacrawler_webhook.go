@@ -0,0 +1,73 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// SetupChiaCrawlerWebhookWithManager registers the ChiaCrawler defaulting and validating webhooks with the manager
+func SetupChiaCrawlerWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaCrawler{}).
+		WithDefaulter(&ChiaCrawlerCustomDefaulter{}).
+		WithValidator(&ChiaCrawlerCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chiacrawler,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiacrawlers,verbs=create;update,versions=v1,name=mchiacrawler-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaCrawlerCustomDefaulter sets the defaults the operator would otherwise use for a ChiaCrawler
+type ChiaCrawlerCustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaCrawlerCustomDefaulter) Default(_ context.Context, crawler *k8schianetv1.ChiaCrawler) error {
+	defaultChiaImage(&crawler.Spec.ChiaConfig.CommonSpecChia)
+	defaultService(&crawler.Spec.ChiaConfig.PeerService, true)
+	defaultService(&crawler.Spec.ChiaConfig.AllService, true)
+	defaultService(&crawler.Spec.ChiaConfig.DaemonService, true)
+	defaultService(&crawler.Spec.ChiaConfig.RPCService, true)
+	defaultChiaExporter(&crawler.Spec.ChiaExporterConfig)
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chiacrawler,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiacrawlers,verbs=create;update,versions=v1,name=vchiacrawler-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaCrawlerCustomValidator validates ChiaCrawler specs
+type ChiaCrawlerCustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaCrawlerCustomValidator) ValidateCreate(_ context.Context, crawler *k8schianetv1.ChiaCrawler) (admission.Warnings, error) {
+	return nil, validateChiaCrawler(crawler)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaCrawlerCustomValidator) ValidateUpdate(_ context.Context, _, crawler *k8schianetv1.ChiaCrawler) (admission.Warnings, error) {
+	if crawler.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaCrawler(crawler)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaCrawlerCustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaCrawler) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaCrawler(crawler *k8schianetv1.ChiaCrawler) error {
+	spec := field.NewPath("spec")
+	chia := spec.Child("chia")
+
+	errs := validateCommonSpec(crawler.Spec.CommonSpec, spec)
+	errs = append(errs, validateCommonSpecChia(crawler.Spec.ChiaConfig.CommonSpecChia, chia)...)
+
+	return invalidError(consts.ChiaCrawlerKind, crawler.Name, errs)
+}
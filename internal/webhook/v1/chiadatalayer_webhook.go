@@ -0,0 +1,77 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// SetupChiaDataLayerWebhookWithManager registers the ChiaDataLayer defaulting and validating webhooks with the manager
+func SetupChiaDataLayerWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaDataLayer{}).
+		WithDefaulter(&ChiaDataLayerCustomDefaulter{}).
+		WithValidator(&ChiaDataLayerCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chiadatalayer,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiadatalayers,verbs=create;update,versions=v1,name=mchiadatalayer-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaDataLayerCustomDefaulter sets the defaults the operator would otherwise use for a ChiaDataLayer
+type ChiaDataLayerCustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaDataLayerCustomDefaulter) Default(_ context.Context, datalayer *k8schianetv1.ChiaDataLayer) error {
+	defaultChiaImage(&datalayer.Spec.ChiaConfig.CommonSpecChia)
+	defaultService(&datalayer.Spec.ChiaConfig.DaemonService, true)
+	defaultService(&datalayer.Spec.ChiaConfig.RPCService, true)
+	if datalayer.Spec.FileserverConfig.Enabled != nil && *datalayer.Spec.FileserverConfig.Enabled {
+		defaultService(&datalayer.Spec.FileserverConfig.Service, true)
+	}
+	defaultChiaExporter(&datalayer.Spec.ChiaExporterConfig)
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chiadatalayer,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiadatalayers,verbs=create;update,versions=v1,name=vchiadatalayer-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaDataLayerCustomValidator validates ChiaDataLayer specs
+type ChiaDataLayerCustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaDataLayerCustomValidator) ValidateCreate(_ context.Context, datalayer *k8schianetv1.ChiaDataLayer) (admission.Warnings, error) {
+	return nil, validateChiaDataLayer(datalayer)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaDataLayerCustomValidator) ValidateUpdate(_ context.Context, _, datalayer *k8schianetv1.ChiaDataLayer) (admission.Warnings, error) {
+	if datalayer.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaDataLayer(datalayer)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaDataLayerCustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaDataLayer) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaDataLayer(datalayer *k8schianetv1.ChiaDataLayer) error {
+	spec := field.NewPath("spec")
+	chia := spec.Child("chia")
+
+	errs := validateCommonSpec(datalayer.Spec.CommonSpec, spec)
+	errs = append(errs, validateCommonSpecChia(datalayer.Spec.ChiaConfig.CommonSpecChia, chia)...)
+	errs = append(errs, validateFullNodePeers(nil, datalayer.Spec.ChiaConfig.FullNodePeers, chia)...)
+	errs = append(errs, validateTrustedCIDRs(datalayer.Spec.ChiaConfig.TrustedCIDRs, chia.Child("trustedCIDRs"))...)
+	errs = append(errs, validateSecretKey(datalayer.Spec.ChiaConfig.SecretKey, chia.Child("secretKey"))...)
+
+	return invalidError(consts.ChiaDataLayerKind, datalayer.Name, errs)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/chiaconfig"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// SetupChiaFarmWebhookWithManager registers the ChiaFarm defaulting and validating webhooks with the manager
+func SetupChiaFarmWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaFarm{}).
+		WithDefaulter(&ChiaFarmCustomDefaulter{}).
+		WithValidator(&ChiaFarmCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chiafarm,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiafarms,verbs=create;update,versions=v1,name=mchiafarm-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaFarmCustomDefaulter sets the defaults the operator would otherwise use for a ChiaFarm.
+// The farm's components are defaulted by their own webhooks when the operator creates them.
+type ChiaFarmCustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaFarmCustomDefaulter) Default(_ context.Context, farm *k8schianetv1.ChiaFarm) error {
+	defaultImage(&farm.Spec.ChiaConfig.Image, consts.DefaultChiaImageName, consts.DefaultChiaImageTag)
+	defaultBool(&farm.Spec.Wallet.Enabled, true)
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chiafarm,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiafarms,verbs=create;update,versions=v1,name=vchiafarm-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaFarmCustomValidator validates ChiaFarm specs
+type ChiaFarmCustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaFarmCustomValidator) ValidateCreate(_ context.Context, farm *k8schianetv1.ChiaFarm) (admission.Warnings, error) {
+	return nil, validateChiaFarm(farm)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaFarmCustomValidator) ValidateUpdate(_ context.Context, _, farm *k8schianetv1.ChiaFarm) (admission.Warnings, error) {
+	if farm.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaFarm(farm)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaFarmCustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaFarm) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaFarm(farm *k8schianetv1.ChiaFarm) error {
+	spec := field.NewPath("spec")
+	chia := spec.Child("chia")
+
+	errs := validateSecretKey(farm.Spec.ChiaConfig.SecretKey, chia.Child("secretKey"))
+	if strings.TrimSpace(farm.Spec.ChiaConfig.CASecretName) != "" && farm.Spec.CA != nil {
+		errs = append(errs, field.Forbidden(spec.Child("ca"), "ca and chia.caSecretName are mutually exclusive"))
+	}
+
+	errs = append(errs, validateChiaFarmComponent(farm.Spec.Node.ChiaFarmComponentSpec, spec.Child("node"))...)
+	errs = append(errs, validateChiaFarmComponent(farm.Spec.Farmer.ChiaFarmComponentSpec, spec.Child("farmer"))...)
+	errs = append(errs, validateChiaFarmComponent(farm.Spec.Wallet.ChiaFarmComponentSpec, spec.Child("wallet"))...)
+	for i, h := range farm.Spec.Harvesters {
+		path := spec.Child("harvesters").Index(i)
+		errs = append(errs, validateChiaFarmComponent(h.ChiaFarmComponentSpec, path)...)
+
+		// Harvester names are appended to the farm's name to name each ChiaHarvester
+		for _, msg := range validation.IsDNS1123Label(h.Name) {
+			errs = append(errs, field.Invalid(path.Child("name"), h.Name, msg))
+		}
+		if farm.Name != "" {
+			name := fmt.Sprintf("%s-%s", farm.Name, h.Name)
+			for _, msg := range validation.IsDNS1123Subdomain(name) {
+				errs = append(errs, field.Invalid(path.Child("name"), h.Name, fmt.Sprintf("ChiaHarvester name %q is invalid: %s", name, msg)))
+			}
+		}
+	}
+
+	return invalidError(consts.ChiaFarmKind, farm.Name, errs)
+}
+
+// validateChiaFarmComponent validates the configuration of one of a ChiaFarm's components
+func validateChiaFarmComponent(component k8schianetv1.ChiaFarmComponentSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if component.Storage != nil {
+		errs = append(errs, validateStorageConfig(*component.Storage, path.Child("storage"))...)
+	}
+	if err := chiaconfig.Validate(component.ConfigOverrides); err != nil {
+		errs = append(errs, field.Invalid(path.Child("configOverrides"), string(component.ConfigOverrides.Raw), err.Error()))
+	}
+	return errs
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// SetupChiaFarmerWebhookWithManager registers the ChiaFarmer defaulting and validating webhooks with the manager
+func SetupChiaFarmerWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaFarmer{}).
+		WithDefaulter(&ChiaFarmerCustomDefaulter{}).
+		WithValidator(&ChiaFarmerCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chiafarmer,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiafarmers,verbs=create;update,versions=v1,name=mchiafarmer-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaFarmerCustomDefaulter sets the defaults the operator would otherwise use for a ChiaFarmer
+type ChiaFarmerCustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaFarmerCustomDefaulter) Default(_ context.Context, farmer *k8schianetv1.ChiaFarmer) error {
+	defaultChiaImage(&farmer.Spec.ChiaConfig.CommonSpecChia)
+	defaultService(&farmer.Spec.ChiaConfig.PeerService, true)
+	defaultService(&farmer.Spec.ChiaConfig.AllService, true)
+	defaultService(&farmer.Spec.ChiaConfig.DaemonService, true)
+	defaultService(&farmer.Spec.ChiaConfig.RPCService, true)
+	defaultChiaExporter(&farmer.Spec.ChiaExporterConfig)
+	defaultChiaHealthcheck(&farmer.Spec.ChiaHealthcheckConfig)
+	defaultHealthcheckProbes(&farmer.Spec.ChiaConfig.CommonSpecChia, kube.ChiaHealthcheckEnabled(farmer.Spec.ChiaHealthcheckConfig), healthcheckProbes{
+		liveness:  "/farmer",
+		readiness: "/farmer",
+		startup:   "/farmer",
+	})
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chiafarmer,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiafarmers,verbs=create;update,versions=v1,name=vchiafarmer-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaFarmerCustomValidator validates ChiaFarmer specs
+type ChiaFarmerCustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaFarmerCustomValidator) ValidateCreate(_ context.Context, farmer *k8schianetv1.ChiaFarmer) (admission.Warnings, error) {
+	return nil, validateChiaFarmer(farmer)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaFarmerCustomValidator) ValidateUpdate(_ context.Context, _, farmer *k8schianetv1.ChiaFarmer) (admission.Warnings, error) {
+	if farmer.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaFarmer(farmer)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaFarmerCustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaFarmer) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaFarmer(farmer *k8schianetv1.ChiaFarmer) error {
+	spec := field.NewPath("spec")
+	chia := spec.Child("chia")
+
+	errs := validateCommonSpec(farmer.Spec.CommonSpec, spec)
+	errs = append(errs, validateCommonSpecChia(farmer.Spec.ChiaConfig.CommonSpecChia, chia)...)
+	errs = append(errs, validateFullNodePeers(farmer.Spec.ChiaConfig.FullNodePeer, farmer.Spec.ChiaConfig.FullNodePeers, chia)...)
+	errs = append(errs, validateSecretKey(farmer.Spec.ChiaConfig.SecretKey, chia.Child("secretKey"))...)
+
+	return invalidError(consts.ChiaFarmerKind, farmer.Name, errs)
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// SetupChiaHarvesterWebhookWithManager registers the ChiaHarvester defaulting and validating webhooks with the manager
+func SetupChiaHarvesterWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaHarvester{}).
+		WithDefaulter(&ChiaHarvesterCustomDefaulter{}).
+		WithValidator(&ChiaHarvesterCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chiaharvester,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiaharvesters,verbs=create;update,versions=v1,name=mchiaharvester-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaHarvesterCustomDefaulter sets the defaults the operator would otherwise use for a ChiaHarvester
+type ChiaHarvesterCustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaHarvesterCustomDefaulter) Default(_ context.Context, harvester *k8schianetv1.ChiaHarvester) error {
+	defaultChiaImage(&harvester.Spec.ChiaConfig.CommonSpecChia)
+	defaultService(&harvester.Spec.ChiaConfig.PeerService, true)
+	defaultService(&harvester.Spec.ChiaConfig.AllService, true)
+	defaultService(&harvester.Spec.ChiaConfig.DaemonService, true)
+	defaultService(&harvester.Spec.ChiaConfig.RPCService, true)
+	defaultChiaExporter(&harvester.Spec.ChiaExporterConfig)
+	defaultChiaHealthcheck(&harvester.Spec.ChiaHealthcheckConfig)
+	defaultHealthcheckProbes(&harvester.Spec.ChiaConfig.CommonSpecChia, kube.ChiaHealthcheckEnabled(harvester.Spec.ChiaHealthcheckConfig), healthcheckProbes{
+		liveness:  "/harvester",
+		readiness: "/harvester",
+		startup:   "/harvester",
+	})
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chiaharvester,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiaharvesters,verbs=create;update,versions=v1,name=vchiaharvester-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaHarvesterCustomValidator validates ChiaHarvester specs
+type ChiaHarvesterCustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaHarvesterCustomValidator) ValidateCreate(_ context.Context, harvester *k8schianetv1.ChiaHarvester) (admission.Warnings, error) {
+	return nil, validateChiaHarvester(harvester)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaHarvesterCustomValidator) ValidateUpdate(_ context.Context, _, harvester *k8schianetv1.ChiaHarvester) (admission.Warnings, error) {
+	if harvester.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaHarvester(harvester)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaHarvesterCustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaHarvester) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaHarvester(harvester *k8schianetv1.ChiaHarvester) error {
+	spec := field.NewPath("spec")
+	chia := spec.Child("chia")
+
+	errs := validateCommonSpec(harvester.Spec.CommonSpec, spec)
+	errs = append(errs, validateCommonSpecChia(harvester.Spec.ChiaConfig.CommonSpecChia, chia)...)
+	if strings.TrimSpace(harvester.Spec.ChiaConfig.FarmerAddress) == "" {
+		errs = append(errs, field.Required(chia.Child("farmerAddress"), "the address of a farmer is required"))
+	}
+	if harvester.Spec.Storage != nil && harvester.Spec.Storage.Plots != nil {
+		errs = append(errs, validateChiaHarvesterPlots(*harvester.Spec.Storage.Plots, spec.Child("storage", "plots"))...)
+	}
+	if harvester.Spec.DaemonSet != nil && harvester.Spec.DaemonSet.Enabled != nil && *harvester.Spec.DaemonSet.Enabled {
+		errs = append(errs, validateChiaHarvesterDaemonSet(harvester.Spec, spec)...)
+	}
+
+	return invalidError(consts.ChiaHarvesterKind, harvester.Name, errs)
+}
+
+// validateChiaHarvesterPlots validates the configuration of a harvester's plot volumes and plot directories
+func validateChiaHarvesterPlots(plots k8schianetv1.PlotsConfig, plotsPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	mountPaths := make(map[string]bool)
+	names := make(map[string]bool)
+
+	// Validates a plot volume's mount path and sub path. An empty mount path defaults to /plots/<volume name>.
+	validateMount := func(name, mountPath, subPath string, volPath *field.Path) {
+		clean := path.Clean(mountPath)
+		if mountPath == "" {
+			clean = "/plots/" + name
+		} else if !path.IsAbs(clean) || clean == "/" || strings.Contains(clean, ":") || containsWhitespace(mountPath) {
+			errs = append(errs, field.Invalid(volPath.Child("mountPath"), mountPath, "must be an absolute path to a directory other than /, and can't contain a colon or whitespace"))
+		} else if clean == "/chia-data" || clean == "/chia-ca" || strings.HasPrefix(clean, "/chia-data/") || strings.HasPrefix(clean, "/chia-ca/") {
+			errs = append(errs, field.Invalid(volPath.Child("mountPath"), mountPath, "can't be in /chia-data or /chia-ca"))
+		}
+		if mountPaths[clean] {
+			errs = append(errs, field.Duplicate(volPath.Child("mountPath"), mountPath))
+		}
+		mountPaths[clean] = true
+
+		if subPath != "" && (path.IsAbs(subPath) || slices.Contains(strings.Split(subPath, "/"), "..")) {
+			errs = append(errs, field.Invalid(volPath.Child("subPath"), subPath, "must be a relative path that doesn't contain '..'"))
+		}
+	}
+
+	for i, pvc := range plots.PersistentVolumeClaim {
+		if pvc == nil {
+			continue
+		}
+		volPath := plotsPath.Child("persistentVolumeClaim").Index(i)
+		validateMount(fmt.Sprintf("pvc-plots-%d", i), pvc.MountPath, pvc.SubPath, volPath)
+		if !pvc.GenerateVolumeClaims {
+			continue
+		}
+		// Generated plot PVCs are named after their plot volume's name, so it can't change when the list is reordered
+		if pvc.Name == "" {
+			errs = append(errs, field.Required(volPath.Child("name"), "a name is required to generate a plot volume claim"))
+		} else if names[pvc.Name] {
+			errs = append(errs, field.Duplicate(volPath.Child("name"), pvc.Name))
+		}
+		names[pvc.Name] = true
+		if _, err := resource.ParseQuantity(pvc.ResourceRequest); err != nil {
+			errs = append(errs, field.Invalid(volPath.Child("resourceRequest"), pvc.ResourceRequest, "a valid storage size is required to generate a plot volume claim"))
+		}
+	}
+	for i, hostPath := range plots.HostPathVolume {
+		if hostPath == nil {
+			continue
+		}
+		validateMount(fmt.Sprintf("hostpath-plots-%d", i), hostPath.MountPath, hostPath.SubPath, plotsPath.Child("hostPathVolume").Index(i))
+	}
+
+	for i, dir := range plots.PlotDirectories {
+		if !path.IsAbs(dir) || strings.Contains(dir, ":") || containsWhitespace(dir) {
+			errs = append(errs, field.Invalid(plotsPath.Child("plotDirectories").Index(i), dir, "must be an absolute path that doesn't contain a colon or whitespace"))
+		}
+	}
+
+	return errs
+}
+
+// containsWhitespace returns true if a path contains whitespace. Plot directories are passed to the harvester container separated by
+// newlines and added to its config as YAML, so paths with whitespace in them can't be used.
+func containsWhitespace(p string) bool {
+	return strings.IndexFunc(p, unicode.IsSpace) >= 0
+}
+
+// validateChiaHarvesterDaemonSet validates the configuration of a harvester ran as a DaemonSet
+func validateChiaHarvesterDaemonSet(harvesterSpec k8schianetv1.ChiaHarvesterSpec, spec *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	ds := spec.Child("daemonSet")
+
+	hostPath := path.Clean(harvesterSpec.DaemonSet.HostPath)
+	if !path.IsAbs(hostPath) || hostPath == "/" {
+		errs = append(errs, field.Invalid(ds.Child("hostPath"), harvesterSpec.DaemonSet.HostPath, "must be an absolute path to a directory other than /"))
+	}
+	for i, glob := range harvesterSpec.DaemonSet.PathGlobs {
+		if containsWhitespace(glob) {
+			errs = append(errs, field.Invalid(ds.Child("pathGlobs").Index(i), glob, "can't contain whitespace"))
+			continue
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			errs = append(errs, field.Invalid(ds.Child("pathGlobs").Index(i), glob, err.Error()))
+			continue
+		}
+		if clean := path.Clean(glob); clean != hostPath && !strings.HasPrefix(clean, hostPath+"/") {
+			errs = append(errs, field.Invalid(ds.Child("pathGlobs").Index(i), glob, "must be under daemonSet.hostPath"))
+		}
+	}
+
+	if harvesterSpec.Strategy != nil {
+		errs = append(errs, field.Forbidden(spec.Child("strategy"), "strategy only applies to harvester Deployments, use daemonSet.updateStrategy instead"))
+	}
+	if kube.ShouldMakeChiaRootVolumeClaim(harvesterSpec.Storage) {
+		errs = append(errs, field.Forbidden(spec.Child("storage", "chiaRoot", "persistentVolumeClaim", "generateVolumeClaims"), "a generated PersistentVolumeClaim can't be shared by harvester Pods on different nodes"))
+	}
+
+	return errs
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// SetupChiaIntroducerWebhookWithManager registers the ChiaIntroducer defaulting and validating webhooks with the manager
+func SetupChiaIntroducerWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaIntroducer{}).
+		WithDefaulter(&ChiaIntroducerCustomDefaulter{}).
+		WithValidator(&ChiaIntroducerCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chiaintroducer,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiaintroducers,verbs=create;update,versions=v1,name=mchiaintroducer-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaIntroducerCustomDefaulter sets the defaults the operator would otherwise use for a ChiaIntroducer
+type ChiaIntroducerCustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaIntroducerCustomDefaulter) Default(_ context.Context, introducer *k8schianetv1.ChiaIntroducer) error {
+	defaultChiaImage(&introducer.Spec.ChiaConfig.CommonSpecChia)
+	defaultService(&introducer.Spec.ChiaConfig.PeerService, true)
+	defaultService(&introducer.Spec.ChiaConfig.AllService, true)
+	defaultService(&introducer.Spec.ChiaConfig.DaemonService, true)
+	defaultChiaExporter(&introducer.Spec.ChiaExporterConfig)
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chiaintroducer,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiaintroducers,verbs=create;update,versions=v1,name=vchiaintroducer-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaIntroducerCustomValidator validates ChiaIntroducer specs
+type ChiaIntroducerCustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaIntroducerCustomValidator) ValidateCreate(_ context.Context, introducer *k8schianetv1.ChiaIntroducer) (admission.Warnings, error) {
+	return nil, validateChiaIntroducer(introducer)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaIntroducerCustomValidator) ValidateUpdate(_ context.Context, _, introducer *k8schianetv1.ChiaIntroducer) (admission.Warnings, error) {
+	if introducer.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaIntroducer(introducer)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaIntroducerCustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaIntroducer) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaIntroducer(introducer *k8schianetv1.ChiaIntroducer) error {
+	spec := field.NewPath("spec")
+	chia := spec.Child("chia")
+
+	errs := validateCommonSpec(introducer.Spec.CommonSpec, spec)
+	errs = append(errs, validateCommonSpecChia(introducer.Spec.ChiaConfig.CommonSpecChia, chia)...)
+
+	return invalidError(consts.ChiaIntroducerKind, introducer.Name, errs)
+}
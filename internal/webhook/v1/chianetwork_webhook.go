@@ -0,0 +1,74 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// SetupChiaNetworkWebhookWithManager registers the ChiaNetwork defaulting and validating webhooks with the manager
+func SetupChiaNetworkWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaNetwork{}).
+		WithDefaulter(&ChiaNetworkCustomDefaulter{}).
+		WithValidator(&ChiaNetworkCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chianetwork,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chianetworks,verbs=create;update,versions=v1,name=mchianetwork-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaNetworkCustomDefaulter sets the defaults the operator would otherwise use for a ChiaNetwork
+type ChiaNetworkCustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaNetworkCustomDefaulter) Default(_ context.Context, network *k8schianetv1.ChiaNetwork) error {
+	// The network name defaults to the ChiaNetwork's name. The name may not be set yet if the ChiaNetwork uses generateName.
+	if (network.Spec.NetworkName == nil || strings.TrimSpace(*network.Spec.NetworkName) == "") && network.Name != "" {
+		name := network.Name
+		network.Spec.NetworkName = &name
+	}
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chianetwork,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chianetworks,verbs=create;update,versions=v1,name=vchianetwork-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaNetworkCustomValidator validates ChiaNetwork specs
+type ChiaNetworkCustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaNetworkCustomValidator) ValidateCreate(_ context.Context, network *k8schianetv1.ChiaNetwork) (admission.Warnings, error) {
+	return nil, validateChiaNetwork(network)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaNetworkCustomValidator) ValidateUpdate(_ context.Context, _, network *k8schianetv1.ChiaNetwork) (admission.Warnings, error) {
+	if network.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaNetwork(network)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaNetworkCustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaNetwork) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaNetwork(network *k8schianetv1.ChiaNetwork) error {
+	spec := field.NewPath("spec")
+
+	var errs field.ErrorList
+	if network.Spec.NetworkPort != nil && *network.Spec.NetworkPort == 0 {
+		errs = append(errs, field.Invalid(spec.Child("networkPort"), *network.Spec.NetworkPort, "must be a valid port number"))
+	}
+
+	return invalidError(consts.ChiaNetworkKind, network.Name, errs)
+}
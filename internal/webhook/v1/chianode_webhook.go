@@ -0,0 +1,148 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// SetupChiaNodeWebhookWithManager registers the ChiaNode defaulting and validating webhooks with the manager
+func SetupChiaNodeWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaNode{}).
+		WithDefaulter(&ChiaNodeCustomDefaulter{}).
+		WithValidator(&ChiaNodeCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chianode,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chianodes,verbs=create;update,versions=v1,name=mchianode-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaNodeCustomDefaulter sets the defaults the operator would otherwise use for a ChiaNode
+type ChiaNodeCustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaNodeCustomDefaulter) Default(_ context.Context, node *k8schianetv1.ChiaNode) error {
+	defaultChiaImage(&node.Spec.ChiaConfig.CommonSpecChia)
+	defaultService(&node.Spec.ChiaConfig.PeerService, true)
+	defaultService(&node.Spec.ChiaConfig.AllService, true)
+	defaultService(&node.Spec.ChiaConfig.DaemonService, true)
+	defaultService(&node.Spec.ChiaConfig.RPCService, true)
+	defaultService(&node.Spec.ChiaConfig.ReplicaService.Service, false)
+	defaultChiaExporter(&node.Spec.ChiaExporterConfig)
+	defaultChiaHealthcheck(&node.Spec.ChiaHealthcheckConfig)
+	defaultHealthcheckProbes(&node.Spec.ChiaConfig.CommonSpecChia, kube.ChiaHealthcheckEnabled(node.Spec.ChiaHealthcheckConfig), healthcheckProbes{
+		liveness:  "/full_node/liveness",
+		readiness: "/full_node/readiness",
+		startup:   "/full_node/startup",
+	})
+	// HTTP and PersistentVolumeClaim sources are restored by chia-db-restore in the operator's image, which isn't defaulted in the spec
+	if kube.ChiaDBPullEnabled(node.Spec.ChiaDBPullConfig) && node.Spec.ChiaDBPullConfig.S3Prefix != "" {
+		defaultImage(&node.Spec.ChiaDBPullConfig.Image, consts.DefaultChiaDBPullImageName, consts.DefaultChiaDBPullImageTag)
+	}
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chianode,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chianodes,verbs=create;update,versions=v1,name=vchianode-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaNodeCustomValidator validates ChiaNode specs
+type ChiaNodeCustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaNodeCustomValidator) ValidateCreate(_ context.Context, node *k8schianetv1.ChiaNode) (admission.Warnings, error) {
+	return nil, validateChiaNode(node)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaNodeCustomValidator) ValidateUpdate(_ context.Context, _, node *k8schianetv1.ChiaNode) (admission.Warnings, error) {
+	if node.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaNode(node)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaNodeCustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaNode) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaNode(node *k8schianetv1.ChiaNode) error {
+	spec := field.NewPath("spec")
+	chia := spec.Child("chia")
+
+	errs := validateCommonSpec(node.Spec.CommonSpec, spec)
+	errs = append(errs, validateCommonSpecChia(node.Spec.ChiaConfig.CommonSpecChia, chia)...)
+	errs = append(errs, validateFullNodePeers(nil, node.Spec.ChiaConfig.FullNodePeers, chia)...)
+	errs = append(errs, validateTrustedCIDRs(node.Spec.ChiaConfig.TrustedCIDRs, chia.Child("trustedCIDRs"))...)
+	if kube.ChiaDBPullEnabled(node.Spec.ChiaDBPullConfig) {
+		errs = append(errs, validateChiaDBPull(node.Spec, spec)...)
+	}
+	if node.Spec.Snapshots != nil && node.Spec.Snapshots.Enabled != nil && *node.Spec.Snapshots.Enabled {
+		errs = append(errs, validateChiaNodeSnapshots(node.Spec, spec)...)
+	}
+
+	return invalidError(consts.ChiaNodeKind, node.Name, errs)
+}
+
+// validateChiaDBPull validates the source of an enabled chia-db-pull config
+func validateChiaDBPull(nodeSpec k8schianetv1.ChiaNodeSpec, spec *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	path := spec.Child("chiaDBPull")
+
+	sources := kube.GetChiaDBPullSources(nodeSpec.ChiaDBPullConfig)
+	switch {
+	case len(sources) == 0:
+		errs = append(errs, field.Required(path, "one of s3Prefix, http, persistentVolumeClaim, or chiaNodeSnapshot is required when chia-db-pull is enabled"))
+	case len(sources) > 1:
+		errs = append(errs, field.Forbidden(path, fmt.Sprintf("only one source may be set, found %s", strings.Join(sources, ", "))))
+	}
+
+	if source := nodeSpec.ChiaDBPullConfig.PersistentVolumeClaim; source != nil {
+		// The path is relative to the root of the source volume
+		if source.Path == "" || strings.HasPrefix(source.Path, "/") || slices.Contains(strings.Split(source.Path, "/"), "..") {
+			errs = append(errs, field.Invalid(path.Child("persistentVolumeClaim", "path"), source.Path, "must be a relative path within the volume"))
+		}
+	}
+
+	// Snapshots of another ChiaNode are restored to the CHIA_ROOT volumes created from the StatefulSet's volume claim template
+	if source := nodeSpec.ChiaDBPullConfig.ChiaNodeSnapshot; source != nil {
+		pvcPath := spec.Child("storage", "chiaRoot", "persistentVolumeClaim")
+		if nodeSpec.Storage == nil || nodeSpec.Storage.ChiaRoot == nil || nodeSpec.Storage.ChiaRoot.PersistentVolumeClaim == nil {
+			errs = append(errs, field.Required(pvcPath, "a CHIA_ROOT persistentVolumeClaim is required to restore from a ChiaNode's snapshots"))
+		}
+	}
+
+	return errs
+}
+
+// validateChiaNodeSnapshots validates the configuration of a ChiaNode's CHIA_ROOT VolumeSnapshots
+func validateChiaNodeSnapshots(nodeSpec k8schianetv1.ChiaNodeSpec, spec *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	// Snapshots are taken of the CHIA_ROOT volumes created from the StatefulSet's volume claim template
+	pvcPath := spec.Child("storage", "chiaRoot", "persistentVolumeClaim")
+	if nodeSpec.Storage == nil || nodeSpec.Storage.ChiaRoot == nil || nodeSpec.Storage.ChiaRoot.PersistentVolumeClaim == nil {
+		errs = append(errs, field.Required(pvcPath, "a CHIA_ROOT persistentVolumeClaim is required to take snapshots"))
+	} else if _, err := resource.ParseQuantity(nodeSpec.Storage.ChiaRoot.PersistentVolumeClaim.ResourceRequest); err != nil {
+		errs = append(errs, field.Invalid(pvcPath.Child("resourceRequest"), nodeSpec.Storage.ChiaRoot.PersistentVolumeClaim.ResourceRequest, "a valid storage size is required to take snapshots"))
+	}
+
+	// Snapshots are only taken of replicas that report being synced
+	if !kube.RPCStatusEnabled(nodeSpec.RPCStatus) {
+		errs = append(errs, field.Forbidden(spec.Child("rpcStatus", "enabled"), "RPC status queries are required to take snapshots of synced replicas"))
+	}
+
+	return errs
+}
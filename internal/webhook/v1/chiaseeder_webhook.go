@@ -0,0 +1,83 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// SetupChiaSeederWebhookWithManager registers the ChiaSeeder defaulting and validating webhooks with the manager
+func SetupChiaSeederWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaSeeder{}).
+		WithDefaulter(&ChiaSeederCustomDefaulter{}).
+		WithValidator(&ChiaSeederCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chiaseeder,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiaseeders,verbs=create;update,versions=v1,name=mchiaseeder-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaSeederCustomDefaulter sets the defaults the operator would otherwise use for a ChiaSeeder
+type ChiaSeederCustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaSeederCustomDefaulter) Default(_ context.Context, seeder *k8schianetv1.ChiaSeeder) error {
+	defaultChiaImage(&seeder.Spec.ChiaConfig.CommonSpecChia)
+	defaultService(&seeder.Spec.ChiaConfig.PeerService, true)
+	defaultService(&seeder.Spec.ChiaConfig.AllService, true)
+	defaultService(&seeder.Spec.ChiaConfig.DaemonService, true)
+	defaultService(&seeder.Spec.ChiaConfig.RPCService, true)
+	defaultChiaExporter(&seeder.Spec.ChiaExporterConfig)
+	defaultChiaHealthcheck(&seeder.Spec.ChiaHealthcheckConfig)
+	// The seeder is only probed by chia-healthcheck when a DNS hostname is set for it to check
+	defaultHealthcheckProbes(&seeder.Spec.ChiaConfig.CommonSpecChia, kube.ChiaHealthcheckEnabled(seeder.Spec.ChiaHealthcheckConfig) && seeder.Spec.ChiaHealthcheckConfig.DNSHostname != nil, healthcheckProbes{
+		readiness: "/seeder/readiness",
+		startup:   "/seeder/readiness",
+	})
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chiaseeder,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiaseeders,verbs=create;update,versions=v1,name=vchiaseeder-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaSeederCustomValidator validates ChiaSeeder specs
+type ChiaSeederCustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaSeederCustomValidator) ValidateCreate(_ context.Context, seeder *k8schianetv1.ChiaSeeder) (admission.Warnings, error) {
+	return nil, validateChiaSeeder(seeder)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaSeederCustomValidator) ValidateUpdate(_ context.Context, _, seeder *k8schianetv1.ChiaSeeder) (admission.Warnings, error) {
+	if seeder.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaSeeder(seeder)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaSeederCustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaSeeder) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaSeeder(seeder *k8schianetv1.ChiaSeeder) error {
+	spec := field.NewPath("spec")
+	chia := spec.Child("chia")
+
+	errs := validateCommonSpec(seeder.Spec.CommonSpec, spec)
+	errs = append(errs, validateCommonSpecChia(seeder.Spec.ChiaConfig.CommonSpecChia, chia)...)
+	if seeder.Spec.ChiaConfig.BootstrapPeer != nil && seeder.Spec.ChiaConfig.BootstrapPeers != nil {
+		errs = append(errs, field.Forbidden(chia.Child("bootstrapPeer"), "bootstrapPeer and bootstrapPeers are mutually exclusive"))
+	}
+
+	return invalidError(consts.ChiaSeederKind, seeder.Name, errs)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// SetupChiaTimelordWebhookWithManager registers the ChiaTimelord defaulting and validating webhooks with the manager
+func SetupChiaTimelordWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaTimelord{}).
+		WithDefaulter(&ChiaTimelordCustomDefaulter{}).
+		WithValidator(&ChiaTimelordCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chiatimelord,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiatimelords,verbs=create;update,versions=v1,name=mchiatimelord-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaTimelordCustomDefaulter sets the defaults the operator would otherwise use for a ChiaTimelord
+type ChiaTimelordCustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaTimelordCustomDefaulter) Default(_ context.Context, timelord *k8schianetv1.ChiaTimelord) error {
+	defaultChiaImage(&timelord.Spec.ChiaConfig.CommonSpecChia)
+	defaultService(&timelord.Spec.ChiaConfig.PeerService, true)
+	defaultService(&timelord.Spec.ChiaConfig.AllService, true)
+	defaultService(&timelord.Spec.ChiaConfig.DaemonService, true)
+	defaultService(&timelord.Spec.ChiaConfig.RPCService, true)
+	defaultChiaExporter(&timelord.Spec.ChiaExporterConfig)
+	defaultChiaHealthcheck(&timelord.Spec.ChiaHealthcheckConfig)
+	defaultHealthcheckProbes(&timelord.Spec.ChiaConfig.CommonSpecChia, kube.ChiaHealthcheckEnabled(timelord.Spec.ChiaHealthcheckConfig), healthcheckProbes{
+		liveness:  "/timelord",
+		readiness: "/timelord/readiness",
+		startup:   "/timelord/readiness",
+	})
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chiatimelord,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiatimelords,verbs=create;update,versions=v1,name=vchiatimelord-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaTimelordCustomValidator validates ChiaTimelord specs
+type ChiaTimelordCustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaTimelordCustomValidator) ValidateCreate(_ context.Context, timelord *k8schianetv1.ChiaTimelord) (admission.Warnings, error) {
+	return nil, validateChiaTimelord(timelord)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaTimelordCustomValidator) ValidateUpdate(_ context.Context, _, timelord *k8schianetv1.ChiaTimelord) (admission.Warnings, error) {
+	if timelord.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaTimelord(timelord)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaTimelordCustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaTimelord) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaTimelord(timelord *k8schianetv1.ChiaTimelord) error {
+	spec := field.NewPath("spec")
+	chia := spec.Child("chia")
+
+	errs := validateCommonSpec(timelord.Spec.CommonSpec, spec)
+	errs = append(errs, validateCommonSpecChia(timelord.Spec.ChiaConfig.CommonSpecChia, chia)...)
+	errs = append(errs, validateFullNodePeers(timelord.Spec.ChiaConfig.FullNodePeer, timelord.Spec.ChiaConfig.FullNodePeers, chia)...)
+
+	return invalidError(consts.ChiaTimelordKind, timelord.Name, errs)
+}
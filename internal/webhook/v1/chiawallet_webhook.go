@@ -0,0 +1,76 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// SetupChiaWalletWebhookWithManager registers the ChiaWallet defaulting and validating webhooks with the manager
+func SetupChiaWalletWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &k8schianetv1.ChiaWallet{}).
+		WithDefaulter(&ChiaWalletCustomDefaulter{}).
+		WithValidator(&ChiaWalletCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-chia-net-v1-chiawallet,mutating=true,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiawallets,verbs=create;update,versions=v1,name=mchiawallet-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaWalletCustomDefaulter sets the defaults the operator would otherwise use for a ChiaWallet
+type ChiaWalletCustomDefaulter struct{}
+
+// Default implements admission.Defaulter
+func (d *ChiaWalletCustomDefaulter) Default(_ context.Context, wallet *k8schianetv1.ChiaWallet) error {
+	defaultChiaImage(&wallet.Spec.ChiaConfig.CommonSpecChia)
+	defaultService(&wallet.Spec.ChiaConfig.PeerService, true)
+	defaultService(&wallet.Spec.ChiaConfig.AllService, true)
+	defaultService(&wallet.Spec.ChiaConfig.DaemonService, true)
+	defaultService(&wallet.Spec.ChiaConfig.RPCService, true)
+	defaultChiaExporter(&wallet.Spec.ChiaExporterConfig)
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-chia-net-v1-chiawallet,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.chia.net,resources=chiawallets,verbs=create;update,versions=v1,name=vchiawallet-v1.k8s.chia.net,admissionReviewVersions=v1
+
+// ChiaWalletCustomValidator validates ChiaWallet specs
+type ChiaWalletCustomValidator struct{}
+
+// ValidateCreate implements admission.Validator
+func (v *ChiaWalletCustomValidator) ValidateCreate(_ context.Context, wallet *k8schianetv1.ChiaWallet) (admission.Warnings, error) {
+	return nil, validateChiaWallet(wallet)
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *ChiaWalletCustomValidator) ValidateUpdate(_ context.Context, _, wallet *k8schianetv1.ChiaWallet) (admission.Warnings, error) {
+	if wallet.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, validateChiaWallet(wallet)
+}
+
+// ValidateDelete implements admission.Validator
+func (v *ChiaWalletCustomValidator) ValidateDelete(_ context.Context, _ *k8schianetv1.ChiaWallet) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateChiaWallet(wallet *k8schianetv1.ChiaWallet) error {
+	spec := field.NewPath("spec")
+	chia := spec.Child("chia")
+
+	errs := validateCommonSpec(wallet.Spec.CommonSpec, spec)
+	errs = append(errs, validateCommonSpecChia(wallet.Spec.ChiaConfig.CommonSpecChia, chia)...)
+	errs = append(errs, validateFullNodePeers(wallet.Spec.ChiaConfig.FullNodePeer, wallet.Spec.ChiaConfig.FullNodePeers, chia)...)
+	errs = append(errs, validateTrustedCIDRs(wallet.Spec.ChiaConfig.TrustedCIDRs, chia.Child("trustedCIDRs"))...)
+	errs = append(errs, validateSecretKey(wallet.Spec.ChiaConfig.SecretKey, chia.Child("secretKey"))...)
+
+	return invalidError(consts.ChiaWalletKind, wallet.Name, errs)
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/chiaconfig"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+	"github.com/chia-network/chia-operator/internal/controller/common/kube"
+)
+
+// invalidError returns an Invalid API error for a resource of the given kind if there are any field errors, otherwise nil
+func invalidError(kind consts.ChiaKind, name string, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(schema.GroupKind{Group: k8schianetv1.GroupVersion.Group, Kind: string(kind)}, name, errs)
+}
+
+// validateCommonSpec validates the configuration shared by all Chia component resources
+func validateCommonSpec(spec k8schianetv1.CommonSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if spec.Storage != nil {
+		errs = append(errs, validateStorageConfig(*spec.Storage, path.Child("storage"))...)
+	}
+	if spec.PodDisruptionBudget != nil && spec.PodDisruptionBudget.MinAvailable != nil && spec.PodDisruptionBudget.MaxUnavailable != nil {
+		errs = append(errs, field.Forbidden(path.Child("podDisruptionBudget", "maxUnavailable"), "minAvailable and maxUnavailable are mutually exclusive"))
+	}
+	return errs
+}
+
+// validateCommonSpecChia validates the chia configuration shared by all Chia component resources
+func validateCommonSpecChia(chia k8schianetv1.CommonSpecChia, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if chia.NetworkPort != nil && *chia.NetworkPort == 0 {
+		errs = append(errs, field.Invalid(path.Child("networkPort"), *chia.NetworkPort, "must be a valid port number"))
+	}
+	if err := chiaconfig.Validate(chia.ConfigOverrides); err != nil {
+		errs = append(errs, field.Invalid(path.Child("configOverrides"), string(chia.ConfigOverrides.Raw), err.Error()))
+	}
+	return errs
+}
+
+// validateStorageConfig validates that no volume claim config sets both a claim name and generateVolumeClaims
+func validateStorageConfig(storage k8schianetv1.StorageConfig, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if storage.ChiaRoot != nil && storage.ChiaRoot.PersistentVolumeClaim != nil {
+		errs = append(errs, validatePersistentVolumeClaimConfig(*storage.ChiaRoot.PersistentVolumeClaim, path.Child("chiaRoot", "persistentVolumeClaim"))...)
+	}
+	if storage.Plots != nil {
+		for i, pvc := range storage.Plots.PersistentVolumeClaim {
+			if pvc != nil {
+				errs = append(errs, validatePersistentVolumeClaimConfig(*pvc, path.Child("plots", "persistentVolumeClaim").Index(i))...)
+			}
+		}
+	}
+	if storage.DataLayerServerFiles != nil && storage.DataLayerServerFiles.PersistentVolumeClaim != nil {
+		errs = append(errs, validatePersistentVolumeClaimConfig(*storage.DataLayerServerFiles.PersistentVolumeClaim, path.Child("dataLayerServerFiles", "persistentVolumeClaim"))...)
+	}
+	return errs
+}
+
+// validatePersistentVolumeClaimConfig validates that a volume claim config doesn't set both a claim name and generateVolumeClaims
+func validatePersistentVolumeClaimConfig(pvc k8schianetv1.PersistentVolumeClaimConfig, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if pvc.ClaimName != "" && pvc.GenerateVolumeClaims {
+		errs = append(errs, field.Forbidden(path.Child("claimName"), "claimName and generateVolumeClaims are mutually exclusive"))
+	}
+	return errs
+}
+
+// validateFullNodePeers validates that only one of fullNodePeer and fullNodePeers is set, and that each peer has a host and port
+func validateFullNodePeers(peer *string, peers *[]k8schianetv1.Peer, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if peer != nil && peers != nil {
+		errs = append(errs, field.Forbidden(path.Child("fullNodePeer"), "fullNodePeer and fullNodePeers are mutually exclusive"))
+	}
+	if peers != nil {
+		errs = append(errs, validatePeers(*peers, path.Child("fullNodePeers"))...)
+	}
+	return errs
+}
+
+// validatePeers validates that each peer has a host and port
+func validatePeers(peers []k8schianetv1.Peer, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, peer := range peers {
+		if strings.TrimSpace(peer.Host) == "" {
+			errs = append(errs, field.Required(path.Index(i).Child("host"), "peer host must not be empty"))
+		}
+		if peer.Port == 0 {
+			errs = append(errs, field.Invalid(path.Index(i).Child("port"), peer.Port, "must be a valid port number"))
+		}
+	}
+	return errs
+}
+
+// validateTrustedCIDRs validates that each trusted CIDR is a valid CIDR or IP address.
+// Chia parses each entry as an IP network, so a bare IP address is treated as a single host network.
+func validateTrustedCIDRs(cidrs *[]string, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if cidrs == nil {
+		return errs
+	}
+	for i, cidr := range *cidrs {
+		if _, _, err := net.ParseCIDR(cidr); err == nil {
+			continue
+		}
+		if net.ParseIP(cidr) != nil {
+			continue
+		}
+		errs = append(errs, field.Invalid(path.Index(i), cidr, "must be a valid CIDR or IP address"))
+	}
+	return errs
+}
+
+// validateSecretKey validates that a Secret name and key were specified for a Chia mnemonic
+func validateSecretKey(key k8schianetv1.ChiaSecretKey, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if strings.TrimSpace(key.Name) == "" {
+		errs = append(errs, field.Required(path.Child("name"), "the name of a Secret containing a mnemonic key is required"))
+	}
+	if strings.TrimSpace(key.Key) == "" {
+		errs = append(errs, field.Required(path.Child("key"), "the key of the mnemonic in the Secret is required"))
+	}
+	return errs
+}
+
+// validateCertManagerIssuerRef validates that a cert-manager issuer reference has a name
+func validateCertManagerIssuerRef(ref *k8schianetv1.CertManagerIssuerRef, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if ref != nil && strings.TrimSpace(ref.Name) == "" {
+		errs = append(errs, field.Required(path.Child("name"), "the name of the issuer is required"))
+	}
+	return errs
+}
+
+// defaultChiaImage sets the chia image to the operator's default chia image if unset
+func defaultChiaImage(chia *k8schianetv1.CommonSpecChia) {
+	defaultImage(&chia.Image, consts.DefaultChiaImageName, consts.DefaultChiaImageTag)
+}
+
+// defaultChiaExporter enables chia-exporter and its Service, and sets its image to the operator's default image if unset
+func defaultChiaExporter(exporter *k8schianetv1.SpecChiaExporter) {
+	defaultBool(&exporter.Enabled, true)
+	if kube.ChiaExporterEnabled(*exporter) {
+		defaultImage(&exporter.Image, consts.DefaultChiaExporterImageName, consts.DefaultChiaExporterImageTag)
+		defaultService(&exporter.Service, true)
+	}
+}
+
+// defaultChiaHealthcheck enables chia-healthcheck and sets its image to the operator's default image if unset.
+// The chia-healthcheck Service is disabled by default.
+func defaultChiaHealthcheck(healthcheck *k8schianetv1.SpecChiaHealthcheck) {
+	defaultBool(&healthcheck.Enabled, true)
+	if kube.ChiaHealthcheckEnabled(*healthcheck) {
+		defaultImage(&healthcheck.Image, consts.DefaultChiaHealthcheckImageName, consts.DefaultChiaHealthcheckImageTag)
+	}
+	defaultService(&healthcheck.Service, false)
+}
+
+// defaultService sets whether a Service is enabled if unset, and the Service type of enabled Services to ClusterIP if unset
+func defaultService(srv *k8schianetv1.Service, enabled bool) {
+	defaultBool(&srv.Enabled, enabled)
+	if *srv.Enabled && srv.ServiceType == nil {
+		serviceType := corev1.ServiceTypeClusterIP
+		srv.ServiceType = &serviceType
+	}
+}
+
+// defaultImage sets an image to the given default image name and tag if unset
+func defaultImage(image **string, name, tag string) {
+	if *image == nil || **image == "" {
+		img := fmt.Sprintf("%s:%s", name, tag)
+		*image = &img
+	}
+}
+
+// defaultBool sets a bool pointer to the given value if unset
+func defaultBool(b **bool, value bool) {
+	if *b == nil {
+		*b = &value
+	}
+}
+
+// healthcheckProbes contains the chia-healthcheck endpoints the operator probes the chia container with for a Chia component.
+// An empty path means the component is not probed by default.
+type healthcheckProbes struct {
+	liveness  string
+	readiness string
+	startup   string
+}
+
+// defaultHealthcheckProbes sets the chia container's probes to the chia-healthcheck probes the operator would otherwise use if they're unset.
+// If chia-healthcheck is disabled, any probes that match the chia-healthcheck probes are removed, since they can't succeed without the chia-healthcheck sidecar.
+func defaultHealthcheckProbes(chia *k8schianetv1.CommonSpecChia, enabled bool, paths healthcheckProbes) {
+	defaultProbe(&chia.LivenessProbe, enabled, healthcheckProbe(paths.liveness, false))
+	defaultProbe(&chia.ReadinessProbe, enabled, healthcheckProbe(paths.readiness, false))
+	defaultProbe(&chia.StartupProbe, enabled, healthcheckProbe(paths.startup, true))
+}
+
+// defaultProbe sets a probe to the default probe if unset and enabled, or removes it if it matches the default probe and isn't enabled
+func defaultProbe(probe **corev1.Probe, enabled bool, def *corev1.Probe) {
+	if def == nil {
+		return
+	}
+	if enabled && *probe == nil {
+		*probe = def
+	}
+	if !enabled && *probe != nil && equality.Semantic.DeepEqual(*probe, def) {
+		*probe = nil
+	}
+}
+
+// healthcheckProbe returns a probe for the given chia-healthcheck endpoint, or nil if the path is empty
+func healthcheckProbe(path string, startup bool) *corev1.Probe {
+	if path == "" {
+		return nil
+	}
+	input := kube.AssembleChiaHealthcheckProbeInputs{
+		Path: path,
+	}
+	if startup {
+		failThresh := int32(30)
+		periodSec := int32(10)
+		input.FailureThreshold = &failThresh
+		input.PeriodSeconds = &periodSec
+	}
+	return kube.AssembleChiaHealthcheckProbe(input)
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2023 Chia Network Inc.
+*/
+
+package v1
+
+import (
+	"fmt"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// SetupWebhooksWithManager registers the defaulting and validating webhooks for every Chia custom resource with the manager
+func SetupWebhooksWithManager(mgr ctrl.Manager) error {
+	setups := []struct {
+		kind  string
+		setup func(ctrl.Manager) error
+	}{
+		{"ChiaCA", SetupChiaCAWebhookWithManager},
+		{"ChiaCertificates", SetupChiaCertificatesWebhookWithManager},
+		{"ChiaCrawler", SetupChiaCrawlerWebhookWithManager},
+		{"ChiaDataLayer", SetupChiaDataLayerWebhookWithManager},
+		{"ChiaFarm", SetupChiaFarmWebhookWithManager},
+		{"ChiaFarmer", SetupChiaFarmerWebhookWithManager},
+		{"ChiaHarvester", SetupChiaHarvesterWebhookWithManager},
+		{"ChiaIntroducer", SetupChiaIntroducerWebhookWithManager},
+		{"ChiaNetwork", SetupChiaNetworkWebhookWithManager},
+		{"ChiaNode", SetupChiaNodeWebhookWithManager},
+		{"ChiaSeeder", SetupChiaSeederWebhookWithManager},
+		{"ChiaTimelord", SetupChiaTimelordWebhookWithManager},
+		{"ChiaWallet", SetupChiaWalletWebhookWithManager},
+	}
+	for _, s := range setups {
+		if err := s.setup(mgr); err != nil {
+			return fmt.Errorf("unable to create webhook for %s: %v", s.kind, err)
+		}
+	}
+	return nil
+}
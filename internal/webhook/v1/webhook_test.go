@@ -0,0 +1,488 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	k8schianetv1 "github.com/chia-network/chia-operator/api/v1"
+	"github.com/chia-network/chia-operator/internal/controller/common/consts"
+)
+
+// Helper function to create a string pointer
+func stringPtr(s string) *string {
+	return &s
+}
+
+// Helper function to create a bool pointer
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestValidateFullNodePeers(t *testing.T) {
+	path := field.NewPath("spec", "chia")
+
+	errs := validateFullNodePeers(stringPtr("node.default.svc.cluster.local:8444"), nil, path)
+	require.Empty(t, errs)
+
+	errs = validateFullNodePeers(nil, &[]k8schianetv1.Peer{{Host: "node", Port: 8444}}, path)
+	require.Empty(t, errs)
+
+	errs = validateFullNodePeers(stringPtr("node:8444"), &[]k8schianetv1.Peer{{Host: "node", Port: 8444}}, path)
+	require.Len(t, errs, 1)
+	require.Equal(t, "spec.chia.fullNodePeer", errs[0].Field)
+
+	errs = validateFullNodePeers(nil, &[]k8schianetv1.Peer{{Host: "node", Port: 8444}, {Host: "", Port: 0}}, path)
+	require.Len(t, errs, 2)
+	require.Equal(t, "spec.chia.fullNodePeers[1].host", errs[0].Field)
+	require.Equal(t, "spec.chia.fullNodePeers[1].port", errs[1].Field)
+}
+
+func TestValidateTrustedCIDRs(t *testing.T) {
+	path := field.NewPath("spec", "chia", "trustedCIDRs")
+
+	require.Empty(t, validateTrustedCIDRs(nil, path))
+	require.Empty(t, validateTrustedCIDRs(&[]string{"10.0.0.0/8", "192.168.1.5", "fd00::/8"}, path))
+
+	errs := validateTrustedCIDRs(&[]string{"10.0.0.0/8", "10.0.0.0/33", "not-a-cidr"}, path)
+	require.Len(t, errs, 2)
+	require.Equal(t, "spec.chia.trustedCIDRs[1]", errs[0].Field)
+	require.Equal(t, "spec.chia.trustedCIDRs[2]", errs[1].Field)
+}
+
+func TestValidateStorageConfig(t *testing.T) {
+	path := field.NewPath("spec", "storage")
+
+	storage := k8schianetv1.StorageConfig{
+		ChiaRoot: &k8schianetv1.ChiaRootConfig{
+			PersistentVolumeClaim: &k8schianetv1.PersistentVolumeClaimConfig{
+				GenerateVolumeClaims: true,
+			},
+		},
+		Plots: &k8schianetv1.PlotsConfig{
+			PersistentVolumeClaim: []*k8schianetv1.PersistentVolumeClaimConfig{
+				{ClaimName: "plots1"},
+			},
+		},
+	}
+	require.Empty(t, validateStorageConfig(storage, path))
+
+	storage.ChiaRoot.PersistentVolumeClaim.ClaimName = "chiaroot"
+	storage.Plots.PersistentVolumeClaim = append(storage.Plots.PersistentVolumeClaim, &k8schianetv1.PersistentVolumeClaimConfig{
+		ClaimName:            "plots2",
+		GenerateVolumeClaims: true,
+	})
+	errs := validateStorageConfig(storage, path)
+	require.Len(t, errs, 2)
+	require.Equal(t, "spec.storage.chiaRoot.persistentVolumeClaim.claimName", errs[0].Field)
+	require.Equal(t, "spec.storage.plots.persistentVolumeClaim[1].claimName", errs[1].Field)
+}
+
+func TestValidateCommonSpecPodDisruptionBudget(t *testing.T) {
+	path := field.NewPath("spec")
+	minAvailable := intstr.FromInt32(1)
+	maxUnavailable := intstr.FromString("25%")
+
+	spec := k8schianetv1.CommonSpec{
+		PodDisruptionBudget: &k8schianetv1.PodDisruptionBudgetConfig{
+			MinAvailable: &minAvailable,
+		},
+	}
+	require.Empty(t, validateCommonSpec(spec, path))
+
+	spec.PodDisruptionBudget.MaxUnavailable = &maxUnavailable
+	errs := validateCommonSpec(spec, path)
+	require.Len(t, errs, 1)
+	require.Equal(t, field.ErrorTypeForbidden, errs[0].Type)
+	require.Equal(t, "spec.podDisruptionBudget.maxUnavailable", errs[0].Field)
+}
+
+func TestValidateCommonSpecChiaConfigOverrides(t *testing.T) {
+	path := field.NewPath("spec", "chia")
+
+	chia := k8schianetv1.CommonSpecChia{
+		ConfigOverrides: &apiextensionsv1.JSON{Raw: []byte(`{"full_node":{"enable_upnp":false,"target_peer_count":80}}`)},
+	}
+	require.Empty(t, validateCommonSpecChia(chia, path))
+
+	chia.ConfigOverrides = &apiextensionsv1.JSON{Raw: []byte(`{"full_node":{"enable_upnpp":false}}`)}
+	errs := validateCommonSpecChia(chia, path)
+	require.Len(t, errs, 1)
+	require.Equal(t, field.ErrorTypeInvalid, errs[0].Type)
+	require.Equal(t, "spec.chia.configOverrides", errs[0].Field)
+	require.Contains(t, errs[0].Detail, "full_node.enable_upnpp")
+}
+
+func TestValidateChiaFarmer(t *testing.T) {
+	farmer := &k8schianetv1.ChiaFarmer{
+		ObjectMeta: metav1.ObjectMeta{Name: "farmer"},
+		Spec: k8schianetv1.ChiaFarmerSpec{
+			ChiaConfig: k8schianetv1.ChiaFarmerSpecChia{
+				CASecretName: "chiaca",
+				SecretKey: k8schianetv1.ChiaSecretKey{
+					Name: "chiakey",
+					Key:  "key.txt",
+				},
+			},
+		},
+	}
+	validator := &ChiaFarmerCustomValidator{}
+
+	_, err := validator.ValidateCreate(context.TODO(), farmer)
+	require.NoError(t, err)
+
+	invalid := farmer.DeepCopy()
+	invalid.Spec.ChiaConfig.SecretKey = k8schianetv1.ChiaSecretKey{}
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "spec.chia.secretKey.name")
+	require.ErrorContains(t, err, "spec.chia.secretKey.key")
+
+	// Updates to resources that are being deleted are always allowed, so finalizers can be removed from invalid resources
+	now := metav1.Now()
+	invalid.DeletionTimestamp = &now
+	_, err = validator.ValidateUpdate(context.TODO(), farmer, invalid)
+	require.NoError(t, err)
+}
+
+func TestValidateChiaHarvester(t *testing.T) {
+	harvester := &k8schianetv1.ChiaHarvester{
+		ObjectMeta: metav1.ObjectMeta{Name: "harvester"},
+		Spec: k8schianetv1.ChiaHarvesterSpec{
+			ChiaConfig: k8schianetv1.ChiaHarvesterSpecChia{
+				CASecretName:  "chiaca",
+				FarmerAddress: "farmer.default.svc.cluster.local",
+			},
+			DaemonSet: &k8schianetv1.ChiaHarvesterDaemonSetConfig{
+				Enabled:   boolPtr(true),
+				HostPath:  "/mnt",
+				PathGlobs: []string{"/mnt/disk*/plots", "/mnt"},
+			},
+		},
+	}
+	validator := &ChiaHarvesterCustomValidator{}
+
+	_, err := validator.ValidateCreate(context.TODO(), harvester)
+	require.NoError(t, err)
+
+	invalid := harvester.DeepCopy()
+	invalid.Spec.DaemonSet.PathGlobs = []string{"/srv/disk*", "/mnt/[disk", "/mnt/disk 2"}
+	invalid.Spec.Strategy = &appsv1.DeploymentStrategy{}
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "spec.daemonSet.pathGlobs[0]")
+	require.ErrorContains(t, err, "spec.daemonSet.pathGlobs[1]")
+	require.ErrorContains(t, err, "spec.daemonSet.pathGlobs[2]")
+	require.ErrorContains(t, err, "spec.strategy")
+
+	invalid = harvester.DeepCopy()
+	invalid.Spec.DaemonSet.HostPath = "/"
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "spec.daemonSet.hostPath")
+
+	// DaemonSet settings aren't validated unless the DaemonSet mode is enabled
+	disabled := invalid.DeepCopy()
+	disabled.Spec.DaemonSet.Enabled = boolPtr(false)
+	_, err = validator.ValidateCreate(context.TODO(), disabled)
+	require.NoError(t, err)
+
+	invalid = harvester.DeepCopy()
+	invalid.Spec.Storage = &k8schianetv1.StorageConfig{
+		Plots: &k8schianetv1.PlotsConfig{
+			PersistentVolumeClaim: []*k8schianetv1.PersistentVolumeClaimConfig{
+				{Name: "disk1", GenerateVolumeClaims: true, ResourceRequest: "10Ti"},
+				{Name: "disk2", GenerateVolumeClaims: true},
+				{GenerateVolumeClaims: true, ResourceRequest: "10Ti"},
+				{Name: "disk1", GenerateVolumeClaims: true, ResourceRequest: "10Ti"},
+			},
+		},
+	}
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "spec.storage.plots.persistentVolumeClaim[1].resourceRequest")
+	require.ErrorContains(t, err, "spec.storage.plots.persistentVolumeClaim[2].name: Required value")
+	require.ErrorContains(t, err, "spec.storage.plots.persistentVolumeClaim[3].name: Duplicate value")
+	require.NotContains(t, err.Error(), "spec.storage.plots.persistentVolumeClaim[0]")
+
+	invalid = harvester.DeepCopy()
+	invalid.Spec.Storage = &k8schianetv1.StorageConfig{
+		Plots: &k8schianetv1.PlotsConfig{
+			PersistentVolumeClaim: []*k8schianetv1.PersistentVolumeClaimConfig{
+				{ClaimName: "plots-1", MountPath: "/mnt/disk1", SubPath: "plots"},
+				{ClaimName: "plots-2", MountPath: "/chia-data/plots"},
+				{ClaimName: "plots-3", SubPath: "../plots"},
+				{ClaimName: "plots-4", MountPath: "/mnt/disk 4"},
+			},
+			HostPathVolume: []*k8schianetv1.HostPathVolumeConfig{
+				{Path: "/srv/plots", MountPath: "/mnt/disk1/"},
+			},
+			PlotDirectories: []string{"/mnt/disk1", "plots", "/mnt/disk1/k32\n/mnt/disk2"},
+		},
+	}
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "spec.storage.plots.persistentVolumeClaim[1].mountPath")
+	require.ErrorContains(t, err, "spec.storage.plots.persistentVolumeClaim[2].subPath")
+	require.ErrorContains(t, err, "spec.storage.plots.hostPathVolume[0].mountPath")
+	require.ErrorContains(t, err, "spec.storage.plots.plotDirectories[1]")
+	require.ErrorContains(t, err, "spec.storage.plots.persistentVolumeClaim[3].mountPath")
+	require.ErrorContains(t, err, "spec.storage.plots.plotDirectories[2]")
+	require.NotContains(t, err.Error(), "spec.storage.plots.persistentVolumeClaim[0]")
+	require.NotContains(t, err.Error(), "spec.storage.plots.plotDirectories[0]")
+}
+
+func TestValidateChiaFarm(t *testing.T) {
+	validator := &ChiaFarmCustomValidator{}
+
+	farm := &k8schianetv1.ChiaFarm{
+		ObjectMeta: metav1.ObjectMeta{Name: "farm"},
+		Spec: k8schianetv1.ChiaFarmSpec{
+			ChiaConfig: k8schianetv1.ChiaFarmSpecChia{
+				SecretKey: k8schianetv1.ChiaSecretKey{
+					Name: "chiakey",
+					Key:  "key.txt",
+				},
+			},
+			Harvesters: []k8schianetv1.ChiaFarmHarvesterSpec{
+				{Name: "harvester-1"},
+			},
+		},
+	}
+	_, err := validator.ValidateCreate(context.TODO(), farm)
+	require.NoError(t, err)
+
+	invalid := farm.DeepCopy()
+	invalid.Spec.ChiaConfig.CASecretName = "chiaca"
+	invalid.Spec.CA = &k8schianetv1.ChiaCASpec{}
+	invalid.Spec.Harvesters[0].Name = "Harvester_1"
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "ca and chia.caSecretName are mutually exclusive")
+	require.ErrorContains(t, err, "spec.harvesters[0].name")
+}
+
+func TestValidateChiaCertificates(t *testing.T) {
+	validator := &ChiaCertificatesCustomValidator{}
+
+	cr := &k8schianetv1.ChiaCertificates{
+		ObjectMeta: metav1.ObjectMeta{Name: "chiaca"},
+		Spec: k8schianetv1.ChiaCertificatesSpec{
+			CASecretName: "chiaca",
+		},
+	}
+	_, err := validator.ValidateCreate(context.TODO(), cr)
+	require.ErrorContains(t, err, "certificate Secret cannot be the same name as the CA Secret")
+
+	cr.Spec.Secret = "chiacerts"
+	_, err = validator.ValidateCreate(context.TODO(), cr)
+	require.NoError(t, err)
+}
+
+func TestValidateChiaCA(t *testing.T) {
+	validator := &ChiaCACustomValidator{}
+
+	ca := &k8schianetv1.ChiaCA{
+		ObjectMeta: metav1.ObjectMeta{Name: "chiaca"},
+		Spec: k8schianetv1.ChiaCASpec{
+			Import: &k8schianetv1.ChiaCAImport{},
+		},
+	}
+	_, err := validator.ValidateCreate(context.TODO(), ca)
+	require.ErrorContains(t, err, "one of secretName or configMapName is required")
+
+	ca.Spec.Import.SecretName = "chiaca"
+	_, err = validator.ValidateCreate(context.TODO(), ca)
+	require.ErrorContains(t, err, "must not be the CA Secret the operator manages")
+
+	ca.Spec.Import.SecretName = "farm-ca"
+	ca.Spec.Rotation = &k8schianetv1.ChiaCARotation{Trigger: "1"}
+	_, err = validator.ValidateCreate(context.TODO(), ca)
+	require.ErrorContains(t, err, "rotation is not supported for imported CAs")
+
+	ca.Spec.Rotation = nil
+	_, err = validator.ValidateCreate(context.TODO(), ca)
+	require.NoError(t, err)
+
+	ca.Spec.CertManager = &k8schianetv1.ChiaCACertManagerConfig{
+		IssuerRef: &k8schianetv1.CertManagerIssuerRef{},
+	}
+	_, err = validator.ValidateCreate(context.TODO(), ca)
+	require.ErrorContains(t, err, "import and certManager are mutually exclusive")
+	require.ErrorContains(t, err, "the name of the issuer is required")
+
+	ca.Spec.Import = nil
+	ca.Spec.CertManager.IssuerRef.Name = "my-cluster-issuer"
+	_, err = validator.ValidateCreate(context.TODO(), ca)
+	require.NoError(t, err)
+}
+
+func TestChiaNodeDefaulter(t *testing.T) {
+	node := &k8schianetv1.ChiaNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Spec: k8schianetv1.ChiaNodeSpec{
+			ChiaConfig: k8schianetv1.ChiaNodeSpecChia{
+				CASecretName: "chiaca",
+				CommonSpecChia: k8schianetv1.CommonSpecChia{
+					RPCService: k8schianetv1.Service{
+						Enabled: boolPtr(false),
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, (&ChiaNodeCustomDefaulter{}).Default(context.TODO(), node))
+
+	clusterIP := corev1.ServiceTypeClusterIP
+	require.Equal(t, fmt.Sprintf("%s:%s", consts.DefaultChiaImageName, consts.DefaultChiaImageTag), *node.Spec.ChiaConfig.Image)
+	require.Equal(t, fmt.Sprintf("%s:%s", consts.DefaultChiaExporterImageName, consts.DefaultChiaExporterImageTag), *node.Spec.ChiaExporterConfig.Image)
+	require.Equal(t, fmt.Sprintf("%s:%s", consts.DefaultChiaHealthcheckImageName, consts.DefaultChiaHealthcheckImageTag), *node.Spec.ChiaHealthcheckConfig.Image)
+	require.True(t, *node.Spec.ChiaConfig.PeerService.Enabled)
+	require.Equal(t, &clusterIP, node.Spec.ChiaConfig.PeerService.ServiceType)
+	require.False(t, *node.Spec.ChiaConfig.RPCService.Enabled)
+	require.Nil(t, node.Spec.ChiaConfig.RPCService.ServiceType)
+	require.False(t, *node.Spec.ChiaHealthcheckConfig.Service.Enabled)
+	require.Nil(t, node.Spec.ChiaDBPullConfig.Image)
+	require.Equal(t, "/full_node/liveness", node.Spec.ChiaConfig.LivenessProbe.HTTPGet.Path)
+	require.Equal(t, "/full_node/readiness", node.Spec.ChiaConfig.ReadinessProbe.HTTPGet.Path)
+	require.Equal(t, "/full_node/startup", node.Spec.ChiaConfig.StartupProbe.HTTPGet.Path)
+	require.Equal(t, int32(30), node.Spec.ChiaConfig.StartupProbe.FailureThreshold)
+
+	// Defaulting is idempotent
+	defaulted := node.DeepCopy()
+	require.NoError(t, (&ChiaNodeCustomDefaulter{}).Default(context.TODO(), defaulted))
+	require.Equal(t, node, defaulted)
+
+	// Disabling chia-healthcheck removes the defaulted probes, but keeps custom probes
+	node.Spec.ChiaHealthcheckConfig.Enabled = boolPtr(false)
+	node.Spec.ChiaConfig.StartupProbe.FailureThreshold = 60
+	require.NoError(t, (&ChiaNodeCustomDefaulter{}).Default(context.TODO(), node))
+	require.Nil(t, node.Spec.ChiaConfig.LivenessProbe)
+	require.Nil(t, node.Spec.ChiaConfig.ReadinessProbe)
+	require.NotNil(t, node.Spec.ChiaConfig.StartupProbe)
+}
+
+func TestValidateChiaNode(t *testing.T) {
+	node := &k8schianetv1.ChiaNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Spec: k8schianetv1.ChiaNodeSpec{
+			ChiaConfig: k8schianetv1.ChiaNodeSpecChia{
+				CASecretName: "chiaca",
+			},
+			CommonSpec: k8schianetv1.CommonSpec{
+				Storage: &k8schianetv1.StorageConfig{
+					ChiaRoot: &k8schianetv1.ChiaRootConfig{
+						PersistentVolumeClaim: &k8schianetv1.PersistentVolumeClaimConfig{
+							ResourceRequest: "500Gi",
+						},
+					},
+				},
+			},
+			Snapshots: &k8schianetv1.ChiaNodeSnapshotConfig{
+				Enabled: boolPtr(true),
+			},
+		},
+	}
+	validator := &ChiaNodeCustomValidator{}
+
+	_, err := validator.ValidateCreate(context.TODO(), node)
+	require.NoError(t, err)
+
+	invalid := node.DeepCopy()
+	invalid.Spec.Storage.ChiaRoot.PersistentVolumeClaim.ResourceRequest = ""
+	invalid.Spec.RPCStatus.Enabled = boolPtr(false)
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "spec.storage.chiaRoot.persistentVolumeClaim.resourceRequest")
+	require.ErrorContains(t, err, "spec.rpcStatus.enabled")
+
+	invalid = node.DeepCopy()
+	invalid.Spec.Storage = nil
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "spec.storage.chiaRoot.persistentVolumeClaim")
+
+	// Snapshot settings aren't validated unless snapshots are enabled
+	invalid.Spec.Snapshots.Enabled = boolPtr(false)
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.NoError(t, err)
+}
+
+func TestDefaultChiaNodeChiaDBPullImage(t *testing.T) {
+	node := &k8schianetv1.ChiaNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Spec: k8schianetv1.ChiaNodeSpec{
+			ChiaDBPullConfig: k8schianetv1.SpecChiaDBPull{
+				Enabled: boolPtr(true),
+				HTTP: &k8schianetv1.ChiaDBPullHTTPSource{
+					URLs: []string{"https://example.com/blockchain_v2_mainnet.sqlite.gz"},
+				},
+			},
+		},
+	}
+
+	// HTTP sources are restored by chia-db-restore in the operator's image, which is chosen by the operator
+	require.NoError(t, (&ChiaNodeCustomDefaulter{}).Default(context.TODO(), node))
+	require.Nil(t, node.Spec.ChiaDBPullConfig.Image)
+
+	node.Spec.ChiaDBPullConfig.HTTP = nil
+	node.Spec.ChiaDBPullConfig.S3Prefix = "s3://bucket/"
+	require.NoError(t, (&ChiaNodeCustomDefaulter{}).Default(context.TODO(), node))
+	require.Equal(t, fmt.Sprintf("%s:%s", consts.DefaultChiaDBPullImageName, consts.DefaultChiaDBPullImageTag), *node.Spec.ChiaDBPullConfig.Image)
+}
+
+func TestValidateChiaNodeChiaDBPull(t *testing.T) {
+	node := &k8schianetv1.ChiaNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Spec: k8schianetv1.ChiaNodeSpec{
+			ChiaConfig: k8schianetv1.ChiaNodeSpecChia{
+				CASecretName: "chiaca",
+			},
+			ChiaDBPullConfig: k8schianetv1.SpecChiaDBPull{
+				Enabled: boolPtr(true),
+				HTTP: &k8schianetv1.ChiaDBPullHTTPSource{
+					URLs: []string{"https://example.com/blockchain_v2_mainnet.sqlite.gz"},
+				},
+			},
+		},
+	}
+	validator := &ChiaNodeCustomValidator{}
+
+	_, err := validator.ValidateCreate(context.TODO(), node)
+	require.NoError(t, err)
+
+	invalid := node.DeepCopy()
+	invalid.Spec.ChiaDBPullConfig.S3Prefix = "s3://bucket/"
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "only one source may be set, found s3Prefix, http")
+
+	invalid = node.DeepCopy()
+	invalid.Spec.ChiaDBPullConfig.HTTP = nil
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "spec.chiaDBPull")
+
+	invalid.Spec.ChiaDBPullConfig.PersistentVolumeClaim = &k8schianetv1.ChiaDBPullPersistentVolumeClaimSource{
+		ClaimName: "db-mirror",
+		Path:      "../db.sqlite",
+	}
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "spec.chiaDBPull.persistentVolumeClaim.path")
+
+	invalid.Spec.ChiaDBPullConfig.PersistentVolumeClaim = nil
+	invalid.Spec.ChiaDBPullConfig.ChiaNodeSnapshot = &k8schianetv1.ChiaDBPullChiaNodeSnapshotSource{Name: "mainnet"}
+	_, err = validator.ValidateCreate(context.TODO(), invalid)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "spec.storage.chiaRoot.persistentVolumeClaim")
+}